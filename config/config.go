@@ -29,6 +29,72 @@ type Config struct {
 	SandboxApiVersion string // Added for Sandbox API Version
 
 	AlphaVantageApiKey string // Stock market data
+
+	SMSProvider       string // fast2sms, msg91 or fake
+	SMSWebhookSecret  string // Shared secret for delivery report callbacks, reports are rejected when empty
+	Fast2SMSApiKey    string
+	Fast2SMSSenderID  string
+	MSG91AuthKey      string
+	MSG91SenderID     string
+	SMSTemplateOTP    string // Default DLT template ID for OTP messages
+	SMSTemplateLogin  string // Default DLT template ID for login alerts
+	SMSTemplateExpiry string // Default DLT template ID for subscription expiry reminders
+
+	OTPSecret             string // HMAC key for hashing stored OTP codes
+	OTPExpiryMinutes      int
+	OTPMaxAttempts        int // Verification attempts per OTP
+	OTPResendCooldownSecs int // Minimum gap between two OTPs to the same identifier
+	OTPMaxPerIdentifier   int // OTPs per identifier per hour
+	OTPMaxPerIP           int // OTPs per IP per hour
+
+	ProxyHeader            string // Header carrying the real client IP behind a proxy (e.g. X-Real-IP)
+	TrustedProxies         string // Comma separated proxy IPs/CIDRs allowed to set ProxyHeader
+	RedisURL               string // Optional, rate limit counters are kept in memory when empty
+	RateLimitGlobal        int    // Requests per window per user/IP across the API
+	RateLimitAuth          int    // Requests per window per IP on /auth
+	RateLimitLogin         int    // Login attempts per window per IP
+	RateLimitOTP           int    // OTP sends per 10 minutes per IP
+	RateLimitStockPrice    int    // Stock price lookups per window per user
+	RateLimitWindowSeconds int    // Window for the global and route group limits (OTP sends use 10 minutes)
+
+	KYCProvider     string // sandbox or local (stub for development)
+	KYCValidityDays int    // Days a verified KYC stays valid, 0 means it never expires
+	KYCMaxUploadMB  int
+
+	RiskModerateMinPercent   int // Questionnaire score (% of max) from which a user is MODERATE
+	RiskAggressiveMinPercent int // Questionnaire score (% of max) from which a user is AGGRESSIVE
+	RiskProfileValidityDays  int // Days before the questionnaire must be retaken, 0 means never
+	SuitabilityBlockGap      int // Risk levels between basket and investor at which subscription is blocked instead of warned
+
+	BankVerifier               string // sandbox or fake (stub for development)
+	BankNameMatchMinScore      int    // Minimum 0-100 name match between bank and KYC name to verify an account
+	BankMaxAccounts            int    // Bank accounts a user may hold
+	BankVerificationMaxRetries int    // Penny-drop retries after provider errors before giving up
+
+	MaintenanceCacheSeconds int    // How long the maintenance record is cached per instance
+	MaintenanceAllowPaths   string // Comma separated path prefixes served during maintenance
+
+	BlobBackend          string // local or s3
+	BlobLocalDir         string // Root directory of the local backend
+	BlobURLPrefix        string // Route serving signed local downloads
+	BlobSigningKey       string // HMAC key for local signed URLs, falls back to JWT_SECRET_KEY
+	BlobSignedURLSeconds int    // Lifetime of signed download URLs
+	S3Endpoint           string // e.g. https://s3.ap-south-1.amazonaws.com or http://localhost:9000 for MinIO
+	S3Region             string
+	S3Bucket             string
+	S3AccessKey          string
+	S3SecretKey          string
+	S3UsePathStyle       bool // Required by MinIO
+
+	PublicBaseURL string // Public address of this API, used in links printed on certificates and sent in emails
+
+	VideoCompletionPercent float64 // Share of a video to watch before it is marked complete, unless the content sets its own
+
+	SupportEscalationEmails string // Comma separated addresses alerted when a ticket misses its SLA, all admins when empty
+
+	FieldEncryptionKeys      string // Comma separated version:base64key pairs, e.g. v1:...,v2:...
+	FieldEncryptionActiveKey string // Key version used for new writes
+	BlindIndexKey            string // HMAC key for searchable blind indexes, must never change
 }
 
 // AppConfig is a global variable to access configuration
@@ -62,12 +128,85 @@ func LoadConfig() {
 		SandboxApiVersion: getEnv("SANDBOX_API_VERSION", "2.0"),
 
 		AlphaVantageApiKey: getEnv("ALPHA_VANTAGE_API_KEY", "defaulstSecret"),
+
+		SMSProvider:       getEnv("SMS_PROVIDER", "fast2sms"),
+		SMSWebhookSecret:  getEnv("SMS_WEBHOOK_SECRET", ""),
+		Fast2SMSApiKey:    getEnv("FAST2SMS_API_KEY", ""),
+		Fast2SMSSenderID:  getEnv("FAST2SMS_SENDER_ID", "CLASIA"),
+		MSG91AuthKey:      getEnv("MSG91_AUTH_KEY", ""),
+		MSG91SenderID:     getEnv("MSG91_SENDER_ID", "CLASIA"),
+		SMSTemplateOTP:    getEnv("SMS_TEMPLATE_OTP", "197302"),
+		SMSTemplateLogin:  getEnv("SMS_TEMPLATE_LOGIN_ALERT", ""),
+		SMSTemplateExpiry: getEnv("SMS_TEMPLATE_SUBSCRIPTION_EXPIRY", ""),
+
+		OTPSecret:             getEnv("OTP_SECRET", getEnv("JWT_SECRET_KEY", "defaultSecret")),
+		OTPExpiryMinutes:      getEnvInt("OTP_EXPIRY_MINUTES", 5),
+		OTPMaxAttempts:        getEnvInt("OTP_MAX_ATTEMPTS", 5),
+		OTPResendCooldownSecs: getEnvInt("OTP_RESEND_COOLDOWN_SECONDS", 60),
+		OTPMaxPerIdentifier:   getEnvInt("OTP_MAX_PER_IDENTIFIER_PER_HOUR", 5),
+		OTPMaxPerIP:           getEnvInt("OTP_MAX_PER_IP_PER_HOUR", 20),
+
+		ProxyHeader:            getEnv("PROXY_HEADER", ""),
+		TrustedProxies:         getEnv("TRUSTED_PROXIES", ""),
+		RedisURL:               getEnv("REDIS_URL", ""),
+		RateLimitGlobal:        getEnvInt("RATE_LIMIT_GLOBAL", 300),
+		RateLimitAuth:          getEnvInt("RATE_LIMIT_AUTH", 30),
+		RateLimitLogin:         getEnvInt("RATE_LIMIT_LOGIN", 10),
+		RateLimitOTP:           getEnvInt("RATE_LIMIT_OTP", 10),
+		RateLimitStockPrice:    getEnvInt("RATE_LIMIT_STOCK_PRICE", 60),
+		RateLimitWindowSeconds: getEnvInt("RATE_LIMIT_WINDOW_SECONDS", 60),
+
+		KYCProvider:     getEnv("KYC_PROVIDER", "sandbox"),
+		KYCValidityDays: getEnvInt("KYC_VALIDITY_DAYS", 730),
+		KYCMaxUploadMB:  getEnvInt("KYC_MAX_UPLOAD_MB", 5),
+
+		RiskModerateMinPercent:   getEnvInt("RISK_MODERATE_MIN_PERCENT", 40),
+		RiskAggressiveMinPercent: getEnvInt("RISK_AGGRESSIVE_MIN_PERCENT", 70),
+		RiskProfileValidityDays:  getEnvInt("RISK_PROFILE_VALIDITY_DAYS", 365),
+		SuitabilityBlockGap:      getEnvInt("SUITABILITY_BLOCK_GAP", 2),
+
+		BankVerifier:               getEnv("BANK_VERIFIER", "sandbox"),
+		BankNameMatchMinScore:      getEnvInt("BANK_NAME_MATCH_MIN_SCORE", 80),
+		BankMaxAccounts:            getEnvInt("BANK_MAX_ACCOUNTS", 5),
+		BankVerificationMaxRetries: getEnvInt("BANK_VERIFICATION_MAX_RETRIES", 3),
+
+		MaintenanceCacheSeconds: getEnvInt("MAINTENANCE_CACHE_SECONDS", 30),
+		MaintenanceAllowPaths:   getEnv("MAINTENANCE_ALLOW_PATHS", "/user/maintenance,/auth/login,/sms/delivery-report"),
+
+		BlobBackend:          getEnv("BLOB_BACKEND", "local"),
+		BlobLocalDir:         getEnv("BLOB_LOCAL_DIR", "./uploads"),
+		BlobURLPrefix:        getEnv("BLOB_URL_PREFIX", "/files"),
+		BlobSigningKey:       getEnv("BLOB_SIGNING_KEY", ""),
+		BlobSignedURLSeconds: getEnvInt("BLOB_SIGNED_URL_SECONDS", 900),
+		S3Endpoint:           getEnv("S3_ENDPOINT", ""),
+		S3Region:             getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:             getEnv("S3_BUCKET", ""),
+		S3AccessKey:          getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:          getEnv("S3_SECRET_KEY", ""),
+		S3UsePathStyle:       getEnv("S3_USE_PATH_STYLE", "true") == "true",
+
+		PublicBaseURL: getEnv("PUBLIC_BASE_URL", "http://localhost:3000"),
+
+		VideoCompletionPercent: float64(getEnvInt("VIDEO_COMPLETION_PERCENT", 90)),
+
+		SupportEscalationEmails: getEnv("SUPPORT_ESCALATION_EMAILS", ""),
+
+		FieldEncryptionKeys:      getEnv("FIELD_ENCRYPTION_KEYS", ""),
+		FieldEncryptionActiveKey: getEnv("FIELD_ENCRYPTION_ACTIVE_KEY", "v1"),
+		BlindIndexKey:            getEnv("BLIND_INDEX_KEY", ""),
 	}
 
 	// Validate critical configuration
 	if AppConfig.JWTKey == "defaultSecret" {
 		log.Println("Warning: Using default JWT_SECRET_KEY. Update it in your environment.")
 	}
+	smsKey := AppConfig.Fast2SMSApiKey
+	if AppConfig.SMSProvider == "msg91" {
+		smsKey = AppConfig.MSG91AuthKey
+	}
+	if AppConfig.SMSProvider != "fake" && smsKey == "" {
+		log.Println("Warning: No key set for SMS_PROVIDER, SMS messages are only logged. Set FAST2SMS_API_KEY or MSG91_AUTH_KEY.")
+	}
 	if AppConfig.DBName == "credUser.db" {
 		log.Println("Warning: Using default DBName. Update it in your environment.")
 	}
@@ -1,10 +1,14 @@
 package amcController
 
 import (
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
-	"fib/utils"
+	"fib/storage"
+	"fmt"
+	"log"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -43,49 +47,37 @@ func CreateOrUpdateAMCProfile(c *fiber.Ctx) error {
 
 	// Handle file uploads
 	if form.File != nil {
-		// Process Required Document
-		if files, ok := form.File["requiredDocument"]; ok && len(files) > 0 {
-			filePath, err := utils.SaveUploadedFile(files[0], "amc/documents")
-			if err != nil {
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save required document", nil)
-			}
-			body.RequiredDocument = filePath
-		}
-
-		// Process Optional Document 1
-		if files, ok := form.File["optionalDocument1"]; ok && len(files) > 0 {
-			filePath, err := utils.SaveUploadedFile(files[0], "amc/documents")
-			if err != nil {
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save optional document 1", nil)
-			}
-			body.OptionalDocument1 = filePath
+		uploads := []struct {
+			field  string
+			prefix string
+			rules  storage.UploadRules
+			dest   *string
+			label  string
+		}{
+			{"requiredDocument", "amc/documents", storage.DocumentRules, &body.RequiredDocument, "required document"},
+			{"optionalDocument1", "amc/documents", storage.DocumentRules, &body.OptionalDocument1, "optional document 1"},
+			{"optionalDocument2", "amc/documents", storage.DocumentRules, &body.OptionalDocument2, "optional document 2"},
+			{"amcLogo", "amc/logos", storage.ImageRules, &body.AmcLogo, "AMC logo"},
+			{"managerImage", "amc/images", storage.ImageRules, &body.ManagerImage, "manager image"},
 		}
 
-		// Process Optional Document 2
-		if files, ok := form.File["optionalDocument2"]; ok && len(files) > 0 {
-			filePath, err := utils.SaveUploadedFile(files[0], "amc/documents")
-			if err != nil {
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save optional document 2", nil)
+		for _, upload := range uploads {
+			files, ok := form.File[upload.field]
+			if !ok || len(files) == 0 {
+				continue
 			}
-			body.OptionalDocument2 = filePath
-		}
-
-		// Process AMC Logo
-		if files, ok := form.File["amcLogo"]; ok && len(files) > 0 {
-			filePath, err := utils.SaveUploadedFile(files[0], "amc/logos")
-			if err != nil {
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save AMC logo", nil)
-			}
-			body.AmcLogo = filePath
-		}
-
-		// Process Manager Image
-		if files, ok := form.File["managerImage"]; ok && len(files) > 0 {
-			filePath, err := utils.SaveUploadedFile(files[0], "amc/images")
+			stored, err := storage.SaveUpload(c.Context(), files[0], upload.prefix, upload.rules)
 			if err != nil {
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save manager image", nil)
+				switch {
+				case errors.Is(err, storage.ErrFileTooLarge):
+					return middleware.JsonResponse(c, fiber.StatusBadRequest, false, fmt.Sprintf("The %s must be smaller than %d MB", upload.label, upload.rules.MaxBytes>>20), nil)
+				case errors.Is(err, storage.ErrFileTypeInvalid):
+					return middleware.JsonResponse(c, fiber.StatusBadRequest, false, fmt.Sprintf("The %s has a file type that is not allowed", upload.label), nil)
+				}
+				log.Printf("Failed to save AMC %s: %v", upload.label, err)
+				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save "+upload.label, nil)
 			}
-			body.ManagerImage = filePath
+			*upload.dest = stored.Key
 		}
 	}
 
@@ -124,12 +116,12 @@ func GetAMCProfile(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "AMC profile not found", nil)
 	}
 
-	// Convert file paths to URLs if needed
-	profile.RequiredDocument = utils.GetFileURL(profile.RequiredDocument)
-	profile.OptionalDocument1 = utils.GetFileURL(profile.OptionalDocument1)
-	profile.OptionalDocument2 = utils.GetFileURL(profile.OptionalDocument2)
-	profile.AmcLogo = utils.GetFileURL(profile.AmcLogo)
-	profile.ManagerImage = utils.GetFileURL(profile.ManagerImage)
+	// Convert storage keys to short-lived signed download URLs
+	profile.RequiredDocument = storage.URL(profile.RequiredDocument)
+	profile.OptionalDocument1 = storage.URL(profile.OptionalDocument1)
+	profile.OptionalDocument2 = storage.URL(profile.OptionalDocument2)
+	profile.AmcLogo = storage.URL(profile.AmcLogo)
+	profile.ManagerImage = storage.URL(profile.ManagerImage)
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "AMC profile retrieved successfully", profile)
 }
@@ -1,6 +1,7 @@
 package authController
 
 import (
+	"errors"
 	"fib/config"
 	"fib/database"
 	"fib/middleware"
@@ -9,8 +10,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -137,6 +141,30 @@ func getDefaultPermissions() []string {
 	}
 }
 
+// otpErrorResponse maps OTP service errors to API responses
+func otpErrorResponse(c *fiber.Ctx, err error) error {
+	var rateErr *utils.OTPRateLimitError
+	if errors.As(err, &rateErr) {
+		retryAfter := int(math.Ceil(rateErr.RetryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return middleware.JsonResponse(c, fiber.StatusTooManyRequests, false, rateErr.Reason+"!", fiber.Map{
+			"retryAfter": retryAfter,
+		})
+	}
+
+	switch {
+	case errors.Is(err, utils.ErrOTPExpired):
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "OTP has expired!", nil)
+	case errors.Is(err, utils.ErrOTPAttemptsExceeded):
+		return middleware.JsonResponse(c, fiber.StatusTooManyRequests, false, "Too many invalid attempts! Please request a new OTP.", nil)
+	case errors.Is(err, utils.ErrOTPInvalid):
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid OTP or OTP expired!", nil)
+	}
+
+	log.Printf("Error processing OTP: %v", err)
+	return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to process OTP!", nil)
+}
+
 func Login(c *fiber.Ctx) error {
 	reqData := new(struct {
 		Mobile   string `json:"mobile"`
@@ -170,10 +198,17 @@ func Login(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Mobile not verified!", nil)
 	}
 
+	ip := c.IP()
+	userAgent := c.Get("User-Agent")
+
 	// Check if the user is blocked
 	if user.IsBlocked && user.BlockedUntil != nil && user.BlockedUntil.After(time.Now()) {
-
-		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Your account is temporarily blocked. Try again later.", nil)
+		retryAfter := int(math.Ceil(time.Until(*user.BlockedUntil).Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Your account is temporarily blocked. Try again later.", fiber.Map{
+			"blockedUntil": user.BlockedUntil,
+			"retryAfter":   retryAfter,
+		})
 	}
 
 	if user.LastFailedLogin != nil && time.Since(*user.LastFailedLogin) > 15*time.Minute {
@@ -183,6 +218,11 @@ func Login(c *fiber.Ctx) error {
 		database.Database.Db.Save(&user)
 	}
 
+	// Lockout escalation decays after a day without lockouts
+	if user.LockoutCount > 0 && user.BlockedUntil != nil && time.Since(*user.BlockedUntil) > 24*time.Hour {
+		user.LockoutCount = 0
+	}
+
 	// Validate password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(reqData.Password)); err != nil {
 
@@ -190,47 +230,71 @@ func Login(c *fiber.Ctx) error {
 		now := time.Now()
 		user.LastFailedLogin = &now
 
-		// Block user after 3 failed attempts
+		// Block user after 3 failed attempts, each consecutive lockout lasts longer
 		if user.FailedLoginAttempts >= 3 {
+			user.LockoutCount++
 			user.IsBlocked = true
+			user.FailedLoginAttempts = 0
 
-			unblockTime := now.Add(1 * time.Minute)
+			unblockTime := now.Add(utils.LockoutDuration(user.LockoutCount))
 			user.BlockedUntil = &unblockTime
 
-			if err := database.Database.Db.Save(&user).Error; err != nil {
-				log.Printf("Error blocking user: %v", err)
+			if user.LockoutCount >= utils.FlagAfterLockouts && !user.IsFlagged {
+				user.IsFlagged = true
+				user.FlaggedReason = fmt.Sprintf("%d consecutive lockouts for failed logins", user.LockoutCount)
+				user.FlaggedAt = &now
 			}
 		}
 
 		// Save the updated user details
-		database.Database.Db.Save(&user)
+		if err := database.Database.Db.Save(&user).Error; err != nil {
+			log.Printf("Error saving failed login: %v", err)
+		}
+
+		database.Database.Db.Create(&models.LoginTracking{
+			UserID:    user.ID,
+			IPAddress: ip,
+			Device:    userAgent,
+			Timestamp: now,
+			Status:    models.LoginStatusFailed,
+		})
 
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Wrong Password", nil)
 	}
 
+	log.Printf("Login attempt: User-Agent: %s, IP Address: %s", userAgent, ip)
+
+	// Step-up verification when the login comes from a new device,
+	// or from a new network right after failed attempts
+	reasons := utils.DetectUnusualLogin(user.ID, ip, userAgent)
+	hadFailures := user.FailedLoginAttempts > 0 || user.LockoutCount > 0
+	if slices.Contains(reasons, utils.LoginReasonNewDevice) || (len(reasons) > 0 && hadFailures) {
+		return startLoginChallenge(c, user, ip, userAgent, reasons)
+	}
+
+	return completeLogin(c, user, ip, userAgent, reasons)
+}
+
+// completeLogin resets lockout state, records the login and issues the JWT
+func completeLogin(c *fiber.Ctx, user models.User, ip, userAgent string, reasons []string) error {
 	// Update last login time
 	user.LastLogin = time.Now()
 	user.FailedLoginAttempts = 0 // Reset failed login attempts after successful login
+	user.LockoutCount = 0
 	user.IsBlocked = false
 	if err := database.Database.Db.Save(&user).Error; err != nil {
 		log.Printf("Error saving last login time: %v", err)
 	}
 
-	ip := c.IP()
-	if forwarded := c.Get("X-Forwarded-For"); forwarded != "" {
-		ip = forwarded
-	}
-
-	userAgent := c.Get("User-Agent")
-
-	log.Printf("Login attempt: User-Agent: %s, IP Address: %s", userAgent, ip)
-
 	// Capture login tracking details
 	loginTracking := models.LoginTracking{
-		UserID:    user.ID,
-		IPAddress: ip,
-		Device:    userAgent,
-		Timestamp: time.Now(),
+		UserID:       user.ID,
+		IPAddress:    ip,
+		Device:       userAgent,
+		Timestamp:    time.Now(),
+		Status:       models.LoginStatusSuccess,
+		IsSuspicious: len(reasons) > 0,
+		Reasons:      strings.Join(reasons, ","),
 	}
 
 	// Log the user login tracking
@@ -255,12 +319,118 @@ func Login(c *fiber.Ctx) error {
 		utils.SendLoginNotificationEmail(user.Email, user.Name, ip, userAgent, time.Now().Format("02 Jan 2006 15:04:05 PM"))
 	}
 
+	// Send Login Alert SMS
+	if user.Mobile != "" {
+		utils.SendLoginAlertSMS(user.ID, user.Mobile, ip, time.Now().Format("02 Jan 2006 15:04"))
+	}
+
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Login successful.", fiber.Map{
 		"user":  user,
 		"token": token,
 	})
 }
 
+// startLoginChallenge sends a step-up OTP and returns a challenge instead of a token
+func startLoginChallenge(c *fiber.Ctx, user models.User, ip, userAgent string, reasons []string) error {
+	// Prefer email, fall back to mobile
+	email, mobile, channel := user.Email, "", "EMAIL"
+	if email == "" {
+		email, mobile, channel = "", user.Mobile, "MOBILE"
+	}
+
+	otp, err := utils.IssueOTP(user.ID, email, mobile, models.OTPPurposeLoginChallenge, ip, "Login Verification OTP")
+	if err != nil {
+		return otpErrorResponse(c, err)
+	}
+
+	if channel == "MOBILE" {
+		if err := utils.SendOTPToMobile(mobile, otp); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send OTP to mobile!", nil)
+		}
+	} else {
+		if err := utils.SendOTPEmail(otp, email); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send OTP to email!", nil)
+		}
+	}
+
+	challenge := models.LoginChallenge{
+		Token:     utils.GenerateSecureToken(32),
+		UserID:    user.ID,
+		IPAddress: ip,
+		Device:    userAgent,
+		Reasons:   strings.Join(reasons, ","),
+		Channel:   channel,
+		Status:    models.ChallengePending,
+		ExpiresAt: time.Now().Add(time.Duration(config.AppConfig.OTPExpiryMinutes) * time.Minute),
+	}
+	if err := database.Database.Db.Create(&challenge).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create login challenge!", nil)
+	}
+
+	database.Database.Db.Create(&models.LoginTracking{
+		UserID:       user.ID,
+		IPAddress:    ip,
+		Device:       userAgent,
+		Timestamp:    time.Now(),
+		Status:       models.LoginStatusChallenged,
+		IsSuspicious: true,
+		Reasons:      challenge.Reasons,
+	})
+
+	return middleware.JsonResponse(c, fiber.StatusAccepted, true, "Unusual login detected. Enter the OTP sent to verify it's you.", fiber.Map{
+		"challengeId": challenge.Token,
+		"channel":     channel,
+		"reasons":     reasons,
+		"expiresAt":   challenge.ExpiresAt,
+	})
+}
+
+// VerifyLoginChallenge completes a login that required step-up verification
+func VerifyLoginChallenge(c *fiber.Ctx) error {
+	reqData, ok := c.Locals("validatedLoginChallenge").(*struct {
+		ChallengeID string `json:"challengeId"`
+		Code        string `json:"code"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var challenge models.LoginChallenge
+	if err := db.Where("token = ? AND status = ? AND is_deleted = false", reqData.ChallengeID, models.ChallengePending).First(&challenge).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid or expired login challenge!", nil)
+	}
+
+	if challenge.ExpiresAt.Before(time.Now()) {
+		db.Model(&challenge).Update("status", models.ChallengeFailed)
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Login challenge has expired! Please login again.", nil)
+	}
+
+	var user models.User
+	if err := db.Where("id = ? AND is_deleted = false", challenge.UserID).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	email, mobile := user.Email, ""
+	if challenge.Channel == "MOBILE" {
+		email, mobile = "", user.Mobile
+	}
+
+	if _, err := utils.VerifyOTP(email, mobile, models.OTPPurposeLoginChallenge, reqData.Code); err != nil {
+		// Burning through every attempt on a step-up is a strong signal of account takeover
+		if errors.Is(err, utils.ErrOTPAttemptsExceeded) {
+			db.Model(&challenge).Update("status", models.ChallengeFailed)
+			utils.FlagUser(user.ID, "Failed step-up verification for unusual login ("+challenge.Reasons+")")
+		}
+		return otpErrorResponse(c, err)
+	}
+
+	db.Model(&challenge).Update("status", models.ChallengeVerified)
+
+	return completeLogin(c, user, challenge.IPAddress, challenge.Device, strings.Split(challenge.Reasons, ","))
+}
+
 func LoginHistoryList(c *fiber.Ctx) error {
 	// Retrieve userId from JWT middleware
 	userId, ok := c.Locals("userId").(uint)
@@ -339,20 +509,10 @@ func SendOTP(c *fiber.Ctx) error {
 		}
 	}
 
-	// Generate OTP
-	otp := utils.GenerateOTP()
-
-	// Set OTP expiration time (e.g., 5 minutes from now)
-	expiresAt := time.Now().Add(5 * time.Minute)
-
-	// Create OTP record
-	otpRecord := models.OTP{
-		UserID:      user.ID,
-		Email:       reqData.Email,
-		Mobile:      reqData.Mobile,
-		Code:        otp,
-		ExpiresAt:   expiresAt,
-		Description: "Email/Mobile Verification OTP",
+	// Generate and store hashed OTP (enforces resend cooldown and send limits)
+	otp, err := utils.IssueOTP(user.ID, reqData.Email, reqData.Mobile, models.OTPPurposeSignup, c.IP(), "Email/Mobile Verification OTP")
+	if err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Send OTP via SMS if mobile is provided
@@ -369,11 +529,6 @@ func SendOTP(c *fiber.Ctx) error {
 		}
 	}
 
-	// Save OTP record to the database
-	if err := database.Database.Db.Create(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to Create OTP!", nil)
-	}
-
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "OTP sent successfully.", nil)
 }
 
@@ -390,45 +545,21 @@ func VerifyOTP(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	var otpRecord models.OTP
 	var result *gorm.DB
 
-	// Retrieve user and OTP record based on email or mobile
+	// Retrieve user based on email or mobile
 	if reqData.Email != "" {
-		// Find user by email
 		result = database.Database.Db.Where("email = ? AND is_deleted = ?", reqData.Email, false).First(&user)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
-		}
-
-		// Find the OTP record for the email
-		result = database.Database.Db.Where("email = ? AND code = ? AND is_used = ? AND is_deleted = ?", reqData.Email, reqData.Code, false, false).First(&otpRecord)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid OTP or OTP expired!", nil)
-		}
 	} else {
-		// Find user by mobile
 		result = database.Database.Db.Where("mobile = ? AND is_deleted = ?", reqData.Mobile, false).First(&user)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
-		}
-
-		// Find the OTP record for the mobile
-		result = database.Database.Db.Where("mobile = ? AND code = ? AND is_used = ? AND is_deleted = ?", reqData.Mobile, reqData.Code, false, false).First(&otpRecord)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid OTP or OTP expired!", nil)
-		}
 	}
-
-	// Check if OTP has expired
-	if otpRecord.ExpiresAt.Before(time.Now()) {
-		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "OTP has expired!", nil)
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
 	}
 
-	// Mark OTP as used
-	otpRecord.IsUsed = true
-	if err := database.Database.Db.Save(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update OTP status!", nil)
+	// Verify the OTP (counts failed attempts and marks it used on success)
+	if _, err := utils.VerifyOTP(reqData.Email, reqData.Mobile, models.OTPPurposeSignup, reqData.Code); err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Update user's verification status based on email or mobile
@@ -472,20 +603,10 @@ func ForgotPasswordSendOTP(c *fiber.Ctx) error {
 		}
 	}
 
-	// Generate OTP
-	otp := utils.GenerateOTP()
-
-	// Set OTP expiration time (e.g., 5 minutes from now)
-	expiresAt := time.Now().Add(5 * time.Minute)
-
-	// Create OTP record
-	otpRecord := models.OTP{
-		UserID:      user.ID,
-		Email:       reqData.Email,
-		Mobile:      reqData.Mobile,
-		Code:        otp,
-		ExpiresAt:   expiresAt,
-		Description: "Forgot Password OTP",
+	// Generate and store hashed OTP (enforces resend cooldown and send limits)
+	otp, err := utils.IssueOTP(user.ID, reqData.Email, reqData.Mobile, models.OTPPurposeForgotPassword, c.IP(), "Forgot Password OTP")
+	if err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Send OTP via SMS if mobile is provided
@@ -502,11 +623,6 @@ func ForgotPasswordSendOTP(c *fiber.Ctx) error {
 		}
 	}
 
-	// Save OTP record to the database
-	if err := database.Database.Db.Create(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to Create OTP!", nil)
-	}
-
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "OTP sent successfully.", nil)
 }
 
@@ -523,45 +639,21 @@ func ForgotPasswordVerifyOTP(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	var otpRecord models.OTP
 	var result *gorm.DB
 
-	// Retrieve user and OTP record based on email or mobile
+	// Retrieve user based on email or mobile
 	if reqData.Email != "" {
-		// Find user by email
 		result = database.Database.Db.Where("email = ? AND is_deleted = ?", reqData.Email, false).First(&user)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
-		}
-
-		// Find the OTP record for the email
-		result = database.Database.Db.Where("email = ? AND code = ? AND is_used = ? AND is_deleted = ?", reqData.Email, reqData.Code, false, false).First(&otpRecord)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid OTP or OTP expired!", nil)
-		}
 	} else {
-		// Find user by mobile
 		result = database.Database.Db.Where("mobile = ? AND is_deleted = ?", reqData.Mobile, false).First(&user)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
-		}
-
-		// Find the OTP record for the mobile
-		result = database.Database.Db.Where("mobile = ? AND code = ? AND is_used = ? AND is_deleted = ?", reqData.Mobile, reqData.Code, false, false).First(&otpRecord)
-		if result.Error != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid OTP or OTP expired!", nil)
-		}
 	}
-
-	// Check if OTP has expired
-	if otpRecord.ExpiresAt.Before(time.Now()) {
-		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "OTP has expired!", nil)
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
 	}
 
-	// Mark OTP as used
-	otpRecord.IsUsed = true
-	if err := database.Database.Db.Save(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update OTP status!", nil)
+	// Verify the OTP (counts failed attempts and marks it used on success)
+	if _, err := utils.VerifyOTP(reqData.Email, reqData.Mobile, models.OTPPurposeForgotPassword, reqData.Code); err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Generate JWT token
@@ -715,18 +807,10 @@ func LoginSendOTP(c *fiber.Ctx) error {
 		user = newUser
 	}
 
-	// Generate OTP and expiry
-	otp := utils.GenerateOTP()
-	expiresAt := time.Now().Add(5 * time.Minute)
-
-	// Create OTP record
-	otpRecord := models.OTP{
-		UserID:      user.ID,
-		Email:       reqData.Email,
-		Mobile:      reqData.Mobile,
-		Code:        otp,
-		ExpiresAt:   expiresAt,
-		Description: "Login OTP",
+	// Generate and store hashed OTP (enforces resend cooldown and send limits)
+	otp, err := utils.IssueOTP(user.ID, reqData.Email, reqData.Mobile, models.OTPPurposeLogin, c.IP(), "Login OTP")
+	if err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Send OTP
@@ -740,11 +824,6 @@ func LoginSendOTP(c *fiber.Ctx) error {
 		}
 	}
 
-	// Save OTP to DB
-	if err := database.Database.Db.Create(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save OTP!", nil)
-	}
-
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "OTP sent successfully.", nil)
 }
 
@@ -766,7 +845,6 @@ func LoginVerifyOTP(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	var otpRecord models.OTP
 
 	// Case: Email-based OTP
 	if reqData.Email != "" {
@@ -794,11 +872,6 @@ func LoginVerifyOTP(c *fiber.Ctx) error {
 		if err := database.Database.Db.Where("email = ? AND is_deleted = false", reqData.Email).First(&user).Error; err != nil {
 			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
 		}
-
-		// Find OTP
-		if err := database.Database.Db.Where("email = ? AND code = ? AND is_used = false AND is_deleted = false", reqData.Email, reqData.Code).First(&otpRecord).Error; err != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid or expired OTP!", nil)
-		}
 	}
 
 	// Case: Mobile-based OTP
@@ -806,21 +879,11 @@ func LoginVerifyOTP(c *fiber.Ctx) error {
 		if err := database.Database.Db.Where("mobile = ? AND is_deleted = false", reqData.Mobile).First(&user).Error; err != nil {
 			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
 		}
-
-		if err := database.Database.Db.Where("mobile = ? AND code = ? AND is_used = false AND is_deleted = false", reqData.Mobile, reqData.Code).First(&otpRecord).Error; err != nil {
-			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid or expired OTP!", nil)
-		}
-	}
-
-	// Check OTP expiration
-	if otpRecord.ExpiresAt.Before(time.Now()) {
-		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "OTP has expired!", nil)
 	}
 
-	// Mark OTP as used
-	otpRecord.IsUsed = true
-	if err := database.Database.Db.Save(&otpRecord).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update OTP status!", nil)
+	// Verify the OTP (counts failed attempts and marks it used on success)
+	if _, err := utils.VerifyOTP(reqData.Email, reqData.Mobile, models.OTPPurposeLogin, reqData.Code); err != nil {
+		return otpErrorResponse(c, err)
 	}
 
 	// Generate JWT
@@ -2,6 +2,7 @@ package basketController
 
 import (
 	"encoding/json"
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
@@ -69,19 +70,41 @@ func ListPendingApprovals(c *fiber.Ctx) error {
 	// Prepare response with extra details
 	type PendingVersionResponse struct {
 		basket.BasketVersion
-		BasketName string `json:"basketName"`
-		AMCName    string `json:"amcName"`
+		BasketName       string `json:"basketName"`
+		AMCName          string `json:"amcName"`
+		ApprovalStepName string `json:"approvalStepName"` // Step the version waits on
+		ApprovalSteps    int    `json:"approvalSteps"`    // Length of its approval chain
 	}
 
+	chains := make(map[string][]basket.ApprovalStep)
 	var response []PendingVersionResponse
 	for _, v := range versions {
+		steps, cached := chains[v.Basket.BasketType]
+		if !cached {
+			var err error
+			if steps, err = approvalChain(db, v.Basket.BasketType); err != nil {
+				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to load approval chain!", nil)
+			}
+			chains[v.Basket.BasketType] = steps
+		}
+		step, _ := currentApprovalStep(steps, v.ApprovalStep)
+
 		var amc models.User
 		db.Select("name").Where("id = ?", v.Basket.AMCID).First(&amc)
 
+		// Versions submitted before the compliance rules existed are checked on first view
+		if v.CompliancePassed == nil {
+			if _, err := checkCompliance(db, v.Basket.BasketType, &v, v.Stocks); err != nil {
+				log.Printf("Error checking compliance of basket version %d: %v", v.ID, err)
+			}
+		}
+
 		response = append(response, PendingVersionResponse{
-			BasketVersion: v,
-			BasketName:    v.Basket.Name,
-			AMCName:       amc.Name,
+			BasketVersion:    v,
+			BasketName:       v.Basket.Name,
+			AMCName:          amc.Name,
+			ApprovalStepName: step.Name,
+			ApprovalSteps:    len(steps),
 		})
 	}
 
@@ -109,6 +132,10 @@ func ApproveBasket(c *fiber.Ctx) error {
 		StartTime       *time.Time `json:"startTime"`
 		EndTime         *time.Time `json:"endTime"`
 		ScheduledDate   *time.Time `json:"scheduledDate"`
+		RiskRating      *string    `json:"riskRating"`
+		Comments        string     `json:"comments"`
+		PublishAt       *time.Time `json:"publishAt"`
+		ExpireAt        *time.Time `json:"expireAt"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -122,51 +149,119 @@ func ApproveBasket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket version not found or not pending approval!", nil)
 	}
 
-	// For INTRA_HOUR baskets, time slot is required
-	if version.Basket.BasketType == basket.BasketTypeIntraHour {
-		if reqData.StartTime == nil || reqData.EndTime == nil || reqData.ScheduledDate == nil {
-			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Time slot (startTime, endTime, scheduledDate) is required for INTRA_HOUR baskets!", nil)
+	// Only the reviewer of the version's current step may sign it off
+	steps, err := approvalChain(db, version.Basket.BasketType)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to load approval chain!", nil)
+	}
+	step, lastStep := currentApprovalStep(steps, version.ApprovalStep)
+	if conflict := reviewerConflict(db, &version, step, userId); conflict != "" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, conflict, nil)
+	}
+
+	approval := basket.BasketApproval{
+		StepOrder:  step.StepOrder,
+		StepName:   step.Name,
+		ReviewerID: userId,
+		Decision:   basket.DecisionApproved,
+		Comments:   reqData.Comments,
+	}
+
+	// Earlier steps hand the version to the next reviewer; the last step publishes it below
+	if !lastStep {
+		next := steps[step.StepOrder]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return decideApprovalStep(tx, &version, &approval, map[string]interface{}{"approval_step": next.StepOrder})
+		})
+		if err != nil {
+			if errors.Is(err, errStepDecided) {
+				return middleware.JsonResponse(c, fiber.StatusConflict, false, "This step was decided by another reviewer meanwhile!", nil)
+			}
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to approve step!", nil)
 		}
+		version.ApprovalStep = next.StepOrder
+
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"step":       step.Name,
+			"nextStep":   next.Name,
+			"round":      version.ApprovalRound,
+			"approvalId": approval.ID,
+		})
+		recordAdminHistory(version.ID, basket.ActionStepApproved, userId, reqData.Comments, metadata)
+		go notifyStepApprovers(next, version.Basket.Name)
+
+		return middleware.JsonResponse(c, fiber.StatusOK, true, step.Name+" approved! Awaiting "+next.Name+".", version)
+	}
 
-		// Create time slot
-		duration := int(reqData.EndTime.Sub(*reqData.StartTime).Minutes())
-		timeSlot := basket.BasketTimeSlot{
+	if version.Basket.BasketType == basket.BasketTypeIntraHour && (reqData.StartTime == nil || reqData.EndTime == nil || reqData.ScheduledDate == nil) {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Time slot (startTime, endTime, scheduledDate) is required for INTRA_HOUR baskets!", nil)
+	}
+
+	// Go-live and sunset: the admin's times win over the ones the AMC asked for
+	now := time.Now()
+	publishAt, expireAt := version.PublishAt, version.ExpireAt
+	if reqData.PublishAt != nil {
+		publishAt = reqData.PublishAt
+	}
+	if reqData.ExpireAt != nil {
+		expireAt = reqData.ExpireAt
+	}
+	if publishAt != nil && !publishAt.After(now) {
+		publishAt = nil // Requested time has passed, go live now
+	}
+	if version.Basket.BasketType != basket.BasketTypeIntraHour {
+		if msg := scheduleConflict(version.Basket.BasketType, publishAt, expireAt, now); msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+	}
+
+	// Review the AMC's risk rating; the admin may override it
+	riskRating := version.Basket.RiskRating
+	if riskRating == "" {
+		riskRating = utils.DefaultBasketRiskRating(version.Basket.BasketType)
+	}
+	riskChanged := reqData.RiskRating != nil && *reqData.RiskRating != riskRating
+	if riskChanged {
+		riskRating = *reqData.RiskRating
+	}
+	previousRiskRating := version.Basket.RiskRating
+	version.RiskRating = riskRating
+
+	var timeSlot *basket.BasketTimeSlot
+	if version.Basket.BasketType == basket.BasketTypeIntraHour {
+		// INTRA_HOUR: SCHEDULED until the time slot starts
+		timeSlot = &basket.BasketTimeSlot{
 			BasketVersionID: version.ID,
 			ScheduledDate:   *reqData.ScheduledDate,
 			StartTime:       *reqData.StartTime,
 			EndTime:         *reqData.EndTime,
-			DurationMinutes: duration,
+			DurationMinutes: int(reqData.EndTime.Sub(*reqData.StartTime).Minutes()),
 			Timezone:        "Asia/Kolkata",
 			SetByAdminID:    userId,
 		}
-
-		if err := db.Create(&timeSlot).Error; err != nil {
-			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create time slot!", nil)
-		}
-
-		// Set status to SCHEDULED (will auto-publish at start time)
 		version.Status = basket.StatusScheduled
-
-		// Record time slot history
-		metadata, _ := json.Marshal(map[string]interface{}{
-			"startTime":     reqData.StartTime,
-			"endTime":       reqData.EndTime,
-			"scheduledDate": reqData.ScheduledDate,
-		})
-		recordAdminHistory(version.ID, basket.ActionTimeSlotSet, userId, "Time slot set by admin", metadata)
-	} else if version.Basket.BasketType == basket.BasketTypeIntraday {
-		// INTRADAY: Set to PUBLISHED immediately, will auto-expire at market close
-		version.Status = basket.StatusPublished
-
-		// Set trading date to today if not specified
-		today := time.Now()
-		version.TradingDate = &today
 	} else {
-		// DELIVERY: Set to PUBLISHED immediately, no auto-expiry
-		version.Status = basket.StatusPublished
+		// DELIVERY and INTRADAY: PUBLISHED now, or SCHEDULED until publishAt; expire at expireAt if set
+		// INTRADAY also expires at market close of its trading date
+		version.PublishAt = publishAt
+		version.ExpireAt = expireAt
+		if publishAt != nil {
+			version.Status = basket.StatusScheduled
+		} else {
+			version.Status = basket.StatusPublished
+			version.PublishedAt = &now
+		}
+
+		if version.Basket.BasketType == basket.BasketTypeIntraday {
+			tradingDate := now
+			if publishAt != nil {
+				tradingDate = *publishAt
+			}
+			version.TradingDate = &tradingDate
+		}
 	}
 
-	// Calculate Initial Pricing at Approval Time
+	// Calculate Initial Pricing at Approval Time. Quotes are fetched before the transaction opens.
 	var bajajToken models.BajajAccessToken
 	db.Order("created_at DESC").First(&bajajToken)
 	accessToken := bajajToken.Token
@@ -174,9 +269,12 @@ func ApproveBasket(c *fiber.Ctx) error {
 	var stocks []basket.BasketStock
 	db.Where("basket_version_id = ? AND is_deleted = false", version.ID).Find(&stocks)
 
+	healed := make(map[uint]bool)
+	prices := make([]float64, len(stocks))
 	var totalInitialValuation float64 = 0
 
-	for _, stock := range stocks {
+	for i := range stocks {
+		stock := &stocks[i]
 		price := stock.PriceAtCreation // Fallback
 
 		// Auto-heal: If Token is missing, fetch from master
@@ -185,70 +283,127 @@ func ApproveBasket(c *fiber.Ctx) error {
 			if err := db.Where("id = ?", stock.StockID).First(&masterStock).Error; err == nil {
 				stock.Token = masterStock.Token
 				stock.Symbol = masterStock.Symbol
-				// Save healed data
-				db.Model(&stock).Select("Token", "Symbol").Updates(basket.BasketStock{Token: masterStock.Token, Symbol: masterStock.Symbol})
+				healed[stock.ID] = true
 			}
 		}
 
-		// Try to fetch live price if token is available
+		// Try to fetch live price if token is available, otherwise keep the creation price
 		if accessToken != "" && stock.Token > 0 {
-			livePrice, err := utils.GetBajajQuote(accessToken, stock.Token)
-			if err == nil && livePrice > 0 {
+			if livePrice, err := utils.GetBajajQuote(accessToken, stock.Token); err == nil && livePrice > 0 {
 				price = livePrice
-			} else {
-				// Log error but continue with creation price
-				// log.Printf("Failed to fetch price for token %d: %v", stock.Token, err)
 			}
 		}
 
-		// Update stock with approval price
-		db.Model(&stock).Update("price_at_approval", price)
-
+		prices[i] = price
 		totalInitialValuation += price * float64(stock.Quantity)
 	}
 
-	// Update version
-	now := time.Now()
 	version.ApprovedAt = &now
 	version.ApprovedBy = &userId
 	version.PriceAtApproval = totalInitialValuation
 
-	if err := db.Save(&version).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to approve basket!", nil)
-	}
+	// The last step and the publish commit together, so a failure leaves the version pending for a retry
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := decideApprovalStep(tx, &version, &approval, map[string]interface{}{"status": basket.StatusApproved}); err != nil {
+			return err
+		}
+
+		if riskRating != previousRiskRating {
+			if err := tx.Model(&basket.Basket{}).Where("id = ?", version.BasketID).Update("risk_rating", riskRating).Error; err != nil {
+				return err
+			}
+			version.Basket.RiskRating = riskRating
+		}
 
-	// Unpublish/expire any previously published or scheduled version of the same basket
-	var oldVersions []basket.BasketVersion
-	db.Preload("Stocks").Where("basket_id = ? AND id != ? AND status IN ?", version.BasketID, version.ID, []string{basket.StatusPublished, basket.StatusScheduled}).Find(&oldVersions)
+		if timeSlot != nil {
+			if err := tx.Create(timeSlot).Error; err != nil {
+				return err
+			}
+		}
 
-	for _, oldV := range oldVersions {
-		var expiryPrice float64 = 0
-		for _, stock := range oldV.Stocks {
-			// Try to fetch live price
-			if accessToken != "" && stock.Token > 0 {
-				if livePrice, err := utils.GetBajajQuote(accessToken, stock.Token); err == nil && livePrice > 0 {
-					expiryPrice += livePrice * float64(stock.Quantity)
-					continue
+		for i := range stocks {
+			stock := &stocks[i]
+			if healed[stock.ID] {
+				// Save healed data
+				if err := tx.Model(stock).Select("Token", "Symbol").Updates(basket.BasketStock{Token: stock.Token, Symbol: stock.Symbol}).Error; err != nil {
+					return err
 				}
 			}
-			// Fallback to approval price (assume no change if live fails)
-			if stock.PriceAtApproval > 0 {
-				expiryPrice += stock.PriceAtApproval * float64(stock.Quantity)
-			} else {
-				expiryPrice += stock.PriceAtCreation * float64(stock.Quantity)
+			// Update stock with approval price
+			if err := tx.Model(stock).Update("price_at_approval", prices[i]).Error; err != nil {
+				return err
 			}
 		}
 
-		oldV.Status = basket.StatusExpired
-		oldV.PriceAtExpiry = expiryPrice
-		db.Save(&oldV)
+		if err := tx.Save(&version).Error; err != nil {
+			return err
+		}
+
+		// Unpublish/expire any previously published or scheduled version of the same basket. A version scheduled
+		// for later leaves the live one running until it goes live itself.
+		keepLive := version.Status == basket.StatusScheduled && version.Basket.BasketType != basket.BasketTypeIntraHour
+		superseded := []string{basket.StatusPublished, basket.StatusScheduled}
+		if keepLive {
+			superseded = []string{basket.StatusScheduled}
+		}
+		var oldVersions []basket.BasketVersion
+		if err := tx.Preload("Stocks").Where("basket_id = ? AND id != ? AND status IN ?", version.BasketID, version.ID, superseded).Find(&oldVersions).Error; err != nil {
+			return err
+		}
+		for i := range oldVersions {
+			if _, err := utils.ExpireBasketVersion(tx, &oldVersions[i], accessToken); err != nil {
+				return err
+			}
+		}
+
+		// Update basket's current version
+		var live int64
+		if keepLive {
+			if err := tx.Model(&basket.BasketVersion{}).Where("basket_id = ? AND id != ? AND status = ? AND is_deleted = false", version.BasketID, version.ID, basket.StatusPublished).Count(&live).Error; err != nil {
+				return err
+			}
+		}
+		if live == 0 {
+			return tx.Model(&basket.Basket{}).Where("id = ?", version.BasketID).Update("current_version_id", version.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errStepDecided) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "This step was decided by another reviewer meanwhile!", nil)
+		}
+		log.Printf("Error approving basket version %d: %v", version.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to approve basket!", nil)
+	}
+
+	if riskChanged {
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"from": previousRiskRating,
+			"to":   riskRating,
+		})
+		recordAdminHistory(version.ID, basket.ActionRiskRated, userId, "Risk rating changed by admin", metadata)
 	}
 
-	// Update basket's current version
-	db.Model(&basket.Basket{}).Where("id = ?", version.BasketID).Update("current_version_id", version.ID)
+	if timeSlot != nil {
+		// Record time slot history
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"startTime":     reqData.StartTime,
+			"endTime":       reqData.EndTime,
+			"scheduledDate": reqData.ScheduledDate,
+		})
+		recordAdminHistory(version.ID, basket.ActionTimeSlotSet, userId, "Time slot set by admin", metadata)
+	}
 
 	// Record history
-	recordAdminHistory(version.ID, basket.ActionApproved, userId, "Basket approved by admin", nil)
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"step":       step.Name,
+		"round":      version.ApprovalRound,
+		"approvalId": approval.ID,
+		"comments":   reqData.Comments,
+		"publishAt":  version.PublishAt,
+		"expireAt":   version.ExpireAt,
+	})
+	recordAdminHistory(version.ID, basket.ActionApproved, userId, "Basket approved by admin", metadata)
 
 	// Send Email to AMC (Async)
 	go func() {
@@ -300,16 +455,40 @@ func RejectBasket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket version not found or not pending approval!", nil)
 	}
 
-	// Update version
-	version.Status = basket.StatusRejected
-	version.RejectionReason = reqData.Reason
+	steps, err := approvalChain(db, version.Basket.BasketType)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to load approval chain!", nil)
+	}
+	step, _ := currentApprovalStep(steps, version.ApprovalStep)
+	if conflict := reviewerConflict(db, &version, step, userId); conflict != "" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, conflict, nil)
+	}
 
-	if err := db.Save(&version).Error; err != nil {
+	// Update version
+	approval := basket.BasketApproval{
+		StepOrder:  step.StepOrder,
+		StepName:   step.Name,
+		ReviewerID: userId,
+		Decision:   basket.DecisionRejected,
+		Comments:   reqData.Reason,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return decideApprovalStep(tx, &version, &approval, map[string]interface{}{
+			"status":           basket.StatusRejected,
+			"rejection_reason": reqData.Reason,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, errStepDecided) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "This step was decided by another reviewer meanwhile!", nil)
+		}
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to reject basket!", nil)
 	}
+	version.Status = basket.StatusRejected
+	version.RejectionReason = reqData.Reason
 
 	// Record history
-	metadata, _ := json.Marshal(map[string]interface{}{"reason": reqData.Reason})
+	metadata, _ := json.Marshal(map[string]interface{}{"reason": reqData.Reason, "step": step.Name, "round": version.ApprovalRound})
 	recordAdminHistory(version.ID, basket.ActionRejected, userId, reqData.Reason, metadata)
 
 	// Send Email to AMC (Async)
@@ -585,7 +764,7 @@ func GetDashboardStats(c *fiber.Ctx) error {
 
 	// Basket counts by status
 	var totalBaskets int64
-	var draftCount, pendingCount, publishedCount, scheduledCount, expiredCount, rejectedCount int64
+	var draftCount, pendingCount, publishedCount, scheduledCount, expiredCount, rejectedCount, changesRequestedCount int64
 
 	db.Model(&basket.Basket{}).Where("is_deleted = false").Count(&totalBaskets)
 
@@ -595,6 +774,7 @@ func GetDashboardStats(c *fiber.Ctx) error {
 	db.Model(&basket.BasketVersion{}).Where("status = ? AND is_deleted = false", basket.StatusScheduled).Count(&scheduledCount)
 	db.Model(&basket.BasketVersion{}).Where("status = ? AND is_deleted = false", basket.StatusExpired).Count(&expiredCount)
 	db.Model(&basket.BasketVersion{}).Where("status = ? AND is_deleted = false", basket.StatusRejected).Count(&rejectedCount)
+	db.Model(&basket.BasketVersion{}).Where("status = ? AND is_deleted = false", basket.StatusChangesRequested).Count(&changesRequestedCount)
 
 	// Basket counts by type
 	var intraHourCount, intradayCount, deliveryCount int64
@@ -624,12 +804,13 @@ func GetDashboardStats(c *fiber.Ctx) error {
 		"baskets": fiber.Map{
 			"total": totalBaskets,
 			"byStatus": fiber.Map{
-				"draft":           draftCount,
-				"pendingApproval": pendingCount,
-				"published":       publishedCount,
-				"scheduled":       scheduledCount,
-				"expired":         expiredCount,
-				"rejected":        rejectedCount,
+				"draft":            draftCount,
+				"pendingApproval":  pendingCount,
+				"published":        publishedCount,
+				"scheduled":        scheduledCount,
+				"expired":          expiredCount,
+				"rejected":         rejectedCount,
+				"changesRequested": changesRequestedCount,
 			},
 			"byType": fiber.Map{
 				"intraHour": intraHourCount,
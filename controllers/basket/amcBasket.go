@@ -7,6 +7,7 @@ import (
 	"fib/models"
 	"fib/models/basket"
 	"fib/utils"
+	"fmt"
 	"log"
 	"time"
 
@@ -28,6 +29,7 @@ func CreateBasket(c *fiber.Ctx) error {
 		BasketType      string  `json:"basketType"`
 		SubscriptionFee float64 `json:"subscriptionFee"`
 		IsFeeBased      bool    `json:"isFeeBased"`
+		RiskRating      string  `json:"riskRating"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -41,6 +43,12 @@ func CreateBasket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Basket with this name already exists!", nil)
 	}
 
+	// Unrated baskets get the default rating for their type until the AMC sets one
+	riskRating := reqData.RiskRating
+	if riskRating == "" {
+		riskRating = utils.DefaultBasketRiskRating(reqData.BasketType)
+	}
+
 	// Create basket
 	newBasket := basket.Basket{
 		Name:            reqData.Name,
@@ -49,6 +57,7 @@ func CreateBasket(c *fiber.Ctx) error {
 		BasketType:      reqData.BasketType,
 		SubscriptionFee: reqData.SubscriptionFee,
 		IsFeeBased:      reqData.IsFeeBased,
+		RiskRating:      riskRating,
 	}
 
 	if err := db.Create(&newBasket).Error; err != nil {
@@ -103,6 +112,7 @@ func UpdateBasket(c *fiber.Ctx) error {
 		Description     *string  `json:"description"`
 		SubscriptionFee *float64 `json:"subscriptionFee"`
 		IsFeeBased      *bool    `json:"isFeeBased"`
+		RiskRating      *string  `json:"riskRating"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -129,6 +139,9 @@ func UpdateBasket(c *fiber.Ctx) error {
 	if reqData.IsFeeBased != nil {
 		existingBasket.IsFeeBased = *reqData.IsFeeBased
 	}
+	if reqData.RiskRating != nil {
+		existingBasket.RiskRating = *reqData.RiskRating
+	}
 
 	if err := db.Save(&existingBasket).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update basket!", nil)
@@ -193,13 +206,13 @@ func AddStocksToBasket(c *fiber.Ctx) error {
 
 	// Get draft or rejected version
 	var version basket.BasketVersion
-	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, []string{basket.StatusDraft, basket.StatusRejected}).
+	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, editableVersionStatuses).
 		Order("version_number DESC").First(&version).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No draft or rejected version available! Create a new version first.", nil)
 	}
 
-	// If rejected, revert to draft
-	if version.Status == basket.StatusRejected {
+	// If rejected or sent back for changes, revert to draft
+	if version.Status != basket.StatusDraft {
 		version.Status = basket.StatusDraft
 		db.Save(&version)
 	}
@@ -326,13 +339,13 @@ func EditBasketStock(c *fiber.Ctx) error {
 
 	// Get draft or rejected version
 	var version basket.BasketVersion
-	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, []string{basket.StatusDraft, basket.StatusRejected}).
+	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, editableVersionStatuses).
 		Order("version_number DESC").First(&version).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No editable version available!", nil)
 	}
 
-	// If rejected, revert to draft to edit
-	if version.Status == basket.StatusRejected {
+	// If rejected or sent back for changes, revert to draft to edit
+	if version.Status != basket.StatusDraft {
 		version.Status = basket.StatusDraft
 		db.Save(&version)
 	}
@@ -394,13 +407,13 @@ func RemoveStockFromBasket(c *fiber.Ctx) error {
 
 	// Get draft or rejected version
 	var version basket.BasketVersion
-	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, []string{basket.StatusDraft, basket.StatusRejected}).
+	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, editableVersionStatuses).
 		Order("version_number DESC").First(&version).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No draft or rejected version available!", nil)
 	}
 
-	// If rejected, revert to draft
-	if version.Status == basket.StatusRejected {
+	// If rejected or sent back for changes, revert to draft
+	if version.Status != basket.StatusDraft {
 		version.Status = basket.StatusDraft
 		db.Save(&version)
 	}
@@ -440,7 +453,9 @@ func SubmitForApproval(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedSubmitForApproval").(*struct {
-		BasketID uint `json:"basketId"`
+		BasketID  uint       `json:"basketId"`
+		PublishAt *time.Time `json:"publishAt"`
+		ExpireAt  *time.Time `json:"expireAt"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -456,7 +471,7 @@ func SubmitForApproval(c *fiber.Ctx) error {
 
 	// Get draft or rejected version
 	var version basket.BasketVersion
-	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, []string{basket.StatusDraft, basket.StatusRejected}).
+	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", reqData.BasketID, editableVersionStatuses).
 		Order("version_number DESC").First(&version).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No draft or rejected version available for submission!", nil)
 	}
@@ -468,14 +483,52 @@ func SubmitForApproval(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Cannot submit basket with no stocks!", nil)
 	}
 
+	// Requested go-live and sunset; INTRA_HOUR baskets get a time slot from the admin instead
+	if reqData.PublishAt != nil || reqData.ExpireAt != nil {
+		if existingBasket.BasketType == basket.BasketTypeIntraHour {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "INTRA_HOUR baskets are scheduled with a time slot!", nil)
+		}
+		if msg := scheduleConflict(existingBasket.BasketType, reqData.PublishAt, reqData.ExpireAt, time.Now()); msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+	}
+
+	// Run the compliance rules; BLOCK failures keep the version in draft, warnings go to the reviewing admin
+	var stocks []basket.BasketStock
+	db.Where("basket_version_id = ? AND is_deleted = false", version.ID).Find(&stocks)
+	report, err := checkCompliance(db, existingBasket.BasketType, &version, stocks)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to run compliance checks!", nil)
+	}
+	if !report.Passed {
+		return middleware.JsonResponse(c, fiber.StatusUnprocessableEntity, false, fmt.Sprintf("Basket failed %d compliance checks!", report.Blocking), report)
+	}
+
+	// Baskets created before risk ratings existed get the default for their type
+	if existingBasket.RiskRating == "" {
+		existingBasket.RiskRating = utils.DefaultBasketRiskRating(existingBasket.BasketType)
+		db.Model(&existingBasket).Update("risk_rating", existingBasket.RiskRating)
+	}
+
 	// Update version status
 	now := time.Now()
 	version.Status = basket.StatusPendingApproval
 	version.SubmittedAt = &now
+	version.SubmittedBy = &userId
+	version.ApprovalRound++
+	version.ApprovalStep = 1
+	version.PublishAt = reqData.PublishAt
+	version.ExpireAt = reqData.ExpireAt
 	db.Save(&version)
 
 	// Record history
-	recordHistory(db, version.ID, basket.ActionSubmitted, userId, basket.ActorAMC, "Basket submitted for approval", nil)
+	metadata, _ := json.Marshal(map[string]interface{}{"complianceWarnings": report.Warnings})
+	recordHistory(db, version.ID, basket.ActionSubmitted, userId, basket.ActorAMC, "Basket submitted for approval", metadata)
+
+	// Named approvers of the first step hear about it; open steps show up in the pending list
+	if steps, err := approvalChain(db, existingBasket.BasketType); err == nil {
+		go notifyStepApprovers(steps[0], existingBasket.Name)
+	}
 
 	// Send Email (Async)
 	go func() {
@@ -0,0 +1,373 @@
+package basketController
+
+import (
+	"encoding/json"
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/models/basket"
+	"fib/utils"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// errStepDecided rolls back a decision when another reviewer decided the same step first
+var errStepDecided = errors.New("approval step already decided")
+
+// approvalChain returns a basket type's approval steps, falling back to the chain for all basket types
+// and then to a single step any admin may decide
+func approvalChain(db *gorm.DB, basketType string) ([]basket.ApprovalStep, error) {
+	for _, chainType := range []string{basketType, ""} {
+		var steps []basket.ApprovalStep
+		if err := db.Preload("Approvers").Where("basket_type = ?", chainType).Order("step_order ASC").Find(&steps).Error; err != nil {
+			return nil, err
+		}
+		if len(steps) > 0 {
+			return steps, nil
+		}
+	}
+	return []basket.ApprovalStep{{StepOrder: 1, Name: "Admin approval"}}, nil
+}
+
+// currentApprovalStep returns the step a pending version waits on and whether it is the last one.
+// Versions submitted before approval chains, or whose chain was shortened, wait on the nearest step.
+func currentApprovalStep(steps []basket.ApprovalStep, position int) (basket.ApprovalStep, bool) {
+	index := position - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(steps) {
+		index = len(steps) - 1
+	}
+	return steps[index], index == len(steps)-1
+}
+
+// reviewerConflict explains why a reviewer may not decide a version's current step, or returns "".
+// The submitter can never review their own version, nor can one admin approve two steps of a submission.
+func reviewerConflict(db *gorm.DB, version *basket.BasketVersion, step basket.ApprovalStep, reviewerID uint) string {
+	submitter := version.Basket.AMCID
+	if version.SubmittedBy != nil {
+		submitter = *version.SubmittedBy
+	}
+	if reviewerID == submitter {
+		return "You cannot review a basket you submitted!"
+	}
+
+	if len(step.Approvers) > 0 {
+		allowed := false
+		for _, approver := range step.Approvers {
+			if approver.UserID == reviewerID {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return "You are not an approver for the " + step.Name + " step!"
+		}
+	}
+
+	var earlier int64
+	db.Model(&basket.BasketApproval{}).
+		Where("basket_version_id = ? AND round = ? AND reviewer_id = ? AND decision = ?", version.ID, version.ApprovalRound, reviewerID, basket.DecisionApproved).
+		Count(&earlier)
+	if earlier > 0 {
+		return "You already approved an earlier step of this submission!"
+	}
+	return ""
+}
+
+// decideApprovalStep records a reviewer's decision on the version's current step and applies the version updates,
+// provided no other reviewer decided the step first
+func decideApprovalStep(tx *gorm.DB, version *basket.BasketVersion, approval *basket.BasketApproval, updates map[string]interface{}) error {
+	result := tx.Model(&basket.BasketVersion{}).
+		Where("id = ? AND status = ? AND approval_round = ? AND approval_step = ?", version.ID, basket.StatusPendingApproval, version.ApprovalRound, version.ApprovalStep).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errStepDecided
+	}
+
+	approval.BasketVersionID = version.ID
+	approval.Round = version.ApprovalRound
+	return tx.Create(approval).Error
+}
+
+// notifyStepApprovers emails the named approvers of a step, steps open to every admin send nothing
+func notifyStepApprovers(step basket.ApprovalStep, basketName string) {
+	if len(step.Approvers) == 0 {
+		return
+	}
+	userIDs := make([]uint, len(step.Approvers))
+	for i, approver := range step.Approvers {
+		userIDs[i] = approver.UserID
+	}
+
+	var approvers []models.User
+	database.Database.Db.Select("name, email").Where("id IN ? AND is_deleted = false", userIDs).Find(&approvers)
+	for _, approver := range approvers {
+		if approver.Email != "" {
+			utils.SendBasketReviewPendingEmail(approver.Email, approver.Name, basketName, step.Name)
+		}
+	}
+}
+
+// RequestBasketChanges sends a pending version back to the AMC with comments and notes on individual stocks
+func RequestBasketChanges(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedRequestChanges").(*struct {
+		BasketVersionID uint   `json:"basketVersionId"`
+		Comments        string `json:"comments"`
+		StockNotes      []struct {
+			BasketStockID uint   `json:"basketStockId"`
+			Note          string `json:"note"`
+		} `json:"stockNotes"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var version basket.BasketVersion
+	if err := db.Preload("Basket").Where("id = ? AND status = ? AND is_deleted = false", reqData.BasketVersionID, basket.StatusPendingApproval).First(&version).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket version not found or not pending approval!", nil)
+	}
+
+	steps, err := approvalChain(db, version.Basket.BasketType)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to load approval chain!", nil)
+	}
+	step, _ := currentApprovalStep(steps, version.ApprovalStep)
+	if conflict := reviewerConflict(db, &version, step, userId); conflict != "" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, conflict, nil)
+	}
+
+	// Notes must point at stocks of this version
+	stockIDs := make([]uint, len(reqData.StockNotes))
+	for i, note := range reqData.StockNotes {
+		stockIDs[i] = note.BasketStockID
+	}
+	var stocks []basket.BasketStock
+	db.Where("id IN ? AND basket_version_id = ? AND is_deleted = false", stockIDs, version.ID).Find(&stocks)
+	symbols := make(map[uint]string)
+	for _, stock := range stocks {
+		symbols[stock.ID] = stock.Symbol
+	}
+
+	approval := basket.BasketApproval{
+		StepOrder:  step.StepOrder,
+		StepName:   step.Name,
+		ReviewerID: userId,
+		Decision:   basket.DecisionChangesRequested,
+		Comments:   reqData.Comments,
+	}
+	for _, note := range reqData.StockNotes {
+		symbol, found := symbols[note.BasketStockID]
+		if !found {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, fmt.Sprintf("Stock %d is not part of this version!", note.BasketStockID), nil)
+		}
+		approval.StockNotes = append(approval.StockNotes, basket.BasketStockNote{
+			BasketVersionID: version.ID,
+			BasketStockID:   note.BasketStockID,
+			Symbol:          symbol,
+			Note:            note.Note,
+		})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return decideApprovalStep(tx, &version, &approval, map[string]interface{}{"status": basket.StatusChangesRequested})
+	})
+	if err != nil {
+		if errors.Is(err, errStepDecided) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "This step was decided by another reviewer meanwhile!", nil)
+		}
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to request changes!", nil)
+	}
+	version.Status = basket.StatusChangesRequested
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"step":       step.Name,
+		"round":      version.ApprovalRound,
+		"approvalId": approval.ID,
+		"stockNotes": len(approval.StockNotes),
+	})
+	recordAdminHistory(version.ID, basket.ActionChangesRequested, userId, reqData.Comments, metadata)
+
+	go func() {
+		var amc models.User
+		if err := db.Where("id = ?", version.Basket.AMCID).First(&amc).Error; err == nil && amc.Email != "" {
+			utils.SendBasketChangesRequestedEmail(amc.Email, amc.Name, version.Basket.Name, step.Name, reqData.Comments, len(approval.StockNotes))
+		}
+	}()
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Changes requested from the AMC!", fiber.Map{
+		"version":  version,
+		"approval": approval,
+	})
+}
+
+// GetApprovalTrail returns a version's approval chain and every decision taken on it, with the stock notes.
+// Defaults to the basket's latest version.
+func GetApprovalTrail(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"AMC", "ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	basketID := c.Locals("basketID").(uint)
+	versionID := c.Locals("versionID").(uint)
+	db := database.Database.Db
+
+	var existingBasket basket.Basket
+	if err := db.Where("id = ? AND is_deleted = false", basketID).First(&existingBasket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket not found!", nil)
+	}
+	if user.Role == "AMC" && existingBasket.AMCID != userId {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You don't have access to this basket!", nil)
+	}
+
+	query := db.Where("basket_id = ?", basketID)
+	if versionID != 0 {
+		query = query.Where("id = ?", versionID)
+	} else {
+		query = query.Where("is_deleted = false")
+	}
+	var version basket.BasketVersion
+	if err := query.Order("version_number DESC").First(&version).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket version not found!", nil)
+	}
+
+	steps, err := approvalChain(db, existingBasket.BasketType)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to load approval chain!", nil)
+	}
+
+	var approvals []basket.BasketApproval
+	if err := db.Preload("StockNotes").Where("basket_version_id = ?", version.ID).Order("created_at ASC").Find(&approvals).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch approvals!", nil)
+	}
+
+	reviewerIDs := []uint{}
+	for _, approval := range approvals {
+		reviewerIDs = append(reviewerIDs, approval.ReviewerID)
+	}
+	var reviewers []models.User
+	db.Select("id, name").Where("id IN ?", reviewerIDs).Find(&reviewers)
+	names := make(map[uint]string)
+	for _, reviewer := range reviewers {
+		names[reviewer.ID] = reviewer.Name
+	}
+
+	type ApprovalWithReviewer struct {
+		basket.BasketApproval
+		ReviewerName string `json:"reviewerName"`
+	}
+	decisions := make([]ApprovalWithReviewer, len(approvals))
+	for i, approval := range approvals {
+		decisions[i] = ApprovalWithReviewer{BasketApproval: approval, ReviewerName: names[approval.ReviewerID]}
+	}
+
+	var awaiting *basket.ApprovalStep
+	if version.Status == basket.StatusPendingApproval {
+		step, _ := currentApprovalStep(steps, version.ApprovalStep)
+		awaiting = &step
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Approval trail fetched!", fiber.Map{
+		"versionId":     version.ID,
+		"versionNumber": version.VersionNumber,
+		"status":        version.Status,
+		"round":         version.ApprovalRound,
+		"chain":         steps,
+		"awaitingStep":  awaiting,
+		"decisions":     decisions,
+	})
+}
+
+// GetApprovalChains lists the configured approval chains for admin
+func GetApprovalChains(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! Admin role required.", nil)
+	}
+
+	var steps []basket.ApprovalStep
+	if err := database.Database.Db.Preload("Approvers").Order("basket_type ASC, step_order ASC").Find(&steps).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch approval chains!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Approval chains fetched!", steps)
+}
+
+// SaveApprovalChain replaces the approval chain of a basket type. Pending versions continue at the same position
+// in the new chain; an empty chain falls back to the chain for all basket types.
+func SaveApprovalChain(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! Admin role required.", nil)
+	}
+
+	reqData, ok := c.Locals("validatedApprovalChain").(*struct {
+		BasketType string `json:"basketType"`
+		Steps      []struct {
+			Name        string `json:"name"`
+			ApproverIDs []uint `json:"approverIds"`
+		} `json:"steps"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	steps := make([]basket.ApprovalStep, len(reqData.Steps))
+	for i, step := range reqData.Steps {
+		if len(step.ApproverIDs) > 0 {
+			var count int64
+			db.Model(&models.User{}).Where("id IN ? AND is_deleted = false AND role IN ?", step.ApproverIDs, []string{"ADMIN", "SUPER-ADMIN"}).Count(&count)
+			if int(count) != len(step.ApproverIDs) {
+				return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Approvers of the "+step.Name+" step must be admins!", nil)
+			}
+		}
+
+		steps[i] = basket.ApprovalStep{BasketType: reqData.BasketType, StepOrder: i + 1, Name: step.Name}
+		for _, approverID := range step.ApproverIDs {
+			steps[i].Approvers = append(steps[i].Approvers, basket.ApprovalStepApprover{UserID: approverID})
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("step_id IN (?)", tx.Model(&basket.ApprovalStep{}).Select("id").Where("basket_type = ?", reqData.BasketType)).
+			Delete(&basket.ApprovalStepApprover{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("basket_type = ?", reqData.BasketType).Delete(&basket.ApprovalStep{}).Error; err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			return nil
+		}
+		return tx.Create(&steps).Error
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save approval chain!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Approval chain saved!", steps)
+}
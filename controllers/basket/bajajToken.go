@@ -225,8 +225,8 @@ func AddStocksWithPricing(c *fiber.Ctx) error {
 			if err := db.Create(&version).Error; err != nil {
 				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create new draft version!", nil)
 			}
-		} else if latestVersion.Status == basket.StatusRejected {
-			// REJECTED -> Revert to DRAFT so it can be edited
+		} else if latestVersion.Status == basket.StatusRejected || latestVersion.Status == basket.StatusChangesRequested {
+			// REJECTED or CHANGES_REQUESTED -> Revert to DRAFT so it can be edited
 			latestVersion.Status = basket.StatusDraft
 			if err := db.Save(&latestVersion).Error; err != nil {
 				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to revert basket to draft!", nil)
@@ -0,0 +1,405 @@
+package basketController
+
+import (
+	"encoding/json"
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/models/basket"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock changes between two versions
+const (
+	diffAdded     = "ADDED"
+	diffRemoved   = "REMOVED"
+	diffChanged   = "CHANGED"
+	diffUnchanged = "UNCHANGED"
+)
+
+// editableVersionStatuses are the statuses in which the AMC may change a version's stocks
+var editableVersionStatuses = []string{basket.StatusDraft, basket.StatusRejected, basket.StatusChangesRequested}
+
+// openVersionStatuses are the statuses of a version still being worked on, a basket has at most one
+var openVersionStatuses = append([]string{basket.StatusPendingApproval}, editableVersionStatuses...)
+
+var errOpenVersionExists = errors.New("basket already has an open version")
+
+// CreateNewVersion starts version N+1 as a DRAFT holding a copy of the stocks of the current (or given) version,
+// so a published basket can be changed and resubmitted. Only the owning AMC drafts versions.
+func CreateNewVersion(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", userId, "AMC").First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! AMC role required.", nil)
+	}
+
+	reqData, ok := c.Locals("validatedNewVersion").(*struct {
+		BasketID      uint `json:"basketId"`
+		FromVersionID uint `json:"fromVersionId"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	// Verify basket ownership
+	var existingBasket basket.Basket
+	if err := db.Where("id = ? AND amc_id = ? AND is_deleted = false", reqData.BasketID, userId).First(&existingBasket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket not found!", nil)
+	}
+
+	fromVersionID := reqData.FromVersionID
+	if fromVersionID == 0 {
+		if existingBasket.CurrentVersionID == nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Basket has no current version to copy!", nil)
+		}
+		fromVersionID = *existingBasket.CurrentVersionID
+	}
+
+	var source basket.BasketVersion
+	if err := db.Preload("Stocks", "is_deleted = false").
+		Where("id = ? AND basket_id = ? AND is_deleted = false", fromVersionID, existingBasket.ID).
+		First(&source).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Version to copy not found!", nil)
+	}
+
+	var version basket.BasketVersion
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Lock the basket so two requests can't both create the next version
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&basket.Basket{}, existingBasket.ID).Error; err != nil {
+			return err
+		}
+
+		var openCount int64
+		tx.Model(&basket.BasketVersion{}).
+			Where("basket_id = ? AND status IN ? AND is_deleted = false", existingBasket.ID, openVersionStatuses).
+			Count(&openCount)
+		if openCount > 0 {
+			return errOpenVersionExists
+		}
+
+		var latest int
+		if err := tx.Model(&basket.BasketVersion{}).Where("basket_id = ?", existingBasket.ID).
+			Select("COALESCE(MAX(version_number), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+
+		version = basket.BasketVersion{
+			BasketID:      existingBasket.ID,
+			VersionNumber: latest + 1,
+			Status:        basket.StatusDraft,
+			RiskRating:    source.RiskRating,
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		// Approval prices belong to the approval of the source version and are set again when this one is approved
+		for _, stock := range source.Stocks {
+			clone := basket.BasketStock{
+				BasketVersionID: version.ID,
+				StockID:         stock.StockID,
+				Quantity:        stock.Quantity,
+				Weightage:       stock.Weightage,
+				PriceAtCreation: stock.PriceAtCreation,
+				OrderType:       stock.OrderType,
+				TargetPrice:     stock.TargetPrice,
+				StopLossPrice:   stock.StopLossPrice,
+				Token:           stock.Token,
+				Symbol:          stock.Symbol,
+				Units:           stock.Units,
+			}
+			if err := tx.Create(&clone).Error; err != nil {
+				return err
+			}
+			version.Stocks = append(version.Stocks, clone)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errOpenVersionExists) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Basket already has a draft, rejected or pending version! Edit or discard it first.", nil)
+		}
+		log.Printf("Error creating version of basket %d: %v", existingBasket.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create new version!", nil)
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"fromVersionId":     source.ID,
+		"fromVersionNumber": source.VersionNumber,
+		"fromStatus":        source.Status,
+		"stocks":            len(version.Stocks),
+	})
+	recordHistory(db, version.ID, basket.ActionVersionCreated, userId, basket.ActorAMC, fmt.Sprintf("New version created from version %d", source.VersionNumber), metadata)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "New draft version created!", version)
+}
+
+// DiscardDraftVersion throws away the basket's DRAFT or REJECTED version for its AMC. A basket's only version
+// can't be discarded, delete the basket instead.
+func DiscardDraftVersion(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", userId, "AMC").First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! AMC role required.", nil)
+	}
+
+	reqData, ok := c.Locals("validatedDiscardDraft").(*struct {
+		BasketID uint   `json:"basketId"`
+		Reason   string `json:"reason"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	// Verify basket ownership
+	var existingBasket basket.Basket
+	if err := db.Where("id = ? AND amc_id = ? AND is_deleted = false", reqData.BasketID, userId).First(&existingBasket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket not found!", nil)
+	}
+
+	var version basket.BasketVersion
+	if err := db.Where("basket_id = ? AND status IN ? AND is_deleted = false", existingBasket.ID, editableVersionStatuses).
+		Order("version_number DESC").First(&version).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No draft or rejected version to discard!", nil)
+	}
+
+	if existingBasket.CurrentVersionID != nil && *existingBasket.CurrentVersionID == version.ID {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "This is the basket's only version! Delete the basket instead.", nil)
+	}
+
+	// The stocks are kept so the discarded draft can still be compared; hiding the version hides them
+	result := db.Model(&basket.BasketVersion{}).
+		Where("id = ? AND status IN ? AND is_deleted = false", version.ID, editableVersionStatuses).
+		Update("is_deleted", true)
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to discard draft!", nil)
+	}
+	if result.RowsAffected == 0 {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Version was submitted or discarded meanwhile!", nil)
+	}
+
+	comments := "Draft version discarded"
+	if reqData.Reason != "" {
+		comments += ": " + reqData.Reason
+	}
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"versionNumber": version.VersionNumber,
+		"status":        version.Status,
+	})
+	recordHistory(db, version.ID, basket.ActionDraftDiscarded, userId, basket.ActorAMC, comments, metadata)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Draft version discarded!", nil)
+}
+
+// stockTerms are the fields of a basket stock compared between versions
+type stockTerms struct {
+	Quantity      int     `json:"quantity"`
+	Weightage     float64 `json:"weightage"`
+	Units         int     `json:"units"`
+	OrderType     string  `json:"orderType"`
+	TargetPrice   float64 `json:"targetPrice"`
+	StopLossPrice float64 `json:"stopLossPrice"`
+}
+
+// stockDiff is one row of a side-by-side version comparison
+type stockDiff struct {
+	StockID       uint        `json:"stockId"`
+	Symbol        string      `json:"symbol"`
+	StockName     string      `json:"stockName"`
+	Change        string      `json:"change"`
+	From          *stockTerms `json:"from"`
+	To            *stockTerms `json:"to"`
+	ChangedFields []string    `json:"changedFields"`
+}
+
+func termsOf(stock *basket.BasketStock) *stockTerms {
+	if stock == nil {
+		return nil
+	}
+	return &stockTerms{
+		Quantity:      stock.Quantity,
+		Weightage:     stock.Weightage,
+		Units:         stock.Units,
+		OrderType:     stock.OrderType,
+		TargetPrice:   stock.TargetPrice,
+		StopLossPrice: stock.StopLossPrice,
+	}
+}
+
+// changedTerms lists the fields that differ between two versions of a stock
+func changedTerms(from, to *stockTerms) []string {
+	changed := []string{}
+	if from.Quantity != to.Quantity {
+		changed = append(changed, "quantity")
+	}
+	if from.Weightage != to.Weightage {
+		changed = append(changed, "weightage")
+	}
+	if from.Units != to.Units {
+		changed = append(changed, "units")
+	}
+	if from.OrderType != to.OrderType {
+		changed = append(changed, "orderType")
+	}
+	if from.TargetPrice != to.TargetPrice {
+		changed = append(changed, "targetPrice")
+	}
+	if from.StopLossPrice != to.StopLossPrice {
+		changed = append(changed, "stopLossPrice")
+	}
+	return changed
+}
+
+// GetVersionDiff compares the stocks of two versions of a basket side by side.
+// AMCs can compare their own baskets, admins any basket.
+func GetVersionDiff(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"AMC", "ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	basketID := c.Locals("basketID").(uint)
+	reqData, ok := c.Locals("validatedVersionDiff").(*struct {
+		From int `query:"from"`
+		To   int `query:"to"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var existingBasket basket.Basket
+	if err := db.Where("id = ? AND is_deleted = false", basketID).First(&existingBasket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket not found!", nil)
+	}
+
+	if user.Role == "AMC" && existingBasket.AMCID != userId {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You don't have access to this basket!", nil)
+	}
+
+	// Discarded drafts can still be compared, they stay in the audit log
+	findVersion := func(number int, fallback func(*gorm.DB) *gorm.DB) (*basket.BasketVersion, error) {
+		query := db.Preload("Stocks", "is_deleted = false").Where("basket_id = ?", basketID)
+		if number > 0 {
+			query = query.Where("version_number = ?", number)
+		} else {
+			query = fallback(query)
+		}
+		var version basket.BasketVersion
+		if err := query.First(&version).Error; err != nil {
+			return nil, err
+		}
+		return &version, nil
+	}
+
+	from, err := findVersion(reqData.From, func(q *gorm.DB) *gorm.DB {
+		if existingBasket.CurrentVersionID != nil {
+			return q.Where("id = ?", *existingBasket.CurrentVersionID)
+		}
+		return q.Order("version_number ASC")
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "From version not found!", nil)
+	}
+
+	to, err := findVersion(reqData.To, func(q *gorm.DB) *gorm.DB {
+		return q.Where("is_deleted = false").Order("version_number DESC")
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "To version not found!", nil)
+	}
+
+	// Pair stocks by stock ID, in the order they appear in the newer version
+	fromStocks := make(map[uint]*basket.BasketStock)
+	for i := range from.Stocks {
+		fromStocks[from.Stocks[i].StockID] = &from.Stocks[i]
+	}
+	toStocks := make(map[uint]*basket.BasketStock)
+	for i := range to.Stocks {
+		toStocks[to.Stocks[i].StockID] = &to.Stocks[i]
+	}
+
+	var stockIDs []uint
+	for _, stock := range to.Stocks {
+		stockIDs = append(stockIDs, stock.StockID)
+	}
+	for _, stock := range from.Stocks {
+		if toStocks[stock.StockID] == nil {
+			stockIDs = append(stockIDs, stock.StockID)
+		}
+	}
+
+	names := make(map[uint]string)
+	if len(stockIDs) > 0 {
+		var stocks []models.Stocks
+		db.Table("stocks").Select("id, name").Where("id IN ?", stockIDs).Find(&stocks)
+		for _, s := range stocks {
+			names[s.ID] = s.Name
+		}
+	}
+
+	summary := map[string]int{diffAdded: 0, diffRemoved: 0, diffChanged: 0, diffUnchanged: 0}
+	diffs := make([]stockDiff, 0, len(stockIDs))
+	for _, stockID := range stockIDs {
+		before, after := fromStocks[stockID], toStocks[stockID]
+		diff := stockDiff{
+			StockID:       stockID,
+			StockName:     names[stockID],
+			From:          termsOf(before),
+			To:            termsOf(after),
+			ChangedFields: []string{},
+		}
+
+		switch {
+		case before == nil:
+			diff.Change = diffAdded
+			diff.Symbol = after.Symbol
+		case after == nil:
+			diff.Change = diffRemoved
+			diff.Symbol = before.Symbol
+		default:
+			diff.Symbol = after.Symbol
+			diff.ChangedFields = changedTerms(diff.From, diff.To)
+			diff.Change = diffUnchanged
+			if len(diff.ChangedFields) > 0 {
+				diff.Change = diffChanged
+			}
+		}
+		summary[diff.Change]++
+		diffs = append(diffs, diff)
+	}
+
+	versionInfo := func(v *basket.BasketVersion) fiber.Map {
+		return fiber.Map{
+			"id":            v.ID,
+			"versionNumber": v.VersionNumber,
+			"status":        v.Status,
+			"isDiscarded":   v.IsDeleted,
+			"stocks":        len(v.Stocks),
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Version diff fetched!", fiber.Map{
+		"basket":  fiber.Map{"id": existingBasket.ID, "name": existingBasket.Name},
+		"from":    versionInfo(from),
+		"to":      versionInfo(to),
+		"summary": summary,
+		"stocks":  diffs,
+	})
+}
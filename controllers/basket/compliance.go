@@ -0,0 +1,139 @@
+package basketController
+
+import (
+	"encoding/json"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/models/basket"
+	"fib/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkCompliance runs the compliance rules against a version's stocks and stores the report on the version
+func checkCompliance(db *gorm.DB, basketType string, version *basket.BasketVersion, stocks []basket.BasketStock) (*utils.ComplianceReport, error) {
+	report, err := utils.EvaluateBasketCompliance(db, basketType, stocks)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	version.CompliancePassed = &report.Passed
+	version.ComplianceReport = encoded
+	version.ComplianceCheckedAt = &report.CheckedAt
+
+	if err := db.Model(&basket.BasketVersion{}).Where("id = ?", version.ID).Updates(map[string]interface{}{
+		"compliance_passed":     report.Passed,
+		"compliance_report":     string(encoded),
+		"compliance_checked_at": report.CheckedAt,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetDraftCompliance lets an AMC check its open draft against the compliance rules before submitting.
+// The report is not stored, submission runs the check again.
+func GetDraftCompliance(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", userId, "AMC").First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! AMC role required.", nil)
+	}
+
+	basketID := c.Locals("basketID").(uint)
+	db := database.Database.Db
+
+	var existingBasket basket.Basket
+	if err := db.Where("id = ? AND amc_id = ? AND is_deleted = false", basketID, userId).First(&existingBasket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket not found!", nil)
+	}
+
+	var version basket.BasketVersion
+	if err := db.Preload("Stocks", "is_deleted = false").
+		Where("basket_id = ? AND status IN ? AND is_deleted = false", basketID, editableVersionStatuses).
+		Order("version_number DESC").First(&version).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "No draft or rejected version to check!", nil)
+	}
+
+	report, err := utils.EvaluateBasketCompliance(db, existingBasket.BasketType, version.Stocks)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to run compliance checks!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Compliance checks completed!", fiber.Map{
+		"versionId":     version.ID,
+		"versionNumber": version.VersionNumber,
+		"report":        report,
+	})
+}
+
+// GetComplianceRules lists the basket compliance rules for admin
+func GetComplianceRules(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! Admin role required.", nil)
+	}
+
+	var rules []basket.ComplianceRule
+	if err := database.Database.Db.Order("rule_type ASC, basket_type ASC").Find(&rules).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch compliance rules!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Compliance rules fetched!", rules)
+}
+
+// SaveComplianceRule creates or updates the rule of a type for a basket type. Versions already submitted
+// keep the report they were submitted with.
+func SaveComplianceRule(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied! Admin role required.", nil)
+	}
+
+	reqData, ok := c.Locals("validatedComplianceRule").(*struct {
+		RuleType      string  `json:"ruleType"`
+		BasketType    string  `json:"basketType"`
+		Threshold     float64 `json:"threshold"`
+		AllowedValues string  `json:"allowedValues"`
+		Severity      string  `json:"severity"`
+		IsActive      *bool   `json:"isActive"`
+		Description   string  `json:"description"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	rule := basket.ComplianceRule{
+		RuleType:      reqData.RuleType,
+		BasketType:    reqData.BasketType,
+		Threshold:     reqData.Threshold,
+		AllowedValues: reqData.AllowedValues,
+		Severity:      reqData.Severity,
+		IsActive:      true,
+		Description:   reqData.Description,
+	}
+	if reqData.IsActive != nil {
+		rule.IsActive = *reqData.IsActive
+	}
+
+	if err := database.Database.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "rule_type"}, {Name: "basket_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"threshold", "allowed_values", "severity", "is_active", "description", "updated_at"}),
+	}).Create(&rule).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save compliance rule!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Compliance rule saved!", rule)
+}
@@ -0,0 +1,146 @@
+package basketController
+
+import (
+	"encoding/json"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/models/basket"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// scheduleConflict explains why a go-live or sunset time can't be used, or returns "".
+// INTRADAY baskets must go live before market close (3:30 PM IST) of their trading date.
+func scheduleConflict(basketType string, publishAt, expireAt *time.Time, now time.Time) string {
+	start := now
+	if publishAt != nil {
+		start = *publishAt
+	}
+	if expireAt != nil && !expireAt.After(start) {
+		return "Expiry must be after the go-live time!"
+	}
+
+	if basketType == basket.BasketTypeIntraday && publishAt != nil {
+		loc, err := time.LoadLocation("Asia/Kolkata")
+		if err != nil {
+			loc = time.FixedZone("IST", 5*60*60+30*60)
+		}
+		local := publishAt.In(loc)
+		marketClose := time.Date(local.Year(), local.Month(), local.Day(), 15, 30, 0, 0, loc)
+		if !local.Before(marketClose) {
+			return "INTRADAY baskets must go live before market close (3:30 PM IST)!"
+		}
+	}
+	return ""
+}
+
+// RescheduleBasket changes when a DELIVERY or INTRADAY version goes live or expires. The go-live time can only
+// change before the version is live; clearExpiry removes the sunset. AMCs may only reschedule versions that are
+// not approved yet, approved times belong to the admin.
+func RescheduleBasket(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"AMC", "ADMIN", "SUPER-ADMIN"}).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedRescheduleBasket").(*struct {
+		BasketVersionID uint       `json:"basketVersionId"`
+		PublishAt       *time.Time `json:"publishAt"`
+		ExpireAt        *time.Time `json:"expireAt"`
+		ClearExpiry     bool       `json:"clearExpiry"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var version basket.BasketVersion
+	if err := db.Preload("Basket").Where("id = ? AND is_deleted = false", reqData.BasketVersionID).First(&version).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Basket version not found!", nil)
+	}
+
+	actorType := basket.ActorAdmin
+	if user.Role == "AMC" {
+		if version.Basket.AMCID != userId {
+			return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You don't have access to this basket!", nil)
+		}
+		if !slices.Contains(openVersionStatuses, version.Status) {
+			return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Approved versions can only be rescheduled by admin!", nil)
+		}
+		actorType = basket.ActorAMC
+	}
+
+	now := time.Now()
+	if (reqData.PublishAt != nil && !reqData.PublishAt.After(now)) || (reqData.ExpireAt != nil && !reqData.ExpireAt.After(now)) {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Go-live and expiry must be in the future!", nil)
+	}
+
+	if version.Basket.BasketType == basket.BasketTypeIntraHour {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "INTRA_HOUR baskets are scheduled with a time slot!", nil)
+	}
+
+	switch version.Status {
+	case basket.StatusExpired, basket.StatusUnpublished:
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Basket version is no longer live!", nil)
+	case basket.StatusPublished:
+		if reqData.PublishAt != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Basket version is already live!", nil)
+		}
+	}
+
+	publishAt, expireAt := version.PublishAt, version.ExpireAt
+	if reqData.PublishAt != nil {
+		publishAt = reqData.PublishAt
+	}
+	if reqData.ExpireAt != nil {
+		expireAt = reqData.ExpireAt
+	}
+	if reqData.ClearExpiry {
+		expireAt = nil
+	}
+	if version.Status == basket.StatusPublished {
+		publishAt = nil
+	}
+
+	if msg := scheduleConflict(version.Basket.BasketType, publishAt, expireAt, now); msg != "" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+	}
+
+	updates := map[string]interface{}{"expire_at": expireAt}
+	if version.Status != basket.StatusPublished {
+		updates["publish_at"] = publishAt
+		if version.Basket.BasketType == basket.BasketTypeIntraday && version.Status == basket.StatusScheduled && publishAt != nil {
+			updates["trading_date"] = *publishAt
+		}
+	}
+
+	// The scheduler may have moved the version on meanwhile
+	result := db.Model(&basket.BasketVersion{}).Where("id = ? AND status = ?", version.ID, version.Status).Updates(updates)
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to reschedule basket!", nil)
+	}
+	if result.RowsAffected == 0 {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Basket version changed status meanwhile, try again!", nil)
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"fromPublishAt": version.PublishAt,
+		"toPublishAt":   publishAt,
+		"fromExpireAt":  version.ExpireAt,
+		"toExpireAt":    expireAt,
+	})
+	recordHistory(db, version.ID, basket.ActionRescheduled, userId, actorType, "Go-live or expiry rescheduled", metadata)
+
+	if version.Status != basket.StatusPublished {
+		version.PublishAt = publishAt
+	}
+	version.ExpireAt = expireAt
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Basket rescheduled!", version)
+}
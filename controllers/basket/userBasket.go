@@ -250,9 +250,17 @@ func Subscribe(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
 	}
 
+	// Subscriptions require a verified KYC
+	if !utils.IsKYCVerified(userId) {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Complete your KYC to subscribe!", fiber.Map{
+			"kycStatus": utils.GetKYCStatus(userId),
+		})
+	}
+
 	reqData, ok := c.Locals("validatedSubscribe").(*struct {
-		BasketID uint   `json:"basketId"`
-		Period   string `json:"period"`
+		BasketID        uint   `json:"basketId"`
+		Period          string `json:"period"`
+		AcknowledgeRisk bool   `json:"acknowledgeRisk"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -304,6 +312,27 @@ func Subscribe(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Already subscribed to this basket!", nil)
 	}
 
+	// Suitability: compare the basket's risk rating with the user's risk profile
+	riskRating := version.RiskRating
+	if riskRating == "" {
+		riskRating = existingBasket.RiskRating
+	}
+	if riskRating == "" {
+		riskRating = utils.DefaultBasketRiskRating(existingBasket.BasketType)
+	}
+	suitability := utils.CheckSuitability(userId, riskRating)
+	switch suitability.Result {
+	case utils.SuitabilityNoProfile:
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Complete your risk profile to subscribe!", suitability)
+	case utils.SuitabilityBlock:
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "This basket is not suitable for your risk profile!", suitability)
+	case utils.SuitabilityWarn:
+		if !reqData.AcknowledgeRisk {
+			// Client shows the warning and retries with acknowledgeRisk=true
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Risk acknowledgement required!", suitability)
+		}
+	}
+
 	// Determine subscription fee based on period
 	var subscriptionFee float64
 	if reqData.Period == basket.PeriodYearly {
@@ -358,6 +387,12 @@ func Subscribe(c *fiber.Ctx) error {
 		BasketPrice:        version.PriceAtApproval,
 		Status:             basket.SubscriptionActive,
 		SubscriptionPeriod: reqData.Period,
+		UserRiskCategory:   suitability.UserRiskCategory,
+		BasketRiskRating:   riskRating,
+	}
+	if suitability.Result == utils.SuitabilityWarn {
+		acknowledgedAt := time.Now()
+		subscription.RiskAcknowledgedAt = &acknowledgedAt
 	}
 
 	// Set expiry based on basket type and period
@@ -402,6 +437,22 @@ func Subscribe(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to subscribe!", nil)
 	}
 
+	// Keep a record of the warning the user accepted
+	if subscription.RiskAcknowledgedAt != nil {
+		db.Create(&models.SuitabilityAcknowledgement{
+			UserID:           userId,
+			BasketID:         existingBasket.ID,
+			BasketVersionID:  version.ID,
+			SubscriptionID:   subscription.ID,
+			RiskProfileID:    suitability.Profile.ID,
+			UserRiskCategory: suitability.UserRiskCategory,
+			BasketRiskRating: riskRating,
+			Warning:          suitability.Message,
+			IP:               c.IP(),
+			UserAgent:        c.Get("User-Agent"),
+		})
+	}
+
 	// Preload basket data for response
 	db.Preload("Basket").Preload("BasketVersion").Preload("BasketVersion.Stocks", "is_deleted = false").First(&subscription)
 
@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// funnelColumns counts the learners of a group of enrollments (aliased e) at each step of the course funnel.
+// A learner has started once they complete content, attempt an MCQ or quiz, or play a video.
+const funnelColumns = `COUNT(*) AS enrolled,
+	COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM content_completions cc WHERE cc.user_id = e.user_id AND cc.course_id = e.course_id AND cc.is_deleted = false)
+		OR EXISTS (SELECT 1 FROM mcq_attempts ma JOIN course_contents ct ON ct.id = ma.content_id WHERE ma.user_id = e.user_id AND ct.course_id = e.course_id AND ma.is_deleted = false)
+		OR EXISTS (SELECT 1 FROM quiz_attempts qa WHERE qa.user_id = e.user_id AND qa.course_id = e.course_id AND qa.is_deleted = false)
+		OR EXISTS (SELECT 1 FROM video_progresses vp WHERE vp.user_id = e.user_id AND vp.course_id = e.course_id AND vp.is_deleted = false)) AS started,
+	COUNT(*) FILTER (WHERE e.progress >= 50) AS halfway,
+	COUNT(*) FILTER (WHERE e.status = 'COMPLETED') AS completed,
+	COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM certificates ce WHERE ce.user_id = e.user_id AND ce.course_id = e.course_id AND ce.is_deleted = false)) AS certified,
+	COALESCE(AVG(e.progress), 0) AS avg_progress,
+	AVG(EXTRACT(EPOCH FROM e.completed_at - e.created_at)) FILTER (WHERE e.status = 'COMPLETED' AND e.completed_at IS NOT NULL) AS avg_seconds_to_complete,
+	PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM e.completed_at - e.created_at)) FILTER (WHERE e.status = 'COMPLETED' AND e.completed_at IS NOT NULL) AS median_seconds_to_complete`
+
+// funnelCounts is a row of funnelColumns
+type funnelCounts struct {
+	Cohort                  string   `json:"cohort,omitempty"` // Enrolment month, YYYY-MM
+	Enrolled                int64    `json:"enrolled"`
+	Started                 int64    `json:"started"`
+	Halfway                 int64    `json:"halfway"`
+	Completed               int64    `json:"completed"`
+	Certified               int64    `json:"certified"`
+	AvgProgress             float64  `json:"avg_progress"`
+	AvgSecondsToComplete    *float64 `json:"-"`
+	MedianSecondsToComplete *float64 `json:"-"`
+}
+
+// contentAnalytics reports how learners fare on one content item
+type contentAnalytics struct {
+	ContentID           uint    `json:"content_id"`
+	ModuleTitle         string  `json:"module_title"`
+	Day                 int     `json:"day"`
+	Title               string  `json:"title"`
+	ContentType         string  `json:"content_type"`
+	IsPublished         bool    `json:"is_published"`
+	Completions         int64   `json:"completions"`
+	CompletionRate      float64 `json:"completion_rate"` // Percent of enrolled learners
+	Attempts            int64   `json:"attempts"`        // MCQ and quiz content only
+	FailedAttempts      int64   `json:"failed_attempts"`
+	FailureRate         float64 `json:"failure_rate"`
+	FirstAttemptFailure float64 `json:"first_attempt_failure_rate"`
+}
+
+// percentOf returns part as a percentage of whole, rounded to 2 decimals
+func percentOf(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part*10000/whole) / 100
+}
+
+// daysOf converts an optional duration in seconds to days, rounded to 2 decimals
+func daysOf(seconds *float64) *float64 {
+	if seconds == nil {
+		return nil
+	}
+	days := float64(int64(*seconds/86400*100)) / 100
+	return &days
+}
+
+// funnelSteps lays out the funnel with each step's share of enrolments and of the step before
+func funnelSteps(counts *funnelCounts) []fiber.Map {
+	steps := []struct {
+		name  string
+		count int64
+	}{
+		{"enrolled", counts.Enrolled},
+		{"started", counts.Started},
+		{"halfway", counts.Halfway},
+		{"completed", counts.Completed},
+		{"certified", counts.Certified},
+	}
+
+	result := make([]fiber.Map, len(steps))
+	previous := counts.Enrolled
+	for i, step := range steps {
+		result[i] = fiber.Map{
+			"step":                step.name,
+			"learners":            step.count,
+			"percent_of_start":    percentOf(step.count, counts.Enrolled),
+			"percent_of_previous": percentOf(step.count, previous),
+		}
+		previous = step.count
+	}
+	return result
+}
+
+// courseContentAnalytics computes completion and failure rates of every content item of a course for the learners
+// selected by enrolled
+func courseContentAnalytics(courseID uint, enrolled func() *gorm.DB, enrolledCount int64) ([]contentAnalytics, error) {
+	db := database.Database.Db
+
+	var contents []struct {
+		courseModels.CourseContent
+		ModuleTitle string
+	}
+	if err := db.Table("course_contents AS ct").
+		Select("ct.*, m.title AS module_title").
+		Joins("JOIN modules m ON m.id = ct.module_id").
+		Where("ct.course_id = ? AND ct.is_deleted = ? AND m.is_deleted = ?", courseID, false, false).
+		Order("m.order_index asc, ct.day asc, ct.order_index asc, ct.id asc").
+		Scan(&contents).Error; err != nil {
+		return nil, err
+	}
+
+	var completions []struct {
+		ContentID   uint
+		Completions int64
+	}
+	if err := db.Model(&courseModels.ContentCompletion{}).
+		Select("course_content_id AS content_id, COUNT(DISTINCT user_id) AS completions").
+		Where("course_id = ? AND is_deleted = ? AND user_id IN (?)", courseID, false, enrolled()).
+		Group("course_content_id").
+		Scan(&completions).Error; err != nil {
+		return nil, err
+	}
+	completed := make(map[uint]int64)
+	for _, row := range completions {
+		completed[row.ContentID] = row.Completions
+	}
+
+	type attemptRow struct {
+		ContentID     uint
+		Attempts      int64
+		Failed        int64
+		FirstAttempts int64
+		FirstFailed   int64
+	}
+
+	var mcqAttempts []attemptRow
+	if err := db.Model(&courseModels.MCQAttempt{}).
+		Select("content_id, COUNT(*) AS attempts, COUNT(*) FILTER (WHERE NOT is_correct) AS failed, "+
+			"COUNT(*) FILTER (WHERE attempt_number = 1) AS first_attempts, COUNT(*) FILTER (WHERE attempt_number = 1 AND NOT is_correct) AS first_failed").
+		Where("content_id IN (?) AND is_deleted = ? AND user_id IN (?)",
+			db.Model(&courseModels.CourseContent{}).Select("id").Where("course_id = ?", courseID), false, enrolled()).
+		Group("content_id").
+		Scan(&mcqAttempts).Error; err != nil {
+		return nil, err
+	}
+
+	var quizAttempts []attemptRow
+	if err := db.Model(&courseModels.QuizAttempt{}).
+		Select("content_id, COUNT(*) AS attempts, COUNT(*) FILTER (WHERE NOT passed) AS failed, "+
+			"COUNT(*) FILTER (WHERE attempt_number = 1) AS first_attempts, COUNT(*) FILTER (WHERE attempt_number = 1 AND NOT passed) AS first_failed").
+		Where("course_id = ? AND status IN ? AND is_deleted = ? AND user_id IN (?)",
+			courseID, []string{courseModels.QuizAttemptSubmitted, courseModels.QuizAttemptExpired}, false, enrolled()).
+		Group("content_id").
+		Scan(&quizAttempts).Error; err != nil {
+		return nil, err
+	}
+
+	attempts := make(map[uint]attemptRow)
+	for _, row := range append(mcqAttempts, quizAttempts...) {
+		attempts[row.ContentID] = row
+	}
+
+	result := make([]contentAnalytics, len(contents))
+	for i, content := range contents {
+		row := attempts[content.ID]
+		result[i] = contentAnalytics{
+			ContentID:           content.ID,
+			ModuleTitle:         content.ModuleTitle,
+			Day:                 content.Day,
+			Title:               content.Title,
+			ContentType:         content.ContentType,
+			IsPublished:         content.IsPublished,
+			Completions:         completed[content.ID],
+			CompletionRate:      percentOf(completed[content.ID], enrolledCount),
+			Attempts:            row.Attempts,
+			FailedAttempts:      row.Failed,
+			FailureRate:         percentOf(row.Failed, row.Attempts),
+			FirstAttemptFailure: percentOf(row.FirstFailed, row.FirstAttempts),
+		}
+	}
+	return result, nil
+}
+
+// sendCSV writes a table as a CSV download
+func sendCSV(c *fiber.Ctx, filename string, header []string, rows [][]string) error {
+	c.Attachment(filename)
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+
+	w := csv.NewWriter(c)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+// formatDays prints an optional number of days for CSV
+func formatDays(days *float64) string {
+	if days == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*days, 'f', 2, 64)
+}
+
+// AdminCourseAnalytics reports a course's learner funnel, time to complete, per-content completion and failure
+// rates and monthly enrolment cohorts, as JSON or as one CSV report
+func AdminCourseAnalytics(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+
+	reqData, ok := c.Locals("validatedAnalyticsQuery").(*struct {
+		From   string `json:"from"`
+		To     string `json:"to"`
+		Format string `json:"format"`
+		Report string `json:"report"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	// Refunded enrolments are deleted and left out
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("e.course_id = ? AND e.is_deleted = ?", course.ID, false)
+		if reqData.From != "" {
+			from, _ := time.ParseInLocation("2006-01-02", reqData.From, time.Local)
+			db = db.Where("e.created_at >= ?", from)
+		}
+		if reqData.To != "" {
+			to, _ := time.ParseInLocation("2006-01-02", reqData.To, time.Local)
+			db = db.Where("e.created_at < ?", to.AddDate(0, 0, 1))
+		}
+		return db
+	}
+	enrolled := func() *gorm.DB {
+		return scope(database.Database.Db.Table("enrollments AS e").Select("e.user_id"))
+	}
+
+	var funnel funnelCounts
+	if err := scope(database.Database.Db.Table("enrollments AS e").Select(funnelColumns)).Scan(&funnel).Error; err != nil {
+		log.Printf("Failed to compute funnel of course %d: %v", course.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch course analytics!", nil)
+	}
+
+	var cohorts []funnelCounts
+	if err := scope(database.Database.Db.Table("enrollments AS e")).
+		Select("TO_CHAR(DATE_TRUNC('month', e.created_at), 'YYYY-MM') AS cohort, " + funnelColumns).
+		Group("cohort").
+		Order("cohort asc").
+		Scan(&cohorts).Error; err != nil {
+		log.Printf("Failed to compute cohorts of course %d: %v", course.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch course analytics!", nil)
+	}
+
+	contents, err := courseContentAnalytics(course.ID, enrolled, funnel.Enrolled)
+	if err != nil {
+		log.Printf("Failed to compute content analytics of course %d: %v", course.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch course analytics!", nil)
+	}
+
+	if reqData.Format == "csv" {
+		filename := fmt.Sprintf("course-%d-%s.csv", course.ID, reqData.Report)
+		switch reqData.Report {
+		case "funnel":
+			rows := [][]string{}
+			for _, step := range funnelSteps(&funnel) {
+				rows = append(rows, []string{
+					step["step"].(string),
+					strconv.FormatInt(step["learners"].(int64), 10),
+					strconv.FormatFloat(step["percent_of_start"].(float64), 'f', 2, 64),
+					strconv.FormatFloat(step["percent_of_previous"].(float64), 'f', 2, 64),
+				})
+			}
+			return sendCSV(c, filename, []string{"step", "learners", "percent_of_start", "percent_of_previous"}, rows)
+		case "contents":
+			rows := make([][]string, len(contents))
+			for i, content := range contents {
+				rows[i] = []string{
+					strconv.FormatUint(uint64(content.ContentID), 10),
+					content.ModuleTitle,
+					strconv.Itoa(content.Day),
+					content.Title,
+					content.ContentType,
+					strconv.FormatBool(content.IsPublished),
+					strconv.FormatInt(content.Completions, 10),
+					strconv.FormatFloat(content.CompletionRate, 'f', 2, 64),
+					strconv.FormatInt(content.Attempts, 10),
+					strconv.FormatInt(content.FailedAttempts, 10),
+					strconv.FormatFloat(content.FailureRate, 'f', 2, 64),
+					strconv.FormatFloat(content.FirstAttemptFailure, 'f', 2, 64),
+				}
+			}
+			return sendCSV(c, filename, []string{"content_id", "module", "day", "title", "content_type", "is_published", "completions",
+				"completion_rate", "attempts", "failed_attempts", "failure_rate", "first_attempt_failure_rate"}, rows)
+		default:
+			rows := make([][]string, len(cohorts))
+			for i, cohort := range cohorts {
+				rows[i] = []string{
+					cohort.Cohort,
+					strconv.FormatInt(cohort.Enrolled, 10),
+					strconv.FormatInt(cohort.Started, 10),
+					strconv.FormatInt(cohort.Halfway, 10),
+					strconv.FormatInt(cohort.Completed, 10),
+					strconv.FormatInt(cohort.Certified, 10),
+					strconv.FormatFloat(percentOf(cohort.Completed, cohort.Enrolled), 'f', 2, 64),
+					strconv.FormatFloat(cohort.AvgProgress, 'f', 2, 64),
+					formatDays(daysOf(cohort.AvgSecondsToComplete)),
+				}
+			}
+			return sendCSV(c, filename, []string{"cohort", "enrolled", "started", "halfway", "completed", "certified",
+				"completion_rate", "avg_progress", "avg_days_to_complete"}, rows)
+		}
+	}
+
+	cohortViews := make([]fiber.Map, len(cohorts))
+	for i := range cohorts {
+		cohortViews[i] = fiber.Map{
+			"cohort":               cohorts[i].Cohort,
+			"funnel":               funnelSteps(&cohorts[i]),
+			"completion_rate":      percentOf(cohorts[i].Completed, cohorts[i].Enrolled),
+			"avg_progress":         cohorts[i].AvgProgress,
+			"avg_days_to_complete": daysOf(cohorts[i].AvgSecondsToComplete),
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Course analytics fetched successfully!", fiber.Map{
+		"course": fiber.Map{
+			"id":    course.ID,
+			"title": course.Title,
+		},
+		"from":   reqData.From,
+		"to":     reqData.To,
+		"funnel": funnelSteps(&funnel),
+		"time_to_complete": fiber.Map{
+			"completed":   funnel.Completed,
+			"avg_days":    daysOf(funnel.AvgSecondsToComplete),
+			"median_days": daysOf(funnel.MedianSecondsToComplete),
+		},
+		"avg_progress": funnel.AvgProgress,
+		"contents":     contents,
+		"cohorts":      cohortViews,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	courseModels "fib/models/course"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 // AdminCreateContent creates new content in a module
@@ -35,14 +36,16 @@ func AdminCreateContent(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedContent").(*struct {
-		Day         int    `json:"day"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		ContentType string `json:"content_type"`
-		TextContent string `json:"text_content"`
-		VideoURL    string `json:"video_url"`
-		ImageURL    string `json:"image_url"`
-		OrderIndex  int    `json:"order_index"`
+		Day                    int     `json:"day"`
+		Title                  string  `json:"title"`
+		Description            string  `json:"description"`
+		ContentType            string  `json:"content_type"`
+		TextContent            string  `json:"text_content"`
+		VideoURL               string  `json:"video_url"`
+		ImageURL               string  `json:"image_url"`
+		OrderIndex             int     `json:"order_index"`
+		VideoDurationSeconds   int     `json:"video_duration_seconds"`
+		VideoCompletionPercent float64 `json:"video_completion_percent"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -70,9 +73,18 @@ func AdminCreateContent(c *fiber.Ctx) error {
 		ImageURL:    reqData.ImageURL,
 		OrderIndex:  orderIndex,
 		IsPublished: false,
+
+		VideoDurationSeconds:   reqData.VideoDurationSeconds,
+		VideoCompletionPercent: reqData.VideoCompletionPercent,
 	}
 
-	if err := database.Database.Db.Create(&content).Error; err != nil {
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&content).Error; err != nil {
+			return err
+		}
+		return ensureQuiz(tx, &content)
+	})
+	if err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create content!", nil)
 	}
 
@@ -103,14 +115,16 @@ func AdminUpdateContent(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedContentUpdate").(*struct {
-		Day         int    `json:"day"`
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		ContentType string `json:"content_type"`
-		TextContent string `json:"text_content"`
-		VideoURL    string `json:"video_url"`
-		ImageURL    string `json:"image_url"`
-		OrderIndex  int    `json:"order_index"`
+		Day                    int     `json:"day"`
+		Title                  string  `json:"title"`
+		Description            string  `json:"description"`
+		ContentType            string  `json:"content_type"`
+		TextContent            string  `json:"text_content"`
+		VideoURL               string  `json:"video_url"`
+		ImageURL               string  `json:"image_url"`
+		OrderIndex             int     `json:"order_index"`
+		VideoDurationSeconds   int     `json:"video_duration_seconds"`
+		VideoCompletionPercent float64 `json:"video_completion_percent"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -140,8 +154,20 @@ func AdminUpdateContent(c *fiber.Ctx) error {
 	if reqData.OrderIndex > 0 {
 		content.OrderIndex = reqData.OrderIndex
 	}
+	if reqData.VideoDurationSeconds > 0 {
+		content.VideoDurationSeconds = reqData.VideoDurationSeconds
+	}
+	if reqData.VideoCompletionPercent > 0 {
+		content.VideoCompletionPercent = reqData.VideoCompletionPercent
+	}
 
-	if err := database.Database.Db.Save(&content).Error; err != nil {
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&content).Error; err != nil {
+			return err
+		}
+		return ensureQuiz(tx, &content)
+	})
+	if err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update content!", nil)
 	}
 
@@ -187,6 +213,14 @@ func AdminDeleteContent(c *fiber.Ctx) error {
 		}
 	}
 
+	// Delete the quiz settings if content type is QUIZ; questions and attempts stay for history
+	if content.ContentType == "QUIZ" {
+		if err := tx.Model(&courseModels.Quiz{}).Where("content_id = ?", contentID).Update("is_deleted", true).Error; err != nil {
+			tx.Rollback()
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete quiz!", nil)
+		}
+	}
+
 	tx.Commit()
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Content deleted successfully!", nil)
@@ -232,6 +266,13 @@ func AdminPublishContent(c *fiber.Ctx) error {
 		}
 	}
 
+	// If publishing a quiz, ensure every question can be answered
+	if publishStatus && content.ContentType == "QUIZ" {
+		if msg := quizPublishError(content.ID); msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+	}
+
 	content.IsPublished = publishStatus
 	if err := database.Database.Db.Save(&content).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update content!", nil)
@@ -388,6 +429,7 @@ func AdminDeleteMCQOption(c *fiber.Ctx) error {
 type AdminContentWithMCQ struct {
 	courseModels.CourseContent
 	MCQOptions []courseModels.MCQOption `json:"mcq_options,omitempty"`
+	Quiz       *courseModels.Quiz       `json:"quiz,omitempty"`
 }
 
 // AdminGetModuleContent gets all content for a module organized by day with MCQ options
@@ -433,6 +475,10 @@ func AdminGetModuleContent(c *fiber.Ctx) error {
 			database.Database.Db.Where("content_id = ? AND is_deleted = ?", content.ID, false).Order("order_index asc").Find(&options)
 			enrichedContents[i].MCQOptions = options
 		}
+
+		if content.ContentType == "QUIZ" {
+			enrichedContents[i].Quiz = findQuiz(content.ID)
+		}
 	}
 
 	// Group by day
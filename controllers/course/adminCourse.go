@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
+	"fib/models/basket"
 	courseModels "fib/models/course"
 
 	"github.com/gofiber/fiber/v2"
@@ -28,11 +30,18 @@ func AdminCreateCourse(c *fiber.Ctx) error {
 
 	// Get validated request data
 	reqData, ok := c.Locals("validatedCourse").(*struct {
-		Title        string `json:"title"`
-		Description  string `json:"description"`
-		Author       string `json:"author"`
-		Duration     int64  `json:"duration"`
-		ThumbnailURL string `json:"thumbnail_url"`
+		Title               string   `json:"title"`
+		Description         string   `json:"description"`
+		Author              string   `json:"author"`
+		Duration            int64    `json:"duration"`
+		ThumbnailURL        string   `json:"thumbnail_url"`
+		CertificatePolicy   string   `json:"certificate_policy"`
+		CertificateMinScore *float64 `json:"certificate_min_score"`
+		DripEnabled         bool     `json:"drip_enabled"`
+		Price               float64  `json:"price"`
+		RefundWindowDays    *int     `json:"refund_window_days"`
+		IncludedBasketIDs   []uint   `json:"included_basket_ids"`
+		InstructorID        *uint    `json:"instructor_id"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -46,6 +55,31 @@ func AdminCreateCourse(c *fiber.Ctx) error {
 		ThumbnailURL: reqData.ThumbnailURL,
 		Status:       "DRAFT",
 		IsPublished:  false,
+
+		CertificatePolicy: reqData.CertificatePolicy,
+		DripEnabled:       reqData.DripEnabled,
+		Price:             reqData.Price,
+		RefundWindowDays:  7,
+		IncludedBasketIDs: "[]",
+	}
+	if reqData.CertificateMinScore != nil {
+		course.CertificateMinScore = *reqData.CertificateMinScore
+	}
+	if reqData.RefundWindowDays != nil {
+		course.RefundWindowDays = *reqData.RefundWindowDays
+	}
+	if reqData.IncludedBasketIDs != nil {
+		basketIDs, msg := checkIncludedBaskets(reqData.IncludedBasketIDs)
+		if msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+		course.IncludedBasketIDs = basketIDs
+	}
+	if reqData.InstructorID != nil && *reqData.InstructorID > 0 {
+		if msg := checkInstructor(*reqData.InstructorID); msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+		course.InstructorID = reqData.InstructorID
 	}
 
 	if err := database.Database.Db.Create(&course).Error; err != nil {
@@ -79,12 +113,19 @@ func AdminUpdateCourse(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedCourseUpdate").(*struct {
-		Title        string `json:"title"`
-		Description  string `json:"description"`
-		Author       string `json:"author"`
-		Duration     int64  `json:"duration"`
-		ThumbnailURL string `json:"thumbnail_url"`
-		Status       string `json:"status"`
+		Title               string   `json:"title"`
+		Description         string   `json:"description"`
+		Author              string   `json:"author"`
+		Duration            int64    `json:"duration"`
+		ThumbnailURL        string   `json:"thumbnail_url"`
+		Status              string   `json:"status"`
+		CertificatePolicy   string   `json:"certificate_policy"`
+		CertificateMinScore *float64 `json:"certificate_min_score"`
+		DripEnabled         *bool    `json:"drip_enabled"`
+		Price               *float64 `json:"price"`
+		RefundWindowDays    *int     `json:"refund_window_days"`
+		IncludedBasketIDs   []uint   `json:"included_basket_ids"`
+		InstructorID        *uint    `json:"instructor_id"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -109,6 +150,41 @@ func AdminUpdateCourse(c *fiber.Ctx) error {
 	if reqData.Status != "" {
 		course.Status = reqData.Status
 	}
+	if reqData.CertificatePolicy != "" {
+		course.CertificatePolicy = reqData.CertificatePolicy
+	}
+	if reqData.CertificateMinScore != nil {
+		course.CertificateMinScore = *reqData.CertificateMinScore
+	}
+	if reqData.DripEnabled != nil {
+		course.DripEnabled = *reqData.DripEnabled
+	}
+	if reqData.Price != nil {
+		course.Price = *reqData.Price
+	}
+	if reqData.RefundWindowDays != nil {
+		course.RefundWindowDays = *reqData.RefundWindowDays
+	}
+	if reqData.IncludedBasketIDs != nil {
+		basketIDs, msg := checkIncludedBaskets(reqData.IncludedBasketIDs)
+		if msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+		course.IncludedBasketIDs = basketIDs
+	}
+	if reqData.InstructorID != nil {
+		if *reqData.InstructorID == 0 {
+			course.InstructorID = nil
+		} else {
+			if msg := checkInstructor(*reqData.InstructorID); msg != "" {
+				return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+			}
+			course.InstructorID = reqData.InstructorID
+		}
+	}
+	if course.CertificatePolicy == courseModels.CertificatePolicyMinScore && course.CertificateMinScore <= 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Set a minimum score for the MIN_SCORE policy!", nil)
+	}
 
 	if err := database.Database.Db.Save(&course).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update course!", nil)
@@ -282,3 +358,36 @@ func AdminPublishCourse(c *fiber.Ctx) error {
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, message, course)
 }
+
+// checkIncludedBaskets verifies the baskets that include a course and returns them as stored JSON,
+// or an error message
+func checkIncludedBaskets(basketIDs []uint) (string, string) {
+	unique := make([]uint, 0, len(basketIDs))
+	seen := make(map[uint]bool)
+	for _, id := range basketIDs {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	if len(unique) > 0 {
+		var found int64
+		database.Database.Db.Model(&basket.Basket{}).Where("id IN ? AND is_deleted = ?", unique, false).Count(&found)
+		if int(found) != len(unique) {
+			return "", "Some included baskets were not found!"
+		}
+	}
+
+	encoded, _ := json.Marshal(unique)
+	return string(encoded), ""
+}
+
+// checkInstructor verifies the user set as a course's instructor, returning the error message or ""
+func checkInstructor(instructorID uint) string {
+	var instructor models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", instructorID, false).First(&instructor).Error; err != nil {
+		return "Instructor not found!"
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AdminExportCourse downloads a course with its modules, contents, MCQ options and quizzes as a JSON or ZIP package
+func AdminExportCourse(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	format := c.Locals("exportFormat").(string)
+
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	pkg, err := buildCoursePackage(&course)
+	if err != nil {
+		log.Printf("Failed to export course %d: %v", courseID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to export course!", nil)
+	}
+
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to export course!", nil)
+	}
+
+	if format == "zip" {
+		if data, err = zipCoursePackage(data); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to export course!", nil)
+		}
+	}
+
+	c.Attachment(fmt.Sprintf("course-%d.%s", course.ID, format))
+	return c.Send(data)
+}
+
+// AdminImportCourse creates a course from a package, or updates the course given by course_id to match it.
+// With dry_run the import runs in a transaction that is rolled back, so the reported changes are exactly what a
+// real import would do.
+func AdminImportCourse(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	dryRun := c.Locals("dryRun").(bool)
+
+	// The package is either an uploaded file or the request body itself
+	data := c.Body()
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > coursePackageMaxBytes {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course package is too large!", nil)
+		}
+		f, err := file.Open()
+		if err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Failed to read course package!", nil)
+		}
+		data, err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Failed to read course package!", nil)
+		}
+	}
+
+	pkg, err := readCoursePackage(data)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid course package: "+err.Error(), nil)
+	}
+
+	if errs := validateCoursePackage(pkg); len(errs) > 0 {
+		return middleware.ValidationErrorResponse(c, errs)
+	}
+
+	var course courseModels.Course
+	if courseID > 0 {
+		if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+		}
+	}
+
+	var changes []packageChange
+	err = database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if changes, err = importCoursePackage(tx, pkg, &course); err != nil {
+			return err
+		}
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		log.Printf("Failed to import course package (course %d): %v", courseID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to import course!", nil)
+	}
+
+	summary := map[string]int{packageCreate: 0, packageUpdate: 0, packageDelete: 0, packageUnchanged: 0}
+	for _, change := range changes {
+		summary[change.Action]++
+	}
+
+	if dryRun {
+		return middleware.JsonResponse(c, fiber.StatusOK, true, "Dry run completed, nothing was changed!", fiber.Map{
+			"dry_run": true,
+			"summary": summary,
+			"changes": changes,
+		})
+	}
+
+	message := "Course updated from package successfully!"
+	if courseID == 0 {
+		message = "Course created from package successfully!"
+	}
+	return middleware.JsonResponse(c, fiber.StatusOK, true, message, fiber.Map{
+		"dry_run": false,
+		"course":  course,
+		"summary": summary,
+		"changes": changes,
+	})
+}
@@ -1,12 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
 	courseModels "fib/models/course"
 	"fib/utils"
-	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -410,31 +410,20 @@ func AdminApproveCertificate(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to approve request!", nil)
 	}
 
-	// Generate certificate number
-	certNumber := fmt.Sprintf("CERT-%d-%d-%d", request.CourseID, request.UserID, time.Now().Unix())
-
-	// Create certificate
-	certificate := courseModels.Certificate{
-		UserID:            request.UserID,
-		CourseID:          request.CourseID,
-		CertificateNumber: certNumber,
-		IssuedAt:          now,
+	certificate, err := utils.CreateCertificate(tx, request.UserID, request.CourseID)
+	if errors.Is(err, utils.ErrCertificateExists) {
+		tx.Rollback()
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Certificate already issued for this course!", nil)
 	}
-
-	if err := tx.Create(&certificate).Error; err != nil {
+	if err != nil {
 		tx.Rollback()
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create certificate!", nil)
 	}
 
 	tx.Commit()
 
-	// Send certificate email asynchronously
-	var reqUser models.User
-	var course courseModels.Course
-	database.Database.Db.Where("id = ?", request.UserID).First(&reqUser)
-	database.Database.Db.Where("id = ?", request.CourseID).First(&course)
-
-	go utils.SendCertificateEmail(reqUser.Email, reqUser.Name, course.Title, certNumber)
+	// Render the PDF and send the certificate email asynchronously
+	go utils.DeliverCertificate(certificate)
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Certificate approved and generated successfully!", certificate)
 }
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminGetDiscussions lists discussion threads across courses, oldest first, so unanswered questions can be worked off
+func AdminGetDiscussions(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	reqData, _ := c.Locals("validatedAdminDiscussionQuery").(*struct {
+		Page       *int `json:"page"`
+		Limit      *int `json:"limit"`
+		CourseID   uint `json:"course_id"`
+		Unanswered bool `json:"unanswered"`
+	})
+
+	page := 1
+	limit := 10
+	if reqData != nil && reqData.Page != nil && *reqData.Page > 0 {
+		page = *reqData.Page
+	}
+	if reqData != nil && reqData.Limit != nil && *reqData.Limit > 0 {
+		limit = *reqData.Limit
+	}
+	offset := (page - 1) * limit
+
+	db := database.Database.Db.Model(&courseModels.DiscussionThread{}).Where("is_deleted = ?", false)
+	if reqData != nil && reqData.CourseID > 0 {
+		db = db.Where("course_id = ?", reqData.CourseID)
+	}
+	if reqData != nil && reqData.Unanswered {
+		db = db.Where("is_answered = ? AND is_resolved = ?", false, false)
+	}
+
+	var total int64
+	db.Count(&total)
+
+	var threads []courseModels.DiscussionThread
+	if err := db.Order("created_at asc").Offset(offset).Limit(limit).Find(&threads).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch discussions!", nil)
+	}
+
+	userIDs := make([]uint, len(threads))
+	courseIDs := make([]uint, len(threads))
+	contentIDs := make([]uint, len(threads))
+	for i, t := range threads {
+		userIDs[i] = t.UserID
+		courseIDs[i] = t.CourseID
+		contentIDs[i] = t.ContentID
+	}
+	names := userNames(userIDs)
+
+	var courses []courseModels.Course
+	database.Database.Db.Select("id, title").Where("id IN ?", courseIDs).Find(&courses)
+	courseTitles := make(map[uint]string)
+	for _, course := range courses {
+		courseTitles[course.ID] = course.Title
+	}
+
+	var contents []courseModels.CourseContent
+	database.Database.Db.Select("id, title").Where("id IN ?", contentIDs).Find(&contents)
+	contentTitles := make(map[uint]string)
+	for _, content := range contents {
+		contentTitles[content.ID] = content.Title
+	}
+
+	type ThreadWithDetails struct {
+		courseModels.DiscussionThread
+		UserName     string `json:"user_name"`
+		CourseTitle  string `json:"course_title"`
+		ContentTitle string `json:"content_title"`
+	}
+
+	result := make([]ThreadWithDetails, len(threads))
+	for i, t := range threads {
+		result[i] = ThreadWithDetails{
+			DiscussionThread: t,
+			UserName:         names[t.UserID],
+			CourseTitle:      courseTitles[t.CourseID],
+			ContentTitle:     contentTitles[t.ContentID],
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Discussions fetched successfully!", fiber.Map{
+		"threads": result,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
@@ -34,9 +34,11 @@ func AdminCreateModule(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedModule").(*struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		OrderIndex  int    `json:"order_index"`
+		Title                string `json:"title"`
+		Description          string `json:"description"`
+		OrderIndex           int    `json:"order_index"`
+		PrerequisiteModuleID *uint  `json:"prerequisite_module_id"`
+		DripOffsetDays       *int   `json:"drip_offset_days"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -56,6 +58,15 @@ func AdminCreateModule(c *fiber.Ctx) error {
 		Description: reqData.Description,
 		OrderIndex:  orderIndex,
 	}
+	if reqData.DripOffsetDays != nil {
+		module.DripOffsetDays = *reqData.DripOffsetDays
+	}
+	if reqData.PrerequisiteModuleID != nil && *reqData.PrerequisiteModuleID > 0 {
+		if msg := checkModulePrerequisite(uint(courseID), 0, *reqData.PrerequisiteModuleID); msg != "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+		}
+		module.PrerequisiteModuleID = reqData.PrerequisiteModuleID
+	}
 
 	if err := database.Database.Db.Create(&module).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create module!", nil)
@@ -89,9 +100,11 @@ func AdminUpdateModule(c *fiber.Ctx) error {
 	}
 
 	reqData, ok := c.Locals("validatedModuleUpdate").(*struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		OrderIndex  int    `json:"order_index"`
+		Title                string `json:"title"`
+		Description          string `json:"description"`
+		OrderIndex           int    `json:"order_index"`
+		PrerequisiteModuleID *uint  `json:"prerequisite_module_id"`
+		DripOffsetDays       *int   `json:"drip_offset_days"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -106,6 +119,19 @@ func AdminUpdateModule(c *fiber.Ctx) error {
 	if reqData.OrderIndex > 0 {
 		module.OrderIndex = reqData.OrderIndex
 	}
+	if reqData.DripOffsetDays != nil {
+		module.DripOffsetDays = *reqData.DripOffsetDays
+	}
+	if reqData.PrerequisiteModuleID != nil {
+		if *reqData.PrerequisiteModuleID == 0 {
+			module.PrerequisiteModuleID = nil
+		} else {
+			if msg := checkModulePrerequisite(uint(courseID), module.ID, *reqData.PrerequisiteModuleID); msg != "" {
+				return middleware.JsonResponse(c, fiber.StatusBadRequest, false, msg, nil)
+			}
+			module.PrerequisiteModuleID = reqData.PrerequisiteModuleID
+		}
+	}
 
 	if err := database.Database.Db.Save(&module).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update module!", nil)
@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ensureQuiz creates default quiz settings for QUIZ content that has none
+func ensureQuiz(tx *gorm.DB, content *courseModels.CourseContent) error {
+	if content.ContentType != "QUIZ" {
+		return nil
+	}
+
+	var quiz courseModels.Quiz
+	err := tx.Where("content_id = ?", content.ID).First(&quiz).Error
+	if err == nil {
+		if !quiz.IsDeleted {
+			return nil
+		}
+		return tx.Model(&quiz).Update("is_deleted", false).Error
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	quiz = courseModels.Quiz{
+		ContentID:        content.ID,
+		CourseID:         content.CourseID,
+		PassPercent:      60,
+		NegativeFraction: 0.25,
+	}
+	return tx.Create(&quiz).Error
+}
+
+// quizPublishError explains why QUIZ content can't be published yet, or returns ""
+func quizPublishError(contentID uint) string {
+	quiz := findQuiz(contentID)
+	if quiz == nil {
+		return "Quiz settings not found!"
+	}
+
+	questions, err := loadQuizQuestions(database.Database.Db, quiz.ID)
+	if err != nil {
+		return "Failed to check quiz questions!"
+	}
+	if len(questions) == 0 {
+		return "Quiz must have at least one question before publishing!"
+	}
+
+	for i, question := range questions {
+		correct := 0
+		for _, option := range question.Options {
+			if option.IsCorrect {
+				correct++
+			}
+		}
+		if len(question.Options) < 2 || correct == 0 {
+			return fmt.Sprintf("Question %d must have at least 2 options and one correct answer!", i+1)
+		}
+	}
+	return ""
+}
+
+// adminQuizForContent loads the quiz of QUIZ content for the admin handlers
+func adminQuizForContent(c *fiber.Ctx, contentID int) (*courseModels.Quiz, error) {
+	var content courseModels.CourseContent
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", contentID, false).First(&content).Error; err != nil {
+		return nil, middleware.JsonResponse(c, fiber.StatusNotFound, false, "Content not found!", nil)
+	}
+
+	if content.ContentType != "QUIZ" {
+		return nil, middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content is not a QUIZ type!", nil)
+	}
+
+	quiz := findQuiz(content.ID)
+	if quiz == nil {
+		return nil, middleware.JsonResponse(c, fiber.StatusNotFound, false, "Quiz not found!", nil)
+	}
+	return quiz, nil
+}
+
+// AdminGetQuiz gets a quiz's settings and questions including the correct answers
+func AdminGetQuiz(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	contentID := c.Locals("contentID").(int)
+
+	quiz, respErr := adminQuizForContent(c, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	questions, err := loadQuizQuestions(database.Database.Db, quiz.ID)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch quiz questions!", nil)
+	}
+
+	var totalMarks float64
+	for _, question := range questions {
+		totalMarks += question.Marks
+	}
+
+	var attemptCount int64
+	database.Database.Db.Model(&courseModels.QuizAttempt{}).Where("quiz_id = ? AND is_deleted = ?", quiz.ID, false).Count(&attemptCount)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz fetched successfully!", fiber.Map{
+		"quiz":          quiz,
+		"questions":     questions,
+		"total_marks":   totalMarks,
+		"attempt_count": attemptCount,
+	})
+}
+
+// AdminUpdateQuizSettings updates a quiz's grading, attempt and timing settings
+func AdminUpdateQuizSettings(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	contentID := c.Locals("contentID").(int)
+
+	reqData, ok := c.Locals("validatedQuizSettings").(*struct {
+		PassPercent      *float64 `json:"pass_percent"`
+		NegativeMarking  *bool    `json:"negative_marking"`
+		NegativeFraction *float64 `json:"negative_fraction"`
+		MaxAttempts      *int     `json:"max_attempts"`
+		TimeLimitSeconds *int     `json:"time_limit_seconds"`
+		ShuffleQuestions *bool    `json:"shuffle_questions"`
+		ShuffleOptions   *bool    `json:"shuffle_options"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	quiz, respErr := adminQuizForContent(c, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	if reqData.PassPercent != nil {
+		quiz.PassPercent = *reqData.PassPercent
+	}
+	if reqData.NegativeMarking != nil {
+		quiz.NegativeMarking = *reqData.NegativeMarking
+	}
+	if reqData.NegativeFraction != nil {
+		quiz.NegativeFraction = *reqData.NegativeFraction
+	}
+	if reqData.MaxAttempts != nil {
+		quiz.MaxAttempts = *reqData.MaxAttempts
+	}
+	if reqData.TimeLimitSeconds != nil {
+		quiz.TimeLimitSeconds = *reqData.TimeLimitSeconds
+	}
+	if reqData.ShuffleQuestions != nil {
+		quiz.ShuffleQuestions = *reqData.ShuffleQuestions
+	}
+	if reqData.ShuffleOptions != nil {
+		quiz.ShuffleOptions = *reqData.ShuffleOptions
+	}
+
+	if err := database.Database.Db.Save(quiz).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update quiz!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz updated successfully!", quiz)
+}
+
+// AdminAddQuizQuestion adds a question with its options to a quiz
+func AdminAddQuizQuestion(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	contentID := c.Locals("contentID").(int)
+
+	reqData, ok := c.Locals("validatedQuizQuestion").(*struct {
+		Question   string  `json:"question"`
+		Marks      float64 `json:"marks"`
+		OrderIndex int     `json:"order_index"`
+		Options    []struct {
+			OptionText string `json:"option_text"`
+			IsCorrect  bool   `json:"is_correct"`
+		} `json:"options"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	quiz, respErr := adminQuizForContent(c, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	// Get the next order index if not provided
+	orderIndex := reqData.OrderIndex
+	if orderIndex == 0 {
+		var maxOrder int
+		database.Database.Db.Model(&courseModels.QuizQuestion{}).
+			Where("quiz_id = ? AND is_deleted = ?", quiz.ID, false).
+			Select("COALESCE(MAX(order_index), 0)").Scan(&maxOrder)
+		orderIndex = maxOrder + 1
+	}
+
+	question := courseModels.QuizQuestion{
+		QuizID:     quiz.ID,
+		Question:   reqData.Question,
+		Marks:      reqData.Marks,
+		OrderIndex: orderIndex,
+	}
+	for i, option := range reqData.Options {
+		question.Options = append(question.Options, courseModels.QuizOption{
+			OptionText: option.OptionText,
+			IsCorrect:  option.IsCorrect,
+			OrderIndex: i + 1,
+		})
+	}
+
+	// Options are created together with the question
+	if err := database.Database.Db.Create(&question).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to add quiz question!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusCreated, true, "Quiz question added successfully!", question)
+}
+
+// AdminUpdateQuizQuestion updates a quiz question; given options replace the existing ones
+func AdminUpdateQuizQuestion(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	questionID := c.Locals("questionID").(int)
+
+	reqData, ok := c.Locals("validatedQuizQuestionUpdate").(*struct {
+		Question   string  `json:"question"`
+		Marks      float64 `json:"marks"`
+		OrderIndex int     `json:"order_index"`
+		Options    []struct {
+			OptionText string `json:"option_text"`
+			IsCorrect  bool   `json:"is_correct"`
+		} `json:"options"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var question courseModels.QuizQuestion
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", questionID, false).First(&question).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Quiz question not found!", nil)
+	}
+
+	if reqData.Question != "" {
+		question.Question = reqData.Question
+	}
+	if reqData.Marks > 0 {
+		question.Marks = reqData.Marks
+	}
+	if reqData.OrderIndex > 0 {
+		question.OrderIndex = reqData.OrderIndex
+	}
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Options").Save(&question).Error; err != nil {
+			return err
+		}
+		if reqData.Options == nil {
+			return nil
+		}
+
+		if err := tx.Model(&courseModels.QuizOption{}).Where("question_id = ?", question.ID).Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+		options := make([]courseModels.QuizOption, len(reqData.Options))
+		for i, option := range reqData.Options {
+			options[i] = courseModels.QuizOption{
+				QuestionID: question.ID,
+				OptionText: option.OptionText,
+				IsCorrect:  option.IsCorrect,
+				OrderIndex: i + 1,
+			}
+		}
+		return tx.Create(&options).Error
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update quiz question!", nil)
+	}
+
+	database.Database.Db.Where("question_id = ? AND is_deleted = ?", question.ID, false).Order("order_index asc, id asc").Find(&question.Options)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz question updated successfully!", question)
+}
+
+// AdminDeleteQuizQuestion soft deletes a quiz question and its options
+func AdminDeleteQuizQuestion(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	questionID := c.Locals("questionID").(int)
+
+	var question courseModels.QuizQuestion
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", questionID, false).First(&question).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Quiz question not found!", nil)
+	}
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&question).Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+		return tx.Model(&courseModels.QuizOption{}).Where("question_id = ?", question.ID).Update("is_deleted", true).Error
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete quiz question!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz question deleted successfully!", nil)
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminGetCourseReviews lists course reviews for moderation, oldest pending first
+func AdminGetCourseReviews(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	reqData, _ := c.Locals("validatedAdminReviewQuery").(*struct {
+		Page     *int   `json:"page"`
+		Limit    *int   `json:"limit"`
+		Status   string `json:"status"`
+		CourseID uint   `json:"course_id"`
+	})
+
+	page := 1
+	limit := 10
+	if reqData != nil && reqData.Page != nil && *reqData.Page > 0 {
+		page = *reqData.Page
+	}
+	if reqData != nil && reqData.Limit != nil && *reqData.Limit > 0 {
+		limit = *reqData.Limit
+	}
+	offset := (page - 1) * limit
+
+	db := database.Database.Db.Model(&courseModels.CourseReview{}).Where("is_deleted = ?", false)
+	if reqData != nil && reqData.Status != "" {
+		db = db.Where("status = ?", reqData.Status)
+	}
+	if reqData != nil && reqData.CourseID > 0 {
+		db = db.Where("course_id = ?", reqData.CourseID)
+	}
+
+	var total int64
+	db.Count(&total)
+
+	var reviews []courseModels.CourseReview
+	if err := db.Order("status = 'PENDING' desc, created_at asc").Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch reviews!", nil)
+	}
+
+	userIDs := make([]uint, len(reviews))
+	courseIDs := make([]uint, len(reviews))
+	for i, r := range reviews {
+		userIDs[i] = r.UserID
+		courseIDs[i] = r.CourseID
+	}
+	names := userNames(userIDs)
+
+	var courses []courseModels.Course
+	database.Database.Db.Select("id, title").Where("id IN ?", courseIDs).Find(&courses)
+	titles := make(map[uint]string)
+	for _, course := range courses {
+		titles[course.ID] = course.Title
+	}
+
+	type ReviewWithDetails struct {
+		courseModels.CourseReview
+		UserName    string `json:"user_name"`
+		CourseTitle string `json:"course_title"`
+	}
+
+	result := make([]ReviewWithDetails, len(reviews))
+	for i, r := range reviews {
+		result[i] = ReviewWithDetails{
+			CourseReview: r,
+			UserName:     names[r.UserID],
+			CourseTitle:  titles[r.CourseID],
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reviews fetched successfully!", fiber.Map{
+		"reviews": result,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// AdminModerateCourseReview approves or rejects a course review and recomputes the course rating
+func AdminModerateCourseReview(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	reviewID := c.Locals("reviewID").(int)
+
+	reqData, ok := c.Locals("validatedReviewModeration").(*struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+		Reply  string `json:"reply"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var review courseModels.CourseReview
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND is_deleted = ?", reviewID, false).First(&review).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		review.ModeratedBy = &userId
+		review.ModeratedAt = &now
+		if reqData.Action == "APPROVE" {
+			review.Status = courseModels.CourseReviewApproved
+			review.RejectionReason = ""
+		} else {
+			review.Status = courseModels.CourseReviewRejected
+			review.RejectionReason = reqData.Reason
+		}
+		if reqData.Reply != "" {
+			review.Reply = reqData.Reply
+			review.RepliedAt = &now
+		}
+
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+		return recomputeCourseRating(tx, review.CourseID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Review not found!", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to moderate course review %d: %v", reviewID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to moderate review!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Review moderated successfully!", review)
+}
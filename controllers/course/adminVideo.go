@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// videoAnalyticsBuckets splits a video into tenths for the drop-off chart
+const videoAnalyticsBuckets = 10
+
+// AdminGetVideoAnalytics reports how far learners get through a video and where they stop watching
+func AdminGetVideoAnalytics(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	if user.Role != "ADMIN" {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Access denied! Admin only.", nil)
+	}
+
+	contentID := c.Locals("contentID").(int)
+
+	var content courseModels.CourseContent
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", contentID, false).First(&content).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Content not found!", nil)
+	}
+
+	if content.ContentType != "VIDEO" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content is not a VIDEO type!", nil)
+	}
+
+	var summary struct {
+		Viewers            int64
+		Completed          int64
+		AvgWatchedPercent  float64
+		AvgWatchedSeconds  float64
+		AvgDurationSeconds float64
+	}
+	if err := database.Database.Db.Model(&courseModels.VideoProgress{}).
+		Select("COUNT(*) AS viewers, COUNT(completed_at) AS completed, COALESCE(AVG(watched_percent), 0) AS avg_watched_percent, "+
+			"COALESCE(AVG(watched_seconds), 0) AS avg_watched_seconds, COALESCE(AVG(duration_seconds), 0) AS avg_duration_seconds").
+		Where("content_id = ? AND is_deleted = ?", contentID, false).
+		Scan(&summary).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch video analytics!", nil)
+	}
+
+	// Bucket each viewer by the furthest point they reached
+	var rows []struct {
+		Bucket  int
+		Viewers int64
+	}
+	if err := database.Database.Db.Model(&courseModels.VideoProgress{}).
+		Select("LEAST(FLOOR(max_position_seconds / duration_seconds * ?), ?)::int AS bucket, COUNT(*) AS viewers", videoAnalyticsBuckets, videoAnalyticsBuckets-1).
+		Where("content_id = ? AND is_deleted = ? AND duration_seconds > 0", contentID, false).
+		Group("bucket").
+		Scan(&rows).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch video analytics!", nil)
+	}
+
+	stopped := make([]int64, videoAnalyticsBuckets)
+	for _, row := range rows {
+		if row.Bucket >= 0 && row.Bucket < videoAnalyticsBuckets {
+			stopped[row.Bucket] += row.Viewers
+		}
+	}
+
+	// Drop-off lists how many viewers stopped within each tenth; retention how many got at least that far
+	step := 100 / videoAnalyticsBuckets
+	dropOff := make([]fiber.Map, videoAnalyticsBuckets)
+	retention := make([]fiber.Map, videoAnalyticsBuckets)
+	remaining := summary.Viewers
+	for i := 0; i < videoAnalyticsBuckets; i++ {
+		retentionPercent := float64(0)
+		if summary.Viewers > 0 {
+			retentionPercent = float64(remaining) / float64(summary.Viewers) * 100
+		}
+		retention[i] = fiber.Map{
+			"from_percent":      i * step,
+			"viewers":           remaining,
+			"retention_percent": retentionPercent,
+		}
+		dropOff[i] = fiber.Map{
+			"from_percent":    i * step,
+			"to_percent":      (i + 1) * step,
+			"viewers_stopped": stopped[i],
+		}
+		remaining -= stopped[i]
+	}
+
+	completionRate := float64(0)
+	if summary.Viewers > 0 {
+		completionRate = float64(summary.Completed) / float64(summary.Viewers) * 100
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Video analytics fetched successfully!", fiber.Map{
+		"content":              content,
+		"viewers":              summary.Viewers,
+		"completed":            summary.Completed,
+		"completion_rate":      completionRate,
+		"completion_percent":   videoCompletionPercent(&content),
+		"avg_watched_percent":  summary.AvgWatchedPercent,
+		"avg_watched_seconds":  summary.AvgWatchedSeconds,
+		"avg_duration_seconds": summary.AvgDurationSeconds,
+		"drop_off":             dropOff,
+		"retention":            retention,
+	})
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
 	courseModels "fib/models/course"
+	"fib/storage"
+	"fib/utils"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -53,6 +56,26 @@ func RequestCertificate(c *fiber.Ctx) error {
 		})
 	}
 
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	// Only MANUAL courses go through admin review, the others issue right away when eligible
+	if course.CertificatePolicy != "" && course.CertificatePolicy != courseModels.CertificatePolicyManual {
+		certificate, eligibility, err := utils.IssueCertificateOnCompletion(userID, uint(courseID))
+		if errors.Is(err, utils.ErrCertificateExists) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Certificate already exists!", nil)
+		}
+		if err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to issue certificate!", nil)
+		}
+		if certificate == nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "You are not eligible for a certificate yet!", eligibility)
+		}
+		return middleware.JsonResponse(c, fiber.StatusCreated, true, "Certificate issued successfully!", certificate)
+	}
+
 	request := courseModels.CertificateRequest{
 		UserID:       userID,
 		CourseID:     uint(courseID),
@@ -153,3 +176,51 @@ func GetUserEnrollmentsList(c *fiber.Ctx) error {
 		"total":       len(result),
 	})
 }
+
+// findIssuedCertificate loads an active certificate by its number
+func findIssuedCertificate(number string) (*courseModels.Certificate, error) {
+	var certificate courseModels.Certificate
+	if err := database.Database.Db.Where("certificate_number = ? AND is_deleted = ?", number, false).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// VerifyCertificate publicly confirms that a certificate number was issued, and to whom
+func VerifyCertificate(c *fiber.Ctx) error {
+	certificate, err := findIssuedCertificate(c.Params("number"))
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Certificate not found!", fiber.Map{
+			"valid": false,
+		})
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Certificate is valid!", fiber.Map{
+		"valid":              true,
+		"certificate_number": certificate.CertificateNumber,
+		"recipient_name":     certificate.RecipientName,
+		"course_title":       certificate.CourseTitle,
+		"issued_at":          certificate.IssuedAt,
+		"certificate_url":    certificate.CertificateURL,
+	})
+}
+
+// DownloadCertificate redirects to a short-lived signed URL of the certificate PDF. PDFs are rendered at issuance,
+// this public endpoint only reads.
+func DownloadCertificate(c *fiber.Ctx) error {
+	certificate, err := findIssuedCertificate(c.Params("number"))
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Certificate not found!", nil)
+	}
+
+	if certificate.FileKey == "" {
+		c.Set(fiber.HeaderRetryAfter, "600")
+		return middleware.JsonResponse(c, fiber.StatusServiceUnavailable, false, "Certificate is still being generated, try again later!", nil)
+	}
+
+	url := storage.URL(certificate.FileKey)
+	if url == "" {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch certificate!", nil)
+	}
+	return c.Redirect(url, fiber.StatusFound)
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"fib/database"
+	courseModels "fib/models/course"
+	"time"
+)
+
+// contentLock tells a learner whether content is available yet and why not
+type contentLock struct {
+	IsLocked   bool       `json:"is_locked"`
+	LockReason string     `json:"lock_reason,omitempty"`
+	UnlocksAt  *time.Time `json:"unlocks_at,omitempty"` // Set for drip releases only
+}
+
+// courseAccess evaluates enrolment, drip release and module prerequisites of one course for one learner
+type courseAccess struct {
+	userID     uint
+	course     courseModels.Course
+	enrollment *courseModels.Enrollment // nil when not enrolled
+	expired    bool                     // Access came with a basket subscription that has ended
+	modules    map[uint]courseModels.Module
+	moduleDone map[uint]bool // Cache of moduleCompleted
+}
+
+// loadCourseAccess loads what is needed to decide which content of a course a learner can open
+func loadCourseAccess(userID, courseID uint) (*courseAccess, error) {
+	access := &courseAccess{
+		userID:     userID,
+		modules:    make(map[uint]courseModels.Module),
+		moduleDone: make(map[uint]bool),
+	}
+
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&access.course).Error; err != nil {
+		return nil, err
+	}
+
+	var enrollment courseModels.Enrollment
+	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&enrollment).Error; err == nil {
+		access.enrollment = &enrollment
+		access.expired = subscriptionAccessEnded(userID, &access.course, &enrollment)
+	}
+
+	var modules []courseModels.Module
+	if err := database.Database.Db.Where("course_id = ? AND is_deleted = ?", courseID, false).Find(&modules).Error; err != nil {
+		return nil, err
+	}
+	for _, module := range modules {
+		access.modules[module.ID] = module
+	}
+	return access, nil
+}
+
+// moduleCompleted reports whether the learner completed every published content of a module.
+// Quiz content only counts once passed.
+func (a *courseAccess) moduleCompleted(moduleID uint) bool {
+	if done, ok := a.moduleDone[moduleID]; ok {
+		return done
+	}
+
+	var remaining int64
+	database.Database.Db.Model(&courseModels.CourseContent{}).
+		Where("module_id = ? AND is_deleted = ? AND is_published = ?", moduleID, false, true).
+		Where("id NOT IN (?)", database.Database.Db.Model(&courseModels.ContentCompletion{}).
+			Select("course_content_id").
+			Where("user_id = ? AND is_deleted = ?", a.userID, false)).
+		Count(&remaining)
+
+	a.moduleDone[moduleID] = remaining == 0
+	return remaining == 0
+}
+
+// moduleLock checks enrolment and the module's prerequisite
+func (a *courseAccess) moduleLock(moduleID uint) contentLock {
+	if a.enrollment == nil {
+		return contentLock{IsLocked: true, LockReason: "Please enroll in this course first!"}
+	}
+	if a.expired {
+		return contentLock{IsLocked: true, LockReason: "The basket subscription that included this course has ended!"}
+	}
+
+	module, ok := a.modules[moduleID]
+	if !ok {
+		return contentLock{IsLocked: true, LockReason: "Module not found!"}
+	}
+
+	// A deleted prerequisite no longer blocks the module
+	if module.PrerequisiteModuleID != nil {
+		if prerequisite, ok := a.modules[*module.PrerequisiteModuleID]; ok && !a.moduleCompleted(prerequisite.ID) {
+			return contentLock{IsLocked: true, LockReason: "Complete the module \"" + prerequisite.Title + "\" first!"}
+		}
+	}
+	return contentLock{}
+}
+
+// dayLock checks a day of a module. With drip enabled, day N of a module unlocks DripOffsetDays + N - 1 days after
+// enrolment, so day 1 is available on the day of enrolment.
+func (a *courseAccess) dayLock(moduleID uint, day int) contentLock {
+	lock := a.moduleLock(moduleID)
+	if lock.IsLocked || !a.course.DripEnabled {
+		return lock
+	}
+
+	days := a.modules[moduleID].DripOffsetDays + max(day, 1) - 1
+	unlocksAt := a.enrollment.CreatedAt.AddDate(0, 0, days)
+	if time.Now().Before(unlocksAt) {
+		return contentLock{
+			IsLocked:   true,
+			LockReason: "This content unlocks on " + unlocksAt.Format("2 Jan 2006 15:04") + "!",
+			UnlocksAt:  &unlocksAt,
+		}
+	}
+	return contentLock{}
+}
+
+// lockForContent checks whether a learner can open a single content item
+func lockForContent(userID uint, content *courseModels.CourseContent) contentLock {
+	access, err := loadCourseAccess(userID, content.CourseID)
+	if err != nil {
+		return contentLock{IsLocked: true, LockReason: "Course not found!"}
+	}
+	return access.dayLock(content.ModuleID, content.Day)
+}
+
+// checkModulePrerequisite validates a module's prerequisite, returning the error message or "".
+// moduleID is 0 for a module that is being created.
+func checkModulePrerequisite(courseID, moduleID, prerequisiteID uint) string {
+	if prerequisiteID == moduleID {
+		return "A module can't be its own prerequisite!"
+	}
+
+	// Follow the prerequisite chain to make sure it doesn't lead back to this module
+	seen := map[uint]bool{moduleID: true}
+	for id := prerequisiteID; ; {
+		var module courseModels.Module
+		if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ?", id, courseID, false).First(&module).Error; err != nil {
+			if id == prerequisiteID {
+				return "Prerequisite module not found in this course!"
+			}
+			return ""
+		}
+		if module.PrerequisiteModuleID == nil {
+			return ""
+		}
+		id = *module.PrerequisiteModuleID
+		if seen[id] {
+			return "Prerequisite would create a cycle between modules!"
+		}
+		seen[id] = true
+	}
+}
@@ -13,8 +13,11 @@ import (
 // ContentWithMCQ represents content with MCQ options
 type ContentWithMCQ struct {
 	courseModels.CourseContent
-	MCQOptions  []courseModels.MCQOption `json:"mcq_options,omitempty"`
-	IsCompleted bool                     `json:"is_completed"`
+	MCQOptions    []courseModels.MCQOption    `json:"mcq_options,omitempty"`
+	Quiz          *courseModels.Quiz          `json:"quiz,omitempty"`
+	VideoProgress *courseModels.VideoProgress `json:"video_progress,omitempty"` // Resume position for VIDEO content
+	IsCompleted   bool                        `json:"is_completed"`
+	contentLock
 }
 
 func GetCourseContent(c *fiber.Ctx) error {
@@ -83,11 +86,18 @@ func GetCourseContent(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch course content!", nil)
 	}
 
-	// Enrich contents with MCQ options and completion status
+	// Drip release and module prerequisites decide what the user can open
+	access, err := loadCourseAccess(userId, uint(courseID))
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	// Enrich contents with MCQ options, completion and lock status
 	result := make([]ContentWithMCQ, len(contents))
 	for i, content := range contents {
 		result[i] = ContentWithMCQ{
 			CourseContent: content,
+			contentLock:   access.dayLock(content.ModuleID, content.Day),
 		}
 
 		// Check if completed by user
@@ -96,6 +106,14 @@ func GetCourseContent(c *fiber.Ctx) error {
 			result[i].IsCompleted = true
 		}
 
+		// Locked content is listed without its body
+		if result[i].IsLocked {
+			result[i].TextContent = ""
+			result[i].VideoURL = ""
+			result[i].ImageURL = ""
+			continue
+		}
+
 		// Get MCQ options if content is MCQ type
 		if content.ContentType == "MCQ" {
 			var options []courseModels.MCQOption
@@ -106,6 +124,15 @@ func GetCourseContent(c *fiber.Ctx) error {
 			}
 			result[i].MCQOptions = options
 		}
+
+		// Quiz settings only, questions are served when an attempt starts
+		if content.ContentType == "QUIZ" {
+			result[i].Quiz = findQuiz(content.ID)
+		}
+
+		if content.ContentType == "VIDEO" {
+			result[i].VideoProgress = findVideoProgress(userId, content.ID)
+		}
 	}
 
 	// Prepare response
@@ -205,6 +232,19 @@ func MarkContentComplete(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "User not enrolled in this course!", nil)
 	}
 
+	if lock := lockForContent(userID, &content); lock.IsLocked {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+	}
+
+	// Quizzes are completed by passing them and videos by watching them. Videos without an admin-set length can't
+	// auto-complete, so those are still completed manually.
+	if content.ContentType == "QUIZ" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Pass the quiz to complete this content!", nil)
+	}
+	if content.ContentType == "VIDEO" && content.VideoDurationSeconds > 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Watch the video to complete this content!", nil)
+	}
+
 	// Check if content is already marked as completed
 	var existingCompletion courseModels.ContentCompletion
 	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND course_content_id = ? AND is_deleted = ?", userID, courseID, contentID, false).First(&existingCompletion).Error; err == nil {
@@ -0,0 +1,917 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fib/database"
+	courseModels "fib/models/course"
+	courseValidator "fib/validators/course"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Course packages carry a whole course as JSON, optionally zipped, to author offline or move between environments.
+// Modules are matched by title and contents by module, day and title when a package updates an existing course.
+const (
+	coursePackageVersion  = 1             // Format written by exports; imports accept this version only
+	coursePackageFile     = "course.json" // Name of the package inside a ZIP
+	coursePackageMaxBytes = 20 << 20      // Largest unpacked package accepted
+)
+
+// Import change actions
+const (
+	packageCreate    = "CREATE"
+	packageUpdate    = "UPDATE"
+	packageDelete    = "DELETE"
+	packageUnchanged = "UNCHANGED"
+)
+
+// errImportDryRun rolls back a dry-run import once its changes are known
+var errImportDryRun = errors.New("dry run")
+
+type coursePackage struct {
+	FormatVersion int             `json:"format_version"`
+	ExportedAt    *time.Time      `json:"exported_at,omitempty"`
+	Course        packageCourse   `json:"course"`
+	Modules       []packageModule `json:"modules"`
+	Media         []string        `json:"media,omitempty"` // Media URLs referenced by the course, informational
+}
+
+// packageCourse holds the portable course settings. Included baskets, the instructor and the publish state depend on
+// the environment and are left out.
+type packageCourse struct {
+	Title               string  `json:"title"`
+	Description         string  `json:"description"`
+	Author              string  `json:"author"`
+	Duration            int64   `json:"duration"`
+	ThumbnailURL        string  `json:"thumbnail_url"`
+	CertificatePolicy   string  `json:"certificate_policy"`
+	CertificateMinScore float64 `json:"certificate_min_score"`
+	DripEnabled         bool    `json:"drip_enabled"`
+	Price               float64 `json:"price"`
+	RefundWindowDays    int     `json:"refund_window_days"`
+}
+
+type packageModule struct {
+	Title          string           `json:"title"`
+	Description    string           `json:"description"`
+	OrderIndex     int              `json:"order_index"`
+	DripOffsetDays int              `json:"drip_offset_days"`
+	Prerequisite   string           `json:"prerequisite,omitempty"` // Title of the module to complete first
+	Contents       []packageContent `json:"contents"`
+}
+
+type packageContent struct {
+	Day                    int             `json:"day"`
+	Title                  string          `json:"title"`
+	Description            string          `json:"description"`
+	ContentType            string          `json:"content_type"`
+	TextContent            string          `json:"text_content,omitempty"`
+	VideoURL               string          `json:"video_url,omitempty"`
+	ImageURL               string          `json:"image_url,omitempty"`
+	OrderIndex             int             `json:"order_index"`
+	IsPublished            bool            `json:"is_published"`
+	VideoDurationSeconds   int             `json:"video_duration_seconds,omitempty"`
+	VideoCompletionPercent float64         `json:"video_completion_percent,omitempty"`
+	MCQOptions             []packageOption `json:"mcq_options,omitempty"`
+	Quiz                   *packageQuiz    `json:"quiz,omitempty"`
+}
+
+type packageOption struct {
+	OptionText string `json:"option_text"`
+	IsCorrect  bool   `json:"is_correct"`
+}
+
+type packageQuiz struct {
+	PassPercent      float64           `json:"pass_percent"`
+	NegativeMarking  bool              `json:"negative_marking"`
+	NegativeFraction float64           `json:"negative_fraction"`
+	MaxAttempts      int               `json:"max_attempts"`
+	TimeLimitSeconds int               `json:"time_limit_seconds"`
+	ShuffleQuestions bool              `json:"shuffle_questions"`
+	ShuffleOptions   bool              `json:"shuffle_options"`
+	Questions        []packageQuestion `json:"questions"`
+}
+
+type packageQuestion struct {
+	Question string          `json:"question"`
+	Marks    float64         `json:"marks"`
+	Options  []packageOption `json:"options"`
+}
+
+// packageChange is one line of an import diff
+type packageChange struct {
+	Action string   `json:"action"` // CREATE, UPDATE, DELETE, UNCHANGED
+	Kind   string   `json:"kind"`   // COURSE, MODULE, CONTENT
+	Title  string   `json:"title"`
+	Module string   `json:"module,omitempty"`
+	Day    int      `json:"day,omitempty"`
+	Fields []string `json:"fields,omitempty"` // What an UPDATE changes
+}
+
+// packageKey normalises a title for matching
+func packageKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// contentKey identifies a content item within its module
+func contentKey(day int, title string) string {
+	return fmt.Sprintf("%d/%s", day, packageKey(title))
+}
+
+// setField assigns value to dst and records the field name when it changes
+func setField[T comparable](changed *[]string, name string, dst *T, value T) {
+	if *dst != value {
+		*dst = value
+		*changed = append(*changed, name)
+	}
+}
+
+// buildCoursePackage exports a course with its active modules, contents, MCQ options and quizzes
+func buildCoursePackage(course *courseModels.Course) (*coursePackage, error) {
+	db := database.Database.Db
+	now := time.Now()
+
+	pkg := &coursePackage{
+		FormatVersion: coursePackageVersion,
+		ExportedAt:    &now,
+		Course: packageCourse{
+			Title:               course.Title,
+			Description:         course.Description,
+			Author:              course.Author,
+			Duration:            course.Duration,
+			ThumbnailURL:        course.ThumbnailURL,
+			CertificatePolicy:   course.CertificatePolicy,
+			CertificateMinScore: course.CertificateMinScore,
+			DripEnabled:         course.DripEnabled,
+			Price:               course.Price,
+			RefundWindowDays:    course.RefundWindowDays,
+		},
+		Modules: []packageModule{},
+	}
+
+	var modules []courseModels.Module
+	if err := db.Where("course_id = ? AND is_deleted = ?", course.ID, false).Order("order_index asc, id asc").Find(&modules).Error; err != nil {
+		return nil, err
+	}
+
+	moduleTitles := make(map[uint]string)
+	for _, module := range modules {
+		moduleTitles[module.ID] = module.Title
+	}
+
+	media := make(map[string]bool)
+	addMedia := func(url string) {
+		if url != "" {
+			media[url] = true
+		}
+	}
+	addMedia(course.ThumbnailURL)
+
+	for _, module := range modules {
+		pm := packageModule{
+			Title:          module.Title,
+			Description:    module.Description,
+			OrderIndex:     module.OrderIndex,
+			DripOffsetDays: module.DripOffsetDays,
+			Contents:       []packageContent{},
+		}
+		if module.PrerequisiteModuleID != nil {
+			pm.Prerequisite = moduleTitles[*module.PrerequisiteModuleID]
+		}
+
+		var contents []courseModels.CourseContent
+		if err := db.Where("module_id = ? AND is_deleted = ?", module.ID, false).Order("day asc, order_index asc, id asc").Find(&contents).Error; err != nil {
+			return nil, err
+		}
+
+		for _, content := range contents {
+			pc := packageContent{
+				Day:                    content.Day,
+				Title:                  content.Title,
+				Description:            content.Description,
+				ContentType:            content.ContentType,
+				TextContent:            content.TextContent,
+				VideoURL:               content.VideoURL,
+				ImageURL:               content.ImageURL,
+				OrderIndex:             content.OrderIndex,
+				IsPublished:            content.IsPublished,
+				VideoDurationSeconds:   content.VideoDurationSeconds,
+				VideoCompletionPercent: content.VideoCompletionPercent,
+			}
+			addMedia(content.VideoURL)
+			addMedia(content.ImageURL)
+
+			switch content.ContentType {
+			case "MCQ":
+				var options []courseModels.MCQOption
+				if err := db.Where("content_id = ? AND is_deleted = ?", content.ID, false).Order("order_index asc, id asc").Find(&options).Error; err != nil {
+					return nil, err
+				}
+				for _, option := range options {
+					pc.MCQOptions = append(pc.MCQOptions, packageOption{OptionText: option.OptionText, IsCorrect: option.IsCorrect})
+				}
+			case "QUIZ":
+				if quiz := findQuiz(content.ID); quiz != nil {
+					questions, err := loadQuizQuestions(db, quiz.ID)
+					if err != nil {
+						return nil, err
+					}
+					pc.Quiz = exportQuiz(quiz, questions)
+				}
+			}
+			pm.Contents = append(pm.Contents, pc)
+		}
+		pkg.Modules = append(pkg.Modules, pm)
+	}
+
+	for url := range media {
+		pkg.Media = append(pkg.Media, url)
+	}
+	sort.Strings(pkg.Media)
+	return pkg, nil
+}
+
+// exportQuiz converts quiz settings and questions to their package form
+func exportQuiz(quiz *courseModels.Quiz, questions []courseModels.QuizQuestion) *packageQuiz {
+	pq := &packageQuiz{
+		PassPercent:      quiz.PassPercent,
+		NegativeMarking:  quiz.NegativeMarking,
+		NegativeFraction: quiz.NegativeFraction,
+		MaxAttempts:      quiz.MaxAttempts,
+		TimeLimitSeconds: quiz.TimeLimitSeconds,
+		ShuffleQuestions: quiz.ShuffleQuestions,
+		ShuffleOptions:   quiz.ShuffleOptions,
+		Questions:        []packageQuestion{},
+	}
+	for _, question := range questions {
+		pqq := packageQuestion{Question: question.Question, Marks: question.Marks, Options: []packageOption{}}
+		for _, option := range question.Options {
+			pqq.Options = append(pqq.Options, packageOption{OptionText: option.OptionText, IsCorrect: option.IsCorrect})
+		}
+		pq.Questions = append(pq.Questions, pqq)
+	}
+	return pq
+}
+
+// zipCoursePackage wraps the package JSON in a ZIP archive
+func zipCoursePackage(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create(coursePackageFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readCoursePackage decodes an uploaded package, either the JSON itself or a ZIP holding course.json
+func readCoursePackage(data []byte) (*coursePackage, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, errors.New("package is not a valid ZIP file")
+		}
+
+		var entry *zip.File
+		for _, f := range archive.File {
+			if path.Base(f.Name) == coursePackageFile {
+				entry = f
+				break
+			}
+		}
+		if entry == nil {
+			return nil, errors.New("ZIP package must contain " + coursePackageFile)
+		}
+		if entry.UncompressedSize64 > coursePackageMaxBytes {
+			return nil, errors.New("package is too large")
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, errors.New("failed to read " + coursePackageFile)
+		}
+		defer rc.Close()
+
+		// The header size can lie, so the read is capped as well
+		data, err = io.ReadAll(io.LimitReader(rc, coursePackageMaxBytes+1))
+		if err != nil {
+			return nil, errors.New("failed to read " + coursePackageFile)
+		}
+		if len(data) > coursePackageMaxBytes {
+			return nil, errors.New("package is too large")
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var pkg coursePackage
+	if err := decoder.Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("package is not valid JSON: %v", err)
+	}
+	return &pkg, nil
+}
+
+// validateCoursePackage normalises a package and checks it against the rules the admin endpoints apply one call at a
+// time. Problems are keyed by their path in the package.
+func validateCoursePackage(pkg *coursePackage) map[string]string {
+	errs := make(map[string]string)
+
+	if pkg.FormatVersion != coursePackageVersion {
+		errs["format_version"] = fmt.Sprintf("Format version must be %d!", coursePackageVersion)
+	}
+
+	course := &pkg.Course
+	course.Title = strings.TrimSpace(course.Title)
+	course.Description = strings.TrimSpace(course.Description)
+	course.Author = strings.TrimSpace(course.Author)
+	course.CertificatePolicy = strings.ToUpper(strings.TrimSpace(course.CertificatePolicy))
+	if course.CertificatePolicy == "" {
+		course.CertificatePolicy = courseModels.CertificatePolicyManual
+	}
+
+	// A package has no way to leave the minimum score out, so 0 counts as missing
+	minScore := &course.CertificateMinScore
+	if *minScore == 0 {
+		minScore = nil
+	}
+	courseValidator.ValidateCourseFields(course.Title, course.Description, course.Author, course.Duration, true, "course", errs)
+	courseValidator.ValidateCertificatePolicy(course.CertificatePolicy, minScore, true, "course", errs)
+	courseValidator.ValidateCoursePricing(&course.Price, &course.RefundWindowDays, "course", errs)
+
+	if len(pkg.Modules) == 0 {
+		errs["modules"] = "A package needs at least one module!"
+	}
+
+	moduleIndex := make(map[string]int)
+	for i := range pkg.Modules {
+		module := &pkg.Modules[i]
+		at := fmt.Sprintf("modules[%d]", i)
+
+		module.Title = strings.TrimSpace(module.Title)
+		module.Description = strings.TrimSpace(module.Description)
+		module.Prerequisite = strings.TrimSpace(module.Prerequisite)
+		if module.OrderIndex == 0 {
+			module.OrderIndex = i + 1
+		}
+
+		courseValidator.ValidateModuleFields(module.Title, &module.DripOffsetDays, true, at, errs)
+		if _, invalid := errs[at+".title"]; !invalid {
+			if _, dup := moduleIndex[packageKey(module.Title)]; dup {
+				errs[at+".title"] = "Module titles must be unique within a course!"
+			} else {
+				moduleIndex[packageKey(module.Title)] = i
+			}
+		}
+
+		validateContentsPackage(module, at, errs)
+	}
+
+	// Prerequisites name other modules of the package and must not form a cycle
+	for i, module := range pkg.Modules {
+		if module.Prerequisite == "" {
+			continue
+		}
+		at := fmt.Sprintf("modules[%d].prerequisite", i)
+		target, ok := moduleIndex[packageKey(module.Prerequisite)]
+		if !ok {
+			errs[at] = "Prerequisite module not found in the package!"
+			continue
+		}
+		if target == i {
+			errs[at] = "A module can't be its own prerequisite!"
+			continue
+		}
+
+		seen := map[int]bool{i: true}
+		for next := target; pkg.Modules[next].Prerequisite != ""; {
+			n, ok := moduleIndex[packageKey(pkg.Modules[next].Prerequisite)]
+			if !ok {
+				break
+			}
+			if seen[n] {
+				errs[at] = "Prerequisite would create a cycle between modules!"
+				break
+			}
+			seen[n] = true
+			next = n
+		}
+	}
+
+	return errs
+}
+
+// validateContentsPackage checks the contents of one package module
+func validateContentsPackage(module *packageModule, at string, errs map[string]string) {
+	seen := make(map[string]bool)
+	dayPositions := make(map[int]int)
+
+	for j := range module.Contents {
+		content := &module.Contents[j]
+		cat := fmt.Sprintf("%s.contents[%d]", at, j)
+
+		content.Title = strings.TrimSpace(content.Title)
+		content.Description = strings.TrimSpace(content.Description)
+		content.ContentType = strings.ToUpper(strings.TrimSpace(content.ContentType))
+		dayPositions[content.Day]++
+		if content.OrderIndex == 0 {
+			content.OrderIndex = dayPositions[content.Day]
+		}
+
+		courseValidator.ValidateContentFields(courseValidator.ContentFields{
+			Day:                    content.Day,
+			Title:                  content.Title,
+			ContentType:            content.ContentType,
+			TextContent:            content.TextContent,
+			VideoURL:               content.VideoURL,
+			ImageURL:               content.ImageURL,
+			VideoDurationSeconds:   content.VideoDurationSeconds,
+			VideoCompletionPercent: content.VideoCompletionPercent,
+		}, true, cat, errs)
+		if _, invalid := errs[cat+".title"]; !invalid {
+			if key := contentKey(content.Day, content.Title); seen[key] {
+				errs[cat+".title"] = "Content titles must be unique within a day of a module!"
+			} else {
+				seen[key] = true
+			}
+		}
+
+		if len(content.MCQOptions) > 0 && content.ContentType != "MCQ" {
+			errs[cat+".mcq_options"] = "Only MCQ content can have options!"
+		}
+		if content.ContentType == "MCQ" {
+			if msg := packageOptionsError(content.MCQOptions, content.IsPublished); msg != "" {
+				errs[cat+".mcq_options"] = msg
+			}
+		}
+
+		if content.Quiz != nil && content.ContentType != "QUIZ" {
+			errs[cat+".quiz"] = "Only QUIZ content can have a quiz!"
+		}
+		if content.ContentType == "QUIZ" {
+			validateQuizPackage(content, cat, errs)
+		}
+	}
+}
+
+// packageOptionsError checks MCQ or quiz options; published MCQs and every quiz question need 2 options and a
+// correct answer
+func packageOptionsError(options []packageOption, required bool) string {
+	if len(options) > 10 {
+		return "At most 10 options are allowed!"
+	}
+	correct := 0
+	for i := range options {
+		options[i].OptionText = strings.TrimSpace(options[i].OptionText)
+		if options[i].OptionText == "" {
+			return "Option text is required!"
+		}
+		if options[i].IsCorrect {
+			correct++
+		}
+	}
+	if required && (len(options) < 2 || correct == 0) {
+		return "At least 2 options with one correct answer are required!"
+	}
+	return ""
+}
+
+// validateQuizPackage checks the quiz of QUIZ content, filling in the defaults of a new quiz
+func validateQuizPackage(content *packageContent, at string, errs map[string]string) {
+	if content.Quiz == nil {
+		content.Quiz = &packageQuiz{PassPercent: 60, NegativeFraction: 0.25}
+	}
+	quiz := content.Quiz
+	at += ".quiz"
+
+	courseValidator.ValidateQuizSettings(&quiz.PassPercent, &quiz.NegativeFraction, &quiz.MaxAttempts, &quiz.TimeLimitSeconds, at, errs)
+	if content.IsPublished && len(quiz.Questions) == 0 {
+		errs[at+".questions"] = "Quiz must have at least one question before publishing!"
+	}
+
+	for k := range quiz.Questions {
+		question := &quiz.Questions[k]
+		qat := fmt.Sprintf("%s.questions[%d]", at, k)
+
+		question.Question = strings.TrimSpace(question.Question)
+		if question.Marks == 0 {
+			question.Marks = 1
+		}
+		if question.Question == "" {
+			errs[qat+".question"] = "Question is required!"
+		}
+		if question.Marks < 0 || question.Marks > 100 {
+			errs[qat+".marks"] = "Marks must be greater than 0 and at most 100!"
+		}
+		if msg := packageOptionsError(question.Options, true); msg != "" {
+			errs[qat+".options"] = msg
+		}
+	}
+}
+
+// importCoursePackage creates a course from a validated package, or makes an existing course match it, and returns
+// what changed. Modules and contents missing from the package are deleted. Option lists and quiz questions are
+// replaced as a whole when they differ.
+func importCoursePackage(tx *gorm.DB, pkg *coursePackage, course *courseModels.Course) ([]packageChange, error) {
+	changes := []packageChange{}
+	creating := course.ID == 0
+
+	var fields []string
+	setField(&fields, "title", &course.Title, pkg.Course.Title)
+	setField(&fields, "description", &course.Description, pkg.Course.Description)
+	setField(&fields, "author", &course.Author, pkg.Course.Author)
+	setField(&fields, "duration", &course.Duration, pkg.Course.Duration)
+	setField(&fields, "thumbnail_url", &course.ThumbnailURL, pkg.Course.ThumbnailURL)
+	setField(&fields, "certificate_policy", &course.CertificatePolicy, pkg.Course.CertificatePolicy)
+	setField(&fields, "certificate_min_score", &course.CertificateMinScore, pkg.Course.CertificateMinScore)
+	setField(&fields, "drip_enabled", &course.DripEnabled, pkg.Course.DripEnabled)
+	setField(&fields, "price", &course.Price, pkg.Course.Price)
+	setField(&fields, "refund_window_days", &course.RefundWindowDays, pkg.Course.RefundWindowDays)
+
+	switch {
+	case creating:
+		course.Status = "DRAFT"
+		course.IsPublished = false
+		course.IncludedBasketIDs = "[]"
+		if err := tx.Create(course).Error; err != nil {
+			return nil, err
+		}
+		changes = append(changes, packageChange{Action: packageCreate, Kind: "COURSE", Title: course.Title})
+	case len(fields) > 0:
+		if err := tx.Save(course).Error; err != nil {
+			return nil, err
+		}
+		changes = append(changes, packageChange{Action: packageUpdate, Kind: "COURSE", Title: course.Title, Fields: fields})
+	default:
+		changes = append(changes, packageChange{Action: packageUnchanged, Kind: "COURSE", Title: course.Title})
+	}
+
+	var existing []courseModels.Module
+	if err := tx.Where("course_id = ? AND is_deleted = ?", course.ID, false).Order("order_index asc, id asc").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	byTitle := make(map[string]courseModels.Module)
+	for _, module := range existing {
+		if _, dup := byTitle[packageKey(module.Title)]; !dup {
+			byTitle[packageKey(module.Title)] = module
+		}
+	}
+
+	moduleIDs := make(map[string]uint)
+	kept := make(map[uint]bool)
+	modules := make([]courseModels.Module, len(pkg.Modules))
+	moduleChanges := make([]int, len(pkg.Modules)) // Index of each module's change, prerequisites are added later
+
+	for i, pm := range pkg.Modules {
+		module, found := byTitle[packageKey(pm.Title)]
+		if !found {
+			module = courseModels.Module{CourseID: course.ID}
+		}
+
+		var fields []string
+		setField(&fields, "title", &module.Title, pm.Title)
+		setField(&fields, "description", &module.Description, pm.Description)
+		setField(&fields, "order_index", &module.OrderIndex, pm.OrderIndex)
+		setField(&fields, "drip_offset_days", &module.DripOffsetDays, pm.DripOffsetDays)
+
+		change := packageChange{Action: packageUnchanged, Kind: "MODULE", Title: pm.Title}
+		if !found {
+			change.Action = packageCreate
+			if err := tx.Create(&module).Error; err != nil {
+				return nil, err
+			}
+		} else if len(fields) > 0 {
+			change.Action = packageUpdate
+			change.Fields = fields
+			if err := tx.Save(&module).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		modules[i] = module
+		moduleIDs[packageKey(pm.Title)] = module.ID
+		kept[module.ID] = true
+		changes = append(changes, change)
+		moduleChanges[i] = len(changes) - 1
+
+		contentChanges, err := importModuleContents(tx, &module, &pm)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, contentChanges...)
+	}
+
+	// Prerequisites refer to modules by title, so they are set once every module exists
+	for i, pm := range pkg.Modules {
+		module := &modules[i]
+		var prerequisite *uint
+		if pm.Prerequisite != "" {
+			id := moduleIDs[packageKey(pm.Prerequisite)]
+			prerequisite = &id
+		}
+
+		current := uint(0)
+		if module.PrerequisiteModuleID != nil {
+			current = *module.PrerequisiteModuleID
+		}
+		wanted := uint(0)
+		if prerequisite != nil {
+			wanted = *prerequisite
+		}
+		if current == wanted {
+			continue
+		}
+
+		if err := tx.Model(module).Update("prerequisite_module_id", prerequisite).Error; err != nil {
+			return nil, err
+		}
+		change := &changes[moduleChanges[i]]
+		if change.Action == packageUnchanged {
+			change.Action = packageUpdate
+		}
+		if change.Action == packageUpdate {
+			change.Fields = append(change.Fields, "prerequisite")
+		}
+	}
+
+	for _, module := range existing {
+		if kept[module.ID] {
+			continue
+		}
+		if err := deletePackageModule(tx, &module); err != nil {
+			return nil, err
+		}
+		changes = append(changes, packageChange{Action: packageDelete, Kind: "MODULE", Title: module.Title})
+	}
+
+	return changes, nil
+}
+
+// importModuleContents makes the contents of a module match its package module
+func importModuleContents(tx *gorm.DB, module *courseModels.Module, pm *packageModule) ([]packageChange, error) {
+	changes := []packageChange{}
+
+	var existing []courseModels.CourseContent
+	if module.ID > 0 {
+		if err := tx.Where("module_id = ? AND is_deleted = ?", module.ID, false).Order("day asc, order_index asc, id asc").Find(&existing).Error; err != nil {
+			return nil, err
+		}
+	}
+	byKey := make(map[string]courseModels.CourseContent)
+	for _, content := range existing {
+		key := contentKey(content.Day, content.Title)
+		if _, dup := byKey[key]; !dup {
+			byKey[key] = content
+		}
+	}
+
+	kept := make(map[uint]bool)
+	for _, pc := range pm.Contents {
+		content, found := byKey[contentKey(pc.Day, pc.Title)]
+		if !found {
+			content = courseModels.CourseContent{CourseID: module.CourseID, ModuleID: module.ID}
+		}
+		previousType := content.ContentType
+
+		var fields []string
+		setField(&fields, "day", &content.Day, pc.Day)
+		setField(&fields, "title", &content.Title, pc.Title)
+		setField(&fields, "description", &content.Description, pc.Description)
+		setField(&fields, "content_type", &content.ContentType, pc.ContentType)
+		setField(&fields, "text_content", &content.TextContent, pc.TextContent)
+		setField(&fields, "video_url", &content.VideoURL, pc.VideoURL)
+		setField(&fields, "image_url", &content.ImageURL, pc.ImageURL)
+		setField(&fields, "order_index", &content.OrderIndex, pc.OrderIndex)
+		setField(&fields, "is_published", &content.IsPublished, pc.IsPublished)
+		setField(&fields, "video_duration_seconds", &content.VideoDurationSeconds, pc.VideoDurationSeconds)
+		setField(&fields, "video_completion_percent", &content.VideoCompletionPercent, pc.VideoCompletionPercent)
+
+		if !found {
+			if err := tx.Create(&content).Error; err != nil {
+				return nil, err
+			}
+		} else if len(fields) > 0 {
+			if err := tx.Save(&content).Error; err != nil {
+				return nil, err
+			}
+		}
+		kept[content.ID] = true
+
+		// Leaving MCQ or QUIZ drops the options or quiz the content no longer uses
+		if found && previousType != content.ContentType {
+			if err := retirePackageContentType(tx, content.ID, previousType); err != nil {
+				return nil, err
+			}
+		}
+
+		switch content.ContentType {
+		case "MCQ":
+			replaced, err := importMCQOptions(tx, content.ID, pc.MCQOptions)
+			if err != nil {
+				return nil, err
+			}
+			if replaced {
+				fields = append(fields, "mcq_options")
+			}
+		case "QUIZ":
+			quizFields, err := importQuiz(tx, &content, pc.Quiz)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, quizFields...)
+		}
+
+		change := packageChange{Action: packageUnchanged, Kind: "CONTENT", Title: pc.Title, Module: pm.Title, Day: pc.Day}
+		if !found {
+			change.Action = packageCreate
+		} else if len(fields) > 0 {
+			change.Action = packageUpdate
+			change.Fields = fields
+		}
+		changes = append(changes, change)
+	}
+
+	for _, content := range existing {
+		if kept[content.ID] {
+			continue
+		}
+		if err := deletePackageContent(tx, &content); err != nil {
+			return nil, err
+		}
+		changes = append(changes, packageChange{Action: packageDelete, Kind: "CONTENT", Title: content.Title, Module: module.Title, Day: content.Day})
+	}
+
+	return changes, nil
+}
+
+// importMCQOptions replaces the options of MCQ content when they differ from the package, reporting whether they did
+func importMCQOptions(tx *gorm.DB, contentID uint, options []packageOption) (bool, error) {
+	var current []courseModels.MCQOption
+	if err := tx.Where("content_id = ? AND is_deleted = ?", contentID, false).Order("order_index asc, id asc").Find(&current).Error; err != nil {
+		return false, err
+	}
+
+	same := len(current) == len(options)
+	for i := 0; same && i < len(options); i++ {
+		same = current[i].OptionText == options[i].OptionText && current[i].IsCorrect == options[i].IsCorrect
+	}
+	if same {
+		return false, nil
+	}
+
+	if err := tx.Model(&courseModels.MCQOption{}).Where("content_id = ?", contentID).Update("is_deleted", true).Error; err != nil {
+		return false, err
+	}
+	for i, option := range options {
+		record := courseModels.MCQOption{
+			ContentID:  contentID,
+			OptionText: option.OptionText,
+			IsCorrect:  option.IsCorrect,
+			OrderIndex: i + 1,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// importQuiz applies the quiz settings of QUIZ content and replaces its questions when they differ from the package.
+// It returns the changed fields.
+func importQuiz(tx *gorm.DB, content *courseModels.CourseContent, pq *packageQuiz) ([]string, error) {
+	if err := ensureQuiz(tx, content); err != nil {
+		return nil, err
+	}
+
+	var quiz courseModels.Quiz
+	if err := tx.Where("content_id = ? AND is_deleted = ?", content.ID, false).First(&quiz).Error; err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	setField(&fields, "quiz.pass_percent", &quiz.PassPercent, pq.PassPercent)
+	setField(&fields, "quiz.negative_marking", &quiz.NegativeMarking, pq.NegativeMarking)
+	setField(&fields, "quiz.negative_fraction", &quiz.NegativeFraction, pq.NegativeFraction)
+	setField(&fields, "quiz.max_attempts", &quiz.MaxAttempts, pq.MaxAttempts)
+	setField(&fields, "quiz.time_limit_seconds", &quiz.TimeLimitSeconds, pq.TimeLimitSeconds)
+	setField(&fields, "quiz.shuffle_questions", &quiz.ShuffleQuestions, pq.ShuffleQuestions)
+	setField(&fields, "quiz.shuffle_options", &quiz.ShuffleOptions, pq.ShuffleOptions)
+	if len(fields) > 0 {
+		if err := tx.Save(&quiz).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	questions, err := loadQuizQuestions(tx, quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	current := exportQuiz(&quiz, questions).Questions
+
+	same := len(current) == len(pq.Questions)
+	for i := 0; same && i < len(pq.Questions); i++ {
+		a, b := current[i], pq.Questions[i]
+		same = a.Question == b.Question && a.Marks == b.Marks && len(a.Options) == len(b.Options)
+		for j := 0; same && j < len(b.Options); j++ {
+			same = a.Options[j] == b.Options[j]
+		}
+	}
+	if same {
+		return fields, nil
+	}
+
+	// Open attempts lay out the old questions. Nothing is answered until submission, so they are dropped rather than
+	// expired, which would use up one of the learner's attempts.
+	if err := tx.Model(&courseModels.QuizAttempt{}).
+		Where("quiz_id = ? AND status = ? AND is_deleted = ?", quiz.ID, courseModels.QuizAttemptInProgress, false).
+		Update("is_deleted", true).Error; err != nil {
+		return nil, err
+	}
+
+	questionIDs := make([]uint, len(questions))
+	for i, question := range questions {
+		questionIDs[i] = question.ID
+	}
+	if len(questionIDs) > 0 {
+		if err := tx.Model(&courseModels.QuizQuestion{}).Where("id IN ?", questionIDs).Update("is_deleted", true).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&courseModels.QuizOption{}).Where("question_id IN ?", questionIDs).Update("is_deleted", true).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for i, pqq := range pq.Questions {
+		question := courseModels.QuizQuestion{
+			QuizID:     quiz.ID,
+			Question:   pqq.Question,
+			Marks:      pqq.Marks,
+			OrderIndex: i + 1,
+		}
+		for j, option := range pqq.Options {
+			question.Options = append(question.Options, courseModels.QuizOption{
+				OptionText: option.OptionText,
+				IsCorrect:  option.IsCorrect,
+				OrderIndex: j + 1,
+			})
+		}
+		if err := tx.Create(&question).Error; err != nil {
+			return nil, err
+		}
+	}
+	return append(fields, "quiz.questions"), nil
+}
+
+// retirePackageContentType removes the MCQ options or quiz of content whose type changed
+func retirePackageContentType(tx *gorm.DB, contentID uint, contentType string) error {
+	switch contentType {
+	case "MCQ":
+		return tx.Model(&courseModels.MCQOption{}).Where("content_id = ?", contentID).Update("is_deleted", true).Error
+	case "QUIZ":
+		return tx.Model(&courseModels.Quiz{}).Where("content_id = ?", contentID).Update("is_deleted", true).Error
+	}
+	return nil
+}
+
+// deletePackageContent soft deletes content missing from a package, like AdminDeleteContent
+func deletePackageContent(tx *gorm.DB, content *courseModels.CourseContent) error {
+	if err := tx.Model(content).Update("is_deleted", true).Error; err != nil {
+		return err
+	}
+	return retirePackageContentType(tx, content.ID, content.ContentType)
+}
+
+// deletePackageModule soft deletes a module missing from a package with its contents, like AdminDeleteModule
+func deletePackageModule(tx *gorm.DB, module *courseModels.Module) error {
+	if err := tx.Model(module).Update("is_deleted", true).Error; err != nil {
+		return err
+	}
+
+	var contents []courseModels.CourseContent
+	if err := tx.Where("module_id = ? AND is_deleted = ?", module.ID, false).Find(&contents).Error; err != nil {
+		return err
+	}
+	for i := range contents {
+		if err := deletePackageContent(tx, &contents[i]); err != nil {
+			return err
+		}
+	}
+
+	// Modules that required the deleted one no longer have a prerequisite
+	return tx.Model(&courseModels.Module{}).Where("prerequisite_module_id = ?", module.ID).Update("prerequisite_module_id", nil).Error
+}
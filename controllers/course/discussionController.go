@@ -0,0 +1,468 @@
+package controllers
+
+import (
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// isCourseStaff reports whether a user answers discussions of a course: admins and the course instructor
+func isCourseStaff(user *models.User, course *courseModels.Course) bool {
+	return user.Role == "ADMIN" || (course.InstructorID != nil && *course.InstructorID == user.ID)
+}
+
+// loadDiscussionCourse loads a course and checks the user may take part in its discussions, either as course staff
+// or as a learner whose access hasn't ended
+func loadDiscussionCourse(c *fiber.Ctx, user *models.User, courseID int) (*courseModels.Course, bool, error) {
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+		return nil, false, middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	if isCourseStaff(user, &course) {
+		return &course, true, nil
+	}
+
+	var enrollment courseModels.Enrollment
+	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", user.ID, courseID, false).First(&enrollment).Error; err != nil {
+		return nil, false, middleware.JsonResponse(c, fiber.StatusForbidden, false, "User not enrolled in this course!", nil)
+	}
+	if subscriptionAccessEnded(user.ID, &course, &enrollment) {
+		return nil, false, middleware.JsonResponse(c, fiber.StatusForbidden, false, "The basket subscription that included this course has ended!", nil)
+	}
+	return &course, false, nil
+}
+
+// refreshThreadStats recounts a thread's replies and whether the course team answered it
+func refreshThreadStats(tx *gorm.DB, threadID uint) error {
+	var stats struct {
+		Replies      int
+		StaffReplies int
+		LastReplyAt  *time.Time
+	}
+	if err := tx.Model(&courseModels.DiscussionReply{}).
+		Select("COUNT(*) AS replies, COUNT(*) FILTER (WHERE is_staff) AS staff_replies, MAX(created_at) AS last_reply_at").
+		Where("thread_id = ? AND is_deleted = ?", threadID, false).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&courseModels.DiscussionThread{}).Where("id = ?", threadID).Updates(map[string]interface{}{
+		"reply_count":   stats.Replies,
+		"is_answered":   stats.StaffReplies > 0,
+		"last_reply_at": stats.LastReplyAt,
+	}).Error
+}
+
+// GetContentDiscussions lists the discussion threads of a content item, most recently active first
+func GetContentDiscussions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	reqData, _ := c.Locals("validatedDiscussionQuery").(*struct {
+		Page       *int `json:"page"`
+		Limit      *int `json:"limit"`
+		Unanswered bool `json:"unanswered"`
+	})
+
+	course, _, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	page := 1
+	limit := 10
+	if reqData != nil && reqData.Page != nil && *reqData.Page > 0 {
+		page = *reqData.Page
+	}
+	if reqData != nil && reqData.Limit != nil && *reqData.Limit > 0 {
+		limit = *reqData.Limit
+	}
+	offset := (page - 1) * limit
+
+	db := database.Database.Db.Model(&courseModels.DiscussionThread{}).
+		Where("course_id = ? AND content_id = ? AND is_deleted = ?", courseID, contentID, false)
+	if reqData != nil && reqData.Unanswered {
+		db = db.Where("is_answered = ?", false)
+	}
+
+	var total int64
+	db.Count(&total)
+
+	var threads []courseModels.DiscussionThread
+	if err := db.Order("COALESCE(last_reply_at, created_at) desc").Offset(offset).Limit(limit).Find(&threads).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch discussions!", nil)
+	}
+
+	userIDs := make([]uint, len(threads))
+	for i, t := range threads {
+		userIDs[i] = t.UserID
+	}
+	names := userNames(userIDs)
+
+	type ThreadWithUser struct {
+		courseModels.DiscussionThread
+		UserName string `json:"user_name"`
+	}
+
+	result := make([]ThreadWithUser, len(threads))
+	for i, t := range threads {
+		result[i] = ThreadWithUser{DiscussionThread: t, UserName: names[t.UserID]}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Discussions fetched successfully!", fiber.Map{
+		"threads": result,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// CreateDiscussionThread starts a question thread on a content item the user can open
+func CreateDiscussionThread(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	reqData, ok := c.Locals("validatedDiscussionThread").(*struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	var content courseModels.CourseContent
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ? AND is_published = ?", contentID, courseID, false, true).First(&content).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Content not found!", nil)
+	}
+
+	if !staff {
+		if lock := lockForContent(userID, &content); lock.IsLocked {
+			return middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+		}
+	}
+
+	thread := courseModels.DiscussionThread{
+		CourseID:  course.ID,
+		ContentID: content.ID,
+		UserID:    userID,
+		Title:     reqData.Title,
+		Body:      reqData.Body,
+	}
+	if err := database.Database.Db.Create(&thread).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create discussion!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusCreated, true, "Discussion created successfully!", thread)
+}
+
+// GetDiscussionThread returns a thread with its replies in posting order
+func GetDiscussionThread(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	threadID := c.Locals("threadID").(int)
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	var thread courseModels.DiscussionThread
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ?", threadID, courseID, false).First(&thread).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Discussion not found!", nil)
+	}
+
+	var replies []courseModels.DiscussionReply
+	if err := database.Database.Db.Where("thread_id = ? AND is_deleted = ?", thread.ID, false).Order("created_at asc").Find(&replies).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch replies!", nil)
+	}
+
+	userIDs := []uint{thread.UserID}
+	for _, r := range replies {
+		userIDs = append(userIDs, r.UserID)
+	}
+	names := userNames(userIDs)
+
+	type ReplyWithUser struct {
+		courseModels.DiscussionReply
+		UserName   string `json:"user_name"`
+		IsAccepted bool   `json:"is_accepted"`
+	}
+
+	result := make([]ReplyWithUser, len(replies))
+	for i, r := range replies {
+		result[i] = ReplyWithUser{
+			DiscussionReply: r,
+			UserName:        names[r.UserID],
+			IsAccepted:      thread.AcceptedReplyID != nil && *thread.AcceptedReplyID == r.ID,
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Discussion fetched successfully!", fiber.Map{
+		"thread":     thread,
+		"user_name":  names[thread.UserID],
+		"replies":    result,
+		"can_manage": staff || thread.UserID == userID,
+	})
+}
+
+// ReplyDiscussion adds a reply to a thread. Replies from admins or the course instructor mark it answered.
+func ReplyDiscussion(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	threadID := c.Locals("threadID").(int)
+
+	reqData, ok := c.Locals("validatedDiscussionReply").(*struct {
+		Body string `json:"body"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	reply := courseModels.DiscussionReply{
+		UserID:  userID,
+		Body:    reqData.Body,
+		IsStaff: staff,
+	}
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		var thread courseModels.DiscussionThread
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND course_id = ? AND is_deleted = ?", threadID, courseID, false).
+			First(&thread).Error; err != nil {
+			return err
+		}
+
+		reply.ThreadID = thread.ID
+		if err := tx.Create(&reply).Error; err != nil {
+			return err
+		}
+		return refreshThreadStats(tx, thread.ID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Discussion not found!", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to reply to discussion %d: %v", threadID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to post reply!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusCreated, true, "Reply posted successfully!", reply)
+}
+
+// ResolveDiscussion closes or reopens a thread, optionally accepting one of its replies.
+// Only the asker and the course team can do this.
+func ResolveDiscussion(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	threadID := c.Locals("threadID").(int)
+
+	reqData, ok := c.Locals("validatedDiscussionResolve").(*struct {
+		IsResolved      bool  `json:"is_resolved"`
+		AcceptedReplyID *uint `json:"accepted_reply_id"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	var thread courseModels.DiscussionThread
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ?", threadID, courseID, false).First(&thread).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Discussion not found!", nil)
+	}
+
+	if !staff && thread.UserID != userID {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Only the author or the course team can resolve this discussion!", nil)
+	}
+
+	if reqData.AcceptedReplyID != nil {
+		var reply courseModels.DiscussionReply
+		if err := database.Database.Db.Where("id = ? AND thread_id = ? AND is_deleted = ?", *reqData.AcceptedReplyID, thread.ID, false).First(&reply).Error; err != nil {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Reply not found in this discussion!", nil)
+		}
+	}
+
+	thread.IsResolved = reqData.IsResolved
+	thread.AcceptedReplyID = reqData.AcceptedReplyID
+	if err := database.Database.Db.Model(&thread).Updates(map[string]interface{}{
+		"is_resolved":       thread.IsResolved,
+		"accepted_reply_id": thread.AcceptedReplyID,
+	}).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update discussion!", nil)
+	}
+
+	message := "Discussion resolved successfully!"
+	if !thread.IsResolved {
+		message = "Discussion reopened successfully!"
+	}
+	return middleware.JsonResponse(c, fiber.StatusOK, true, message, thread)
+}
+
+// DeleteDiscussionThread removes a thread. The author can delete it until someone replies; the course team any time.
+func DeleteDiscussionThread(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	threadID := c.Locals("threadID").(int)
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	var thread courseModels.DiscussionThread
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ?", threadID, courseID, false).First(&thread).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Discussion not found!", nil)
+	}
+
+	if !staff {
+		if thread.UserID != userID {
+			return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You can only delete your own discussions!", nil)
+		}
+		if thread.ReplyCount > 0 {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Discussions with replies can't be deleted!", nil)
+		}
+	}
+
+	if err := database.Database.Db.Model(&thread).Update("is_deleted", true).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete discussion!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Discussion deleted successfully!", nil)
+}
+
+// DeleteDiscussionReply removes a reply; authors can remove their own and the course team any reply
+func DeleteDiscussionReply(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	replyID := c.Locals("replyID").(int)
+
+	course, staff, respErr := loadDiscussionCourse(c, &user, courseID)
+	if course == nil {
+		return respErr
+	}
+
+	var reply courseModels.DiscussionReply
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", replyID, false).First(&reply).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Reply not found!", nil)
+	}
+
+	if !staff && reply.UserID != userID {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You can only delete your own replies!", nil)
+	}
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		var thread courseModels.DiscussionThread
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND course_id = ? AND is_deleted = ?", reply.ThreadID, courseID, false).
+			First(&thread).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&reply).Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+		if thread.AcceptedReplyID != nil && *thread.AcceptedReplyID == reply.ID {
+			if err := tx.Model(&thread).Update("accepted_reply_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return refreshThreadStats(tx, thread.ID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Discussion not found!", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to delete discussion reply %d: %v", replyID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete reply!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reply deleted successfully!", nil)
+}
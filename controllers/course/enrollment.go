@@ -1,13 +1,24 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
+	"fib/models/basket"
 	courseModels "fib/models/course"
 	"fib/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAlreadyEnrolled     = errors.New("already enrolled")
+	errInsufficientBalance = errors.New("insufficient balance")
 )
 
 func EnrollInCourse(c *fiber.Ctx) error {
@@ -32,10 +43,12 @@ func EnrollInCourse(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found or not available!", nil)
 	}
 
-	// Check if user is already enrolled
+	// Check if user is already enrolled. Learners whose basket subscription ended may buy the course instead.
 	var existingEnrollment courseModels.Enrollment
 	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&existingEnrollment).Error; err == nil {
-		return middleware.JsonResponse(c, fiber.StatusConflict, false, "User already enrolled in this course!", nil)
+		if !subscriptionAccessEnded(userID, &course, &existingEnrollment) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "User already enrolled in this course!", nil)
+		}
 	}
 
 	// Get total published content count
@@ -48,15 +61,82 @@ func EnrollInCourse(c *fiber.Ctx) error {
 		CourseID:      uint(courseID),
 		Status:        "ENROLLED",
 		TotalContents: int(totalContents),
+		AccessType:    courseModels.EnrollmentAccessFree,
 	}
 
-	// Save to database with transaction
-	tx := database.Database.Db.Begin()
-	if err := tx.Create(&enrollment).Error; err != nil {
-		tx.Rollback()
+	// Paid courses are free for subscribers of an included basket, everyone else pays from the wallet
+	if course.Price > 0 {
+		if basketID := activeIncludedBasket(userID, &course); basketID > 0 {
+			enrollment.AccessType = courseModels.EnrollmentAccessSubscription
+			enrollment.AccessBasketID = basketID
+		} else {
+			enrollment.AccessType = courseModels.EnrollmentAccessPurchase
+			enrollment.PricePaid = course.Price
+		}
+	}
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so parallel requests can't enrol or charge twice
+		var buyer models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&buyer).Error; err != nil {
+			return err
+		}
+
+		var current courseModels.Enrollment
+		if err := tx.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&current).Error; err == nil {
+			if current.ID != existingEnrollment.ID || enrollment.AccessType != courseModels.EnrollmentAccessPurchase {
+				return errAlreadyEnrolled
+			}
+			// Switching from ended subscription access to a purchase keeps the learner's progress
+			enrollment = current
+			enrollment.AccessType = courseModels.EnrollmentAccessPurchase
+			enrollment.AccessBasketID = 0
+			enrollment.PricePaid = course.Price
+		}
+
+		if enrollment.AccessType == courseModels.EnrollmentAccessPurchase {
+			if float64(buyer.MainBalance) < course.Price {
+				return errInsufficientBalance
+			}
+
+			balanceBefore := float64(buyer.MainBalance)
+			balanceAfter := balanceBefore - course.Price
+			walletTxn := models.WalletTransaction{
+				UserID:          userID,
+				TransactionType: models.TransactionTypeCoursePurchase,
+				Amount:          course.Price,
+				BalanceBefore:   balanceBefore,
+				BalanceAfter:    balanceAfter,
+				Status:          models.TransactionStatusCompleted,
+				Description:     "Course purchase: " + course.Title,
+				ReferenceType:   "course",
+				ReferenceID:     course.ID,
+				ReferenceName:   course.Title,
+				TransactionDate: time.Now(),
+			}
+			if err := tx.Create(&walletTxn).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&buyer).Update("main_balance", uint(balanceAfter)).Error; err != nil {
+				return err
+			}
+			enrollment.WalletTransactionID = walletTxn.ID
+		}
+
+		return tx.Save(&enrollment).Error
+	})
+	if errors.Is(err, errAlreadyEnrolled) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "User already enrolled in this course!", nil)
+	}
+	if errors.Is(err, errInsufficientBalance) {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Insufficient wallet balance to buy this course!", fiber.Map{
+			"price":   course.Price,
+			"balance": user.MainBalance,
+		})
+	}
+	if err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to enroll in course!", nil)
 	}
-	tx.Commit()
 
 	// Send enrollment email asynchronously
 	go utils.SendEnrollmentEmail(user.Email, user.Name, course.Title)
@@ -64,6 +144,154 @@ func EnrollInCourse(c *fiber.Ctx) error {
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Enrolled in course successfully!", enrollment)
 }
 
+// RefundCourse refunds a purchased course to the wallet within the course's refund window and revokes access
+func RefundCourse(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	reason := c.Locals("refundReason").(string)
+
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ?", courseID).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	var enrollment courseModels.Enrollment
+	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&enrollment).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not enrolled in this course!", nil)
+	}
+
+	if enrollment.AccessType != courseModels.EnrollmentAccessPurchase || enrollment.PricePaid <= 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Only purchased courses can be refunded!", nil)
+	}
+
+	// The window runs from the purchase, which is the enrolment unless the learner switched from a subscription
+	purchasedAt := enrollment.CreatedAt
+	var purchase models.WalletTransaction
+	if err := database.Database.Db.Where("id = ?", enrollment.WalletTransactionID).First(&purchase).Error; err == nil {
+		purchasedAt = purchase.TransactionDate
+	}
+	refundDeadline := purchasedAt.AddDate(0, 0, course.RefundWindowDays)
+	if time.Now().After(refundDeadline) {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "The refund window for this course has ended!", fiber.Map{
+			"refund_deadline": refundDeadline,
+		})
+	}
+
+	var certificateCount int64
+	database.Database.Db.Model(&courseModels.Certificate{}).Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).Count(&certificateCount)
+	if certificateCount > 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Courses with an issued certificate can't be refunded!", nil)
+	}
+
+	var refundTxn models.WalletTransaction
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the enrollment and user so the refund is paid only once
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND is_deleted = ?", enrollment.ID, false).First(&enrollment).Error; err != nil {
+			return err
+		}
+		var buyer models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&buyer).Error; err != nil {
+			return err
+		}
+
+		balanceBefore := float64(buyer.MainBalance)
+		balanceAfter := balanceBefore + enrollment.PricePaid
+		refundTxn = models.WalletTransaction{
+			UserID:          userID,
+			TransactionType: models.TransactionTypeRefund,
+			Amount:          enrollment.PricePaid,
+			BalanceBefore:   balanceBefore,
+			BalanceAfter:    balanceAfter,
+			Status:          models.TransactionStatusCompleted,
+			Description:     "Course refund: " + course.Title,
+			ReferenceType:   "course",
+			ReferenceID:     course.ID,
+			ReferenceName:   course.Title,
+			Reason:          reason,
+			TransactionDate: time.Now(),
+		}
+		if err := tx.Create(&refundTxn).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&buyer).Update("main_balance", uint(balanceAfter)).Error; err != nil {
+			return err
+		}
+		if enrollment.WalletTransactionID > 0 {
+			if err := tx.Model(&models.WalletTransaction{}).Where("id = ?", enrollment.WalletTransactionID).
+				Update("status", models.TransactionStatusRefunded).Error; err != nil {
+				return err
+			}
+		}
+
+		// Revoke access; pending certificate requests go with it
+		now := time.Now()
+		if err := tx.Model(&enrollment).Updates(map[string]interface{}{
+			"status":      "REFUNDED",
+			"refunded_at": now,
+			"is_deleted":  true,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&courseModels.CertificateRequest{}).
+			Where("user_id = ? AND course_id = ? AND status = ? AND is_deleted = ?", userID, courseID, "PENDING", false).
+			Updates(map[string]interface{}{"status": "REJECTED", "rejection_reason": "Course refunded"}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Course already refunded!", nil)
+	}
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to refund course!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Course refunded to your wallet!", fiber.Map{
+		"enrollment":  enrollment,
+		"transaction": refundTxn,
+	})
+}
+
+// includedBasketIDs parses the baskets whose subscribers get a course for free
+func includedBasketIDs(course *courseModels.Course) []uint {
+	var ids []uint
+	json.Unmarshal([]byte(course.IncludedBasketIDs), &ids)
+	return ids
+}
+
+// activeIncludedBasket returns a basket the user actively subscribes to that includes the course, or 0
+func activeIncludedBasket(userID uint, course *courseModels.Course) uint {
+	ids := includedBasketIDs(course)
+	if len(ids) == 0 {
+		return 0
+	}
+
+	var subscription basket.BasketSubscription
+	if err := database.Database.Db.
+		Where("user_id = ? AND basket_id IN ? AND status = ? AND is_deleted = ?", userID, ids, basket.SubscriptionActive, false).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("expires_at DESC NULLS FIRST").
+		First(&subscription).Error; err != nil {
+		return 0
+	}
+	return subscription.BasketID
+}
+
+// subscriptionAccessEnded reports whether an enrollment granted by a basket subscription lost its access because
+// no included basket subscription is active any more
+func subscriptionAccessEnded(userID uint, course *courseModels.Course, enrollment *courseModels.Enrollment) bool {
+	if enrollment.AccessType != courseModels.EnrollmentAccessSubscription || course.Price <= 0 {
+		return false
+	}
+	return activeIncludedBasket(userID, course) == 0
+}
+
 func GetEnrollments(c *fiber.Ctx) error {
 	// Retrieve validated course ID
 	courseID := c.Locals("courseID").(int)
@@ -2,10 +2,13 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
 	courseModels "fib/models/course"
+	"fib/utils"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -41,6 +44,10 @@ func SubmitMCQAnswer(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content is not an MCQ!", nil)
 	}
 
+	if lock := lockForContent(userID, &content); lock.IsLocked {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+	}
+
 	reqData := new(struct {
 		SelectedOptionIDs []uint `json:"selected_option_ids"`
 	})
@@ -203,6 +210,7 @@ func updateEnrollmentProgress(userID uint, courseID uint) {
 		return
 	}
 
+	wasCompleted := enrollment.Status == "COMPLETED"
 	enrollment.CompletedContents = int(completedContent)
 	enrollment.TotalContents = int(totalContent)
 
@@ -219,4 +227,13 @@ func updateEnrollmentProgress(userID uint, courseID uint) {
 	}
 
 	database.Database.Db.Save(&enrollment)
+
+	// Courses with an automatic certificate policy issue the certificate on completion
+	if !wasCompleted && enrollment.Status == "COMPLETED" {
+		go func() {
+			if _, _, err := utils.IssueCertificateOnCompletion(userID, courseID); err != nil && !errors.Is(err, utils.ErrCertificateExists) {
+				log.Printf("Failed to issue certificate for user %d course %d: %v", userID, courseID, err)
+			}
+		}()
+	}
 }
@@ -0,0 +1,508 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// quizSubmitGrace allows for network latency when a timed quiz is submitted right at the deadline
+const quizSubmitGrace = 30 * time.Second
+
+var (
+	errQuizNoAttemptsLeft = errors.New("no attempts left")
+	errQuizNoQuestions    = errors.New("quiz has no questions")
+)
+
+// quizLayoutItem is one question of an attempt in the order it was shown, with its option order
+type quizLayoutItem struct {
+	QuestionID uint   `json:"question_id"`
+	OptionIDs  []uint `json:"option_ids"`
+}
+
+// quizAnswer is the learner's chosen options for a question
+type quizAnswer struct {
+	QuestionID uint   `json:"question_id"`
+	OptionIDs  []uint `json:"option_ids"`
+}
+
+// quizQuestionResult is the outcome of one question in a graded attempt
+type quizQuestionResult struct {
+	QuestionID uint    `json:"question_id"`
+	Answered   bool    `json:"answered"`
+	Correct    bool    `json:"correct"`
+	Marks      float64 `json:"marks"`     // Marks awarded, negative for a penalised wrong answer
+	MaxMarks   float64 `json:"max_marks"` // Marks for a correct answer
+}
+
+// findQuiz loads the settings of QUIZ content, or nil if it has none
+func findQuiz(contentID uint) *courseModels.Quiz {
+	var quiz courseModels.Quiz
+	if err := database.Database.Db.Where("content_id = ? AND is_deleted = ?", contentID, false).First(&quiz).Error; err != nil {
+		return nil
+	}
+	return &quiz
+}
+
+// loadQuizQuestions loads a quiz's questions with their active options, in authoring order
+func loadQuizQuestions(db *gorm.DB, quizID uint) ([]courseModels.QuizQuestion, error) {
+	var questions []courseModels.QuizQuestion
+	err := db.Where("quiz_id = ? AND is_deleted = ?", quizID, false).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_deleted = ?", false).Order("order_index asc, id asc")
+		}).
+		Order("order_index asc, id asc").
+		Find(&questions).Error
+	return questions, err
+}
+
+// quizAttemptsLeft returns how many more attempts a learner may start, or -1 when unlimited
+func quizAttemptsLeft(quiz *courseModels.Quiz, used int64) int {
+	if quiz.MaxAttempts <= 0 {
+		return -1
+	}
+	return max(quiz.MaxAttempts-int(used), 0)
+}
+
+// gradeQuiz marks the answers to the questions of an attempt. A question scores only when exactly its correct
+// options are chosen; with negative marking a wrong answer loses a share of its marks. The total never drops below 0.
+func gradeQuiz(quiz *courseModels.Quiz, questions []courseModels.QuizQuestion, answers map[uint][]uint) ([]quizQuestionResult, float64, float64) {
+	results := make([]quizQuestionResult, 0, len(questions))
+	var score, maxScore float64
+
+	for _, question := range questions {
+		result := quizQuestionResult{QuestionID: question.ID, MaxMarks: question.Marks}
+		maxScore += question.Marks
+
+		correct := make(map[uint]bool)
+		valid := make(map[uint]bool)
+		for _, option := range question.Options {
+			valid[option.ID] = true
+			if option.IsCorrect {
+				correct[option.ID] = true
+			}
+		}
+
+		selected := make(map[uint]bool)
+		for _, id := range answers[question.ID] {
+			if valid[id] {
+				selected[id] = true
+			}
+		}
+
+		if len(selected) > 0 {
+			result.Answered = true
+			result.Correct = len(selected) == len(correct)
+			for id := range selected {
+				if !correct[id] {
+					result.Correct = false
+				}
+			}
+
+			if result.Correct {
+				result.Marks = question.Marks
+			} else if quiz.NegativeMarking {
+				result.Marks = -question.Marks * quiz.NegativeFraction
+			}
+		}
+
+		score += result.Marks
+		results = append(results, result)
+	}
+
+	return results, math.Max(score, 0), maxScore
+}
+
+// layoutQuestions returns an attempt's questions in the order they were shown; questions removed since are skipped
+func layoutQuestions(attempt *courseModels.QuizAttempt, questions []courseModels.QuizQuestion) []courseModels.QuizQuestion {
+	var layout []quizLayoutItem
+	json.Unmarshal([]byte(attempt.Layout), &layout)
+
+	byID := make(map[uint]courseModels.QuizQuestion, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+
+	ordered := make([]courseModels.QuizQuestion, 0, len(layout))
+	for _, item := range layout {
+		question, ok := byID[item.QuestionID]
+		if !ok {
+			continue
+		}
+
+		// Keep the option order the learner saw
+		position := make(map[uint]int, len(item.OptionIDs))
+		for i, id := range item.OptionIDs {
+			position[id] = i
+		}
+		options := append([]courseModels.QuizOption(nil), question.Options...)
+		sort.SliceStable(options, func(a, b int) bool {
+			pa, okA := position[options[a].ID]
+			pb, okB := position[options[b].ID]
+			if okA != okB {
+				return okA
+			}
+			return pa < pb
+		})
+		question.Options = options
+		ordered = append(ordered, question)
+	}
+	return ordered
+}
+
+// quizPaper is the learner's view of an attempt's questions, without the answers
+func quizPaper(questions []courseModels.QuizQuestion) []fiber.Map {
+	paper := make([]fiber.Map, len(questions))
+	for i, question := range questions {
+		options := make([]fiber.Map, len(question.Options))
+		for j, option := range question.Options {
+			options[j] = fiber.Map{
+				"id":          option.ID,
+				"option_text": option.OptionText,
+			}
+		}
+		paper[i] = fiber.Map{
+			"id":       question.ID,
+			"question": question.Question,
+			"marks":    question.Marks,
+			"options":  options,
+		}
+	}
+	return paper
+}
+
+// finishQuizAttempt grades an attempt and stores the result. Expired attempts are graded without answers.
+func finishQuizAttempt(tx *gorm.DB, quiz *courseModels.Quiz, attempt *courseModels.QuizAttempt, answers map[uint][]uint, status string) error {
+	questions, err := loadQuizQuestions(tx, quiz.ID)
+	if err != nil {
+		return err
+	}
+	questions = layoutQuestions(attempt, questions)
+
+	if status == courseModels.QuizAttemptExpired {
+		answers = nil
+	}
+	results, score, maxScore := gradeQuiz(quiz, questions, answers)
+
+	submitted := make([]quizAnswer, 0, len(answers))
+	for _, question := range questions {
+		if ids, ok := answers[question.ID]; ok {
+			submitted = append(submitted, quizAnswer{QuestionID: question.ID, OptionIDs: ids})
+		}
+	}
+	answersJSON, _ := json.Marshal(submitted)
+	resultsJSON, _ := json.Marshal(results)
+
+	now := time.Now()
+	attempt.Status = status
+	attempt.SubmittedAt = &now
+	attempt.Answers = string(answersJSON)
+	attempt.Results = string(resultsJSON)
+	attempt.Score = score
+	attempt.MaxScore = maxScore
+	attempt.Percent = 0
+	if maxScore > 0 {
+		attempt.Percent = score / maxScore * 100
+	}
+	attempt.Passed = status == courseModels.QuizAttemptSubmitted && maxScore > 0 && attempt.Percent >= quiz.PassPercent
+
+	return tx.Save(attempt).Error
+}
+
+// loadQuizContent checks enrollment and loads published QUIZ content with its settings
+func loadQuizContent(c *fiber.Ctx, userID uint, courseID, contentID int) (*courseModels.Quiz, error) {
+	var enrollment courseModels.Enrollment
+	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&enrollment).Error; err != nil {
+		return nil, middleware.JsonResponse(c, fiber.StatusForbidden, false, "User not enrolled in this course!", nil)
+	}
+
+	var content courseModels.CourseContent
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ? AND is_published = ?", contentID, courseID, false, true).First(&content).Error; err != nil {
+		return nil, middleware.JsonResponse(c, fiber.StatusNotFound, false, "Content not found!", nil)
+	}
+
+	if content.ContentType != "QUIZ" {
+		return nil, middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content is not a quiz!", nil)
+	}
+
+	if lock := lockForContent(userID, &content); lock.IsLocked {
+		return nil, middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+	}
+
+	quiz := findQuiz(content.ID)
+	if quiz == nil {
+		return nil, middleware.JsonResponse(c, fiber.StatusNotFound, false, "Quiz not found!", nil)
+	}
+	return quiz, nil
+}
+
+// StartQuiz starts a new quiz attempt, or resumes the one in progress, and returns its questions
+func StartQuiz(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	quiz, respErr := loadQuizContent(c, userID, courseID, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	var attempt courseModels.QuizAttempt
+	var questions []courseModels.QuizQuestion
+	var used int64
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the enrollment so parallel requests can't start two attempts
+		var enrollment courseModels.Enrollment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).
+			First(&enrollment).Error; err != nil {
+			return err
+		}
+
+		allQuestions, err := loadQuizQuestions(tx, quiz.ID)
+		if err != nil {
+			return err
+		}
+
+		// Resume an unfinished attempt, closing it first if its time ran out
+		if err := tx.Where("quiz_id = ? AND user_id = ? AND status = ? AND is_deleted = ?", quiz.ID, userID, courseModels.QuizAttemptInProgress, false).
+			First(&attempt).Error; err == nil {
+			if attempt.DeadlineAt == nil || time.Now().Before(attempt.DeadlineAt.Add(quizSubmitGrace)) {
+				questions = layoutQuestions(&attempt, allQuestions)
+				return tx.Model(&courseModels.QuizAttempt{}).Where("quiz_id = ? AND user_id = ? AND is_deleted = ?", quiz.ID, userID, false).Count(&used).Error
+			}
+			if err := finishQuizAttempt(tx, quiz, &attempt, nil, courseModels.QuizAttemptExpired); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&courseModels.QuizAttempt{}).Where("quiz_id = ? AND user_id = ? AND is_deleted = ?", quiz.ID, userID, false).Count(&used).Error; err != nil {
+			return err
+		}
+		if quiz.MaxAttempts > 0 && used >= int64(quiz.MaxAttempts) {
+			return errQuizNoAttemptsLeft
+		}
+		if len(allQuestions) == 0 {
+			return errQuizNoQuestions
+		}
+
+		// Fix the question and option order for this attempt
+		questions = allQuestions
+		if quiz.ShuffleQuestions {
+			rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+		}
+		layout := make([]quizLayoutItem, len(questions))
+		for i := range questions {
+			options := questions[i].Options
+			if quiz.ShuffleOptions {
+				rand.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+			}
+			layout[i] = quizLayoutItem{QuestionID: questions[i].ID}
+			for _, option := range options {
+				layout[i].OptionIDs = append(layout[i].OptionIDs, option.ID)
+			}
+		}
+		layoutJSON, _ := json.Marshal(layout)
+
+		now := time.Now()
+		attempt = courseModels.QuizAttempt{
+			QuizID:        quiz.ID,
+			ContentID:     uint(contentID),
+			CourseID:      uint(courseID),
+			UserID:        userID,
+			AttemptNumber: int(used) + 1,
+			Status:        courseModels.QuizAttemptInProgress,
+			StartedAt:     now,
+			Layout:        string(layoutJSON),
+			Answers:       "[]",
+			Results:       "[]",
+		}
+		if quiz.TimeLimitSeconds > 0 {
+			deadline := now.Add(time.Duration(quiz.TimeLimitSeconds) * time.Second)
+			attempt.DeadlineAt = &deadline
+		}
+		used++
+		return tx.Create(&attempt).Error
+	})
+	if errors.Is(err, errQuizNoAttemptsLeft) {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "You have used all attempts for this quiz!", nil)
+	}
+	if errors.Is(err, errQuizNoQuestions) {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Quiz has no questions yet!", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to start quiz %d for user %d: %v", quiz.ID, userID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to start quiz!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz started!", fiber.Map{
+		"attempt":       attempt,
+		"questions":     quizPaper(questions),
+		"quiz":          quiz,
+		"attempts_left": quizAttemptsLeft(quiz, used),
+		"server_time":   time.Now(),
+	})
+}
+
+// SubmitQuiz grades the attempt in progress. Passing completes the quiz content and updates course progress.
+func SubmitQuiz(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	reqData, ok := c.Locals("validatedQuizSubmission").(*struct {
+		AttemptID uint `json:"attempt_id"`
+		Answers   []struct {
+			QuestionID uint   `json:"question_id"`
+			OptionIDs  []uint `json:"option_ids"`
+		} `json:"answers"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	quiz, respErr := loadQuizContent(c, userID, courseID, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	answers := make(map[uint][]uint, len(reqData.Answers))
+	for _, answer := range reqData.Answers {
+		answers[answer.QuestionID] = answer.OptionIDs
+	}
+
+	var attempt courseModels.QuizAttempt
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND quiz_id = ? AND user_id = ? AND is_deleted = ?", reqData.AttemptID, quiz.ID, userID, false).
+			First(&attempt).Error; err != nil {
+			return err
+		}
+		if attempt.Status != courseModels.QuizAttemptInProgress {
+			return nil
+		}
+
+		status := courseModels.QuizAttemptSubmitted
+		if attempt.DeadlineAt != nil && time.Now().After(attempt.DeadlineAt.Add(quizSubmitGrace)) {
+			status = courseModels.QuizAttemptExpired
+		}
+		return finishQuizAttempt(tx, quiz, &attempt, answers, status)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Quiz attempt not found!", nil)
+	}
+	if err != nil {
+		log.Printf("Failed to submit quiz attempt %d: %v", reqData.AttemptID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to submit quiz!", nil)
+	}
+	if attempt.SubmittedAt == nil || attempt.Status == courseModels.QuizAttemptInProgress {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Quiz attempt is not in progress!", nil)
+	}
+
+	// Passing completes the content
+	if attempt.Passed {
+		var existingCompletion courseModels.ContentCompletion
+		if err := database.Database.Db.Where("user_id = ? AND course_content_id = ? AND is_deleted = ?", userID, contentID, false).First(&existingCompletion).Error; err != nil {
+			completion := courseModels.ContentCompletion{
+				UserID:          userID,
+				CourseID:        uint(courseID),
+				CourseContentID: uint(contentID),
+				Status:          "COMPLETED",
+			}
+			database.Database.Db.Create(&completion)
+
+			updateEnrollmentProgress(userID, uint(courseID))
+		}
+	}
+
+	var used int64
+	database.Database.Db.Model(&courseModels.QuizAttempt{}).Where("quiz_id = ? AND user_id = ? AND is_deleted = ?", quiz.ID, userID, false).Count(&used)
+
+	message := "Quiz submitted!"
+	if attempt.Status == courseModels.QuizAttemptExpired {
+		message = "Time is up, the quiz was submitted without answers!"
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, message, fiber.Map{
+		"attempt":       attempt,
+		"passed":        attempt.Passed,
+		"score":         attempt.Score,
+		"max_score":     attempt.MaxScore,
+		"percent":       attempt.Percent,
+		"pass_percent":  quiz.PassPercent,
+		"attempts_left": quizAttemptsLeft(quiz, used),
+	})
+}
+
+// GetQuizAttempts lists the learner's attempts at a quiz, newest first
+func GetQuizAttempts(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	quiz, respErr := loadQuizContent(c, userID, courseID, contentID)
+	if quiz == nil {
+		return respErr
+	}
+
+	var attempts []courseModels.QuizAttempt
+	if err := database.Database.Db.Where("quiz_id = ? AND user_id = ? AND is_deleted = ?", quiz.ID, userID, false).
+		Order("attempt_number desc").Find(&attempts).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch quiz attempts!", nil)
+	}
+
+	bestPercent := float64(0)
+	passed := false
+	for _, attempt := range attempts {
+		bestPercent = math.Max(bestPercent, attempt.Percent)
+		passed = passed || attempt.Passed
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Quiz attempts fetched successfully!", fiber.Map{
+		"attempts":      attempts,
+		"best_percent":  bestPercent,
+		"passed":        passed,
+		"pass_percent":  quiz.PassPercent,
+		"attempts_left": quizAttemptsLeft(quiz, int64(len(attempts))),
+	})
+}
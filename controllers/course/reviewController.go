@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recomputeCourseRating refreshes a course's rating from its approved reviews. The course row is locked so parallel
+// moderations can't overwrite each other's result.
+func recomputeCourseRating(tx *gorm.DB, courseID uint) error {
+	var course courseModels.Course
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", courseID).First(&course).Error; err != nil {
+		return err
+	}
+
+	var stats struct {
+		Count   int64
+		Average float64
+	}
+	if err := tx.Model(&courseModels.CourseReview{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(rating), 0) AS average").
+		Where("course_id = ? AND status = ? AND is_deleted = ?", courseID, courseModels.CourseReviewApproved, false).
+		Scan(&stats).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&course).Updates(map[string]interface{}{
+		"average_rating": math.Round(stats.Average*100) / 100,
+		"rating_count":   stats.Count,
+		"rating":         uint(math.Round(stats.Average)),
+	}).Error
+}
+
+// userNames looks up the display names of a set of users
+func userNames(userIDs []uint) map[uint]string {
+	names := make(map[uint]string)
+	if len(userIDs) == 0 {
+		return names
+	}
+
+	var users []models.User
+	database.Database.Db.Select("id, name").Where("id IN ?", userIDs).Find(&users)
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
+	return names
+}
+
+// SubmitCourseReview creates or updates the user's review of a course they are enrolled in.
+// An edited review goes back to moderation.
+func SubmitCourseReview(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+
+	reqData, ok := c.Locals("validatedCourseReview").(*struct {
+		Rating int    `json:"rating"`
+		Review string `json:"review"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ? AND is_published = ?", courseID, false, true).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	var enrollment courseModels.Enrollment
+	if err := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&enrollment).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Only enrolled users can review this course!", nil)
+	}
+
+	// An edited review goes back into moderation. The upsert keeps parallel submissions to one review per learner.
+	review := courseModels.CourseReview{
+		CourseID: course.ID,
+		UserID:   userID,
+		Rating:   reqData.Rating,
+		Review:   reqData.Review,
+		Status:   courseModels.CourseReviewPending,
+	}
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "course_id"}, {Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "is_deleted", Value: false}}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"rating":           review.Rating,
+				"review":           review.Review,
+				"status":           review.Status,
+				"rejection_reason": "",
+				"moderated_by":     nil,
+				"moderated_at":     nil,
+				"updated_at":       time.Now(),
+			}),
+		}).Create(&review).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("course_id = ? AND user_id = ? AND is_deleted = ?", course.ID, userID, false).First(&review).Error; err != nil {
+			return err
+		}
+
+		// An approved version no longer counts once it is back in moderation
+		return recomputeCourseRating(tx, course.ID)
+	})
+	if err != nil {
+		log.Printf("Failed to save review of course %d by user %d: %v", courseID, userID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to submit review!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Review submitted successfully! Pending approval.", review)
+}
+
+// DeleteCourseReview removes the user's own review of a course
+func DeleteCourseReview(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+
+	var review courseModels.CourseReview
+	if err := database.Database.Db.Where("course_id = ? AND user_id = ? AND is_deleted = ?", courseID, userID, false).First(&review).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Review not found!", nil)
+	}
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&review).Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+		if review.Status == courseModels.CourseReviewApproved {
+			return recomputeCourseRating(tx, review.CourseID)
+		}
+		return nil
+	})
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete review!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Review deleted successfully!", nil)
+}
+
+// GetCourseReviews lists the approved reviews of a course with its rating breakdown and the user's own review
+func GetCourseReviews(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+
+	reqData, _ := c.Locals("validatedReviewQuery").(*struct {
+		Page  *int `json:"page"`
+		Limit *int `json:"limit"`
+	})
+
+	page := 1
+	limit := 10
+	if reqData != nil && reqData.Page != nil && *reqData.Page > 0 {
+		page = *reqData.Page
+	}
+	if reqData != nil && reqData.Limit != nil && *reqData.Limit > 0 {
+		limit = *reqData.Limit
+	}
+	offset := (page - 1) * limit
+
+	var course courseModels.Course
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ? AND is_published = ?", courseID, false, true).First(&course).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+
+	db := database.Database.Db.Model(&courseModels.CourseReview{}).
+		Where("course_id = ? AND status = ? AND is_deleted = ?", courseID, courseModels.CourseReviewApproved, false)
+
+	var total int64
+	db.Count(&total)
+
+	var reviews []courseModels.CourseReview
+	if err := db.Order("created_at desc").Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch reviews!", nil)
+	}
+
+	userIDs := make([]uint, len(reviews))
+	for i, r := range reviews {
+		userIDs[i] = r.UserID
+	}
+	names := userNames(userIDs)
+
+	type ReviewWithUser struct {
+		courseModels.CourseReview
+		UserName string `json:"user_name"`
+	}
+
+	result := make([]ReviewWithUser, len(reviews))
+	for i, r := range reviews {
+		result[i] = ReviewWithUser{CourseReview: r, UserName: names[r.UserID]}
+	}
+
+	// Number of approved reviews per star
+	var counts []struct {
+		Rating int
+		Count  int64
+	}
+	database.Database.Db.Model(&courseModels.CourseReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("course_id = ? AND status = ? AND is_deleted = ?", courseID, courseModels.CourseReviewApproved, false).
+		Group("rating").
+		Scan(&counts)
+
+	distribution := fiber.Map{"1": 0, "2": 0, "3": 0, "4": 0, "5": 0}
+	for _, row := range counts {
+		distribution[strconv.Itoa(row.Rating)] = row.Count
+	}
+
+	var myReview *courseModels.CourseReview
+	var own courseModels.CourseReview
+	if err := database.Database.Db.Where("course_id = ? AND user_id = ? AND is_deleted = ?", courseID, userID, false).First(&own).Error; err == nil {
+		myReview = &own
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reviews fetched successfully!", fiber.Map{
+		"reviews":        result,
+		"average_rating": course.AverageRating,
+		"rating_count":   course.RatingCount,
+		"distribution":   distribution,
+		"my_review":      myReview,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
@@ -36,11 +36,21 @@ func GetCourseDetails(c *fiber.Ctx) error {
 	var enrollment courseModels.Enrollment
 	isEnrolled := database.Database.Db.Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).First(&enrollment).Error == nil
 
+	// What enrolling costs this user: nothing for free courses or subscribers of an included basket
+	includedWith := activeIncludedBasket(userID, &course)
+	price := course.Price
+	if includedWith > 0 {
+		price = 0
+	}
+
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Course details fetched successfully!", fiber.Map{
-		"course":      course,
-		"modules":     modules,
-		"is_enrolled": isEnrolled,
-		"enrollment":  enrollment,
+		"course":              course,
+		"modules":             modules,
+		"is_enrolled":         isEnrolled,
+		"enrollment":          enrollment,
+		"price_to_pay":        price,
+		"included_via_basket": includedWith,
+		"access_ended":        isEnrolled && subscriptionAccessEnded(userID, &course, &enrollment),
 	})
 }
 
@@ -72,6 +82,15 @@ func GetDayContent(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Module not found!", nil)
 	}
 
+	// Drip release and module prerequisites
+	access, err := loadCourseAccess(userID, uint(courseID))
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Course not found!", nil)
+	}
+	if lock := access.dayLock(module.ID, day); lock.IsLocked {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+	}
+
 	// Get content for the day
 	var contents []courseModels.CourseContent
 	if err := database.Database.Db.Where("module_id = ? AND day = ? AND is_deleted = ? AND is_published = ?", moduleID, day, false, true).
@@ -82,8 +101,10 @@ func GetDayContent(c *fiber.Ctx) error {
 	// Get content with MCQ options for MCQ type
 	type ContentWithOptions struct {
 		courseModels.CourseContent
-		MCQOptions  []courseModels.MCQOption `json:"mcq_options,omitempty"`
-		IsCompleted bool                     `json:"is_completed"`
+		MCQOptions    []courseModels.MCQOption    `json:"mcq_options,omitempty"`
+		Quiz          *courseModels.Quiz          `json:"quiz,omitempty"`
+		VideoProgress *courseModels.VideoProgress `json:"video_progress,omitempty"`
+		IsCompleted   bool                        `json:"is_completed"`
 	}
 
 	result := make([]ContentWithOptions, len(contents))
@@ -108,6 +129,16 @@ func GetDayContent(c *fiber.Ctx) error {
 			}
 			result[i].MCQOptions = options
 		}
+
+		// Quiz settings only, questions are served when an attempt starts
+		if content.ContentType == "QUIZ" {
+			result[i].Quiz = findQuiz(content.ID)
+		}
+
+		// Where to resume the video
+		if content.ContentType == "VIDEO" {
+			result[i].VideoProgress = findVideoProgress(userID, content.ID)
+		}
 	}
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Day content fetched successfully!", fiber.Map{
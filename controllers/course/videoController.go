@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"fib/config"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	courseModels "fib/models/course"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// videoFirstEventMaxSeconds caps the watched time credited by the first event, when there is no previous event to
+// measure elapsed time against
+const videoFirstEventMaxSeconds = 30
+
+// findVideoProgress loads a learner's progress on VIDEO content to resume from, or nil if they haven't started it
+func findVideoProgress(userID, contentID uint) *courseModels.VideoProgress {
+	var progress courseModels.VideoProgress
+	if err := database.Database.Db.Where("user_id = ? AND content_id = ? AND is_deleted = ?", userID, contentID, false).First(&progress).Error; err != nil {
+		return nil
+	}
+	return &progress
+}
+
+// videoCompletionPercent is the share of a video to watch before it completes
+func videoCompletionPercent(content *courseModels.CourseContent) float64 {
+	if content.VideoCompletionPercent > 0 {
+		return content.VideoCompletionPercent
+	}
+	return config.AppConfig.VideoCompletionPercent
+}
+
+// markVideoSegments sizes the segment map to the video and marks the segments played between from and to.
+// A segment counts as played once the range covers its midpoint.
+func markVideoSegments(segments string, duration, from, to float64) string {
+	count := int(math.Ceil(duration / courseModels.VideoSegmentSeconds))
+	if len(segments) > count {
+		segments = segments[:count]
+	}
+	marks := []byte(segments + strings.Repeat("0", count-len(segments)))
+
+	for i := range marks {
+		mid := float64(i)*courseModels.VideoSegmentSeconds + courseModels.VideoSegmentSeconds/2.0
+		if mid >= from && mid <= to {
+			marks[i] = '1'
+		}
+	}
+	return string(marks)
+}
+
+// videoWatchedSeconds adds up the length of the played segments; the last segment may be shorter
+func videoWatchedSeconds(segments string, duration float64) float64 {
+	var watched float64
+	for i, mark := range segments {
+		if mark != '1' {
+			continue
+		}
+		start := float64(i) * courseModels.VideoSegmentSeconds
+		watched += math.Min(courseModels.VideoSegmentSeconds, duration-start)
+	}
+	return watched
+}
+
+// RecordVideoProgress stores a player progress event and completes the video once enough of it was watched
+func RecordVideoProgress(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
+	}
+
+	courseID := c.Locals("courseID").(int)
+	contentID := c.Locals("contentID").(int)
+
+	reqData, ok := c.Locals("validatedVideoProgress").(*struct {
+		PositionSeconds float64 `json:"position_seconds"`
+		DurationSeconds float64 `json:"duration_seconds"`
+		WatchedSeconds  float64 `json:"watched_seconds"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var content courseModels.CourseContent
+	if err := database.Database.Db.Where("id = ? AND course_id = ? AND is_deleted = ? AND is_published = ?", contentID, courseID, false, true).First(&content).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Content not found!", nil)
+	}
+
+	if content.ContentType != "VIDEO" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content is not a video!", nil)
+	}
+
+	if lock := lockForContent(userID, &content); lock.IsLocked {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, lock.LockReason, lock)
+	}
+
+	// The admin-set length wins over what the player reports. A length only the player reported is good enough to
+	// resume from but not to complete the video, the client could claim any length.
+	duration := float64(content.VideoDurationSeconds)
+	autoComplete := duration > 0
+	if duration <= 0 {
+		duration = reqData.DurationSeconds
+	}
+	if duration <= 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Video duration is required!", nil)
+	}
+
+	threshold := videoCompletionPercent(&content)
+	justCompleted := false
+
+	var progress courseModels.VideoProgress
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Create the row on the first event, then lock it so parallel events don't lose updates
+		progress = courseModels.VideoProgress{UserID: userID, ContentID: content.ID, CourseID: content.CourseID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&progress).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND content_id = ?", userID, content.ID).
+			First(&progress).Error; err != nil {
+			return err
+		}
+
+		// Credit no more playback than could have happened since the last event, allowing for 2x speed
+		now := time.Now()
+		watched := reqData.WatchedSeconds
+		if progress.LastEventAt == nil {
+			watched = math.Min(watched, videoFirstEventMaxSeconds)
+		} else {
+			watched = math.Min(watched, 2*now.Sub(*progress.LastEventAt).Seconds()+5)
+		}
+
+		position := math.Min(reqData.PositionSeconds, duration)
+		progress.Segments = markVideoSegments(progress.Segments, duration, math.Max(position-watched, 0), position)
+		progress.DurationSeconds = duration
+		progress.PositionSeconds = position
+		progress.MaxPositionSeconds = math.Max(progress.MaxPositionSeconds, position)
+		progress.WatchedSeconds = videoWatchedSeconds(progress.Segments, duration)
+		progress.WatchedPercent = math.Min(progress.WatchedSeconds/duration*100, 100)
+		progress.LastEventAt = &now
+		progress.IsDeleted = false
+
+		if autoComplete && progress.CompletedAt == nil && progress.WatchedPercent >= threshold {
+			progress.CompletedAt = &now
+			justCompleted = true
+		}
+		return tx.Save(&progress).Error
+	})
+	if err != nil {
+		log.Printf("Failed to record video progress for user %d content %d: %v", userID, contentID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to record video progress!", nil)
+	}
+
+	// Watching enough of the video completes the content
+	if justCompleted {
+		var existingCompletion courseModels.ContentCompletion
+		if err := database.Database.Db.Where("user_id = ? AND course_content_id = ? AND is_deleted = ?", userID, contentID, false).First(&existingCompletion).Error; err != nil {
+			completion := courseModels.ContentCompletion{
+				UserID:          userID,
+				CourseID:        uint(courseID),
+				CourseContentID: uint(contentID),
+				Status:          "COMPLETED",
+			}
+			database.Database.Db.Create(&completion)
+
+			updateEnrollmentProgress(userID, uint(courseID))
+		}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Video progress recorded!", fiber.Map{
+		"progress":           progress,
+		"completed":          progress.CompletedAt != nil,
+		"completion_percent": threshold,
+	})
+}
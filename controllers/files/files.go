@@ -0,0 +1,42 @@
+package filesController
+
+import (
+	"errors"
+	"fib/middleware"
+	"fib/storage"
+	"log"
+	"mime"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServeFile streams a blob from the local store when the URL's signature is valid and unexpired.
+// S3 signed URLs point straight at the bucket and never reach this route.
+func ServeFile(c *fiber.Ctx) error {
+	store, ok := storage.Default().(*storage.LocalStore)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "File not found!", nil)
+	}
+
+	key := c.Params("*")
+	if !store.VerifySignature(key, c.Query("expires"), c.Query("sig")) {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Link is invalid or has expired!", nil)
+	}
+
+	body, err := store.Open(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "File not found!", nil)
+		}
+		log.Printf("Failed to open blob %s: %v", key, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch file!", nil)
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		c.Set(fiber.HeaderContentType, contentType)
+	}
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(body)
+}
@@ -0,0 +1,339 @@
+package kycController
+
+import (
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/storage"
+	"fib/utils"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// sendDocument streams a stored KYC document
+func sendDocument(c *fiber.Ctx, document *models.KYCDocument) error {
+	body, err := storage.Default().Open(c.Context(), document.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Document not found!", nil)
+		}
+		log.Printf("Failed to open KYC document %d: %v", document.ID, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch document!", nil)
+	}
+
+	c.Set(fiber.HeaderContentType, document.MimeType)
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.SendStream(body)
+}
+
+// GetMyKYC returns the user's KYC status, uploaded documents and what is still missing
+func GetMyKYC(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	userKYC, err := utils.GetOrCreateUserKYC(database.Database.Db, userId)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch KYC!", nil)
+	}
+
+	var documents []models.KYCDocument
+	database.Database.Db.Where("user_kyc_id = ? AND is_deleted = false", userKYC.ID).Order("created_at DESC").Find(&documents)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "KYC status.", fiber.Map{
+		"status":          userKYC.Status,
+		"rejectionReason": userKYC.RejectionReason,
+		"submittedAt":     userKYC.SubmittedAt,
+		"verifiedAt":      userKYC.VerifiedAt,
+		"expiresAt":       userKYC.ExpiresAt,
+		"documents":       documents,
+		"missing":         utils.MissingKYCRequirements(userKYC),
+		"editable":        utils.IsKYCEditable(userKYC.Status),
+	})
+}
+
+// UploadDocument stores a KYC document, replacing any earlier upload of the same type
+func UploadDocument(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	documentType, ok := c.Locals("validatedKYCDocumentType").(string)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "File is required!", nil)
+	}
+
+	db := database.Database.Db
+
+	userKYC, err := utils.GetOrCreateUserKYC(db, userId)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch KYC!", nil)
+	}
+	if !utils.IsKYCEditable(userKYC.Status) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC is already "+userKYC.Status+"!", nil)
+	}
+
+	// The type is sniffed from the content, the client's extension isn't trusted
+	rules := storage.DocumentRules
+	rules.MaxBytes = int64(config.AppConfig.KYCMaxUploadMB) << 20
+	stored, err := storage.SaveUpload(c.Context(), file, fmt.Sprintf("kyc/%d", userId), rules)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrFileTooLarge):
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, fmt.Sprintf("File must be smaller than %d MB!", config.AppConfig.KYCMaxUploadMB), nil)
+		case errors.Is(err, storage.ErrFileTypeInvalid):
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Only JPG, PNG and PDF files are allowed!", nil)
+		}
+		log.Printf("Failed to save KYC document: %v", err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to upload document!", nil)
+	}
+
+	document := models.KYCDocument{
+		UserID:       userId,
+		UserKYCID:    userKYC.ID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(file.Filename),
+		FilePath:     stored.Key,
+		MimeType:     stored.ContentType,
+		Size:         stored.Size,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.KYCDocument{}).
+			Where("user_kyc_id = ? AND document_type = ? AND is_deleted = false", userKYC.ID, documentType).
+			Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		return utils.StartKYC(tx, userKYC, userId)
+	})
+	if err != nil {
+		// Content-addressed keys are shared, only remove the blob if no other document uses it
+		var inUse int64
+		db.Model(&models.KYCDocument{}).Where("file_path = ?", stored.Key).Count(&inUse)
+		if inUse == 0 {
+			storage.Default().Delete(c.Context(), stored.Key)
+		}
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to upload document!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Document uploaded successfully.", document)
+}
+
+// GetMyDocument streams one of the user's own KYC documents
+func GetMyDocument(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var document models.KYCDocument
+	if err := database.Database.Db.Where("id = ? AND user_id = ? AND is_deleted = false", c.Params("id"), userId).First(&document).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Document not found!", nil)
+	}
+
+	return sendDocument(c, &document)
+}
+
+// SubmitKYC sends a complete KYC to the admin review queue
+func SubmitKYC(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	db := database.Database.Db
+
+	userKYC, err := utils.GetOrCreateUserKYC(db, userId)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch KYC!", nil)
+	}
+
+	if missing := utils.MissingKYCRequirements(userKYC); len(missing) > 0 {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "KYC is incomplete!", fiber.Map{
+			"missing": missing,
+		})
+	}
+
+	if err := utils.TransitionKYC(db, userKYC, models.KYCStatusPendingReview, userId, ""); err != nil {
+		if errors.Is(err, utils.ErrKYCInvalidTransition) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC can't be submitted while "+userKYC.Status+"!", nil)
+		}
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to submit KYC!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "KYC submitted for review.", fiber.Map{
+		"status": userKYC.Status,
+	})
+}
+
+// ListKYC lists KYC records for admins, the review queue (PENDING_REVIEW) by default
+func ListKYC(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedKYCList").(*struct {
+		Page   *int   `json:"page"`
+		Limit  *int   `json:"limit"`
+		Status string `json:"status"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	status := reqData.Status
+	if status == "" {
+		status = models.KYCStatusPendingReview
+	}
+
+	offset := (*reqData.Page - 1) * (*reqData.Limit)
+
+	var records []models.UserKYC
+	var total int64
+
+	db := database.Database.Db
+	db.Model(&models.UserKYC{}).Where("status = ? AND is_deleted = false", status).Count(&total)
+
+	// Oldest submissions first so the queue is worked in order
+	if err := db.Where("status = ? AND is_deleted = false", status).Preload("Aadhar").Preload("Pan").
+		Order("submitted_at ASC NULLS LAST, id ASC").
+		Offset(offset).
+		Limit(*reqData.Limit).
+		Find(&records).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch KYC list!", nil)
+	}
+
+	// Aadhaar photos are large and not needed in the list
+	for i := range records {
+		records[i].Aadhar.ProfileImage = ""
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "KYC list.", fiber.Map{
+		"records": records,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  *reqData.Page,
+			"limit": *reqData.Limit,
+		},
+	})
+}
+
+// GetKYCDetails returns a user's full KYC record, documents and history for review
+func GetKYCDetails(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	db := database.Database.Db
+
+	var userKYC models.UserKYC
+	if err := db.Preload("Aadhar").Preload("Pan").
+		Where("user_id = ? AND is_deleted = false", c.Params("userId")).First(&userKYC).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "KYC not found!", nil)
+	}
+
+	var user models.User
+	db.Select("id", "name", "email", "mobile", "role").Where("id = ?", userKYC.UserID).First(&user)
+
+	var documents []models.KYCDocument
+	db.Where("user_kyc_id = ? AND is_deleted = false", userKYC.ID).Order("created_at DESC").Find(&documents)
+
+	var history []models.KYCAuditLog
+	db.Where("user_kyc_id = ?", userKYC.ID).Order("created_at DESC").Find(&history)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "KYC details.", fiber.Map{
+		"user":      user,
+		"kyc":       userKYC,
+		"documents": documents,
+		"history":   history,
+	})
+}
+
+// GetDocument streams any user's KYC document to an admin
+func GetDocument(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	var document models.KYCDocument
+	if err := database.Database.Db.Where("id = ?", c.Params("id")).First(&document).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Document not found!", nil)
+	}
+
+	return sendDocument(c, &document)
+}
+
+// reviewKYC approves or rejects a KYC that is pending review
+func reviewKYC(c *fiber.Ctx, to string) error {
+	adminId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(adminId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedKYCReview").(*struct {
+		UserID uint   `json:"userId"`
+		Reason string `json:"reason"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	if to == models.KYCStatusRejected && strings.TrimSpace(reqData.Reason) == "" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Rejection reason is required!", nil)
+	}
+
+	db := database.Database.Db
+
+	var userKYC models.UserKYC
+	if err := db.Where("user_id = ? AND is_deleted = false", reqData.UserID).First(&userKYC).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "KYC not found!", nil)
+	}
+
+	if userKYC.Status != models.KYCStatusPendingReview {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC is not pending review!", nil)
+	}
+
+	if err := utils.TransitionKYC(db, &userKYC, to, adminId, reqData.Reason); err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update KYC!", nil)
+	}
+
+	// Let the user know the outcome
+	var user models.User
+	if err := db.Where("id = ?", userKYC.UserID).First(&user).Error; err == nil && user.Email != "" {
+		subject := "Your KYC has been verified"
+		body := fmt.Sprintf("<p>Hi %s,</p><p>Your KYC has been verified. You can now subscribe to baskets and withdraw funds.</p>", user.Name)
+		if to == models.KYCStatusRejected {
+			subject = "Your KYC was rejected"
+			body = fmt.Sprintf("<p>Hi %s,</p><p>Your KYC was rejected for the following reason:</p><p>%s</p><p>Please update your details and submit again.</p>", user.Name, reqData.Reason)
+		}
+		go func() {
+			if err := utils.SendEmail([]string{user.Email}, subject, body); err != nil {
+				log.Printf("Failed to send KYC review email to user %d: %v", user.ID, err)
+			}
+		}()
+	}
+
+	message := "KYC approved."
+	if to == models.KYCStatusRejected {
+		message = "KYC rejected."
+	}
+	return middleware.JsonResponse(c, fiber.StatusOK, true, message, userKYC)
+}
+
+// ApproveKYC marks a KYC pending review as VERIFIED
+func ApproveKYC(c *fiber.Ctx) error {
+	return reviewKYC(c, models.KYCStatusVerified)
+}
+
+// RejectKYC marks a KYC pending review as REJECTED with a reason shown to the user
+func RejectKYC(c *fiber.Ctx) error {
+	return reviewKYC(c, models.KYCStatusRejected)
+}
@@ -0,0 +1,226 @@
+package riskProfileController
+
+import (
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GetQuestionnaire returns the active risk profiling questions; option scores are hidden from users
+func GetQuestionnaire(c *fiber.Ctx) error {
+	questions, err := utils.GetRiskQuestionnaire()
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch questionnaire!", nil)
+	}
+
+	type option struct {
+		ID   uint   `json:"id"`
+		Text string `json:"text"`
+	}
+	type question struct {
+		ID       uint     `json:"id"`
+		Question string   `json:"question"`
+		Options  []option `json:"options"`
+	}
+
+	result := make([]question, 0, len(questions))
+	for _, q := range questions {
+		item := question{ID: q.ID, Question: q.Question, Options: make([]option, 0, len(q.Options))}
+		for _, o := range q.Options {
+			item.Options = append(item.Options, option{ID: o.ID, Text: o.Text})
+		}
+		result = append(result, item)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Risk questionnaire.", result)
+}
+
+// SubmitRiskProfile scores the user's answers and stores the result as their current risk profile
+func SubmitRiskProfile(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	reqData, ok := c.Locals("validatedRiskProfileSubmit").(*struct {
+		Answers []utils.RiskAnswer `json:"answers"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	profile, err := utils.ScoreRiskProfile(userId, reqData.Answers)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrRiskQuestionnaireEmpty):
+			return middleware.JsonResponse(c, fiber.StatusServiceUnavailable, false, "Risk questionnaire is not available yet!", nil)
+		case errors.Is(err, utils.ErrRiskAnswersIncomplete):
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Please answer every question!", nil)
+		case errors.Is(err, utils.ErrRiskInvalidOption):
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid option selected!", nil)
+		}
+		log.Printf("Failed to save risk profile for user %d: %v", userId, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save risk profile!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Risk profile saved!", profile)
+}
+
+// GetMyRiskProfile returns the user's current risk profile, if any
+func GetMyRiskProfile(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	profile, err := utils.GetCurrentRiskProfile(userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Risk profile not completed!", nil)
+		}
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch risk profile!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Risk profile.", profile)
+}
+
+// ListRiskQuestions returns every questionnaire question, including inactive ones, with option scores
+func ListRiskQuestions(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	var questions []models.RiskQuestion
+	if err := database.Database.Db.
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_deleted = false").Order("sort_order ASC, id ASC")
+		}).
+		Where("is_deleted = false").
+		Order("sort_order ASC, id ASC").
+		Find(&questions).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch questions!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Risk questions.", questions)
+}
+
+// SaveRiskQuestion creates a question, or replaces an existing question and its options.
+// Past profiles keep a snapshot of their answers, so editing doesn't change earlier results.
+func SaveRiskQuestion(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedRiskQuestion").(*struct {
+		QuestionID uint   `json:"questionId"`
+		Question   string `json:"question"`
+		SortOrder  int    `json:"sortOrder"`
+		IsActive   *bool  `json:"isActive"`
+		Options    []struct {
+			Text      string `json:"text"`
+			Score     int    `json:"score"`
+			SortOrder int    `json:"sortOrder"`
+		} `json:"options"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	var question models.RiskQuestion
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if reqData.QuestionID != 0 {
+			if err := tx.Where("id = ? AND is_deleted = false", reqData.QuestionID).First(&question).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.RiskOption{}).Where("question_id = ? AND is_deleted = false", question.ID).Update("is_deleted", true).Error; err != nil {
+				return err
+			}
+		}
+
+		question.Question = reqData.Question
+		question.SortOrder = reqData.SortOrder
+		if reqData.IsActive != nil {
+			question.IsActive = *reqData.IsActive
+		} else if question.ID == 0 {
+			question.IsActive = true
+		}
+		if err := tx.Save(&question).Error; err != nil {
+			return err
+		}
+
+		question.Options = nil
+		for _, o := range reqData.Options {
+			option := models.RiskOption{
+				QuestionID: question.ID,
+				Text:       strings.TrimSpace(o.Text),
+				Score:      o.Score,
+				SortOrder:  o.SortOrder,
+			}
+			if err := tx.Create(&option).Error; err != nil {
+				return err
+			}
+			question.Options = append(question.Options, option)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Question not found!", nil)
+		}
+		log.Printf("Failed to save risk question: %v", err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save question!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Question saved!", question)
+}
+
+// DeleteRiskQuestion removes a question from the questionnaire
+func DeleteRiskQuestion(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	questionId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid question ID!", nil)
+	}
+
+	result := database.Database.Db.Model(&models.RiskQuestion{}).Where("id = ? AND is_deleted = false", questionId).Update("is_deleted", true)
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete question!", nil)
+	}
+	if result.RowsAffected == 0 {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Question not found!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Question deleted!", nil)
+}
+
+// GetUserRiskProfiles returns a user's risk profile history, newest first
+func GetUserRiskProfiles(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	targetId, err := strconv.ParseUint(c.Params("userId"), 10, 64)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid user ID!", nil)
+	}
+
+	var profiles []models.UserRiskProfile
+	database.Database.Db.Where("user_id = ? AND is_deleted = false", targetId).Order("assessed_at DESC").Find(&profiles)
+
+	var acknowledgements []models.SuitabilityAcknowledgement
+	database.Database.Db.Where("user_id = ?", targetId).Order("created_at DESC").Find(&acknowledgements)
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "User risk profiles.", fiber.Map{
+		"profiles":         profiles,
+		"acknowledgements": acknowledgements,
+	})
+}
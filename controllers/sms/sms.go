@@ -0,0 +1,188 @@
+package smsController
+
+import (
+	"crypto/subtle"
+	"fib/config"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListTemplates lists all configured DLT templates
+func ListTemplates(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	var templates []models.SMSTemplate
+	if err := database.Database.Db.Where("is_deleted = false").Order("message_type ASC, provider ASC").Find(&templates).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch SMS templates!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "SMS templates fetched!", fiber.Map{
+		"templates":      templates,
+		"activeProvider": utils.GetSMSSender().Name(),
+	})
+}
+
+// UpsertTemplate creates or updates the DLT template for a message type and provider
+func UpsertTemplate(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSMSTemplate").(*struct {
+		MessageType string `json:"messageType"`
+		Provider    string `json:"provider"`
+		TemplateID  string `json:"templateId"`
+		SenderID    string `json:"senderId"`
+		Body        string `json:"body"`
+		IsActive    *bool  `json:"isActive"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var template models.SMSTemplate
+	if err := db.Where("message_type = ? AND provider = ? AND is_deleted = false", reqData.MessageType, reqData.Provider).First(&template).Error; err != nil {
+		template = models.SMSTemplate{
+			MessageType: reqData.MessageType,
+			Provider:    reqData.Provider,
+			IsActive:    true,
+		}
+	}
+
+	template.TemplateID = reqData.TemplateID
+	template.SenderID = reqData.SenderID
+	template.Body = reqData.Body
+	if reqData.IsActive != nil {
+		template.IsActive = *reqData.IsActive
+	}
+
+	if err := db.Save(&template).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save SMS template!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "SMS template saved!", template)
+}
+
+// ListLogs lists sent SMS with their delivery status
+func ListLogs(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+	if !utils.IsAdmin(userId) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSMSLogs").(*struct {
+		Page        *int    `json:"page"`
+		Limit       *int    `json:"limit"`
+		Mobile      *string `json:"mobile"`
+		MessageType *string `json:"messageType"`
+		Status      *string `json:"status"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	offset := (*reqData.Page - 1) * (*reqData.Limit)
+
+	query := database.Database.Db.Model(&models.SMSLog{}).Where("is_deleted = false")
+	if reqData.Mobile != nil && *reqData.Mobile != "" {
+		query = query.Where("mobile = ?", *reqData.Mobile)
+	}
+	if reqData.MessageType != nil && *reqData.MessageType != "" {
+		query = query.Where("message_type = ?", strings.ToUpper(*reqData.MessageType))
+	}
+	if reqData.Status != nil && *reqData.Status != "" {
+		query = query.Where("status = ?", strings.ToUpper(*reqData.Status))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.SMSLog
+	if err := query.Order("created_at DESC").Offset(offset).Limit(*reqData.Limit).Find(&logs).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch SMS logs!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "SMS logs fetched!", fiber.Map{
+		"logs": logs,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  *reqData.Page,
+			"limit": *reqData.Limit,
+		},
+	})
+}
+
+// DeliveryReport receives delivery status callbacks from SMS providers
+func DeliveryReport(c *fiber.Ctx) error {
+	secret := config.AppConfig.SMSWebhookSecret
+	if secret == "" {
+		return middleware.JsonResponse(c, fiber.StatusServiceUnavailable, false, "Delivery reports are not configured!", nil)
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(secret)) != 1 {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid webhook token!", nil)
+	}
+
+	provider := strings.ToLower(c.Params("provider"))
+
+	// Fast2SMS posts a single report, MSG91 posts a list of reports
+	type report struct {
+		RequestID      string `json:"request_id"`
+		MSG91RequestID string `json:"requestId"`
+		Status         string `json:"status"`
+		Reason         string `json:"description"`
+	}
+
+	var reports []report
+	if err := c.BodyParser(&reports); err != nil {
+		var single report
+		if err := c.BodyParser(&single); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+		reports = []report{single}
+	}
+
+	updated := 0
+	for _, r := range reports {
+		messageID := r.RequestID
+		if messageID == "" {
+			messageID = r.MSG91RequestID
+		}
+		if messageID == "" {
+			continue
+		}
+
+		if err := utils.UpdateSMSDeliveryStatus(provider, messageID, normalizeDeliveryStatus(r.Status), r.Reason); err != nil {
+			log.Printf("Error updating SMS delivery status: %v", err)
+			continue
+		}
+		updated++
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Delivery report processed.", fiber.Map{
+		"updated": updated,
+	})
+}
+
+// normalizeDeliveryStatus maps provider specific statuses to SMSLog statuses
+func normalizeDeliveryStatus(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "DELIVERED", "DELIVRD", "1":
+		return models.SMSStatusDelivered
+	case "SENT", "SUBMITTED", "ACCEPTED", "8":
+		return models.SMSStatusSent
+	default:
+		return models.SMSStatusFailed
+	}
+}
@@ -0,0 +1,171 @@
+package superAdminController
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// isSecurityAdmin checks the caller is an ADMIN or SUPER-ADMIN
+func isSecurityAdmin(c *fiber.Ctx) bool {
+	userId, ok := c.Locals("userId").(uint)
+	return ok && utils.IsAdmin(userId)
+}
+
+// listSecurityAccounts pages through users matching the given condition
+func listSecurityAccounts(c *fiber.Ctx, message string, query string, args ...interface{}) error {
+	if !isSecurityAdmin(c) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSecurityList").(*struct {
+		Page  *int `json:"page"`
+		Limit *int `json:"limit"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	offset := (*reqData.Page - 1) * (*reqData.Limit)
+
+	var users []models.User
+	var total int64
+
+	db := database.Database.Db
+	db.Model(&models.User{}).Where("is_deleted = false").Where(query, args...).Count(&total)
+
+	if err := db.Select("id", "name", "email", "mobile", "role", "is_blocked", "blocked_until",
+		"failed_login_attempts", "last_failed_login", "lockout_count", "is_flagged", "flagged_reason", "flagged_at", "last_login").
+		Where("is_deleted = false").Where(query, args...).
+		Offset(offset).
+		Limit(*reqData.Limit).
+		Order("updated_at DESC").
+		Find(&users).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch accounts!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, message, fiber.Map{
+		"users": users,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  *reqData.Page,
+			"limit": *reqData.Limit,
+		},
+	})
+}
+
+// LockedAccounts lists users currently locked out by failed logins
+func LockedAccounts(c *fiber.Ctx) error {
+	return listSecurityAccounts(c, "Locked accounts.", "is_blocked = true AND blocked_until > ?", time.Now())
+}
+
+// FlaggedAccounts lists users flagged for suspicious login activity
+func FlaggedAccounts(c *fiber.Ctx) error {
+	return listSecurityAccounts(c, "Flagged accounts.", "is_flagged = true")
+}
+
+// SuspiciousLogins lists recent logins marked suspicious, newest first
+func SuspiciousLogins(c *fiber.Ctx) error {
+	if !isSecurityAdmin(c) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSecurityList").(*struct {
+		Page  *int `json:"page"`
+		Limit *int `json:"limit"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	offset := (*reqData.Page - 1) * (*reqData.Limit)
+
+	var logins []models.LoginTracking
+	var total int64
+
+	db := database.Database.Db
+	db.Model(&models.LoginTracking{}).Where("is_suspicious = true AND is_deleted = false").Count(&total)
+
+	if err := db.Where("is_suspicious = true AND is_deleted = false").
+		Offset(offset).
+		Limit(*reqData.Limit).
+		Order("timestamp DESC").
+		Find(&logins).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch suspicious logins!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Suspicious logins.", fiber.Map{
+		"logins": logins,
+		"pagination": fiber.Map{
+			"total": total,
+			"page":  *reqData.Page,
+			"limit": *reqData.Limit,
+		},
+	})
+}
+
+// UnlockAccount lifts a lockout and resets the escalation counters
+func UnlockAccount(c *fiber.Ctx) error {
+	if !isSecurityAdmin(c) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSecurityAction").(*struct {
+		UserID uint `json:"userId"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	result := database.Database.Db.Model(&models.User{}).
+		Where("id = ? AND is_deleted = false", reqData.UserID).
+		Updates(map[string]interface{}{
+			"is_blocked":            false,
+			"blocked_until":         nil,
+			"failed_login_attempts": 0,
+			"last_failed_login":     nil,
+			"lockout_count":         0,
+		})
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to unlock account!", nil)
+	}
+	if result.RowsAffected == 0 {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not found!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Account unlocked.", nil)
+}
+
+// ClearAccountFlag removes the suspicious activity flag after review
+func ClearAccountFlag(c *fiber.Ctx) error {
+	if !isSecurityAdmin(c) {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedSecurityAction").(*struct {
+		UserID uint `json:"userId"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	result := database.Database.Db.Model(&models.User{}).
+		Where("id = ? AND is_deleted = false", reqData.UserID).
+		Updates(map[string]interface{}{
+			"is_flagged":     false,
+			"flagged_reason": "",
+			"flagged_at":     nil,
+		})
+	if result.Error != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to clear flag!", nil)
+	}
+	if result.RowsAffected == 0 {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not found!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Account flag cleared.", nil)
+}
@@ -7,6 +7,7 @@ import (
 	"fib/models"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
@@ -598,10 +599,15 @@ func CreateMaintenance(c *fiber.Ctx) error {
 
 	// ✅ Get validated request data
 	reqData, ok := c.Locals("validatedMaintenance").(*struct {
-		AppMaintenance       bool   `json:"app_maintenance"`
-		ForceUpdate          bool   `json:"force_update"`
-		IosLatestVersion     string `json:"ios_latest_version"`
-		AndroidLatestVersion string `json:"android_latest_version"`
+		AppMaintenance       bool       `json:"app_maintenance"`
+		ForceUpdate          bool       `json:"force_update"`
+		IosLatestVersion     string     `json:"ios_latest_version"`
+		AndroidLatestVersion string     `json:"android_latest_version"`
+		IosMinVersion        string     `json:"ios_min_version"`
+		AndroidMinVersion    string     `json:"android_min_version"`
+		StartsAt             *time.Time `json:"starts_at"`
+		EndsAt               *time.Time `json:"ends_at"`
+		Message              string     `json:"message"`
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -613,12 +619,20 @@ func CreateMaintenance(c *fiber.Ctx) error {
 		ForceUpdate:          reqData.ForceUpdate,
 		IosLatestVersion:     reqData.IosLatestVersion,
 		AndroidLatestVersion: reqData.AndroidLatestVersion,
+		IosMinVersion:        reqData.IosMinVersion,
+		AndroidMinVersion:    reqData.AndroidMinVersion,
+		StartsAt:             reqData.StartsAt,
+		EndsAt:               reqData.EndsAt,
+		Message:              reqData.Message,
 	}
 
 	if err := database.Database.Db.Create(&maintenance).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create maintenance record", nil)
 	}
 
+	// Apply on this instance right away, others pick it up when their cache expires
+	middleware.InvalidateMaintenanceCache()
+
 	return middleware.JsonResponse(c, fiber.StatusCreated, true, "Maintenance created successfully", maintenance)
 }
 
@@ -0,0 +1,244 @@
+package supportControllers
+
+import (
+	"errors"
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/storage"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// saveAttachments stores the files uploaded as "attachments" under prefix.
+// Call it outside transactions, and discardAttachments if the message can't be saved.
+func saveAttachments(c *fiber.Ctx, prefix string) ([]models.SupportAttachment, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, nil
+	}
+
+	var attachments []models.SupportAttachment
+	for _, file := range form.File["attachments"] {
+		stored, err := storage.SaveUpload(c.Context(), file, prefix, storage.DocumentRules)
+		if err != nil {
+			discardAttachments(c, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, models.SupportAttachment{
+			FileName: filepath.Base(file.Filename),
+			FileKey:  stored.Key,
+			MimeType: stored.ContentType,
+			Size:     stored.Size,
+		})
+	}
+	return attachments, nil
+}
+
+// ticketAttachmentPrefix is where the attachments of a ticket's replies are stored
+func ticketAttachmentPrefix(ticketID uint) string {
+	return fmt.Sprintf("support/%d", ticketID)
+}
+
+// attachmentErrorResponse explains why saveAttachments failed
+func attachmentErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrFileTooLarge):
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, fmt.Sprintf("Attachments must be smaller than %d MB!", storage.DocumentRules.MaxBytes>>20), nil)
+	case errors.Is(err, storage.ErrFileTypeInvalid):
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Only JPG, PNG and PDF attachments are allowed!", nil)
+	}
+	log.Printf("Failed to save support attachment: %v", err)
+	return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to upload attachments!", nil)
+}
+
+// discardAttachments removes the blobs of attachments that were never saved.
+// Content-addressed keys are shared, so a blob another attachment uses is kept.
+func discardAttachments(c *fiber.Ctx, attachments []models.SupportAttachment) {
+	for _, attachment := range attachments {
+		var inUse int64
+		database.Database.Db.Model(&models.SupportAttachment{}).Where("file_key = ?", attachment.FileKey).Count(&inUse)
+		if inUse == 0 {
+			storage.Default().Delete(c.Context(), attachment.FileKey)
+		}
+	}
+}
+
+// addMessage appends a message to a ticket's conversation and bumps the ticket's last message time
+func addMessage(tx *gorm.DB, ticketID uint, message *models.SupportMessage) error {
+	message.TicketID = ticketID
+	if err := tx.Create(message).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.SupportTicket{}).Where("id = ?", ticketID).UpdateColumn("last_message_at", message.CreatedAt).Error
+}
+
+// signAttachments fills in the download URLs of a message's attachments
+func signAttachments(messages []models.SupportMessage) {
+	for i := range messages {
+		for j := range messages[i].Attachments {
+			messages[i].Attachments[j].URL = storage.URL(messages[i].Attachments[j].FileKey)
+		}
+	}
+}
+
+// ticketMessages returns a ticket's conversation, oldest first, and marks the other side's messages as read.
+// Internal notes are only included for admins.
+func ticketMessages(ticketID uint, forAdmin bool) ([]models.SupportMessage, error) {
+	db := database.Database.Db
+
+	query := db.Where("ticket_id = ? AND is_deleted = ?", ticketID, false)
+	if !forAdmin {
+		query = query.Where("is_internal = ?", false)
+	}
+
+	var messages []models.SupportMessage
+	if err := query.Preload("Attachments", "is_deleted = ?", false).Order("created_at asc, id asc").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	// Read receipts: admins read user messages, users read admin replies
+	sender := models.SupportSenderUser
+	if !forAdmin {
+		sender = models.SupportSenderAdmin
+	}
+	now := time.Now()
+	if err := db.Model(&models.SupportMessage{}).
+		Where("ticket_id = ? AND sender_role = ? AND is_internal = ? AND read_at IS NULL AND is_deleted = ?", ticketID, sender, false, false).
+		UpdateColumn("read_at", now).Error; err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		if messages[i].SenderRole == sender && !messages[i].IsInternal && messages[i].ReadAt == nil {
+			messages[i].ReadAt = &now
+		}
+	}
+
+	signAttachments(messages)
+	return messages, nil
+}
+
+// unreadCounts returns, per ticket, how many messages from sender haven't been read yet
+func unreadCounts(tickets []models.SupportTicket, sender string) map[uint]int64 {
+	ticketIDs := make([]uint, len(tickets))
+	for i, ticket := range tickets {
+		ticketIDs[i] = ticket.ID
+	}
+
+	var rows []struct {
+		TicketID uint
+		Unread   int64
+	}
+	database.Database.Db.Model(&models.SupportMessage{}).
+		Select("ticket_id, COUNT(*) AS unread").
+		Where("ticket_id IN ? AND sender_role = ? AND is_internal = ? AND read_at IS NULL AND is_deleted = ?", ticketIDs, sender, false, false).
+		Group("ticket_id").
+		Scan(&rows)
+
+	counts := make(map[uint]int64)
+	for _, row := range rows {
+		counts[row.TicketID] = row.Unread
+	}
+	return counts
+}
+
+// withUnread pairs tickets with their unread message counts for list responses
+func withUnread(tickets []models.SupportTicket, sender string) []fiber.Map {
+	counts := unreadCounts(tickets, sender)
+	result := make([]fiber.Map, len(tickets))
+	for i, ticket := range tickets {
+		result[i] = fiber.Map{
+			"ticket":       ticket,
+			"unread_count": counts[ticket.ID],
+		}
+	}
+	return result
+}
+
+// GetTicketMessages returns the conversation of one of the user's tickets
+func GetTicketMessages(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false", userId).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	ticketID := c.Locals("ticketID").(uint)
+
+	var ticket models.SupportTicket
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND user_id = ?", ticketID, userId).First(&ticket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Ticket not found or access denied!", nil)
+	}
+
+	messages, err := ticketMessages(ticket.ID, false)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch messages!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Messages fetched successfully!", fiber.Map{
+		"ticket":   ticket,
+		"messages": messages,
+	})
+}
+
+// AdminGetTicketMessages returns a ticket's full conversation, internal notes included
+func AdminGetTicketMessages(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	ticketID := c.Locals("ticketID").(uint)
+
+	var ticket models.SupportTicket
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false", ticketID).First(&ticket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Support ticket not found!", nil)
+	}
+
+	messages, err := ticketMessages(ticket.ID, true)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch messages!", nil)
+	}
+
+	// Name senders so admins can tell colleagues apart
+	senderIDs := []uint{}
+	for _, message := range messages {
+		if message.SenderID != 0 {
+			senderIDs = append(senderIDs, message.SenderID)
+		}
+	}
+	var senders []models.User
+	database.Database.Db.Select("id, name").Where("id IN ?", senderIDs).Find(&senders)
+	names := make(map[uint]string)
+	for _, sender := range senders {
+		names[sender.ID] = sender.Name
+	}
+
+	type MessageWithSender struct {
+		models.SupportMessage
+		SenderName string `json:"sender_name"`
+	}
+	result := make([]MessageWithSender, len(messages))
+	for i, message := range messages {
+		result[i] = MessageWithSender{SupportMessage: message, SenderName: names[message.SenderID]}
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Messages fetched successfully!", fiber.Map{
+		"ticket":   ticket,
+		"messages": result,
+	})
+}
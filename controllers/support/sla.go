@@ -0,0 +1,189 @@
+package supportControllers
+
+import (
+	"fib/database"
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminAssignTicket assigns a ticket to an admin, or round-robin to its category's agents when no admin is given
+func AdminAssignTicket(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	reqData := c.Locals("validatedAssignTicket").(*struct {
+		TicketID uint `json:"ticketId"`
+		AdminID  uint `json:"adminId"`
+	})
+
+	var ticket models.SupportTicket
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false", reqData.TicketID).First(&ticket).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Support ticket not found!", nil)
+	}
+
+	if ticket.Status == "CLOSED" {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Ticket is already closed!", nil)
+	}
+
+	var assignee *models.User
+	if reqData.AdminID != 0 {
+		var target models.User
+		if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", reqData.AdminID, "ADMIN").First(&target).Error; err != nil {
+			return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Admin not found!", nil)
+		}
+		if err := utils.AssignSupportTicket(database.Database.Db, &ticket, target.ID); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to assign ticket!", nil)
+		}
+		assignee = &target
+	} else {
+		err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			assignee, err = utils.AutoAssignSupportTicket(tx, &ticket)
+			return err
+		})
+		if err != nil {
+			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to assign ticket!", nil)
+		}
+		if assignee == nil {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "No active support agents for category "+ticket.Category+"!", nil)
+		}
+	}
+
+	if assignee.ID != adminID {
+		utils.SendSupportAssignmentEmail(&ticket, assignee)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Ticket assigned to "+assignee.Name+"!", ticket)
+}
+
+// AdminGetSupportAgents lists the admins in each category's assignment rotation
+func AdminGetSupportAgents(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	type AgentWithName struct {
+		models.SupportAgent
+		Name string `json:"name"`
+	}
+	var agents []AgentWithName
+	if err := database.Database.Db.Table("support_agents").
+		Select("support_agents.*, users.name").
+		Joins("JOIN users ON users.id = support_agents.user_id").
+		Where("support_agents.deleted_at IS NULL").
+		Order("support_agents.category, support_agents.last_assigned_at ASC NULLS FIRST").
+		Scan(&agents).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch support agents!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Support agents fetched successfully!", agents)
+}
+
+// AdminSaveSupportAgent adds an admin to a category's rotation, or pauses or resumes them with isActive
+func AdminSaveSupportAgent(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	reqData := c.Locals("validatedSupportAgent").(*struct {
+		AdminID  uint   `json:"adminId"`
+		Category string `json:"category"`
+		IsActive *bool  `json:"isActive"`
+	})
+
+	var target models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", reqData.AdminID, "ADMIN").First(&target).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Admin not found!", nil)
+	}
+
+	agent := models.SupportAgent{UserID: target.ID, Category: reqData.Category, IsActive: true}
+	if reqData.IsActive != nil {
+		agent.IsActive = *reqData.IsActive
+	}
+
+	if err := database.Database.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_active", "updated_at"}),
+	}).Create(&agent).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save support agent!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Support agent saved successfully!", agent)
+}
+
+// AdminGetSLAPolicies lists the first response and resolution targets per priority
+func AdminGetSLAPolicies(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	var policies []models.SupportSLAPolicy
+	if err := database.Database.Db.Order("first_response_minutes ASC").Find(&policies).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch SLA policies!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "SLA policies fetched successfully!", policies)
+}
+
+// AdminSaveSLAPolicy sets the targets of a priority. Open tickets keep the deadlines they were opened with.
+func AdminSaveSLAPolicy(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Unauthorized!", nil)
+	}
+
+	var admin models.User
+	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", adminID, "ADMIN").First(&admin).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access denied!", nil)
+	}
+
+	reqData := c.Locals("validatedSLAPolicy").(*struct {
+		Priority             string `json:"priority"`
+		FirstResponseMinutes int    `json:"firstResponseMinutes"`
+		ResolutionMinutes    int    `json:"resolutionMinutes"`
+	})
+
+	policy := models.SupportSLAPolicy{
+		Priority:             reqData.Priority,
+		FirstResponseMinutes: reqData.FirstResponseMinutes,
+		ResolutionMinutes:    reqData.ResolutionMinutes,
+	}
+	if err := database.Database.Db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "priority"}},
+		DoUpdates: clause.AssignmentColumns([]string{"first_response_minutes", "resolution_minutes", "updated_at"}),
+	}).Create(&policy).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save SLA policy!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "SLA policy saved successfully!", policy)
+}
@@ -1,14 +1,16 @@
 package supportControllers
 
 import (
-	"encoding/json"
 	"fib/database"
 	"fib/middleware"
 	"fib/models"
+	"fib/utils"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 func CreateSupportTicket(c *fiber.Ctx) error {
@@ -35,22 +37,10 @@ func CreateSupportTicket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
 	}
 
-	// Build structured JSON message
-	msgStruct := map[string]interface{}{
-		"sender": "user",
-		"text":   reqData.Message,
-		"time":   time.Now().UTC().Format(time.RFC3339),
-	}
-	msgJSON, err := json.Marshal(msgStruct)
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to format message!", nil)
-	}
-
 	// Prepare ticket model
 	ticket := models.SupportTicket{
 		UserID:   userId,
 		Title:    reqData.Title,
-		Message:  msgJSON,
 		Status:   "OPEN",
 		Priority: "MEDIUM",
 		Category: "GENERAL",
@@ -60,18 +50,52 @@ func CreateSupportTicket(c *fiber.Ctx) error {
 		ticket.Subject = *reqData.Subject
 	}
 	if reqData.Priority != nil {
-		ticket.Priority = *reqData.Priority
+		ticket.Priority = strings.ToUpper(*reqData.Priority)
 	}
 	if reqData.Category != nil {
-		ticket.Category = *reqData.Category
+		ticket.Category = strings.ToUpper(*reqData.Category)
+	}
+
+	// Attachments are uploaded before the transaction so a slow upload doesn't hold it open. The ticket has no ID yet,
+	// so the first message's files go under the user's prefix.
+	attachments, err := saveAttachments(c, fmt.Sprintf("support/users/%d", userId))
+	if err != nil {
+		return attachmentErrorResponse(c, err)
 	}
 
-	// Save ticket
-	if err := database.Database.Db.Create(&ticket).Error; err != nil {
+	// The ticket and its first message are saved together
+	message := models.SupportMessage{
+		SenderID:    userId,
+		SenderRole:  models.SupportSenderUser,
+		Body:        reqData.Message,
+		Attachments: attachments,
+	}
+	var assignee *models.User
+	err = database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ticket).Error; err != nil {
+			return err
+		}
+		if err := utils.ApplySupportSLA(tx, &ticket); err != nil {
+			return err
+		}
+		var err error
+		if assignee, err = utils.AutoAssignSupportTicket(tx, &ticket); err != nil {
+			return err
+		}
+		return addMessage(tx, ticket.ID, &message)
+	})
+	if err != nil {
+		discardAttachments(c, attachments)
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create support ticket!", nil)
 	}
+	ticket.LastMessageAt = &message.CreatedAt
+	signAttachments([]models.SupportMessage{message})
+	utils.SendSupportAssignmentEmail(&ticket, assignee)
 
-	return middleware.JsonResponse(c, fiber.StatusOK, true, "Support ticket created successfully!", ticket)
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Support ticket created successfully!", fiber.Map{
+		"ticket":  ticket,
+		"message": message,
+	})
 }
 
 func TicketList(c *fiber.Ctx) error {
@@ -120,7 +144,7 @@ func TicketList(c *fiber.Ctx) error {
 	}
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Tickets fetched successfully!", fiber.Map{
-		"tickets": tickets,
+		"tickets": withUnread(tickets, models.SupportSenderAdmin),
 		"pagination": fiber.Map{
 			"total": total,
 			"page":  page,
@@ -143,11 +167,13 @@ func AdminTicketList(c *fiber.Ctx) error {
 
 	// Get validated query data
 	reqData, ok := c.Locals("validatedAdminList").(*struct {
-		Page     *int    `query:"page"`
-		Limit    *int    `query:"limit"`
-		Status   *string `query:"status"`
-		Priority *string `query:"priority"`
-		Category *string `query:"category"`
+		Page       *int    `query:"page"`
+		Limit      *int    `query:"limit"`
+		Status     *string `query:"status"`
+		Priority   *string `query:"priority"`
+		Category   *string `query:"category"`
+		AssignedTo *uint   `query:"assignedTo"` // 0 lists unassigned tickets
+		Breached   *bool   `query:"breached"`   // Tickets that missed an SLA deadline
 	})
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
@@ -177,6 +203,16 @@ func AdminTicketList(c *fiber.Ctx) error {
 	if reqData.Category != nil {
 		db = db.Where("UPPER(category) = ?", strings.ToUpper(*reqData.Category))
 	}
+	if reqData.AssignedTo != nil {
+		if *reqData.AssignedTo == 0 {
+			db = db.Where("assigned_to IS NULL")
+		} else {
+			db = db.Where("assigned_to = ?", *reqData.AssignedTo)
+		}
+	}
+	if reqData.Breached != nil {
+		db = db.Where("(first_response_breached OR resolution_breached) = ?", *reqData.Breached)
+	}
 
 	// Count total
 	var total int64
@@ -189,7 +225,7 @@ func AdminTicketList(c *fiber.Ctx) error {
 	}
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Tickets fetched successfully!", fiber.Map{
-		"tickets": tickets,
+		"tickets": withUnread(tickets, models.SupportSenderUser),
 		"pagination": fiber.Map{
 			"page":  page,
 			"limit": limit,
@@ -214,6 +250,7 @@ func AdminReplyTicket(c *fiber.Ctx) error {
 	reqData := c.Locals("validatedAdminReply").(*struct {
 		TicketID uint   `json:"ticketId"`
 		Message  string `json:"message"`
+		Internal bool   `json:"internal"`
 	})
 
 	// Fetch the ticket
@@ -222,40 +259,47 @@ func AdminReplyTicket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Support ticket not found!", nil)
 	}
 
-	// Load and decode existing message thread
-	var messages []map[string]interface{}
-	if len(ticket.Message) > 0 {
-		if err := json.Unmarshal(ticket.Message, &messages); err != nil {
-			messages = []map[string]interface{}{} // fallback to empty
-		}
-	}
-
-	// Append admin reply to the thread
-	adminMsg := map[string]interface{}{
-		"sender": "admin",
-		"text":   reqData.Message,
-		"time":   time.Now().UTC().Format(time.RFC3339),
-	}
-	messages = append(messages, adminMsg)
-
-	// Encode updated messages
-	updatedJSON, err := json.Marshal(messages)
+	attachments, err := saveAttachments(c, ticketAttachmentPrefix(ticket.ID))
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update message thread!", nil)
+		return attachmentErrorResponse(c, err)
 	}
 
-	// Update ticket message and optionally status
-	ticket.Message = updatedJSON
-
-	if ticket.Status == "OPEN" {
-		ticket.Status = "PENDING"
+	message := models.SupportMessage{
+		SenderID:    adminID,
+		SenderRole:  models.SupportSenderAdmin,
+		Body:        reqData.Message,
+		IsInternal:  reqData.Internal,
+		Attachments: attachments,
 	}
 
-	if err := database.Database.Db.Save(&ticket).Error; err != nil {
+	// Each reply is its own row, so concurrent replies can't overwrite each other
+	err = database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := addMessage(tx, ticket.ID, &message); err != nil {
+			return err
+		}
+		// Internal notes aren't a reply to the user
+		if message.IsInternal {
+			return nil
+		}
+		if err := tx.Model(&models.SupportTicket{}).Where("id = ? AND status = ?", ticket.ID, "OPEN").UpdateColumn("status", "PENDING").Error; err != nil {
+			return err
+		}
+		if err := utils.RecordSupportFirstResponse(tx, ticket.ID, message.CreatedAt); err != nil {
+			return err
+		}
+		// The first admin to answer an unassigned ticket takes it
+		if ticket.AssignedTo == nil {
+			return utils.AssignSupportTicket(tx, &ticket, adminID)
+		}
+		return nil
+	})
+	if err != nil {
+		discardAttachments(c, attachments)
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save reply!", nil)
 	}
+	signAttachments([]models.SupportMessage{message})
 
-	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reply added successfully!", ticket)
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reply added successfully!", message)
 }
 
 func UserReplyTicket(c *fiber.Ctx) error {
@@ -271,7 +315,7 @@ func UserReplyTicket(c *fiber.Ctx) error {
 	}
 
 	// Extract validated request
-	reqData := c.Locals("validatedAdminReply").(*struct {
+	reqData := c.Locals("validatedUserReply").(*struct {
 		TicketID uint   `json:"ticketId"`
 		Message  string `json:"message"`
 	})
@@ -282,33 +326,24 @@ func UserReplyTicket(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Ticket not found or access denied!", nil)
 	}
 
-	// Parse existing messages
-	var messages []map[string]interface{}
-	if len(ticket.Message) > 0 {
-		_ = json.Unmarshal(ticket.Message, &messages)
-	}
-
-	// Append user reply
-	reply := map[string]interface{}{
-		"sender": "user",
-		"text":   reqData.Message,
-		"time":   time.Now().UTC().Format(time.RFC3339),
-	}
-	messages = append(messages, reply)
-
-	// ✅ Assign updated message JSON back to ticket.Message
-	updatedJSON, err := json.Marshal(messages)
+	attachments, err := saveAttachments(c, ticketAttachmentPrefix(ticket.ID))
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to encode reply!", nil)
+		return attachmentErrorResponse(c, err)
 	}
-	ticket.Message = updatedJSON
 
-	// Save changes
-	if err := database.Database.Db.Save(&ticket).Error; err != nil {
+	message := models.SupportMessage{
+		SenderID:    userID,
+		SenderRole:  models.SupportSenderUser,
+		Body:        reqData.Message,
+		Attachments: attachments,
+	}
+	if err := addMessage(database.Database.Db, ticket.ID, &message); err != nil {
+		discardAttachments(c, attachments)
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save user reply!", nil)
 	}
+	signAttachments([]models.SupportMessage{message})
 
-	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reply added successfully!", ticket)
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Reply added successfully!", message)
 }
 
 func closeTicket(c *fiber.Ctx, userId uint, isAdmin bool) error {
@@ -338,11 +373,18 @@ func closeTicket(c *fiber.Ctx, userId uint, isAdmin bool) error {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Ticket is already closed!", nil)
 	}
 
-	// Update status to closed
-	ticket.Status = "CLOSED"
-	if err := database.Database.Db.Save(&ticket).Error; err != nil {
+	// Update status to closed, closing resolves the ticket for its SLA
+	now := time.Now()
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ticket).UpdateColumn("status", "CLOSED").Error; err != nil {
+			return err
+		}
+		return utils.RecordSupportResolution(tx, ticket.ID, now)
+	})
+	if err != nil {
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to close ticket!", nil)
 	}
+	database.Database.Db.Where("id = ?", ticket.ID).First(&ticket)
 
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Ticket closed successfully.", ticket)
 }
@@ -417,6 +459,54 @@ func AdminSupportStats(c *fiber.Ctx) error {
 	var categoryCounts []CategoryCount
 	db.Model(&models.SupportTicket{}).Select("category, count(*) as count").Where("is_deleted = false").Group("category").Scan(&categoryCounts)
 
+	// SLA performance, only tickets opened with SLA deadlines are measured
+	var sla struct {
+		FirstResponseMeasured int64
+		FirstResponseMet      int64
+		AvgFirstResponseMins  float64
+		ResolutionMeasured    int64
+		ResolutionMet         int64
+		AvgResolutionMins     float64
+		OpenBreached          int64
+		OpenEscalated         int64
+		OpenUnassigned        int64
+	}
+	db.Model(&models.SupportTicket{}).Select(`
+		COUNT(*) FILTER (WHERE first_response_due_at IS NOT NULL AND (first_response_at IS NOT NULL OR first_response_breached)) AS first_response_measured,
+		COUNT(*) FILTER (WHERE first_response_at IS NOT NULL AND first_response_at <= first_response_due_at) AS first_response_met,
+		COALESCE(AVG(EXTRACT(EPOCH FROM first_response_at - created_at) / 60) FILTER (WHERE first_response_at IS NOT NULL), 0) AS avg_first_response_mins,
+		COUNT(*) FILTER (WHERE resolution_due_at IS NOT NULL AND (resolved_at IS NOT NULL OR resolution_breached)) AS resolution_measured,
+		COUNT(*) FILTER (WHERE resolved_at IS NOT NULL AND resolved_at <= resolution_due_at) AS resolution_met,
+		COALESCE(AVG(EXTRACT(EPOCH FROM resolved_at - created_at) / 60) FILTER (WHERE resolved_at IS NOT NULL), 0) AS avg_resolution_mins,
+		COUNT(*) FILTER (WHERE UPPER(status) <> 'CLOSED' AND (first_response_breached OR resolution_breached)) AS open_breached,
+		COUNT(*) FILTER (WHERE UPPER(status) <> 'CLOSED' AND escalation_level > 0) AS open_escalated,
+		COUNT(*) FILTER (WHERE UPPER(status) <> 'CLOSED' AND assigned_to IS NULL) AS open_unassigned`).
+		Where("is_deleted = false").
+		Scan(&sla)
+
+	metRate := func(met, measured int64) float64 {
+		if measured == 0 {
+			return 0
+		}
+		return float64(met*10000/measured) / 100
+	}
+
+	// Open workload per assignee
+	type AssigneeLoad struct {
+		AdminID  uint   `json:"admin_id"`
+		Name     string `json:"name"`
+		Open     int64  `json:"open"`
+		Breached int64  `json:"breached"`
+	}
+	var assigneeLoads []AssigneeLoad
+	db.Table("support_tickets t").
+		Select("t.assigned_to AS admin_id, u.name, COUNT(*) AS open, COUNT(*) FILTER (WHERE t.first_response_breached OR t.resolution_breached) AS breached").
+		Joins("JOIN users u ON u.id = t.assigned_to").
+		Where("t.is_deleted = false AND UPPER(t.status) <> ?", "CLOSED").
+		Group("t.assigned_to, u.name").
+		Order("open DESC").
+		Scan(&assigneeLoads)
+
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Support stats fetched successfully!", fiber.Map{
 		"total": totalTickets,
 		"status": fiber.Map{
@@ -426,5 +516,23 @@ func AdminSupportStats(c *fiber.Ctx) error {
 		},
 		"priority": priorityCounts,
 		"category": categoryCounts,
+		"sla": fiber.Map{
+			"first_response": fiber.Map{
+				"measured":    sla.FirstResponseMeasured,
+				"met":         sla.FirstResponseMet,
+				"met_rate":    metRate(sla.FirstResponseMet, sla.FirstResponseMeasured),
+				"avg_minutes": sla.AvgFirstResponseMins,
+			},
+			"resolution": fiber.Map{
+				"measured":    sla.ResolutionMeasured,
+				"met":         sla.ResolutionMet,
+				"met_rate":    metRate(sla.ResolutionMet, sla.ResolutionMeasured),
+				"avg_minutes": sla.AvgResolutionMins,
+			},
+			"open_breached":   sla.OpenBreached,
+			"open_escalated":  sla.OpenEscalated,
+			"open_unassigned": sla.OpenUnassigned,
+			"assignees":       assigneeLoads,
+		},
 	})
 }
@@ -0,0 +1,312 @@
+package userController
+
+import (
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/encryption"
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// errBankAccountVerifying rolls back a delete that raced with a penny-drop claiming the account
+var errBankAccountVerifying = errors.New("bank account is being verified")
+
+// findUserBankAccount loads one of the user's active bank accounts
+func findUserBankAccount(userId, bankId uint) (*models.BankDetails, error) {
+	var bank models.BankDetails
+	err := database.Database.Db.Where("id = ? AND user_id = ? AND is_deleted = false", bankId, userId).First(&bank).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// bankAccountInUse reports whether another active account already has this account number
+func bankAccountInUse(accountNo string, excludeId uint) bool {
+	var count int64
+	database.Database.Db.Model(&models.BankDetails{}).
+		Where("account_no_index = ? AND id <> ? AND is_deleted = false", encryption.BlindIndex(accountNo), excludeId).
+		Count(&count)
+	return count > 0
+}
+
+// AddBankAccount stores a new bank account and starts its penny-drop verification in the background.
+// The user's first account becomes their primary payout account.
+func AddBankAccount(c *fiber.Ctx) error {
+	userId, ok := c.Locals("userId").(uint)
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid user ID!", nil)
+	}
+
+	reqData, ok := c.Locals("validatedBankDetails").(*struct {
+		BankName    string `json:"bankName"`
+		AccountNo   string `json:"accountNo"`
+		HolderName  string `json:"holderName"`
+		IFSCCode    string `json:"ifscCode"`
+		BranchName  string `json:"branchName"`
+		AccountType string `json:"accountType"` // Optional
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	db := database.Database.Db
+
+	var user models.User
+	if err := db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Access Denied!", nil)
+	}
+
+	var accountCount int64
+	db.Model(&models.BankDetails{}).Where("user_id = ? AND is_deleted = false", userId).Count(&accountCount)
+	if accountCount >= int64(config.AppConfig.BankMaxAccounts) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, fmt.Sprintf("You can add at most %d bank accounts!", config.AppConfig.BankMaxAccounts), nil)
+	}
+
+	if bankAccountInUse(reqData.AccountNo, 0) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account already exists!", nil)
+	}
+
+	accountType := strings.ToLower(reqData.AccountType)
+	if accountType == "" {
+		accountType = "savings"
+	}
+
+	newBankDetails := models.BankDetails{
+		BankName:           reqData.BankName,
+		AccountNo:          reqData.AccountNo,
+		HolderName:         reqData.HolderName,
+		IFSCCode:           strings.ToUpper(reqData.IFSCCode),
+		BranchName:         reqData.BranchName,
+		AccountType:        accountType,
+		UserID:             userId,
+		IsPrimary:          accountCount == 0,
+		VerificationStatus: models.BankVerificationPending,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newBankDetails).Error; err != nil {
+			return err
+		}
+		if newBankDetails.IsPrimary {
+			return utils.SetPrimaryBankAccount(tx, userId, newBankDetails.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to add bank account for user %d: %v", userId, err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to add bank account!", nil)
+	}
+
+	utils.QueueBankVerification(newBankDetails.ID)
+
+	return middleware.JsonResponse(c, fiber.StatusAccepted, true, "Bank account added, verification in progress.", newBankDetails)
+}
+
+// ListBankAccounts returns the user's bank accounts, primary first
+func ListBankAccounts(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	var accounts []models.BankDetails
+	if err := database.Database.Db.Where("user_id = ? AND is_deleted = false", userId).
+		Order("is_primary DESC, created_at ASC").
+		Find(&accounts).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to fetch bank accounts!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Bank accounts.", accounts)
+}
+
+// UpdateBankAccount edits an account; changing the account number, IFSC or holder name re-runs verification
+func UpdateBankAccount(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	reqData, ok := c.Locals("validatedUpdateBankAccount").(*struct {
+		BankID      uint    `json:"bankId"`
+		BankName    *string `json:"bankName"`
+		AccountNo   *string `json:"accountNo"`
+		HolderName  *string `json:"holderName"`
+		IFSCCode    *string `json:"ifscCode"`
+		BranchName  *string `json:"branchName"`
+		AccountType *string `json:"accountType"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	bank, err := findUserBankAccount(userId, reqData.BankID)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Bank account not found!", nil)
+	}
+
+	if bank.VerificationStatus == models.BankVerificationVerifying {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account is being verified, try again shortly!", nil)
+	}
+
+	reverify := false
+	if reqData.AccountNo != nil && *reqData.AccountNo != bank.AccountNo {
+		if bankAccountInUse(*reqData.AccountNo, bank.ID) {
+			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account already exists!", nil)
+		}
+		bank.AccountNo = *reqData.AccountNo
+		reverify = true
+	}
+	if reqData.IFSCCode != nil && !strings.EqualFold(*reqData.IFSCCode, bank.IFSCCode) {
+		bank.IFSCCode = strings.ToUpper(*reqData.IFSCCode)
+		reverify = true
+	}
+	if reqData.HolderName != nil && *reqData.HolderName != bank.HolderName {
+		bank.HolderName = *reqData.HolderName
+		reverify = true
+	}
+	if reqData.BankName != nil {
+		bank.BankName = *reqData.BankName
+	}
+	if reqData.BranchName != nil {
+		bank.BranchName = *reqData.BranchName
+	}
+	if reqData.AccountType != nil {
+		bank.AccountType = strings.ToLower(*reqData.AccountType)
+	}
+
+	if reverify {
+		utils.ResetBankVerification(bank)
+	}
+
+	if err := database.Database.Db.Save(bank).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update bank account!", nil)
+	}
+
+	if reverify {
+		utils.QueueBankVerification(bank.ID)
+		return middleware.JsonResponse(c, fiber.StatusAccepted, true, "Bank account updated, verification in progress.", bank)
+	}
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Bank account updated.", bank)
+}
+
+// SetPrimaryBankAccount makes a verified account the user's payout account
+func SetPrimaryBankAccount(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	reqData, ok := c.Locals("validatedBankAccountAction").(*struct {
+		BankID uint `json:"bankId"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	bank, err := findUserBankAccount(userId, reqData.BankID)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Bank account not found!", nil)
+	}
+
+	if bank.VerificationStatus == models.BankVerificationVerifying {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account is being verified, try again shortly!", nil)
+	}
+	if !bank.IsVerified {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Only verified bank accounts can be primary!", nil)
+	}
+
+	if err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		return utils.SetPrimaryBankAccount(tx, userId, bank.ID)
+	}); err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to set primary bank account!", nil)
+	}
+	bank.IsPrimary = true
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Primary bank account updated.", bank)
+}
+
+// ReverifyBankAccount re-runs the penny-drop for an account that failed or didn't match the KYC name
+func ReverifyBankAccount(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	reqData, ok := c.Locals("validatedBankAccountAction").(*struct {
+		BankID uint `json:"bankId"`
+	})
+	if !ok {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request data!", nil)
+	}
+
+	bank, err := findUserBankAccount(userId, reqData.BankID)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Bank account not found!", nil)
+	}
+
+	switch bank.VerificationStatus {
+	case models.BankVerificationVerified:
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Bank account is already verified!", nil)
+	case models.BankVerificationVerifying:
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account is being verified, try again shortly!", nil)
+	}
+
+	utils.ResetBankVerification(bank)
+	if err := database.Database.Db.Save(bank).Error; err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update bank account!", nil)
+	}
+	utils.QueueBankVerification(bank.ID)
+
+	return middleware.JsonResponse(c, fiber.StatusAccepted, true, "Verification in progress.", bank)
+}
+
+// DeleteBankAccount removes an account; deleting the primary promotes the oldest remaining verified account
+func DeleteBankAccount(c *fiber.Ctx) error {
+	userId := c.Locals("userId").(uint)
+
+	bankId, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid bank account ID!", nil)
+	}
+
+	bank, err := findUserBankAccount(userId, uint(bankId))
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "Bank account not found!", nil)
+	}
+
+	if bank.VerificationStatus == models.BankVerificationVerifying {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account is being verified, try again shortly!", nil)
+	}
+
+	err = database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// A penny-drop may have claimed the account since it was loaded
+		deleted := tx.Model(&models.BankDetails{}).
+			Where("id = ? AND verification_status <> ? AND is_deleted = false", bank.ID, models.BankVerificationVerifying).
+			Updates(map[string]interface{}{"is_deleted": true, "is_primary": false})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		if deleted.RowsAffected == 0 {
+			return errBankAccountVerifying
+		}
+		if !bank.IsPrimary {
+			return nil
+		}
+
+		// Promote the oldest remaining verified account
+		var next models.BankDetails
+		if err := tx.Where("user_id = ? AND is_verified = true AND is_deleted = false", userId).Order("created_at ASC").First(&next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return tx.Model(&models.User{}).Where("id = ?", userId).Update("bank_details", 0).Error
+			}
+			return err
+		}
+		return utils.SetPrimaryBankAccount(tx, userId, next.ID)
+	})
+	if errors.Is(err, errBankAccountVerifying) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account is being verified, try again shortly!", nil)
+	}
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to delete bank account!", nil)
+	}
+
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Bank account deleted.", nil)
+}
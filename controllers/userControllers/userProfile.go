@@ -3,17 +3,16 @@ package userController
 import (
 	"encoding/json"
 	"errors"
-	"fib/config"
 	"fib/database"
+	"fib/encryption"
 	"fib/middleware"
 	"fib/models"
+	"fib/utils"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
+	"math"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/gofiber/fiber/v2"
@@ -21,194 +20,6 @@ import (
 	"gorm.io/gorm"
 )
 
-type BankVerifyResponse struct {
-	Code          int    `json:"code"`
-	Timestamp     int64  `json:"timestamp"`
-	TransactionID string `json:"transaction_id"`
-	Data          struct {
-		Entity          string `json:"@entity"`
-		Message         string `json:"message"`
-		AccountExists   bool   `json:"account_exists"`
-		NameAtBank      string `json:"name_at_bank"`
-		Utr             string `json:"utr"`
-		AmountDeposited string `json:"amount_deposited"`
-	} `json:"data"`
-}
-
-func VerifyBankDetails(accountNo, ifscCode, holderName, mobile string) (bool, error) {
-	// 1. Get token
-	authToken, err := sandboxJwt()
-	if err != nil {
-		return false, fmt.Errorf("failed to get auth token: %v", err)
-	}
-
-	// 2. Prepare URL
-	endpoint := fmt.Sprintf(
-		"%sbank/%s/accounts/%s/verify?name=%s&mobile=%s",
-		config.AppConfig.SandboxApiURL,
-		ifscCode,
-		accountNo,
-		url.QueryEscape(holderName),
-		url.QueryEscape(mobile),
-	)
-
-	// 3. Make HTTP request
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
-	if err != nil {
-		return false, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Authorization", authToken)
-	req.Header.Set("x-api-key", config.AppConfig.SandboxApiKey)
-	req.Header.Set("x-api-version", "2.0")
-	req.Header.Set("x-accept-cache", "true")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// 4. Read Response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	// 5. Parse JSON
-	var verifyResp BankVerifyResponse
-	err = json.Unmarshal(body, &verifyResp)
-	if err != nil {
-		return false, fmt.Errorf("failed to unmarshal response: %v", err)
-	}
-
-	// 6. Check if verification was successful
-	if verifyResp.Code == 200 && verifyResp.Data.AccountExists {
-		return true, nil
-	}
-
-	return false, fmt.Errorf("bank verification failed: %s", verifyResp.Data.Message)
-}
-
-func AddBankAccount(c *fiber.Ctx) error {
-	// Retrieve userId from JWT token
-	userId, ok := c.Locals("userId").(uint)
-	if !ok {
-		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid user ID!", nil)
-	}
-
-	// Parse the request body
-	reqData := new(struct {
-		BankName    string `json:"bankName"`
-		AccountNo   string `json:"accountNo"`
-		HolderName  string `json:"holderName"`
-		IFSCCode    string `json:"ifscCode"`
-		BranchName  string `json:"branchName"`
-		AccountType string `json:"accountType"` // optional
-		Mobile      string `json:"mobile"`
-	})
-	if err := c.BodyParser(reqData); err != nil {
-		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Failed to parse request body!", nil)
-	}
-
-	// Check if user exists
-	var user models.User
-	if err := database.Database.Db.Where("id = ? AND is_deleted = ?", userId, false).First(&user).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Access Denied!", nil)
-	}
-
-	// Check if user already has bank details linked
-	if user.BankDetails != 0 {
-		return middleware.JsonResponse(c, fiber.StatusConflict, false, "You already have a bank account!", nil)
-	}
-
-	// Check if the bank account already exists in DB
-	var existingBankDetails models.BankDetails
-	if err := database.Database.Db.Where("account_no = ?", reqData.AccountNo).First(&existingBankDetails).Error; err == nil {
-		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Bank account already exists!", nil)
-	}
-
-	// Sandbox API call - Verify bank details (new way)
-	isVerified, err := VerifyBankDetails(reqData.AccountNo, reqData.IFSCCode, reqData.HolderName, reqData.Mobile)
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Bank verification failed: "+err.Error(), nil)
-	}
-
-	// Prepare BankDetails record
-	now := time.Now()
-	newBankDetails := models.BankDetails{
-		BankName:    reqData.BankName,
-		AccountNo:   reqData.AccountNo,
-		HolderName:  reqData.HolderName,
-		IFSCCode:    reqData.IFSCCode,
-		BranchName:  reqData.BranchName,
-		AccountType: reqData.AccountType,
-		UserID:      userId,
-		IsVerified:  isVerified,
-	}
-	if isVerified {
-		newBankDetails.VerifiedAt = &now
-	}
-
-	// Save new bank details
-	if err := database.Database.Db.Create(&newBankDetails).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to add bank account!", nil)
-	}
-
-	// Update user table with BankDetails ID
-	user.BankDetails = newBankDetails.ID
-	if err := database.Database.Db.Save(&user).Error; err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update user with bank details!", nil)
-	}
-
-	return middleware.JsonResponse(c, fiber.StatusOK, true, "Bank account added successfully.", newBankDetails)
-}
-
-func sandboxJwt() (string, error) {
-	config := config.AppConfig
-	url := config.SandboxApiURL + "authenticate"
-
-	req, err := http.NewRequest(http.MethodPost, url, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Add("x-api-key", config.SandboxApiKey)
-	req.Header.Add("x-api-secret", config.SandboxSecretKey)
-	req.Header.Add("x-api-version", config.SandboxApiVersion)
-	req.Header.Add("accept", "application/json")
-
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make HTTP request: %v", err)
-	}
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("authentication failed with status %d: %s", res.StatusCode, string(body))
-	}
-
-	var response struct {
-		AccessToken string `json:"access_token"`
-	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response JSON: %v", err)
-	}
-
-	if response.AccessToken == "" {
-		return "", fmt.Errorf("access token is missing in the response")
-	}
-
-	return response.AccessToken, nil
-}
-
 func SendAdharOtp(c *fiber.Ctx) error {
 	userId, ok := c.Locals("userId").(uint)
 	if !ok {
@@ -227,109 +38,59 @@ func SendAdharOtp(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not found!", nil)
 	}
 
-	var existingKYC models.UserKYC
-	if err := database.Database.Db.Where("user_id = ?", userId).First(&existingKYC).Error; err == nil {
-		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC record already exists!", nil)
+	userKYC, err := utils.GetOrCreateUserKYC(database.Database.Db, userId)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Database error!", nil)
+	}
+	if !utils.IsKYCEditable(userKYC.Status) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC is already "+userKYC.Status+"!", nil)
 	}
 
 	var existingAadhar models.AadharDetails
-	if err := database.Database.Db.Where("aadhar_number = ?", reqData.AadharNumber).First(&existingAadhar).Error; err == nil {
+	if err := database.Database.Db.Where("aadhar_number_index = ?", encryption.BlindIndex(reqData.AadharNumber)).First(&existingAadhar).Error; err == nil && existingAadhar.ID != userKYC.AdharID {
 		return middleware.JsonResponse(c, fiber.StatusConflict, false, "Aadhaar number already exists!", nil)
 	}
 
-	url := config.AppConfig.SandboxApiURL + "kyc/aadhaar/okyc/otp"
-
-	payload := fmt.Sprintf(`{
-		"@entity":"in.co.sandbox.kyc.aadhaar.okyc.otp.request",
-		"consent":"y",
-		"aadhaar_number":"%s",
-		"reason":"Verification"
-	}`, reqData.AadharNumber)
-
-	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create OTP request!", nil)
-	}
-
-	authToken, err := sandboxJwt()
-
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to get authentication token!", nil)
-	}
-
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("authorization", authToken)
-	req.Header.Add("x-api-key", config.AppConfig.SandboxApiKey)
-	req.Header.Add("x-api-version", "2.0")
-	req.Header.Add("content-type", "application/json")
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
+	// Throttle Aadhaar OTP requests with the same limits as our own OTPs
+	if err := utils.CheckOTPSendLimits(user.Email, user.Mobile, models.OTPPurposeAadhaar, c.IP()); err != nil {
+		var rateErr *utils.OTPRateLimitError
+		if errors.As(err, &rateErr) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+			return middleware.JsonResponse(c, fiber.StatusTooManyRequests, false, rateErr.Reason+"!", nil)
+		}
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send Aadhaar OTP!", nil)
 	}
-	defer res.Body.Close()
 
-	body, err := io.ReadAll(res.Body)
+	provider := utils.GetKYCProvider()
+	referenceID, err := provider.SendAadhaarOTP(reqData.AadharNumber)
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to read response body!", nil)
+		log.Printf("Failed to send Aadhaar OTP via %s: %v", provider.Name(), err)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send Aadhaar OTP!", nil)
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send Aadhaar OTP: "+string(body), nil)
+	// Track the reference so verification attempts can be limited
+	if err := utils.TrackExternalOTP(user.ID, user.Email, user.Mobile, models.OTPPurposeAadhaar, c.IP(), referenceID); err != nil {
+		log.Printf("Failed to track Aadhaar OTP: %v", err)
 	}
 
-	var response struct {
-		TransactionID string `json:"transaction_id"`
-		Data          struct {
-			ReferenceID int    `json:"reference_id"`
-			Message     string `json:"message"`
-		} `json:"data"`
-		Code int `json:"code"`
-	}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to parse response JSON!", nil)
+	if err := utils.StartKYC(database.Database.Db, userKYC, userId); err != nil {
+		log.Printf("Failed to start KYC for user %d: %v", userId, err)
 	}
 
 	// Return success with extracted details
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "Aadhaar OTP sent successfully.", map[string]interface{}{
-		"transaction_id": response.TransactionID,
-		"reference_id":   response.Data.ReferenceID,
+		"reference_id": referenceID,
 	})
 }
 
-type AadhaarOKYCResponse struct {
-	Code          int    `json:"code"`
-	Timestamp     int64  `json:"timestamp"`
-	TransactionID string `json:"transaction_id"`
-	Data          struct {
-		Entity      string      `json:"@entity"`
-		ReferenceID interface{} `json:"reference_id"`
-		Status      string      `json:"status"`
-		Message     string      `json:"message"`
-		CareOf      string      `json:"care_of"`
-		FullAddress string      `json:"full_address"`
-		DateOfBirth string      `json:"date_of_birth"`
-		EmailHash   string      `json:"email_hash"`
-		Gender      string      `json:"gender"`
-		Name        string      `json:"name"`
-		Address     struct {
-			Entity      string      `json:"@entity"`
-			Country     string      `json:"country"`
-			District    string      `json:"district"`
-			House       string      `json:"house"`
-			Landmark    string      `json:"landmark"`
-			Pincode     interface{} `json:"pincode"`
-			PostOffice  string      `json:"post_office"`
-			State       string      `json:"state"`
-			Street      string      `json:"street"`
-			Subdistrict string      `json:"subdistrict"`
-			Vtc         string      `json:"vtc"`
-		} `json:"address"`
-		YearOfBirth interface{} `json:"year_of_birth"`
-		MobileHash  string      `json:"mobile_hash"`
-		Photo       string      `json:"photo"`
-		ShareCode   string      `json:"share_code"`
-	} `json:"data"`
+// kycProviderErrorResponse passes the provider's status code and message on to the client
+func kycProviderErrorResponse(c *fiber.Ctx, prefix string, err error) error {
+	var providerErr *utils.KYCProviderError
+	if errors.As(err, &providerErr) {
+		return middleware.JsonResponse(c, providerErr.StatusCode, false, fmt.Sprintf("%s: %s", prefix, providerErr.Message), nil)
+	}
+	log.Printf("%s: %v", prefix, err)
+	return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, prefix+"!", nil)
 }
 
 func VerifyAdharOtp(c *fiber.Ctx) error {
@@ -360,195 +121,86 @@ func VerifyAdharOtp(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not found!", nil)
 	}
 
-	// Prepare API request
-	url := config.AppConfig.SandboxApiURL + "kyc/aadhaar/okyc/otp/verify"
-	payload := fmt.Sprintf(`{
-		"@entity": "in.co.sandbox.kyc.aadhaar.okyc.request",
-		"reference_id": "%s",
-		"otp": "%s"
-	}`, reqData.ReferenceID, reqData.Otp)
-
-	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create request!", nil)
-	}
-
-	// Set headers as per Sandbox documentation
-	authToken, err := sandboxJwt()
+	userKYC, err := utils.GetOrCreateUserKYC(database.Database.Db, userId)
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to generate auth token!", nil)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Database error!", nil)
 	}
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("authorization", authToken)
-	req.Header.Set("x-api-key", config.AppConfig.SandboxApiKey)
-	req.Header.Set("x-api-version", "2.0")
-	req.Header.Set("content-type", "application/json")
-
-	// Send API request
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send request!", nil)
+	if !utils.IsKYCEditable(userKYC.Status) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC is already "+userKYC.Status+"!", nil)
 	}
-	defer res.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(res.Body)
+	// Count the attempt against the Aadhaar OTP before hitting the provider
+	otpRecord, err := utils.RecordExternalOTPAttempt(user.Email, user.Mobile, models.OTPPurposeAadhaar, reqData.ReferenceID)
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to read response!", nil)
-	}
-
-	// Check for non-200 status
-	if res.StatusCode != http.StatusOK {
-		var errorResp struct {
-			Message string `json:"message"`
-			Code    int    `json:"code"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Message != "" {
-			return middleware.JsonResponse(c, res.StatusCode, false, fmt.Sprintf("OTP verification failed: %s", errorResp.Message), nil)
+		switch {
+		case errors.Is(err, utils.ErrOTPAttemptsExceeded):
+			return middleware.JsonResponse(c, fiber.StatusTooManyRequests, false, "Too many invalid attempts! Please request a new OTP.", nil)
+		case errors.Is(err, utils.ErrOTPExpired):
+			return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "OTP has expired!", nil)
 		}
-		return middleware.JsonResponse(c, res.StatusCode, false, fmt.Sprintf("OTP verification failed: %s", string(body)), nil)
+		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid or unknown reference ID!", nil)
 	}
 
-	// Parse API response
-	var response AadhaarOKYCResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		log.Printf("Failed to parse API response: %v, Body: %s", err, string(body))
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to parse API response!", nil)
+	provider := utils.GetKYCProvider()
+	data, err := provider.VerifyAadhaarOTP(reqData.ReferenceID, reqData.Otp)
+	if err != nil {
+		return kycProviderErrorResponse(c, "OTP verification failed", err)
 	}
 
-	// Validate critical fields with detailed logging
-	var validationErrors []string
-	if response.Data.Name == "" {
-		validationErrors = append(validationErrors, "Name is empty")
-	}
-	if response.Data.DateOfBirth == "" {
-		validationErrors = append(validationErrors, "DateOfBirth is empty")
-	}
-	if !strings.EqualFold(response.Data.Status, "VALID") {
-		validationErrors = append(validationErrors, fmt.Sprintf("Status is invalid: %s", response.Data.Status))
-	}
-	if len(validationErrors) > 0 {
-		log.Printf("Validation failed: %v, Response Data: %+v", validationErrors, response.Data)
-		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Failed To verify", nil)
+	// OTP accepted by UIDAI, it can't be reused
+	if err := utils.MarkOTPUsed(otpRecord); err != nil {
+		log.Printf("Failed to mark Aadhaar OTP used: %v", err)
 	}
 
-	// Convert reference_id to string
-	var refID string
-	switch v := response.Data.ReferenceID.(type) {
-	case string:
-		refID = v
-	case float64:
-		refID = fmt.Sprintf("%d", int(v))
-	default:
-		log.Printf("Unexpected reference_id type: %T", v)
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Invalid reference_id format!", nil)
-	}
+	err = database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Reuse the record when re-verifying the Aadhaar already linked to this KYC
+		var aadhar models.AadharDetails
+		if err := tx.Where("aadhar_number_index = ?", encryption.BlindIndex(reqData.AadharNumber)).First(&aadhar).Error; err == nil && aadhar.ID != userKYC.AdharID {
+			return gorm.ErrDuplicatedKey
+		}
 
-	// Convert pincode to string
-	var pincode string
-	switch v := response.Data.Address.Pincode.(type) {
-	case string:
-		pincode = v
-	case float64:
-		pincode = fmt.Sprintf("%d", int(v))
-	default:
-		pincode = ""
-	}
+		aadhar.AadharNumber = reqData.AadharNumber
+		aadhar.Name = data.Name
+		aadhar.DOB = data.DOB
+		aadhar.Address = data.Address
+		aadhar.ProfileImage = data.Photo
+		aadhar.RefID = data.ReferenceID
+		aadhar.IsVerified = true
+		if err := tx.Save(&aadhar).Error; err != nil {
+			return err
+		}
 
-	// Convert address struct to string
-	addr := response.Data.Address
-	var addressParts []string
-	if addr.House != "" {
-		addressParts = append(addressParts, addr.House)
-	}
-	if addr.Street != "" {
-		addressParts = append(addressParts, addr.Street)
-	}
-	if addr.Landmark != "" {
-		addressParts = append(addressParts, addr.Landmark)
-	}
-	if addr.Vtc != "" {
-		addressParts = append(addressParts, addr.Vtc)
-	}
-	if addr.District != "" {
-		addressParts = append(addressParts, addr.District)
-	}
-	if addr.State != "" {
-		addressParts = append(addressParts, addr.State)
-	}
-	if addr.Country != "" {
-		addressParts = append(addressParts, addr.Country)
-	}
-	if pincode != "" {
-		addressParts = append(addressParts, pincode)
-	}
-	address := strings.Join(addressParts, ", ")
+		// Leave PanID unchanged to avoid constraint violation
+		if err := tx.Model(userKYC).Updates(map[string]interface{}{
+			"adhar_id": aadhar.ID,
+			"provider": provider.Name(),
+		}).Error; err != nil {
+			return err
+		}
 
-	// Prepare AadharDetails
-	aadhar := models.AadharDetails{
-		AadharNumber: reqData.AadharNumber,
-		Name:         response.Data.Name,
-		DOB:          response.Data.DateOfBirth,
-		Address:      address,
-		ProfileImage: response.Data.Photo,
-		RefID:        refID,
-		IsVerified:   true,
-	}
+		if err := utils.StartKYC(tx, userKYC, userId); err != nil {
+			return err
+		}
 
-	// Save AadharDetails
-	if err := database.Database.Db.Create(&aadhar).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
+		return tx.Model(&user).Update("is_adhar_verified", true).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
 			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Aadhaar number already exists!", nil)
 		}
-		log.Printf("Failed to save AadharDetails: %v", err)
+		log.Printf("Failed to save Aadhaar KYC: %v", err)
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save Aadhaar details!", nil)
 	}
 
-	// Handle UserKYC: create or update
-	var userKYC models.UserKYC
-	if err := database.Database.Db.Where("user_id = ?", userId).First(&userKYC).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Create new UserKYC, omitting PanID to allow NULL
-			userKYC = models.UserKYC{
-				UserID:     userId,
-				AdharID:    aadhar.ID,
-				IsVerified: true,
-				IsDeleted:  false,
-				// PanID is not set, allowing it to default to NULL in the database
-			}
-			if err := database.Database.Db.Omit("PanID").Create(&userKYC).Error; err != nil {
-				log.Printf("Failed to create UserKYC: %v", err)
-				return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create UserKYC record!", nil)
-			}
-		} else {
-			log.Printf("Database error: %v", err)
-			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Database error!", nil)
-		}
-	} else {
-		// Update existing UserKYC
-		userKYC.AdharID = aadhar.ID
-		userKYC.IsVerified = true
-		// Leave PanID unchanged to avoid constraint violation
-		if err := database.Database.Db.Omit("PanID").Save(&userKYC).Error; err != nil {
-			log.Printf("Failed to update UserKYC: %v", err)
-			return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update UserKYC record!", nil)
-		}
-	}
-
-	if err := database.Database.Db.Model(&user).
-		Update("is_adhar_verified", true).Error; err != nil {
-		log.Printf("Failed to update user Aadhaar verification flag: %v", err)
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update user Aadhaar verification status!", nil)
-	}
-
 	// Return success response
-	return middleware.JsonResponse(c, fiber.StatusOK, true, "Aadhaar OTP verified and details saved successfully.", nil)
+	return middleware.JsonResponse(c, fiber.StatusOK, true, "Aadhaar OTP verified and details saved successfully.", fiber.Map{
+		"kycStatus": userKYC.Status,
+	})
 }
 
 func PanLinkStatus(c *fiber.Ctx) error {
 	// Extract user ID from context
 	userId, ok := c.Locals("userId").(uint)
-	fmt.Print(userId)
 	if !ok {
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid user ID!", nil)
 	}
@@ -574,76 +226,32 @@ func PanLinkStatus(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusNotFound, false, "User not found!", nil)
 	}
 
-	// Prepare API request
-	url := config.AppConfig.SandboxApiURL + "kyc/pan-aadhaar/status"
-	payload := fmt.Sprintf(`{
-		"@entity": "in.co.sandbox.kyc.pan_aadhaar.status",
-		"pan": "%s",
-		"aadhaar_number": "%s",
-		"consent": "Y",
-		"reason": "Verification"
-	}`, reqData.PanNumber, reqData.AadhaarNumber)
-
-	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to create request!", nil)
-	}
-
-	// Set headers
-	authToken, err := sandboxJwt()
+	userKYC, err := utils.GetOrCreateUserKYC(database.Database.Db, userId)
 	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to generate auth token!", nil)
+		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Database error!", nil)
 	}
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("authorization", authToken)
-	req.Header.Set("x-api-key", config.AppConfig.SandboxApiKey)
-	req.Header.Set("x-api-version", "2.0")
-	req.Header.Set("content-type", "application/json")
-
-	// Send API request
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to send request!", nil)
+	if !utils.IsKYCEditable(userKYC.Status) {
+		return middleware.JsonResponse(c, fiber.StatusConflict, false, "KYC is already "+userKYC.Status+"!", nil)
 	}
-	defer res.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to read response!", nil)
-	}
-
-	// Check for non-200 status
-	if res.StatusCode != http.StatusOK {
-		var errorResp struct {
-			Message string `json:"message"`
-			Code    int    `json:"code"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Message != "" {
-			return middleware.JsonResponse(c, res.StatusCode, false, fmt.Sprintf("PAN-Aadhaar status check failed: %s", errorResp.Message), nil)
+	// An Aadhaar already on the KYC was verified by OTP; the link is checked for that Aadhaar and doesn't replace it
+	var linkedAadhar *models.AadharDetails
+	if userKYC.AdharID != 0 {
+		var aadhar models.AadharDetails
+		if err := database.Database.Db.First(&aadhar, userKYC.AdharID).Error; err == nil {
+			if aadhar.AadharNumber != reqData.AadhaarNumber {
+				return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Aadhaar number doesn't match the one on your KYC!", nil)
+			}
+			linkedAadhar = &aadhar
 		}
-		return middleware.JsonResponse(c, res.StatusCode, false, fmt.Sprintf("PAN-Aadhaar status check failed: %s", string(body)), nil)
 	}
 
-	// Parse API response
-	type PanAadhaarStatusResponse struct {
-		Data struct {
-			Entity               string `json:"@entity"`
-			AadhaarSeedingStatus string `json:"aadhaar_seeding_status"`
-			Message              string `json:"message"`
-		} `json:"data"`
-	}
-
-	var apiResponse PanAadhaarStatusResponse
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		log.Printf("Failed to parse API response: %v, Body: %s", err, string(body))
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to parse API response!", nil)
+	provider := utils.GetKYCProvider()
+	isLinked, message, err := provider.CheckPanAadhaarLink(reqData.PanNumber, reqData.AadhaarNumber)
+	if err != nil {
+		return kycProviderErrorResponse(c, "PAN-Aadhaar status check failed", err)
 	}
 
-	// Check if message matches the required pattern
-	isLinked := apiResponse.Data.AadhaarSeedingStatus == "y" &&
-		strings.HasPrefix(apiResponse.Data.Message, "Your PAN is linked to Aadhaar Number")
-
 	if !isLinked {
 		return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "PAN-Aadhaar link verification failed: Invalid response message!", nil)
 	}
@@ -656,7 +264,9 @@ func PanLinkStatus(c *fiber.Ctx) error {
 			IsVerified:   isLinked,
 		}
 		// Save or update AadharDetails
-		if err := tx.Where("aadhar_number = ?", reqData.AadhaarNumber).FirstOrCreate(&aadhar).Error; err != nil {
+		if linkedAadhar != nil {
+			aadhar = *linkedAadhar
+		} else if err := tx.Where("aadhar_number_index = ?", encryption.BlindIndex(reqData.AadhaarNumber)).FirstOrCreate(&aadhar).Error; err != nil {
 			log.Printf("Failed to save AadharDetails: %v", err)
 			return err
 		}
@@ -667,65 +277,50 @@ func PanLinkStatus(c *fiber.Ctx) error {
 			IsVerified: isLinked,
 		}
 		// Save or update PanDetails
-		if err := tx.Where("pan_number = ?", reqData.PanNumber).FirstOrCreate(&pan).Error; err != nil {
+		if err := tx.Where("pan_number_index = ?", encryption.BlindIndex(reqData.PanNumber)).FirstOrCreate(&pan).Error; err != nil {
 			log.Printf("Failed to save PanDetails: %v", err)
 			return err
 		}
 
-		// Handle UserKYC: create or update
-		var userKYC models.UserKYC
-		if err := tx.Where("user_id = ?", userId).First(&userKYC).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// Create new UserKYC
-				userKYC = models.UserKYC{
-					UserID:     userId,
-					AdharID:    aadhar.ID,
-					PanID:      pan.ID,
-					IsVerified: isLinked,
-					IsDeleted:  false,
-				}
-				if err := tx.Create(&userKYC).Error; err != nil {
-					log.Printf("Failed to create UserKYC: %v", err)
-					return err
-				}
-			} else {
-				log.Printf("Database error: %v", err)
-				return err
-			}
-		} else {
-			// Update existing UserKYC
-			userKYC.AdharID = aadhar.ID
-			userKYC.PanID = pan.ID
-			userKYC.IsVerified = isLinked
-			if err := tx.Save(&userKYC).Error; err != nil {
-				log.Printf("Failed to update UserKYC: %v", err)
-				return err
-			}
+		// The same Aadhaar or PAN can't back two users' KYC
+		var linkedElsewhere int64
+		tx.Model(&models.UserKYC{}).Where("user_id <> ? AND (adhar_id = ? OR pan_id = ?) AND is_deleted = false", userId, aadhar.ID, pan.ID).Count(&linkedElsewhere)
+		if linkedElsewhere > 0 {
+			return gorm.ErrDuplicatedKey
 		}
 
-		return nil
+		if err := tx.Model(userKYC).Updates(map[string]interface{}{
+			"adhar_id": aadhar.ID,
+			"pan_id":   pan.ID,
+			"provider": provider.Name(),
+		}).Error; err != nil {
+			log.Printf("Failed to update UserKYC: %v", err)
+			return err
+		}
+
+		if err := utils.StartKYC(tx, userKYC, userId); err != nil {
+			return err
+		}
+
+		return tx.Model(&user).Update("is_pan_verified", true).Error
 	})
 
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
 			return middleware.JsonResponse(c, fiber.StatusConflict, false, "Aadhaar or PAN number already exists!", nil)
 		}
 		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to save KYC details!", nil)
 	}
 
-	if err := database.Database.Db.Model(&user).
-		Update("is_pan_verified", true).Error; err != nil {
-		log.Printf("Failed to update user Pan verification flag: %v", err)
-		return middleware.JsonResponse(c, fiber.StatusInternalServerError, false, "Failed to update user Pan verification status!", nil)
-	}
-
 	// Return success response
 	responseData := struct {
-		IsLinked bool   `json:"is_linked"`
-		Message  string `json:"message"`
+		IsLinked  bool   `json:"is_linked"`
+		Message   string `json:"message"`
+		KYCStatus string `json:"kyc_status"`
 	}{
-		IsLinked: isLinked,
-		Message:  apiResponse.Data.Message,
+		IsLinked:  isLinked,
+		Message:   message,
+		KYCStatus: userKYC.Status,
 	}
 	return middleware.JsonResponse(c, fiber.StatusOK, true, "PAN-Aadhaar link status verified and details saved successfully.", responseData)
 }
@@ -930,6 +525,19 @@ func Withdraw(c *fiber.Ctx) error {
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "User not found!", nil)
 	}
 
+	// Withdrawals require a verified KYC
+	if !utils.IsKYCVerified(userId) {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Complete your KYC to withdraw!", fiber.Map{
+			"kycStatus": utils.GetKYCStatus(userId),
+		})
+	}
+
+	// Payouts go to the verified primary bank account
+	payoutBank, err := utils.GetPayoutBankAccount(userId)
+	if err != nil {
+		return middleware.JsonResponse(c, fiber.StatusForbidden, false, "Add and verify a primary bank account to withdraw!", nil)
+	}
+
 	var amc models.User
 	if err := database.Database.Db.Where("id = ? AND is_deleted = false AND role = ?", reqData.AmcId, "AMC").First(&amc).Error; err != nil {
 		return middleware.JsonResponse(c, fiber.StatusUnauthorized, false, "Invalid AMC!", nil)
@@ -950,6 +558,7 @@ func Withdraw(c *fiber.Ctx) error {
 		Status:          "COMPLETED",
 		UserID:          userId,
 		AmcID:           reqData.AmcId,
+		BankDetailsID:   payoutBank.ID,
 	}
 
 	// Save the new bank account to the database
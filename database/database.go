@@ -1,12 +1,18 @@
 package database
 
 import (
+	"bytes"
+	"context"
 	"fib/models"
 	"fib/models/basket"
 	course "fib/models/course"
+	"fib/storage"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"regexp"
+	"strings"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -77,6 +83,25 @@ func runMigrations(db *gorm.DB) {
 	db.Exec("UPDATE transactions SET amount = 0 WHERE amount IS NULL")
 	db.Exec("UPDATE transactions SET status = 'pending' WHERE status IS NULL")
 
+	// OTP codes are now stored hashed in code_hash; drop the old plaintext column
+	db.Exec("ALTER TABLE otps DROP COLUMN IF EXISTS code")
+
+	// Aadhaar and PAN numbers are encrypted with random nonces, uniqueness moves to the blind index columns
+	db.Exec("ALTER TABLE aadhar_details DROP CONSTRAINT IF EXISTS uni_aadhar_details_aadhar_number")
+	db.Exec("ALTER TABLE aadhar_details DROP CONSTRAINT IF EXISTS aadhar_details_aadhar_number_key")
+	db.Exec("ALTER TABLE pan_details DROP CONSTRAINT IF EXISTS uni_pan_details_pan_number")
+	db.Exec("ALTER TABLE pan_details DROP CONSTRAINT IF EXISTS pan_details_pan_number_key")
+
+	// Keep only the latest active course review per learner before the unique index is created
+	if result := db.Exec(`UPDATE course_reviews SET is_deleted = true WHERE is_deleted = false AND id NOT IN (
+			SELECT MAX(id) FROM course_reviews WHERE is_deleted = false GROUP BY course_id, user_id)`); result.Error == nil && result.RowsAffected > 0 {
+		db.Exec(`UPDATE courses c SET average_rating = ROUND(COALESCE(r.average, 0)::numeric, 2), rating_count = COALESCE(r.count, 0), rating = ROUND(COALESCE(r.average, 0))
+			FROM (SELECT courses.id, AVG(course_reviews.rating) AS average, COUNT(course_reviews.id) AS count FROM courses
+				LEFT JOIN course_reviews ON course_reviews.course_id = courses.id AND course_reviews.status = 'APPROVED' AND course_reviews.is_deleted = false
+				GROUP BY courses.id) r
+			WHERE r.id = c.id`)
+	}
+
 	// Drop foreign key constraint on baskets.current_version_id if it exists (to avoid circular dependency)
 	db.Exec("ALTER TABLE baskets DROP CONSTRAINT IF EXISTS fk_baskets_current_version")
 
@@ -101,8 +126,15 @@ func runMigrations(db *gorm.DB) {
 		&models.User{},
 		&models.OTP{},
 		&models.LoginTracking{},
+		&models.LoginChallenge{},
 		&models.BankDetails{},
 		&models.UserKYC{},
+		&models.KYCDocument{},
+		&models.KYCAuditLog{},
+		&models.RiskQuestion{},
+		&models.RiskOption{},
+		&models.UserRiskProfile{},
+		&models.SuitabilityAcknowledgement{},
 		&models.Stocks{},
 		&models.AmcStocks{},
 		&models.AMCProfile{},
@@ -116,7 +148,19 @@ func runMigrations(db *gorm.DB) {
 		&course.CourseContent{},
 		&course.MCQOption{},
 		&course.MCQAttempt{},
+		&course.Quiz{},
+		&course.QuizQuestion{},
+		&course.QuizOption{},
+		&course.QuizAttempt{},
+		&course.VideoProgress{},
+		&course.CourseReview{},
+		&course.DiscussionThread{},
+		&course.DiscussionReply{},
 		&models.SupportTicket{},
+		&models.SupportMessage{},
+		&models.SupportAttachment{},
+		&models.SupportSLAPolicy{},
+		&models.SupportAgent{},
 		&course.Enrollment{},
 		&course.ContentCompletion{},
 		&course.CertificateRequest{},
@@ -133,10 +177,130 @@ func runMigrations(db *gorm.DB) {
 		&basket.BasketHistory{},
 		&basket.BasketReview{},
 		&basket.BasketMessage{},
+		&basket.ComplianceRule{},
+		&basket.ApprovalStep{},
+		&basket.ApprovalStepApprover{},
+		&basket.BasketApproval{},
+		&basket.BasketStockNote{},
+		&models.SMSTemplate{},
+		&models.SMSLog{},
 	)
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 
+	// Backfill KYC status for records created before the KYC lifecycle existed
+	db.Exec("UPDATE user_kycs SET status = 'VERIFIED', verified_at = updated_at WHERE is_verified = true AND (status IS NULL OR status = 'NOT_STARTED')")
+	db.Exec("UPDATE user_kycs SET status = 'IN_PROGRESS' WHERE is_verified = false AND (status IS NULL OR status = 'NOT_STARTED') AND (adhar_id IS NOT NULL OR pan_id IS NOT NULL)")
+
+	// Backfill bank verification state and the primary flag for accounts added before multi-account support
+	db.Exec("UPDATE bank_details SET verification_status = 'VERIFIED' WHERE is_verified = true AND verification_status = 'PENDING'")
+	db.Exec("UPDATE bank_details SET is_primary = true WHERE id IN (SELECT bank_details FROM users WHERE bank_details <> 0) AND is_primary = false")
+
+	// KYC documents saved before blob storage hold a path under ./uploads; make them store keys
+	db.Exec("UPDATE kyc_documents SET file_path = regexp_replace(file_path, '^(\\./)?uploads/', '') WHERE file_path ~ '^(\\./)?uploads/'")
+
+	migrateAMCFiles(db)
+
+	migrateSupportMessages(db)
+
+	// Default support SLA targets, admins can change them later
+	db.Exec(`INSERT INTO support_sla_policies (created_at, updated_at, priority, first_response_minutes, resolution_minutes)
+		VALUES (NOW(), NOW(), 'HIGH', 60, 1440), (NOW(), NOW(), 'MEDIUM', 240, 4320), (NOW(), NOW(), 'LOW', 1440, 10080)
+		ON CONFLICT (priority) DO NOTHING`)
+
+	// Default basket compliance rules; the market cap and instrument rules start inactive until admins set them
+	db.Exec(`INSERT INTO basket_compliance_rules (created_at, updated_at, rule_type, basket_type, threshold, allowed_values, severity, is_active, description)
+		VALUES (NOW(), NOW(), 'WEIGHT_SUM', '', 0.5, '', 'BLOCK', true, 'Weights must add up to 100%'),
+		(NOW(), NOW(), 'MAX_STOCK_COUNT', '', 30, '', 'BLOCK', true, 'At most 30 stocks'),
+		(NOW(), NOW(), 'MAX_STOCK_WEIGHT', '', 25, '', 'BLOCK', true, 'No stock above 25%'),
+		(NOW(), NOW(), 'MAX_SECTOR_WEIGHT', '', 50, '', 'WARN', true, 'No sector above 50%'),
+		(NOW(), NOW(), 'ALLOWED_EXCHANGES', '', 0, 'NSE,BSE', 'BLOCK', true, 'Cash segment stocks only'),
+		(NOW(), NOW(), 'ALLOWED_SERIES', '', 0, 'EQ,BE', 'BLOCK', true, 'Equity series only'),
+		(NOW(), NOW(), 'ALLOWED_SERIES', 'INTRADAY', 0, 'EQ', 'BLOCK', true, 'BE stocks are trade-for-trade and cannot be traded intraday'),
+		(NOW(), NOW(), 'ALLOWED_SERIES', 'INTRA_HOUR', 0, 'EQ', 'BLOCK', true, 'BE stocks are trade-for-trade and cannot be traded intraday'),
+		(NOW(), NOW(), 'ALLOWED_INSTRUMENTS', '', 0, '', 'BLOCK', false, 'Allowed instrument types'),
+		(NOW(), NOW(), 'MIN_MARKET_CAP', '', 0, '', 'WARN', false, 'Minimum market cap')
+		ON CONFLICT (rule_type, basket_type) DO NOTHING`)
+
 	log.Println("Migrations completed successfully.")
 }
+
+// migrateAMCFiles copies AMC documents and images saved before blob storage into the store. They were written to
+// amc/<folder>/<name> under the working directory, so their paths stay valid as keys once copied and the rows don't
+// change. Files already in the store are skipped, so it is safe to run on every start.
+func migrateAMCFiles(db *gorm.DB) {
+	legacy := "^amc/[a-z]+/[^/]+$" // Content-addressed keys have a digest folder: amc/<folder>/ab/<digest>.<ext>
+
+	var profiles []models.AMCProfile
+	if err := db.Select("id, required_document, optional_document1, optional_document2, amc_logo, manager_image").
+		Where("required_document ~ ? OR optional_document1 ~ ? OR optional_document2 ~ ? OR amc_logo ~ ? OR manager_image ~ ?",
+			legacy, legacy, legacy, legacy, legacy).
+		Find(&profiles).Error; err != nil {
+		log.Printf("Failed to load AMC files to migrate: %v", err)
+		return
+	}
+
+	ctx := context.Background()
+	store := storage.Default()
+	pattern := regexp.MustCompile(legacy)
+	for _, profile := range profiles {
+		for _, key := range []string{profile.RequiredDocument, profile.OptionalDocument1, profile.OptionalDocument2, profile.AmcLogo, profile.ManagerImage} {
+			if !pattern.MatchString(key) {
+				continue
+			}
+			if blob, err := store.Open(ctx, key); err == nil {
+				blob.Close()
+				continue
+			}
+
+			data, err := os.ReadFile(key)
+			if err != nil {
+				log.Printf("AMC file %s of profile %d can't be migrated: %v", key, profile.ID, err)
+				continue
+			}
+			contentType := http.DetectContentType(data)
+			if i := strings.Index(contentType, ";"); i >= 0 {
+				contentType = contentType[:i]
+			}
+			if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+				log.Printf("Failed to migrate AMC file %s of profile %d: %v", key, profile.ID, err)
+				continue
+			}
+			log.Printf("Migrated AMC file %s of profile %d", key, profile.ID)
+		}
+	}
+}
+
+// migrateSupportMessages moves support conversations out of the old support_tickets.message JSON column into
+// support_messages. The column holds a single message object for tickets that were never replied to, or an array.
+// It is dropped only once every thread has been copied.
+func migrateSupportMessages(db *gorm.DB) {
+	if !db.Migrator().HasColumn("support_tickets", "message") {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO support_messages (created_at, updated_at, ticket_id, sender_id, sender_role, body, is_internal, read_at, is_deleted)
+			SELECT COALESCE((m.msg->>'time')::timestamptz, t.created_at), COALESCE((m.msg->>'time')::timestamptz, t.created_at), t.id,
+				CASE WHEN LOWER(m.msg->>'sender') = 'admin' THEN 0 ELSE t.user_id END,
+				CASE WHEN LOWER(m.msg->>'sender') = 'admin' THEN 'ADMIN' ELSE 'USER' END,
+				COALESCE(m.msg->>'text', ''), false, t.updated_at, false
+			FROM support_tickets t
+			CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(t.message::jsonb) = 'array' THEN t.message::jsonb
+				ELSE jsonb_build_array(t.message::jsonb) END) WITH ORDINALITY AS m(msg, n)
+			WHERE t.message IS NOT NULL AND jsonb_typeof(m.msg) = 'object'
+				AND NOT EXISTS (SELECT 1 FROM support_messages sm WHERE sm.ticket_id = t.id)
+			ORDER BY t.id, m.n`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE support_tickets t SET last_message_at = (SELECT MAX(created_at) FROM support_messages sm WHERE sm.ticket_id = t.id)
+			WHERE last_message_at IS NULL`).Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE support_tickets DROP COLUMN message").Error
+	})
+	if err != nil {
+		log.Printf("Failed to migrate support ticket messages, keeping the message column: %v", err)
+	}
+}
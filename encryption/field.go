@@ -0,0 +1,235 @@
+// Package encryption provides field level encryption for sensitive columns (Aadhaar, PAN, bank account numbers).
+//
+// Values are encrypted with AES-256-GCM and stored as "enc:<keyVersion>:<base64(nonce|ciphertext)>",
+// so keys can be rotated: new writes use the active key while older versions stay readable.
+// Because ciphertexts are randomised, equality lookups use a separate HMAC blind index column.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fib/config"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+const ciphertextPrefix = "enc:"
+
+// derivedKeyVersion tags values written with the key derived from JWT_SECRET_KEY, so they never share a version
+// with a configured key
+const derivedKeyVersion = "jwt"
+
+var (
+	ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+	ErrMalformed         = errors.New("malformed encrypted value")
+)
+
+type keyring struct {
+	active   string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+var (
+	loadOnce sync.Once
+	ring     *keyring
+)
+
+// deriveKey stretches a secret into a 32 byte key for a given purpose
+func deriveKey(purpose, secret string) []byte {
+	sum := sha256.Sum256([]byte(purpose + "|" + secret))
+	return sum[:]
+}
+
+// loadKeyring parses FIELD_ENCRYPTION_KEYS ("v1:<base64>,v2:<base64>") once.
+// Without configured keys a key derived from the JWT secret is used so development setups keep working. It is
+// filed under its own version and stays readable once real keys are configured, until encryptFields re-encrypts
+// its rows.
+func loadKeyring() *keyring {
+	loadOnce.Do(func() {
+		cfg := config.AppConfig
+		r := &keyring{active: cfg.FieldEncryptionActiveKey, keys: map[string][]byte{}}
+
+		for _, entry := range strings.Split(cfg.FieldEncryptionKeys, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			version, encoded, found := strings.Cut(entry, ":")
+			if !found {
+				log.Fatalf("Invalid FIELD_ENCRYPTION_KEYS entry %q, expected version:base64key", version)
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(key) != 32 {
+				log.Fatalf("Invalid FIELD_ENCRYPTION_KEYS key for version %s, expected 32 bytes base64 encoded", version)
+			}
+			if version == derivedKeyVersion {
+				log.Fatalf("FIELD_ENCRYPTION_KEYS version %q is reserved", version)
+			}
+			r.keys[version] = key
+		}
+
+		if len(r.keys) == 0 {
+			log.Println("Warning: FIELD_ENCRYPTION_KEYS not set. Deriving the field encryption key from JWT_SECRET_KEY.")
+			r.active = derivedKeyVersion
+		}
+		r.keys[derivedKeyVersion] = deriveKey("field-encryption", cfg.JWTKey)
+		if _, ok := r.keys[r.active]; !ok {
+			log.Fatalf("FIELD_ENCRYPTION_ACTIVE_KEY %q is not in FIELD_ENCRYPTION_KEYS", r.active)
+		}
+
+		if cfg.BlindIndexKey != "" {
+			r.indexKey = deriveKey("blind-index", cfg.BlindIndexKey)
+		} else {
+			r.indexKey = deriveKey("blind-index", cfg.JWTKey)
+		}
+
+		ring = r
+	})
+	return ring
+}
+
+// ActiveKeyVersion returns the key version used for new writes
+func ActiveKeyVersion() string {
+	return loadKeyring().active
+}
+
+// IsEncrypted reports whether a stored value is already ciphertext
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ciphertextPrefix)
+}
+
+// KeyVersion returns the key version of a ciphertext, or "" for plaintext
+func KeyVersion(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(value, ciphertextPrefix), ":")
+	return version
+}
+
+// Encrypt encrypts a value with the active key. Empty values are stored as is.
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	r := loadKeyring()
+	gcm, err := newGCM(r.keys[r.active])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(r.active))
+	return ciphertextPrefix + r.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a stored value with the key version it was written with.
+// Plaintext values (rows not yet migrated) are returned unchanged.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	version, encoded, found := strings.Cut(strings.TrimPrefix(value, ciphertextPrefix), ":")
+	if !found {
+		return "", ErrMalformed
+	}
+
+	key, ok := loadKeyring().keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyVersion, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(version))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// BlindIndex returns a deterministic HMAC of a normalised value for equality lookups and unique constraints.
+// Returns nil for empty values so unique indexes ignore them.
+func BlindIndex(value string) *string {
+	normalised := strings.ToUpper(strings.Join(strings.Fields(value), ""))
+	if normalised == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, loadKeyring().indexKey)
+	mac.Write([]byte(normalised))
+	index := hex.EncodeToString(mac.Sum(nil))
+	return &index
+}
+
+// Serializer encrypts string fields tagged `gorm:"serializer:encrypted"`
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	return Encrypt(plaintext)
+}
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
@@ -0,0 +1,29 @@
+package encryption
+
+import "strings"
+
+// maskTail hides all but the last n characters
+func maskTail(value string, n int) string {
+	if len(value) <= n {
+		return value
+	}
+	return strings.Repeat("X", len(value)-n) + value[len(value)-n:]
+}
+
+// MaskAadhaar hides all but the last four digits of an Aadhaar number
+func MaskAadhaar(aadhaarNumber string) string {
+	return maskTail(aadhaarNumber, 4)
+}
+
+// MaskPAN keeps the first two and last character of a PAN (e.g. ABXXXXXXXF)
+func MaskPAN(pan string) string {
+	if len(pan) < 4 {
+		return pan
+	}
+	return pan[:2] + strings.Repeat("X", len(pan)-3) + pan[len(pan)-1:]
+}
+
+// MaskAccountNo hides all but the last four digits of a bank account number
+func MaskAccountNo(accountNo string) string {
+	return maskTail(accountNo, 4)
+}
@@ -4,10 +4,15 @@ import (
 	"fib/config"
 	stockCronController "fib/controllers/amc"
 	"fib/database"
+	"fib/middleware"
 	amcRoutes "fib/routers/amcRoutes"
 	authRoutes "fib/routers/authRoutes"
 	basketRoutes "fib/routers/basketRoutes"
 	courseRoutes "fib/routers/courseRoutes"
+	fileRoutes "fib/routers/fileRoutes"
+	kycRoutes "fib/routers/kycRoutes"
+	riskProfileRoutes "fib/routers/riskProfileRoutes"
+	smsRoutes "fib/routers/smsRoutes"
 	superAdminRoutes "fib/routers/superAdmin"
 	supportRoutes "fib/routers/supportRoutes"
 	userProfileRoutes "fib/routers/userRoutes"
@@ -15,6 +20,7 @@ import (
 	"fib/utils"
 
 	"log"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -26,12 +32,24 @@ func main() {
 	config.LoadConfig()
 	database.ConnectDb()
 
-	app := fiber.New()
+	// c.IP() is the only source of the client IP: the proxy header is honoured only from trusted proxies
+	var trustedProxies []string
+	for _, proxy := range strings.Split(config.AppConfig.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             config.AppConfig.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
+	})
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,DELETE",        // Allowed HTTP methods
-		AllowHeaders: "Content-Type,Authorization", // Allowed headers
+		AllowMethods: "GET,POST,PUT,DELETE",                                     // Allowed HTTP methods
+		AllowHeaders: "Content-Type,Authorization,X-App-Version,X-App-Platform", // Allowed headers
 	}))
 
 	// Enable the built-in logger middleware to log all requests
@@ -39,6 +57,13 @@ func main() {
 		Format: "[${time}] ${ip} ${method} ${path} ${status} ${latency}\n",
 	}))
 
+	// Global rate limit (per user when a token is sent, per IP otherwise)
+	middleware.InitRateLimitStorage()
+	app.Use(middleware.RateLimit("global", config.AppConfig.RateLimitGlobal, middleware.RateLimitWindow(), true))
+
+	// Maintenance windows and minimum app versions
+	app.Use(middleware.Maintenance())
+
 	// Serve static files from the public folder
 	app.Static("/", "./public")
 
@@ -60,12 +85,39 @@ func main() {
 	// Wallet routes
 	walletRoutes.SetupWalletRoutes(app)
 
+	// SMS templates and delivery reports
+	smsRoutes.SetupSMSRoutes(app)
+
+	// KYC documents and admin review
+	kycRoutes.SetupKYCRoutes(app)
+
+	// Risk profiling questionnaire
+	riskProfileRoutes.SetupRiskProfileRoutes(app)
+
+	// Signed file downloads
+	fileRoutes.SetupFileRoutes(app)
+
 	// Start basket scheduler for auto-publish/expire
 	utils.InitializeBasketSchedulers()
 
 	// Start subscription scheduler for expiry reminders
 	utils.InitializeSubscriptionScheduler()
 
+	// Start OTP cleanup job
+	utils.InitializeOTPCleanupScheduler()
+
+	// Start KYC expiry job
+	utils.InitializeKYCExpiryScheduler()
+
+	// Retry bank account penny-drops
+	utils.InitializeBankVerificationScheduler()
+
+	// Escalate support tickets that miss their SLA
+	utils.InitializeSupportSLAScheduler()
+
+	// Render certificate PDFs that failed at issuance
+	utils.InitializeCertificateScheduler()
+
 	// startCron()
 
 	log.Printf("Server is running on port %s", config.AppConfig.Port)
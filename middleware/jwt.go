@@ -28,6 +28,18 @@ func GenerateJWT(userID uint, name, role, email, mobile string) (string, error)
 	return token.SignedString(jwtSecret)
 }
 
+// parseToken parses and verifies a JWT signed with our HMAC secret
+func parseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Check if the token method is valid
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		jwtSecret := []byte(config.AppConfig.JWTKey)
+		return jwtSecret, nil
+	})
+}
+
 // JWTMiddleware is a middleware to check for valid JWT token in the request
 func JWTMiddleware(c *fiber.Ctx) error {
 	// Get the token from the Authorization header
@@ -51,14 +63,7 @@ func JWTMiddleware(c *fiber.Ctx) error {
 	tokenString := authHeader[len("Bearer "):]
 
 	// Parse and validate the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Check if the token method is valid
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		jwtSecret := []byte(config.AppConfig.JWTKey)
-		return jwtSecret, nil
-	})
+	token, err := parseToken(tokenString)
 
 	// If there's an error parsing the token
 	if err != nil || !token.Valid {
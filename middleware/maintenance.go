@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// maintenanceCache keeps the latest maintenance record so every request doesn't hit the database
+var maintenanceCache struct {
+	sync.RWMutex
+	record    *models.Maintenance // nil when no record exists
+	fetchedAt time.Time
+}
+
+// CurrentMaintenance returns the latest maintenance record, cached for MAINTENANCE_CACHE_SECONDS.
+// Returns nil when no record exists.
+func CurrentMaintenance() *models.Maintenance {
+	ttl := time.Duration(config.AppConfig.MaintenanceCacheSeconds) * time.Second
+
+	maintenanceCache.RLock()
+	record, fetchedAt := maintenanceCache.record, maintenanceCache.fetchedAt
+	maintenanceCache.RUnlock()
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < ttl {
+		return record
+	}
+
+	maintenanceCache.Lock()
+	defer maintenanceCache.Unlock()
+	if !maintenanceCache.fetchedAt.IsZero() && time.Since(maintenanceCache.fetchedAt) < ttl {
+		return maintenanceCache.record
+	}
+
+	var latest models.Maintenance
+	if err := database.Database.Db.Where("is_deleted = false").Order("created_at DESC").First(&latest).Error; err != nil {
+		maintenanceCache.record = nil
+	} else {
+		maintenanceCache.record = &latest
+	}
+	maintenanceCache.fetchedAt = time.Now()
+	return maintenanceCache.record
+}
+
+// InvalidateMaintenanceCache makes the next request reload the maintenance record
+func InvalidateMaintenanceCache() {
+	maintenanceCache.Lock()
+	maintenanceCache.fetchedAt = time.Time{}
+	maintenanceCache.Unlock()
+}
+
+// maintenanceAllowed reports whether a path stays reachable during maintenance
+func maintenanceAllowed(path string) bool {
+	for _, prefix := range strings.Split(config.AppConfig.MaintenanceAllowPaths, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestRole returns the role claim of a valid bearer token, or "" without one
+func requestRole(c *fiber.Ctx) string {
+	authHeader := c.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return ""
+	}
+	token, err := parseToken(authHeader[len("Bearer "):])
+	if err != nil || !token.Valid {
+		return ""
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if role, ok := claims["role"].(string); ok {
+			return role
+		}
+	}
+	return ""
+}
+
+// Maintenance blocks non-admin traffic during maintenance windows with 503 and rejects
+// app versions older than the platform minimum (X-App-Version / X-App-Platform headers) with 426.
+// Paths in MAINTENANCE_ALLOW_PATHS (status endpoint, login, webhooks) are never blocked.
+func Maintenance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if maintenanceAllowed(c.Path()) {
+			return c.Next()
+		}
+
+		record := CurrentMaintenance()
+		if record == nil {
+			return c.Next()
+		}
+
+		now := time.Now()
+		if record.IsActiveAt(now) {
+			role := requestRole(c)
+			if role != "ADMIN" && role != "SUPER-ADMIN" {
+				data := fiber.Map{
+					"maintenance": true,
+					"message":     record.Message,
+					"startsAt":    record.StartsAt,
+					"endsAt":      record.EndsAt,
+				}
+				if record.EndsAt != nil {
+					retryAfter := int(record.EndsAt.Sub(now).Seconds()) + 1
+					c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+					data["retryAfter"] = retryAfter
+				}
+				return JsonResponse(c, fiber.StatusServiceUnavailable, false, "We are under maintenance! Please try again later.", data)
+			}
+		}
+
+		// Web and server clients don't send app headers and are never forced to update
+		platform := strings.ToLower(strings.TrimSpace(c.Get("X-App-Platform")))
+		appVersion := strings.TrimSpace(c.Get("X-App-Version"))
+		if platform != "" && appVersion != "" {
+			if minVersion := record.MinVersion(platform); minVersion != "" && models.CompareVersions(appVersion, minVersion) < 0 {
+				latest := record.IosLatestVersion
+				if platform == models.PlatformAndroid {
+					latest = record.AndroidLatestVersion
+				}
+				return JsonResponse(c, fiber.StatusUpgradeRequired, false, "Please update the app to continue!", fiber.Map{
+					"forceUpdate":   true,
+					"platform":      platform,
+					"minVersion":    minVersion,
+					"latestVersion": latest,
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"fib/config"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/storage/redis/v3"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// rateLimitStorage holds the shared counter store; nil means each limiter keeps its own in-memory store
+var rateLimitStorage fiber.Storage
+
+// InitRateLimitStorage uses Redis for rate limit counters when REDIS_URL is set,
+// so limits are shared across instances. Must be called before routes are set up.
+func InitRateLimitStorage() {
+	if config.AppConfig.RedisURL == "" {
+		log.Println("Rate limiting uses in-memory storage (REDIS_URL not set)")
+		return
+	}
+
+	rateLimitStorage = redis.New(redis.Config{URL: config.AppConfig.RedisURL})
+	log.Println("Rate limiting uses Redis storage")
+}
+
+// RateLimitWindow returns the configured window for route group limits
+func RateLimitWindow() time.Duration {
+	return time.Duration(config.AppConfig.RateLimitWindowSeconds) * time.Second
+}
+
+// RateLimit allows max requests per window for each client of the named limiter.
+// When byUser is set, clients with a valid JWT are limited by user ID, everyone else by IP.
+func RateLimit(name string, max int, window time.Duration, byUser bool) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		Storage:    rateLimitStorage,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "ratelimit:" + name + ":" + rateLimitClientKey(c, byUser)
+		},
+		// limiter sets the Retry-After header before calling this
+		LimitReached: func(c *fiber.Ctx) error {
+			return JsonResponse(c, fiber.StatusTooManyRequests, false, "Too many requests! Please try again later.", fiber.Map{
+				"retryAfter": c.GetRespHeader(fiber.HeaderRetryAfter),
+			})
+		},
+	})
+}
+
+// rateLimitClientKey identifies the caller by JWT user ID or client IP
+func rateLimitClientKey(c *fiber.Ctx, byUser bool) string {
+	if byUser {
+		if userID, ok := c.Locals("userId").(uint); ok {
+			return fmt.Sprintf("user:%d", userID)
+		}
+
+		// Limiters run before JWTMiddleware, so read the token directly
+		authHeader := c.Get("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			if token, err := parseToken(authHeader[len("Bearer "):]); err == nil && token.Valid {
+				if claims, ok := token.Claims.(jwt.MapClaims); ok {
+					if userID, ok := claims["userId"].(float64); ok {
+						return fmt.Sprintf("user:%d", uint(userID))
+					}
+				}
+			}
+		}
+	}
+
+	// c.IP() honours PROXY_HEADER; raw X-Forwarded-For is client controlled and not trusted here
+	return "ip:" + c.IP()
+}
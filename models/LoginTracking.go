@@ -6,11 +6,42 @@ import (
 	"gorm.io/gorm"
 )
 
+// Login tracking status values
+const (
+	LoginStatusSuccess    = "SUCCESS"
+	LoginStatusFailed     = "FAILED"
+	LoginStatusChallenged = "CHALLENGED" // Password was correct but an OTP step-up was required
+)
+
 type LoginTracking struct {
 	gorm.Model
-	UserID    uint      `json:"user_id"`
+	UserID       uint      `json:"user_id"`
+	IPAddress    string    `json:"ip_address"`
+	Device       string    `json:"device"`
+	Timestamp    time.Time `json:"timestamp"`
+	Status       string    `gorm:"type:varchar(20);default:'SUCCESS'" json:"status"`
+	IsSuspicious bool      `gorm:"default:false" json:"is_suspicious"`
+	Reasons      string    `json:"reasons,omitempty"` // Comma separated detection reasons (NEW_DEVICE, NEW_NETWORK)
+	IsDeleted    bool      `gorm:"default:false"`
+}
+
+// Login challenge status values
+const (
+	ChallengePending  = "PENDING"
+	ChallengeVerified = "VERIFIED"
+	ChallengeFailed   = "FAILED"
+)
+
+// LoginChallenge is a pending step-up verification for an unusual login
+type LoginChallenge struct {
+	gorm.Model
+	Token     string    `gorm:"size:64;uniqueIndex;not null" json:"challenge_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
 	IPAddress string    `json:"ip_address"`
 	Device    string    `json:"device"`
-	Timestamp time.Time `json:"timestamp"`
+	Reasons   string    `json:"reasons"`
+	Channel   string    `gorm:"type:varchar(10)" json:"channel"` // EMAIL or MOBILE
+	Status    string    `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
 	IsDeleted bool      `gorm:"default:false"`
 }
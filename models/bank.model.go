@@ -1,23 +1,60 @@
 package models
 
 import (
+	"encoding/json"
+	"fib/encryption"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// BankDetails model
+// Bank account verification states
+const (
+	BankVerificationPending   = "PENDING"   // Waiting for (or retrying) the penny-drop
+	BankVerificationVerifying = "VERIFYING" // Penny-drop in flight
+	BankVerificationVerified  = "VERIFIED"
+	BankVerificationFailed    = "FAILED"        // Account not found or provider rejected it
+	BankVerificationMismatch  = "NAME_MISMATCH" // Account exists but the name at bank doesn't match the KYC name
+)
+
+// BankDetails model, a user may hold several accounts and pays out to the primary one
 type BankDetails struct {
-	gorm.Model             // Auto includes ID, CreatedAt, UpdatedAt, DeletedAt
-	BankName    string     `gorm:"default:''"`
-	AccountNo   string     `gorm:"default:''"`
-	HolderName  string     `gorm:"default:''"`
-	IFSCCode    string     `gorm:"default:''"`
-	BranchName  string     `gorm:"default:''"`
-	AccountType string     `gorm:"type:text;default:'savings'"`
-	UserID      uint       `gorm:"foreignKey:UserID"`
-	Image       string     `gorm:"default:''"`
-	IsVerified  bool       `gorm:"default:false" json:"isVerified"`
-	VerifiedAt  *time.Time `json:"verifiedAt"`
-	IsDeleted   bool       `gorm:"default:false"`
+	gorm.Model                // Auto includes ID, CreatedAt, UpdatedAt, DeletedAt
+	BankName       string     `gorm:"default:''"`
+	AccountNo      string     `gorm:"serializer:encrypted;default:''"` // Encrypted at rest, masked in JSON
+	AccountNoIndex *string    `gorm:"index" json:"-"`                  // Blind index for duplicate checks
+	HolderName     string     `gorm:"default:''"`
+	IFSCCode       string     `gorm:"default:''"`
+	BranchName     string     `gorm:"default:''"`
+	AccountType    string     `gorm:"type:text;default:'savings'"`
+	UserID         uint       `gorm:"foreignKey:UserID"`
+	Image          string     `gorm:"default:''"`
+	IsVerified     bool       `gorm:"default:false" json:"isVerified"`
+	VerifiedAt     *time.Time `json:"verifiedAt"`
+	IsPrimary      bool       `gorm:"default:false" json:"isPrimary"` // Account used for payouts
+
+	VerificationStatus   string     `gorm:"type:varchar(20);default:'PENDING';index" json:"verificationStatus"`
+	VerificationProvider string     `gorm:"type:varchar(20)" json:"verificationProvider"`
+	VerificationRef      string     `json:"verificationRef"` // Provider transaction ID of the penny-drop
+	VerificationMessage  string     `json:"verificationMessage"`
+	VerificationAttempts int        `gorm:"default:0" json:"verificationAttempts"`
+	LastVerificationAt   *time.Time `json:"lastVerificationAt"`
+	NameAtBank           string     `json:"nameAtBank"`
+	NameMatchScore       int        `gorm:"default:0" json:"nameMatchScore"` // 0-100 similarity of NameAtBank to the KYC name
+
+	IsDeleted bool `gorm:"default:false"`
+}
+
+// BeforeSave keeps the blind index in sync with the account number
+func (b *BankDetails) BeforeSave(tx *gorm.DB) error {
+	b.AccountNoIndex = encryption.BlindIndex(b.AccountNo)
+	return nil
+}
+
+// MarshalJSON masks the account number in every API response
+func (b BankDetails) MarshalJSON() ([]byte, error) {
+	type alias BankDetails
+	masked := alias(b)
+	masked.AccountNo = encryption.MaskAccountNo(b.AccountNo)
+	return json.Marshal(masked)
 }
@@ -11,6 +11,13 @@ const (
 	BasketTypeDelivery  = "DELIVERY"
 )
 
+// RiskRating enum values, matched against the investor's risk profile at subscription
+const (
+	RiskRatingLow    = "LOW"
+	RiskRatingMedium = "MEDIUM"
+	RiskRatingHigh   = "HIGH"
+)
+
 // Basket is the master entity for investment baskets
 type Basket struct {
 	gorm.Model
@@ -22,6 +29,7 @@ type Basket struct {
 	SubscriptionFee       float64 `gorm:"default:0" json:"subscriptionFee"`       // Monthly fee
 	YearlySubscriptionFee float64 `gorm:"default:0" json:"yearlySubscriptionFee"` // Yearly fee
 	IsFeeBased            bool    `gorm:"default:false" json:"isFeeBased"`
+	RiskRating            string  `gorm:"type:varchar(10)" json:"riskRating"` // LOW, MEDIUM, HIGH - set by the AMC, confirmed by admin at approval
 	IsDeleted             bool    `gorm:"default:false" json:"isDeleted"`
 
 	// Relations
@@ -0,0 +1,73 @@
+package basket
+
+import (
+	"gorm.io/gorm"
+)
+
+// ApprovalDecision enum values
+const (
+	DecisionApproved         = "APPROVED"
+	DecisionChangesRequested = "CHANGES_REQUESTED"
+	DecisionRejected         = "REJECTED"
+)
+
+// ApprovalStep is one step of a basket type's approval chain, e.g. research review then compliance.
+// A chain for a basket type replaces the chain for all basket types (empty BasketType).
+// Without any chain a single admin approval is enough.
+type ApprovalStep struct {
+	gorm.Model
+	BasketType string `gorm:"not null;type:varchar(20);default:'';index" json:"basketType"` // Empty for every basket type
+	StepOrder  int    `gorm:"not null" json:"stepOrder"`
+	Name       string `gorm:"not null;type:varchar(100)" json:"name"`
+
+	// Relations
+	Approvers []ApprovalStepApprover `gorm:"foreignKey:StepID" json:"approvers"`
+}
+
+func (ApprovalStep) TableName() string {
+	return "basket_approval_steps"
+}
+
+// ApprovalStepApprover lets an admin decide a step. A step without approvers is open to every admin.
+type ApprovalStepApprover struct {
+	gorm.Model
+	StepID uint `gorm:"not null;index" json:"stepId"`
+	UserID uint `gorm:"not null;index" json:"userId"`
+}
+
+func (ApprovalStepApprover) TableName() string {
+	return "basket_approval_step_approvers"
+}
+
+// BasketApproval is a reviewer's decision on one step of a version's approval chain
+type BasketApproval struct {
+	gorm.Model
+	BasketVersionID uint   `gorm:"not null;index" json:"basketVersionId"`
+	Round           int    `gorm:"not null" json:"round"` // Submission the decision belongs to
+	StepOrder       int    `gorm:"not null" json:"stepOrder"`
+	StepName        string `gorm:"type:varchar(100)" json:"stepName"` // Kept in case the chain changes later
+	ReviewerID      uint   `gorm:"not null;index" json:"reviewerId"`
+	Decision        string `gorm:"not null;type:varchar(20)" json:"decision"`
+	Comments        string `gorm:"type:text" json:"comments"`
+
+	// Relations
+	StockNotes []BasketStockNote `gorm:"foreignKey:ApprovalID" json:"stockNotes,omitempty"`
+}
+
+func (BasketApproval) TableName() string {
+	return "basket_approvals"
+}
+
+// BasketStockNote is a reviewer's note on one stock of a version sent back for changes
+type BasketStockNote struct {
+	gorm.Model
+	ApprovalID      uint   `gorm:"not null;index" json:"approvalId"`
+	BasketVersionID uint   `gorm:"not null;index" json:"basketVersionId"`
+	BasketStockID   uint   `gorm:"not null;index" json:"basketStockId"`
+	Symbol          string `gorm:"type:varchar(50)" json:"symbol"`
+	Note            string `gorm:"type:text;not null" json:"note"`
+}
+
+func (BasketStockNote) TableName() string {
+	return "basket_stock_notes"
+}
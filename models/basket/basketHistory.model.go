@@ -6,16 +6,22 @@ import (
 
 // HistoryAction enum values
 const (
-	ActionCreated      = "CREATED"
-	ActionSubmitted    = "SUBMITTED"
-	ActionApproved     = "APPROVED"
-	ActionRejected     = "REJECTED"
-	ActionTimeSlotSet  = "TIME_SLOT_SET"
-	ActionWentLive     = "WENT_LIVE"
-	ActionExpired      = "EXPIRED"
-	ActionUnpublished  = "UNPUBLISHED"
-	ActionStockAdded   = "STOCK_ADDED"
-	ActionStockRemoved = "STOCK_REMOVED"
+	ActionCreated          = "CREATED"
+	ActionSubmitted        = "SUBMITTED"
+	ActionApproved         = "APPROVED"
+	ActionRejected         = "REJECTED"
+	ActionTimeSlotSet      = "TIME_SLOT_SET"
+	ActionWentLive         = "WENT_LIVE"
+	ActionExpired          = "EXPIRED"
+	ActionUnpublished      = "UNPUBLISHED"
+	ActionStockAdded       = "STOCK_ADDED"
+	ActionStockRemoved     = "STOCK_REMOVED"
+	ActionRiskRated        = "RISK_RATED"
+	ActionVersionCreated   = "VERSION_CREATED" // Draft cloned from an earlier version
+	ActionDraftDiscarded   = "DRAFT_DISCARDED"
+	ActionStepApproved     = "STEP_APPROVED" // One step of the approval chain signed off
+	ActionChangesRequested = "CHANGES_REQUESTED"
+	ActionRescheduled      = "RESCHEDULED" // Go-live or sunset time changed
 )
 
 // ActorType enum values
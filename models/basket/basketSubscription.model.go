@@ -33,6 +33,9 @@ type BasketSubscription struct {
 	ExpiresAt          *time.Time `json:"expiresAt"`
 	ReminderSent       bool       `gorm:"default:false" json:"reminderSent"` // Track if expiry reminder was sent
 	PaymentID          string     `json:"paymentId"`
+	UserRiskCategory   string     `gorm:"type:varchar(20)" json:"userRiskCategory"` // Investor's risk profile at subscription
+	BasketRiskRating   string     `gorm:"type:varchar(10)" json:"basketRiskRating"`
+	RiskAcknowledgedAt *time.Time `json:"riskAcknowledgedAt"` // Set when the user accepted a suitability warning
 	IsDeleted          bool       `gorm:"default:false" json:"isDeleted"`
 
 	// Relations
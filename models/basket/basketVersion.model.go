@@ -3,19 +3,21 @@ package basket
 import (
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 // VersionStatus enum values
 const (
-	StatusDraft           = "DRAFT"
-	StatusPendingApproval = "PENDING_APPROVAL"
-	StatusApproved        = "APPROVED"
-	StatusScheduled       = "SCHEDULED"
-	StatusPublished       = "PUBLISHED"
-	StatusExpired         = "EXPIRED"
-	StatusUnpublished     = "UNPUBLISHED"
-	StatusRejected        = "REJECTED"
+	StatusDraft            = "DRAFT"
+	StatusPendingApproval  = "PENDING_APPROVAL"
+	StatusApproved         = "APPROVED"
+	StatusScheduled        = "SCHEDULED"
+	StatusPublished        = "PUBLISHED"
+	StatusExpired          = "EXPIRED"
+	StatusUnpublished      = "UNPUBLISHED"
+	StatusRejected         = "REJECTED"
+	StatusChangesRequested = "CHANGES_REQUESTED" // Sent back to the AMC with review notes
 )
 
 // BasketVersion tracks each version of a basket
@@ -25,13 +27,30 @@ type BasketVersion struct {
 	VersionNumber   int        `gorm:"not null" json:"versionNumber"`
 	Status          string     `gorm:"not null;type:varchar(20);default:'DRAFT'" json:"status"`
 	SubmittedAt     *time.Time `json:"submittedAt"`
+	SubmittedBy     *uint      `json:"submittedBy"` // Maker, who may not approve their own submission
 	ApprovedAt      *time.Time `json:"approvedAt"`
 	ApprovedBy      *uint      `json:"approvedBy"`
 	RejectionReason string     `gorm:"type:text" json:"rejectionReason"`
+	RiskRating      string     `gorm:"type:varchar(10)" json:"riskRating"` // Risk rating confirmed by admin at approval
+
+	// Approval chain progress, see ApprovalStep
+	ApprovalRound int `gorm:"default:0" json:"approvalRound"` // Bumped on every submission, earlier decisions no longer count
+	ApprovalStep  int `gorm:"default:0" json:"approvalStep"`  // Position in the chain awaiting a decision, from 1
 	// Pricing
 	PriceAtApproval float64 `gorm:"default:0" json:"priceAtApproval"`
 	PriceAtExpiry   float64 `gorm:"default:0" json:"priceAtExpiry"`
 
+	// Compliance report of the last pre-submission check, see ComplianceRule
+	CompliancePassed    *bool          `json:"compliancePassed"`
+	ComplianceReport    datatypes.JSON `gorm:"type:jsonb" json:"complianceReport"`
+	ComplianceCheckedAt *time.Time     `json:"complianceCheckedAt"`
+
+	// Go-live and sunset of DELIVERY and INTRADAY versions; INTRA_HOUR versions follow their BasketTimeSlot
+	PublishAt   *time.Time `json:"publishAt"` // Requested by the AMC at submission, confirmed by admin at approval
+	ExpireAt    *time.Time `json:"expireAt"`  // Optional sunset
+	PublishedAt *time.Time `json:"publishedAt"`
+	ExpiredAt   *time.Time `json:"expiredAt"`
+
 	TradingDate *time.Time `json:"tradingDate"` // For INTRADAY: specific trading date
 	IsDeleted   bool       `gorm:"default:false" json:"isDeleted"`
 
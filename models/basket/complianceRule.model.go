@@ -0,0 +1,52 @@
+package basket
+
+import (
+	"gorm.io/gorm"
+)
+
+// ComplianceRuleType enum values
+const (
+	RuleWeightSum          = "WEIGHT_SUM"          // Weights must add up to 100%, Threshold is the tolerance
+	RuleMaxStockWeight     = "MAX_STOCK_WEIGHT"    // No stock may exceed Threshold %
+	RuleMaxSectorWeight    = "MAX_SECTOR_WEIGHT"   // No sector may exceed Threshold %
+	RuleAllowedSeries      = "ALLOWED_SERIES"      // Series must be in AllowedValues
+	RuleAllowedInstruments = "ALLOWED_INSTRUMENTS" // Instrument type must be in AllowedValues
+	RuleAllowedExchanges   = "ALLOWED_EXCHANGES"   // Exchange must be in AllowedValues, keeps F&O out of cash baskets
+	RuleMinMarketCap       = "MIN_MARKET_CAP"      // Market cap must be at least Threshold, in the stock master's unit
+	RuleMaxStockCount      = "MAX_STOCK_COUNT"     // At most Threshold stocks
+)
+
+// ComplianceRuleTypes lists the rule types in the order they are reported
+var ComplianceRuleTypes = []string{
+	RuleWeightSum,
+	RuleMaxStockCount,
+	RuleMaxStockWeight,
+	RuleMaxSectorWeight,
+	RuleAllowedExchanges,
+	RuleAllowedSeries,
+	RuleAllowedInstruments,
+	RuleMinMarketCap,
+}
+
+// ComplianceSeverity enum values
+const (
+	SeverityBlock = "BLOCK" // A failure stops the submission
+	SeverityWarn  = "WARN"  // A failure is only reported to the reviewing admin
+)
+
+// ComplianceRule is one pre-submission check. A rule for a specific basket type replaces the
+// rule of the same type that applies to all baskets (empty BasketType).
+type ComplianceRule struct {
+	gorm.Model
+	RuleType      string  `gorm:"not null;type:varchar(30);uniqueIndex:idx_compliance_rule_type_basket" json:"ruleType"`
+	BasketType    string  `gorm:"not null;type:varchar(20);default:'';uniqueIndex:idx_compliance_rule_type_basket" json:"basketType"` // Empty for every basket type
+	Threshold     float64 `gorm:"default:0" json:"threshold"`
+	AllowedValues string  `gorm:"type:text;default:''" json:"allowedValues"` // Comma separated, for the ALLOWED_* rules
+	Severity      string  `gorm:"not null;type:varchar(10);default:'BLOCK'" json:"severity"`
+	IsActive      bool    `gorm:"default:false" json:"isActive"`
+	Description   string  `gorm:"type:text" json:"description"`
+}
+
+func (ComplianceRule) TableName() string {
+	return "basket_compliance_rules"
+}
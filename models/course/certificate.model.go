@@ -25,8 +25,11 @@ type Certificate struct {
 	gorm.Model
 	UserID            uint      `json:"user_id" gorm:"index;not null"`
 	CourseID          uint      `json:"course_id" gorm:"index;not null"`
-	CertificateURL    string    `json:"certificate_url"`
+	CertificateURL    string    `json:"certificate_url"` // Public download link, redirects to a signed URL of the PDF
 	CertificateNumber string    `json:"certificate_number" gorm:"unique"`
+	RecipientName     string    `json:"recipient_name"` // Name and title as printed, kept even if they change later
+	CourseTitle       string    `json:"course_title"`
+	FileKey           string    `json:"-"` // Storage key of the rendered PDF
 	IssuedAt          time.Time `json:"issued_at"`
 	IsDeleted         bool      `gorm:"default:false"`
 }
@@ -2,6 +2,13 @@ package course
 
 import "gorm.io/gorm"
 
+// Certificate issuance policies
+const (
+	CertificatePolicyManual   = "MANUAL"    // Learners request a certificate and an admin approves it
+	CertificatePolicyAuto     = "AUTO"      // Issued as soon as the course is completed
+	CertificatePolicyMinScore = "MIN_SCORE" // Issued on completion when the assessment score reaches CertificateMinScore
+)
+
 // Course represents a learning course
 type Course struct {
 	gorm.Model
@@ -10,8 +17,22 @@ type Course struct {
 	Author       string `json:"author"`
 	Duration     int64  `json:"duration" gorm:"default:0"`     // duration in hours
 	Status       string `json:"status" gorm:"default:'DRAFT'"` // DRAFT, ACTIVE, INACTIVE
-	Rating       uint   `json:"rating" gorm:"default:0"`
+	Rating       uint   `json:"rating" gorm:"default:0"`       // Average of approved reviews, rounded
 	ThumbnailURL string `json:"thumbnail_url"`
 	IsPublished  bool   `json:"is_published" gorm:"default:false"`
-	IsDeleted    bool   `gorm:"default:false"`
+
+	CertificatePolicy   string  `json:"certificate_policy" gorm:"default:'MANUAL'"` // MANUAL, AUTO, MIN_SCORE
+	CertificateMinScore float64 `json:"certificate_min_score" gorm:"default:0"`     // Minimum MCQ and quiz score in percent for MIN_SCORE
+
+	DripEnabled bool `json:"drip_enabled" gorm:"default:false"` // Release each day of content relative to the enrolment date
+
+	Price             float64 `json:"price" gorm:"default:0"`                             // One-time price debited from the wallet, 0 for free courses
+	IncludedBasketIDs string  `json:"included_basket_ids" gorm:"type:jsonb;default:'[]'"` // Active subscribers of these baskets enrol for free
+	RefundWindowDays  int     `json:"refund_window_days" gorm:"default:7"`                // Days after purchase a refund can be requested
+
+	AverageRating float64 `json:"average_rating" gorm:"default:0"` // Recomputed whenever a review is moderated or removed
+	RatingCount   int64   `json:"rating_count" gorm:"default:0"`
+	InstructorID  *uint   `json:"instructor_id"` // User who answers discussions alongside admins
+
+	IsDeleted bool `gorm:"default:false"`
 }
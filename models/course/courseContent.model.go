@@ -10,13 +10,17 @@ type CourseContent struct {
 	Day         int    `json:"day" gorm:"default:1"` // Day number within module
 	Title       string `json:"title"`
 	Description string `json:"description"`
-	ContentType string `json:"content_type" gorm:"default:'TEXT'"` // TEXT, MCQ, VIDEO, IMAGE
+	ContentType string `json:"content_type" gorm:"default:'TEXT'"` // TEXT, MCQ, VIDEO, IMAGE, QUIZ
 	TextContent string `json:"text_content" gorm:"type:text"`      // For TEXT type
 	VideoURL    string `json:"video_url"`                          // For VIDEO type
 	ImageURL    string `json:"image_url"`                          // For IMAGE type
 	OrderIndex  int    `json:"order_index" gorm:"default:0"`       // Order within day
 	IsPublished bool   `json:"is_published" gorm:"default:false"`
-	IsDeleted   bool   `gorm:"default:false"`
+
+	VideoDurationSeconds   int     `json:"video_duration_seconds" gorm:"default:0"`   // For VIDEO type, 0 tracks progress against the player's duration and is completed manually
+	VideoCompletionPercent float64 `json:"video_completion_percent" gorm:"default:0"` // For VIDEO type, share to watch for completion, 0 uses VIDEO_COMPLETION_PERCENT
+
+	IsDeleted bool `gorm:"default:false"`
 }
 
 // ContentCompletion tracks user's completion of course content
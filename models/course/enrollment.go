@@ -6,15 +6,28 @@ import (
 	"gorm.io/gorm"
 )
 
+// Enrollment access types
+const (
+	EnrollmentAccessFree         = "FREE"
+	EnrollmentAccessPurchase     = "PURCHASE"
+	EnrollmentAccessSubscription = "SUBSCRIPTION" // Included with a basket subscription, lasts as long as the subscription
+)
+
 // Enrollment tracks a user's enrollment in a course with progress
 type Enrollment struct {
 	gorm.Model
 	UserID            uint       `json:"user_id" gorm:"index;not null"`
 	CourseID          uint       `json:"course_id" gorm:"index;not null"`
-	Status            string     `json:"status" gorm:"default:'ENROLLED'"` // ENROLLED, IN_PROGRESS, COMPLETED
+	Status            string     `json:"status" gorm:"default:'ENROLLED'"` // ENROLLED, IN_PROGRESS, COMPLETED, REFUNDED
 	Progress          float64    `json:"progress" gorm:"default:0"`        // Completion percentage (0-100)
 	CompletedContents int        `json:"completed_contents" gorm:"default:0"`
 	TotalContents     int        `json:"total_contents" gorm:"default:0"`
 	CompletedAt       *time.Time `json:"completed_at"`
-	IsDeleted         bool       `gorm:"default:false"`
+
+	AccessType          string     `json:"access_type" gorm:"type:varchar(20);default:'FREE'"` // FREE, PURCHASE, SUBSCRIPTION
+	AccessBasketID      uint       `json:"access_basket_id" gorm:"default:0"`                  // Basket whose subscription grants SUBSCRIPTION access
+	PricePaid           float64    `json:"price_paid" gorm:"default:0"`
+	WalletTransactionID uint       `json:"wallet_transaction_id" gorm:"default:0"` // COURSE_PURCHASE debit
+	RefundedAt          *time.Time `json:"refunded_at"`                            // Refunded enrolments are also marked deleted, revoking access
+	IsDeleted           bool       `gorm:"default:false"`
 }
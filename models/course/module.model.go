@@ -9,5 +9,9 @@ type Module struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	OrderIndex  int    `json:"order_index" gorm:"default:0"` // Module order in course
-	IsDeleted   bool   `gorm:"default:false"`
+
+	PrerequisiteModuleID *uint `json:"prerequisite_module_id"`            // Module whose content must all be completed first
+	DripOffsetDays       int   `json:"drip_offset_days" gorm:"default:0"` // Days after enrolment before day 1 unlocks, drip courses only
+
+	IsDeleted bool `gorm:"default:false"`
 }
@@ -0,0 +1,72 @@
+package course
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Quiz attempt statuses
+const (
+	QuizAttemptInProgress = "IN_PROGRESS"
+	QuizAttemptSubmitted  = "SUBMITTED"
+	QuizAttemptExpired    = "EXPIRED" // The time limit ran out before the answers were submitted
+)
+
+// Quiz holds the settings of QUIZ content, a graded group of questions such as a module test or final assessment
+type Quiz struct {
+	gorm.Model
+	ContentID        uint    `json:"content_id" gorm:"uniqueIndex;not null"`
+	CourseID         uint    `json:"course_id" gorm:"index;not null"`
+	PassPercent      float64 `json:"pass_percent" gorm:"default:60"` // Score in percent needed to pass and complete the content
+	NegativeMarking  bool    `json:"negative_marking" gorm:"default:false"`
+	NegativeFraction float64 `json:"negative_fraction" gorm:"default:0.25"` // Share of a question's marks deducted for a wrong answer
+	MaxAttempts      int     `json:"max_attempts" gorm:"default:0"`         // 0 means unlimited
+	TimeLimitSeconds int     `json:"time_limit_seconds" gorm:"default:0"`   // 0 means untimed
+	ShuffleQuestions bool    `json:"shuffle_questions" gorm:"default:false"`
+	ShuffleOptions   bool    `json:"shuffle_options" gorm:"default:false"`
+	IsDeleted        bool    `gorm:"default:false"`
+}
+
+// QuizQuestion is one question of a quiz; it is answered correctly only when exactly the correct options are chosen
+type QuizQuestion struct {
+	gorm.Model
+	QuizID     uint         `json:"quiz_id" gorm:"index;not null"`
+	Question   string       `json:"question" gorm:"type:text"`
+	Marks      float64      `json:"marks" gorm:"default:1"`
+	OrderIndex int          `json:"order_index" gorm:"default:0"`
+	IsDeleted  bool         `gorm:"default:false"`
+	Options    []QuizOption `json:"options" gorm:"foreignKey:QuestionID"`
+}
+
+// QuizOption is an answer option of a quiz question
+type QuizOption struct {
+	gorm.Model
+	QuestionID uint   `json:"question_id" gorm:"index;not null"`
+	OptionText string `json:"option_text"`
+	IsCorrect  bool   `json:"is_correct" gorm:"default:false"`
+	OrderIndex int    `json:"order_index" gorm:"default:0"`
+	IsDeleted  bool   `gorm:"default:false"`
+}
+
+// QuizAttempt is one sitting of a quiz by a learner
+type QuizAttempt struct {
+	gorm.Model
+	QuizID        uint       `json:"quiz_id" gorm:"index;not null"`
+	ContentID     uint       `json:"content_id" gorm:"index;not null"`
+	CourseID      uint       `json:"course_id" gorm:"index;not null"`
+	UserID        uint       `json:"user_id" gorm:"index;not null"`
+	AttemptNumber int        `json:"attempt_number"`
+	Status        string     `json:"status" gorm:"type:varchar(20);default:'IN_PROGRESS'"`
+	StartedAt     time.Time  `json:"started_at"`
+	DeadlineAt    *time.Time `json:"deadline_at"` // nil for untimed quizzes
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	Layout        string     `json:"-" gorm:"type:jsonb"`       // Question and option order shown to the learner
+	Answers       string     `json:"answers" gorm:"type:jsonb"` // Submitted option IDs per question
+	Results       string     `json:"results" gorm:"type:jsonb"` // Marks awarded per question
+	Score         float64    `json:"score"`
+	MaxScore      float64    `json:"max_score"`
+	Percent       float64    `json:"percent"`
+	Passed        bool       `json:"passed" gorm:"default:false"`
+	IsDeleted     bool       `gorm:"default:false"`
+}
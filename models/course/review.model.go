@@ -0,0 +1,57 @@
+package course
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Course review moderation statuses, as for basket reviews
+const (
+	CourseReviewPending  = "PENDING"
+	CourseReviewApproved = "APPROVED"
+	CourseReviewRejected = "REJECTED"
+)
+
+// CourseReview is an enrolled learner's rating of a course. Only approved reviews count towards the course rating.
+// A learner has at most one active review per course.
+type CourseReview struct {
+	gorm.Model
+	CourseID        uint       `json:"course_id" gorm:"index;not null;uniqueIndex:idx_course_review_user,where:is_deleted = false"`
+	UserID          uint       `json:"user_id" gorm:"index;not null;uniqueIndex:idx_course_review_user,where:is_deleted = false"`
+	Rating          int        `json:"rating" gorm:"not null;check:rating >= 1 AND rating <= 5"`
+	Review          string     `json:"review" gorm:"type:text"`
+	Status          string     `json:"status" gorm:"type:varchar(20);default:'PENDING'"` // PENDING, APPROVED, REJECTED
+	RejectionReason string     `json:"rejection_reason"`
+	ModeratedBy     *uint      `json:"moderated_by"`
+	ModeratedAt     *time.Time `json:"moderated_at"`
+	Reply           string     `json:"reply" gorm:"type:text"` // Public answer from the course team
+	RepliedAt       *time.Time `json:"replied_at"`
+	IsDeleted       bool       `gorm:"default:false"`
+}
+
+// DiscussionThread is a learner's question on a piece of course content
+type DiscussionThread struct {
+	gorm.Model
+	CourseID        uint       `json:"course_id" gorm:"index;not null"`
+	ContentID       uint       `json:"content_id" gorm:"index;not null"`
+	UserID          uint       `json:"user_id" gorm:"index;not null"`
+	Title           string     `json:"title"`
+	Body            string     `json:"body" gorm:"type:text"`
+	IsAnswered      bool       `json:"is_answered" gorm:"default:false"` // An admin or the course instructor replied
+	IsResolved      bool       `json:"is_resolved" gorm:"default:false"` // Closed by the asker or the course team
+	AcceptedReplyID *uint      `json:"accepted_reply_id"`
+	ReplyCount      int        `json:"reply_count" gorm:"default:0"`
+	LastReplyAt     *time.Time `json:"last_reply_at"`
+	IsDeleted       bool       `gorm:"default:false"`
+}
+
+// DiscussionReply is an answer or follow-up in a discussion thread
+type DiscussionReply struct {
+	gorm.Model
+	ThreadID  uint   `json:"thread_id" gorm:"index;not null"`
+	UserID    uint   `json:"user_id" gorm:"index;not null"`
+	Body      string `json:"body" gorm:"type:text"`
+	IsStaff   bool   `json:"is_staff" gorm:"default:false"` // Posted by an admin or the course instructor
+	IsDeleted bool   `gorm:"default:false"`
+}
@@ -0,0 +1,27 @@
+package course
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VideoProgress is a learner's watch progress on VIDEO content, updated by player progress events
+type VideoProgress struct {
+	gorm.Model
+	UserID             uint       `json:"user_id" gorm:"uniqueIndex:idx_video_progress_user_content;not null"`
+	ContentID          uint       `json:"content_id" gorm:"uniqueIndex:idx_video_progress_user_content;index;not null"`
+	CourseID           uint       `json:"course_id" gorm:"index;not null"`
+	PositionSeconds    float64    `json:"position_seconds"`     // Resume position
+	MaxPositionSeconds float64    `json:"max_position_seconds"` // Furthest point reached, used for drop-off analytics
+	DurationSeconds    float64    `json:"duration_seconds"`
+	WatchedSeconds     float64    `json:"watched_seconds"` // Distinct seconds played; rewatching doesn't add up
+	WatchedPercent     float64    `json:"watched_percent"`
+	Segments           string     `json:"-" gorm:"type:text"` // One '0'/'1' per VideoSegmentSeconds of the video, '1' once played
+	LastEventAt        *time.Time `json:"last_event_at"`
+	CompletedAt        *time.Time `json:"completed_at"`
+	IsDeleted          bool       `gorm:"default:false"`
+}
+
+// VideoSegmentSeconds is the granularity of VideoProgress.Segments
+const VideoSegmentSeconds = 5
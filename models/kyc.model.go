@@ -1,34 +1,120 @@
 package models
 
 import (
+	"encoding/json"
+	"fib/encryption"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// KYC lifecycle states
+const (
+	KYCStatusNotStarted    = "NOT_STARTED"
+	KYCStatusInProgress    = "IN_PROGRESS"    // Aadhaar/PAN checks or document upload under way
+	KYCStatusPendingReview = "PENDING_REVIEW" // Submitted, waiting for an admin
+	KYCStatusVerified      = "VERIFIED"
+	KYCStatusRejected      = "REJECTED"
+	KYCStatusExpired       = "EXPIRED" // Verification lapsed and must be redone
+)
+
+// KYC document types
+const (
+	KYCDocPanCard      = "PAN_CARD"
+	KYCDocAadhaarFront = "AADHAAR_FRONT"
+	KYCDocAadhaarBack  = "AADHAAR_BACK"
+	KYCDocPhoto        = "PHOTO"
+	KYCDocSignature    = "SIGNATURE"
+	KYCDocAddressProof = "ADDRESS_PROOF"
+)
+
 type UserKYC struct {
 	gorm.Model
-	UserID     uint          `gorm:"not null;index"` // Foreign key to User table
-	AdharID    uint          `gorm:"index"`          // Foreign key to AadharDetails table
-	PanID      uint          `gorm:"index"`          // Foreign key to PanDetails table
-	Aadhar     AadharDetails `gorm:"foreignKey:AdharID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	Pan        PanDetails    `gorm:"foreignKey:PanID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	IsVerified bool          `gorm:"default:false"` // KYC verification status
-	IsDeleted  bool          `gorm:"default:false"` // Soft delete flag
+	UserID          uint          `gorm:"not null;index"` // Foreign key to User table
+	AdharID         uint          `gorm:"index"`          // Foreign key to AadharDetails table
+	PanID           uint          `gorm:"index"`          // Foreign key to PanDetails table
+	Aadhar          AadharDetails `gorm:"foreignKey:AdharID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Pan             PanDetails    `gorm:"foreignKey:PanID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Status          string        `gorm:"type:varchar(20);default:'NOT_STARTED';index" json:"status"`
+	Provider        string        `gorm:"type:varchar(20)" json:"provider"` // KYC provider used for Aadhaar/PAN checks
+	RejectionReason string        `json:"rejection_reason"`
+	SubmittedAt     *time.Time    `json:"submitted_at"`
+	ReviewedBy      *uint         `json:"reviewed_by"`
+	ReviewedAt      *time.Time    `json:"reviewed_at"`
+	VerifiedAt      *time.Time    `json:"verified_at"`
+	ExpiresAt       *time.Time    `json:"expires_at"`    // Re-KYC is required after this
+	IsVerified      bool          `gorm:"default:false"` // KYC verification status, true only while Status is VERIFIED
+	IsDeleted       bool          `gorm:"default:false"` // Soft delete flag
+}
+
+// KYCDocument is a file uploaded by the user as part of KYC
+type KYCDocument struct {
+	gorm.Model
+	UserID       uint   `gorm:"not null;index" json:"user_id"`
+	UserKYCID    uint   `gorm:"not null;index" json:"user_kyc_id"`
+	DocumentType string `gorm:"type:varchar(20);not null" json:"document_type"`
+	FileName     string `json:"file_name"` // Original file name
+	FilePath     string `json:"-"`         // Storage path, never exposed
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	IsDeleted    bool   `gorm:"default:false"`
+}
+
+// KYCAuditLog records every KYC status change and who made it
+type KYCAuditLog struct {
+	gorm.Model
+	UserKYCID  uint   `gorm:"not null;index" json:"user_kyc_id"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	FromStatus string `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string `gorm:"type:varchar(20)" json:"to_status"`
+	ActorID    uint   `json:"actor_id"` // User or admin who made the change, 0 for the system
+	Reason     string `json:"reason"`
 }
 
 type AadharDetails struct {
 	gorm.Model
-	AadharNumber string `gorm:"unique;not null"` // Aadhar number must be unique and not null
-	Name         string `gorm:"default:''"`      // Name on the Aadhar card
-	ProfileImage string `gorm:"default:''"`      // Profile image
-	DOB          string `gorm:"default:''"`      // Date of Birth
-	Address      string `gorm:"default:''"`      // Address on the Aadhar card
-	IsVerified   bool   `gorm:"default:false"`   // Verification status, default is false
-	RefID        string `gorm:"default:''"`      // Reference ID
+	AadharNumber      string  `gorm:"serializer:encrypted;not null"` // Encrypted at rest, masked in JSON
+	AadharNumberIndex *string `gorm:"uniqueIndex" json:"-"`          // Blind index, Aadhar number must be unique
+	Name              string  `gorm:"default:''"`                    // Name on the Aadhar card
+	ProfileImage      string  `gorm:"default:''"`                    // Profile image
+	DOB               string  `gorm:"default:''"`                    // Date of Birth
+	Address           string  `gorm:"default:''"`                    // Address on the Aadhar card
+	IsVerified        bool    `gorm:"default:false"`                 // Verification status, default is false
+	RefID             string  `gorm:"default:''"`                    // Reference ID
+}
+
+// BeforeSave keeps the blind index in sync with the Aadhar number
+func (a *AadharDetails) BeforeSave(tx *gorm.DB) error {
+	a.AadharNumberIndex = encryption.BlindIndex(a.AadharNumber)
+	return nil
+}
+
+// MarshalJSON masks the Aadhar number in every API response
+func (a AadharDetails) MarshalJSON() ([]byte, error) {
+	type alias AadharDetails
+	masked := alias(a)
+	masked.AadharNumber = encryption.MaskAadhaar(a.AadharNumber)
+	return json.Marshal(masked)
 }
 
 type PanDetails struct {
 	gorm.Model
-	PanNumber  string `gorm:"unique;not null"` // PAN number must be unique and not null
-	Name       string `gorm:"default:''"`      // Name on the PAN card
-	IsVerified bool   `gorm:"default:false"`   // Verification status, default is false
+	PanNumber      string  `gorm:"serializer:encrypted;not null"` // Encrypted at rest, masked in JSON
+	PanNumberIndex *string `gorm:"uniqueIndex" json:"-"`          // Blind index, PAN number must be unique
+	Name           string  `gorm:"default:''"`                    // Name on the PAN card
+	IsVerified     bool    `gorm:"default:false"`                 // Verification status, default is false
+}
+
+// BeforeSave keeps the blind index in sync with the PAN number
+func (p *PanDetails) BeforeSave(tx *gorm.DB) error {
+	p.PanNumberIndex = encryption.BlindIndex(p.PanNumber)
+	return nil
+}
+
+// MarshalJSON masks the PAN number in every API response
+func (p PanDetails) MarshalJSON() ([]byte, error) {
+	type alias PanDetails
+	masked := alias(p)
+	masked.PanNumber = encryption.MaskPAN(p.PanNumber)
+	return json.Marshal(masked)
 }
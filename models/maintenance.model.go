@@ -1,14 +1,103 @@
 package models
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// App platforms sent in the X-App-Platform header
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+// Maintenance is the app status; the latest record is the one in effect
 type Maintenance struct {
 	gorm.Model                // Auto includes ID, CreatedAt, UpdatedAt, DeletedAt
 	AppMaintenance       bool `gorm:"default:false"`
-	ForceUpdate          bool `gorm:"default:false"`
+	ForceUpdate          bool `gorm:"default:false"` // Without a minimum version, the latest version becomes the minimum
 	IosLatestVersion     string
-	AndroidLatestVersion string `gorm:"not null"`
-	IsDeleted            bool   `gorm:"default:false"`
+	AndroidLatestVersion string     `gorm:"not null"`
+	IosMinVersion        string     // Older iOS apps must update, empty means no minimum
+	AndroidMinVersion    string     // Older Android apps must update, empty means no minimum
+	StartsAt             *time.Time // Scheduled maintenance start, nil means immediately
+	EndsAt               *time.Time // Scheduled maintenance end, nil means until switched off
+	Message              string     `gorm:"type:text"` // Shown to users during maintenance
+	IsDeleted            bool       `gorm:"default:false"`
+}
+
+// IsActiveAt reports whether the app is under maintenance at the given time
+func (m *Maintenance) IsActiveAt(t time.Time) bool {
+	if !m.AppMaintenance {
+		return false
+	}
+	if m.StartsAt != nil && t.Before(*m.StartsAt) {
+		return false
+	}
+	if m.EndsAt != nil && !t.Before(*m.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// MinVersion returns the oldest app version still allowed on a platform, or "" if any version is
+func (m *Maintenance) MinVersion(platform string) string {
+	var min, latest string
+	switch strings.ToLower(platform) {
+	case PlatformIOS:
+		min, latest = m.IosMinVersion, m.IosLatestVersion
+	case PlatformAndroid:
+		min, latest = m.AndroidMinVersion, m.AndroidLatestVersion
+	default:
+		return ""
+	}
+	if min == "" && m.ForceUpdate {
+		return latest
+	}
+	return min
+}
+
+// CompareVersions compares dotted versions numerically, returning -1, 0 or 1.
+// Anything after the numeric part ("1.4.0-beta", "2.1 (45)") is ignored and missing parts count as 0.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+		if end < len(part) {
+			break
+		}
+	}
+	return parts
 }
@@ -6,13 +6,26 @@ import (
 	"gorm.io/gorm"
 )
 
+// OTP purpose values (an OTP can only be verified for the purpose it was issued for)
+const (
+	OTPPurposeSignup         = "SIGNUP" // Email/mobile verification
+	OTPPurposeLogin          = "LOGIN"
+	OTPPurposeForgotPassword = "FORGOT_PASSWORD"
+	OTPPurposeLoginChallenge = "LOGIN_CHALLENGE" // Step-up verification for unusual logins
+	OTPPurposeAadhaar        = "AADHAAR"         // Sent by UIDAI, we only track the reference
+)
+
 type OTP struct {
 	gorm.Model
-	UserID      uint      `gorm:"not null" json:"user_id"`               // Foreign key to the user (optional if OTP is for specific users)
-	Email       string    `gorm:"size:100;index" json:"email,omitempty"` // Email for OTP, if applicable
-	Mobile      string    `gorm:"size:15;index" json:"mobile,omitempty"` // Mobile for OTP, if applicable
-	Code        string    `gorm:"size:6;not null" json:"code"`           // The OTP code
-	ExpiresAt   time.Time `gorm:"not null" json:"expires_at"`            // Expiry time for the OTP
+	UserID      uint      `gorm:"not null" json:"user_id"`                   // Foreign key to the user (optional if OTP is for specific users)
+	Email       string    `gorm:"size:100;index" json:"email,omitempty"`     // Email for OTP, if applicable
+	Mobile      string    `gorm:"size:15;index" json:"mobile,omitempty"`     // Mobile for OTP, if applicable
+	Purpose     string    `gorm:"type:varchar(30);index" json:"purpose"`     // SIGNUP, LOGIN, FORGOT_PASSWORD, AADHAAR
+	CodeHash    string    `gorm:"size:64;not null;default:''" json:"-"`      // HMAC-SHA256 of the OTP code
+	Attempts    int       `gorm:"default:0" json:"attempts"`                 // Failed verification attempts
+	MaxAttempts int       `gorm:"default:5" json:"max_attempts"`             // Attempts allowed before the OTP is burned
+	IPAddress   string    `gorm:"size:64;index" json:"ip_address,omitempty"` // Requesting IP, used for send limits
+	ExpiresAt   time.Time `gorm:"not null" json:"expires_at"`                // Expiry time for the OTP
 	IsUsed      bool      `gorm:"default:false" json:"is_used"`
 	Description string    `gorm:"size:255" json:"description,omitempty"` // Description of the OTP
 	IsDeleted   bool      `gorm:"default:false"`                         // Flag to indicate if the OTP has been used
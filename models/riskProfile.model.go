@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Investor risk categories, from least to most risk tolerant
+const (
+	RiskCategoryConservative = "CONSERVATIVE"
+	RiskCategoryModerate     = "MODERATE"
+	RiskCategoryAggressive   = "AGGRESSIVE"
+)
+
+// RiskQuestion is one question of the admin managed risk profiling questionnaire
+type RiskQuestion struct {
+	gorm.Model
+	Question  string       `gorm:"type:text;not null" json:"question"`
+	SortOrder int          `gorm:"default:0" json:"sortOrder"`
+	IsActive  bool         `gorm:"default:true" json:"isActive"` // Inactive questions are hidden and not scored
+	IsDeleted bool         `gorm:"default:false" json:"isDeleted"`
+	Options   []RiskOption `gorm:"foreignKey:QuestionID" json:"options,omitempty"`
+}
+
+// RiskOption is an answer to a RiskQuestion; higher scores mean more risk tolerance
+type RiskOption struct {
+	gorm.Model
+	QuestionID uint   `gorm:"not null;index" json:"questionId"`
+	Text       string `gorm:"not null" json:"text"`
+	Score      int    `gorm:"not null;default:0" json:"score"`
+	SortOrder  int    `gorm:"default:0" json:"sortOrder"`
+	IsDeleted  bool   `gorm:"default:false" json:"isDeleted"`
+}
+
+// UserRiskProfile is the result of one questionnaire submission; the latest one is the user's current profile
+type UserRiskProfile struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index" json:"userId"`
+	Score      int        `gorm:"not null" json:"score"`
+	MaxScore   int        `gorm:"not null" json:"maxScore"`
+	Category   string     `gorm:"type:varchar(20);not null" json:"category"` // CONSERVATIVE, MODERATE, AGGRESSIVE
+	Answers    string     `gorm:"type:jsonb" json:"answers"`                 // Snapshot of questions and chosen options
+	AssessedAt time.Time  `gorm:"not null" json:"assessedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	IsDeleted  bool       `gorm:"default:false" json:"isDeleted"`
+}
+
+// SuitabilityAcknowledgement records a user accepting a risk mismatch warning before subscribing
+type SuitabilityAcknowledgement struct {
+	gorm.Model
+	UserID           uint   `gorm:"not null;index" json:"userId"`
+	BasketID         uint   `gorm:"not null;index" json:"basketId"`
+	BasketVersionID  uint   `gorm:"not null" json:"basketVersionId"`
+	SubscriptionID   uint   `gorm:"index" json:"subscriptionId"`
+	RiskProfileID    uint   `json:"riskProfileId"`
+	UserRiskCategory string `gorm:"type:varchar(20)" json:"userRiskCategory"`
+	BasketRiskRating string `gorm:"type:varchar(10)" json:"basketRiskRating"`
+	Warning          string `gorm:"type:text" json:"warning"` // Warning text shown to the user
+	IP               string `json:"ip"`
+	UserAgent        string `json:"userAgent"`
+}
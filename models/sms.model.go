@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SMS message types (each maps to its own DLT template)
+const (
+	SMSTypeOTP                = "OTP"
+	SMSTypeLoginAlert         = "LOGIN_ALERT"
+	SMSTypeSubscriptionExpiry = "SUBSCRIPTION_EXPIRY"
+)
+
+// SMS delivery status values
+const (
+	SMSStatusQueued    = "QUEUED"
+	SMSStatusSent      = "SENT"
+	SMSStatusDelivered = "DELIVERED"
+	SMSStatusFailed    = "FAILED"
+)
+
+// SMSTemplate holds the DLT registered template for a message type
+type SMSTemplate struct {
+	gorm.Model
+	MessageType string `gorm:"type:varchar(50);not null;index" json:"messageType"` // OTP, LOGIN_ALERT, SUBSCRIPTION_EXPIRY
+	Provider    string `gorm:"type:varchar(20);default:''" json:"provider"`        // Empty applies to every provider
+	TemplateID  string `gorm:"type:varchar(50);not null" json:"templateId"`        // DLT template ID
+	SenderID    string `gorm:"type:varchar(20);default:''" json:"senderId"`        // Overrides the configured sender ID
+	Body        string `gorm:"type:text" json:"body"`                              // Registered template text (for reference)
+	IsActive    bool   `gorm:"default:true" json:"isActive"`
+	IsDeleted   bool   `gorm:"default:false" json:"isDeleted"`
+}
+
+// SMSLog tracks every outgoing SMS and its delivery status
+type SMSLog struct {
+	gorm.Model
+	UserID            uint       `gorm:"default:0;index" json:"userId"`
+	Mobile            string     `gorm:"size:15;index" json:"mobile"`
+	MessageType       string     `gorm:"type:varchar(50);index" json:"messageType"`
+	TemplateID        string     `gorm:"type:varchar(50)" json:"templateId"`
+	Provider          string     `gorm:"type:varchar(20)" json:"provider"`
+	ProviderMessageID string     `gorm:"type:varchar(100);index" json:"providerMessageId"`
+	Status            string     `gorm:"type:varchar(20);default:'QUEUED'" json:"status"` // QUEUED, SENT, DELIVERED, FAILED
+	ErrorMessage      string     `gorm:"type:text" json:"errorMessage"`
+	SentAt            *time.Time `json:"sentAt"`
+	DeliveredAt       *time.Time `json:"deliveredAt"`
+	IsDeleted         bool       `gorm:"default:false" json:"isDeleted"`
+}
@@ -1,18 +1,84 @@
 package models
 
 import (
-	"gorm.io/datatypes"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Support message senders
+const (
+	SupportSenderUser  = "USER"
+	SupportSenderAdmin = "ADMIN"
+)
+
+// SupportMaxAttachments is how many files one support message may carry
+const SupportMaxAttachments = 5
+
 type SupportTicket struct {
 	gorm.Model
-	UserID    uint           `json:"user_id"`
-	Title     string         `json:"title"`
-	Subject   string         `json:"subject"`
-	Message   datatypes.JSON `json:"message" gorm:"type:json"`
-	Status    string         `json:"status" gorm:"default:'open'"`
-	Priority  string         `json:"priority" gorm:"default:'medium'"`
-	Category  string         `json:"category" gorm:"default:'general'"`
-	IsDeleted bool           `json:"is_deleted" gorm:"default:false"`
+	UserID        uint       `json:"user_id"`
+	Title         string     `json:"title"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status" gorm:"default:'open'"`
+	Priority      string     `json:"priority" gorm:"default:'medium'"`
+	Category      string     `json:"category" gorm:"default:'general'"`
+	LastMessageAt *time.Time `json:"last_message_at"`
+
+	AssignedTo *uint      `json:"assigned_to" gorm:"index"` // Admin who owns the ticket
+	AssignedAt *time.Time `json:"assigned_at"`
+
+	// SLA targets come from the SupportSLAPolicy of the ticket's priority
+	FirstResponseDueAt    *time.Time `json:"first_response_due_at"`
+	FirstResponseAt       *time.Time `json:"first_response_at"` // First admin reply the user could see
+	ResolutionDueAt       *time.Time `json:"resolution_due_at"`
+	ResolvedAt            *time.Time `json:"resolved_at"`
+	FirstResponseBreached bool       `json:"first_response_breached" gorm:"default:false"`
+	ResolutionBreached    bool       `json:"resolution_breached" gorm:"default:false"`
+	EscalationLevel       int        `json:"escalation_level" gorm:"default:0"` // Raised each time an SLA target is missed
+	EscalatedAt           *time.Time `json:"escalated_at"`
+
+	IsDeleted bool `json:"is_deleted" gorm:"default:false"`
+}
+
+// SupportSLAPolicy sets how quickly tickets of a priority must be answered and resolved
+type SupportSLAPolicy struct {
+	gorm.Model
+	Priority             string `json:"priority" gorm:"type:varchar(10);uniqueIndex;not null"`
+	FirstResponseMinutes int    `json:"first_response_minutes" gorm:"not null"`
+	ResolutionMinutes    int    `json:"resolution_minutes" gorm:"not null"`
+}
+
+// SupportAgent puts an admin in the round-robin rotation for a ticket category
+type SupportAgent struct {
+	gorm.Model
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex:idx_support_agent_user_category;not null"`
+	Category       string     `json:"category" gorm:"type:varchar(20);uniqueIndex:idx_support_agent_user_category;not null"`
+	IsActive       bool       `json:"is_active" gorm:"default:true"`
+	LastAssignedAt *time.Time `json:"last_assigned_at"` // The agent longest without a new ticket gets the next one
+}
+
+// SupportMessage is one message in a support ticket's conversation
+type SupportMessage struct {
+	gorm.Model
+	TicketID    uint                `json:"ticket_id" gorm:"index;not null"`
+	SenderID    uint                `json:"sender_id" gorm:"index"` // 0 for admin replies migrated from the old JSON thread
+	SenderRole  string              `json:"sender_role" gorm:"type:varchar(10);not null"`
+	Body        string              `json:"body" gorm:"type:text"`
+	IsInternal  bool                `json:"is_internal" gorm:"default:false"` // Admin-only note, never shown to the user
+	ReadAt      *time.Time          `json:"read_at"`                          // When the other side first saw the message
+	Attachments []SupportAttachment `json:"attachments" gorm:"foreignKey:MessageID"`
+	IsDeleted   bool                `json:"is_deleted" gorm:"default:false"`
+}
+
+// SupportAttachment is a file sent with a support message
+type SupportAttachment struct {
+	gorm.Model
+	MessageID uint   `json:"message_id" gorm:"index;not null"`
+	FileName  string `json:"file_name"` // Original file name
+	FileKey   string `json:"-"`         // Storage key, exposed only as a signed URL
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	URL       string `json:"url" gorm:"-"`
+	IsDeleted bool   `json:"is_deleted" gorm:"default:false"`
 }
@@ -11,6 +11,7 @@ type Transactions struct {
 	TransactionType string `gorm:"not null"` // DEPOSIT/WITHDRAW
 	Amount          uint   `gorm:"not null"`
 	AmcID           uint
+	BankDetailsID   uint   // Payout account for withdrawals
 	Status          string `gorm:"not null"` // pending/completed
 	IsDeleted       bool   `gorm:"default:false"`
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fib/encryption"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,9 +22,9 @@ type User struct {
 	IsEmailVerified       bool      `gorm:"default:false"`
 	MainBalance           uint      `gorm:"default:0"`
 	LastLogin             time.Time `gorm:"default:NULL"`
-	PanNumber             string
-	IsAdharVerified       bool `gorm:"default:false"`
-	IsPanVerified         bool `gorm:"default:false"`
+	PanNumber             string    `gorm:"serializer:encrypted"` // Encrypted at rest, masked in JSON
+	IsAdharVerified       bool      `gorm:"default:false"`
+	IsPanVerified         bool      `gorm:"default:false"`
 	Address               string
 	City                  string
 	State                 string
@@ -37,5 +39,17 @@ type User struct {
 	LastFailedLogin       *time.Time `json:"last_failed_login"`
 	IsBlocked             bool       `gorm:"default:false"`
 	BlockedUntil          *time.Time `json:"blocked_until"`
+	LockoutCount          int        `gorm:"default:0" json:"lockout_count"`  // Consecutive lockouts, drives escalating lockout duration
+	IsFlagged             bool       `gorm:"default:false" json:"is_flagged"` // Flagged for suspicious login activity
+	FlaggedReason         string     `json:"flagged_reason"`
+	FlaggedAt             *time.Time `json:"flagged_at"`
 	IsDeleted             bool       `gorm:"default:false"`
 }
+
+// MarshalJSON masks the PAN number in every API response
+func (u User) MarshalJSON() ([]byte, error) {
+	type alias User
+	masked := alias(u)
+	masked.PanNumber = encryption.MaskPAN(u.PanNumber)
+	return json.Marshal(masked)
+}
@@ -10,12 +10,13 @@ import (
 type TransactionType string
 
 const (
-	TransactionTypeDeposit      TransactionType = "DEPOSIT"
-	TransactionTypeWithdrawal   TransactionType = "WITHDRAWAL"
-	TransactionTypeSubscription TransactionType = "SUBSCRIPTION"
-	TransactionTypeRefund       TransactionType = "REFUND"
-	TransactionTypeAdminCredit  TransactionType = "ADMIN_CREDIT"
-	TransactionTypeAdminDebit   TransactionType = "ADMIN_DEBIT"
+	TransactionTypeDeposit        TransactionType = "DEPOSIT"
+	TransactionTypeWithdrawal     TransactionType = "WITHDRAWAL"
+	TransactionTypeSubscription   TransactionType = "SUBSCRIPTION"
+	TransactionTypeRefund         TransactionType = "REFUND"
+	TransactionTypeAdminCredit    TransactionType = "ADMIN_CREDIT"
+	TransactionTypeAdminDebit     TransactionType = "ADMIN_DEBIT"
+	TransactionTypeCoursePurchase TransactionType = "COURSE_PURCHASE"
 )
 
 // TransactionStatus defines the status of a transaction
@@ -1,29 +1,36 @@
 package authRoutes
 
 import (
+	"fib/config"
 	authControllers "fib/controllers/auth"
 	"fib/middleware"
 	authValidators "fib/validators/auth"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func SetupAuthRoutes(app *fiber.App) {
-	authGroup := app.Group("/auth")
+	authGroup := app.Group("/auth", middleware.RateLimit("auth", config.AppConfig.RateLimitAuth, middleware.RateLimitWindow(), false))
+
+	// Stricter per-IP limits for credential and OTP endpoints
+	loginLimiter := middleware.RateLimit("login", config.AppConfig.RateLimitLogin, middleware.RateLimitWindow(), false)
+	otpLimiter := middleware.RateLimit("otp", config.AppConfig.RateLimitOTP, 10*time.Minute, false)
 
 	authGroup.Post("/signup", authValidators.Signup(), authControllers.Signup)
-	authGroup.Post("/login", authValidators.Login(), authControllers.Login)
+	authGroup.Post("/login", loginLimiter, authValidators.Login(), authControllers.Login)
+	authGroup.Post("/login/challenge/verify", loginLimiter, authValidators.LoginChallengeVerify(), authControllers.VerifyLoginChallenge)
 	authGroup.Get("/login/history", authValidators.LoginHistoryList(), middleware.JWTMiddleware, authControllers.LoginHistoryList)
-	authGroup.Post("/send/otp", authValidators.SendOTP(), authControllers.SendOTP)
+	authGroup.Post("/send/otp", otpLimiter, authValidators.SendOTP(), authControllers.SendOTP)
 	authGroup.Patch("/verify/otp", authValidators.VerifyOTP(), authControllers.VerifyOTP)
-	authGroup.Post("/forgot/password/send/otp", authValidators.SendOTP(), authControllers.ForgotPasswordSendOTP)
+	authGroup.Post("/forgot/password/send/otp", otpLimiter, authValidators.SendOTP(), authControllers.ForgotPasswordSendOTP)
 	authGroup.Patch("/forgot/password/verify/otp", authValidators.VerifyOTP(), authControllers.ForgotPasswordVerifyOTP)
 	authGroup.Patch("/reset/password", authValidators.ResetPassword(), middleware.JWTMiddleware, authControllers.ResetPassword)
 	authGroup.Put("/change/login/password", authValidators.ChangeLoginPassword(), middleware.JWTMiddleware, authControllers.ChangeLoginPassword)
 
 	// Send login otp
-	authGroup.Post("/login-otp", authValidators.LoginOtp(), authControllers.LoginSendOTP)
+	authGroup.Post("/login-otp", otpLimiter, authValidators.LoginOtp(), authControllers.LoginSendOTP)
 	// Verify login otp
-	authGroup.Post("/verify-login-otp", authValidators.LoginVerifyOtpValidator(), authControllers.LoginVerifyOTP)
+	authGroup.Post("/verify-login-otp", loginLimiter, authValidators.LoginVerifyOtpValidator(), authControllers.LoginVerifyOTP)
 
 }
@@ -1,6 +1,7 @@
 package basketRoutes
 
 import (
+	"fib/config"
 	basketController "fib/controllers/basket"
 	"fib/middleware"
 	basketValidator "fib/validators/basket"
@@ -36,6 +37,14 @@ func SetupAMCBasketRoutes(app *fiber.App) {
 
 	// Approval workflow
 	amcGroup.Post("/submit", basketValidator.SubmitForApproval(), middleware.JWTMiddleware, basketController.SubmitForApproval)
+	amcGroup.Get("/:id/compliance", middleware.JWTMiddleware, basketValidator.BasketCompliance(), basketController.GetDraftCompliance)
+	amcGroup.Get("/:id/approvals", middleware.JWTMiddleware, basketValidator.ApprovalTrail(), basketController.GetApprovalTrail)
+	amcGroup.Post("/schedule", basketValidator.RescheduleBasket(), middleware.JWTMiddleware, basketController.RescheduleBasket)
+
+	// Versions: branch a new draft from the live version, discard it, compare any two
+	amcGroup.Post("/new-version", basketValidator.NewVersion(), middleware.JWTMiddleware, basketController.CreateNewVersion)
+	amcGroup.Post("/discard-draft", basketValidator.DiscardDraft(), middleware.JWTMiddleware, basketController.DiscardDraftVersion)
+	amcGroup.Get("/:id/diff", middleware.JWTMiddleware, basketValidator.VersionDiff(), basketController.GetVersionDiff)
 
 	// Subscribers list
 	amcGroup.Get("/:id/subscribers", middleware.JWTMiddleware, basketController.GetBasketSubscribers)
@@ -70,6 +79,16 @@ func SetupAdminBasketRoutes(app *fiber.App) {
 	adminGroup.Get("/pending", basketValidator.ListPendingApprovals(), middleware.JWTMiddleware, basketController.ListPendingApprovals)
 	adminGroup.Post("/approve", basketValidator.ApproveBasket(), middleware.JWTMiddleware, basketController.ApproveBasket)
 	adminGroup.Post("/reject", basketValidator.RejectBasket(), middleware.JWTMiddleware, basketController.RejectBasket)
+	adminGroup.Post("/request-changes", basketValidator.RequestChanges(), middleware.JWTMiddleware, basketController.RequestBasketChanges)
+	adminGroup.Get("/:id/approvals", middleware.JWTMiddleware, basketValidator.ApprovalTrail(), basketController.GetApprovalTrail)
+
+	// Approval chains (maker-checker)
+	adminGroup.Get("/approval-chain", middleware.JWTMiddleware, basketController.GetApprovalChains)
+	adminGroup.Post("/approval-chain", basketValidator.SaveApprovalChain(), middleware.JWTMiddleware, basketController.SaveApprovalChain)
+
+	// Pre-submission compliance rules
+	adminGroup.Get("/compliance-rules", middleware.JWTMiddleware, basketController.GetComplianceRules)
+	adminGroup.Post("/compliance-rules", basketValidator.SaveComplianceRule(), middleware.JWTMiddleware, basketController.SaveComplianceRule)
 
 	// Basket management
 	adminGroup.Post("/unpublish", middleware.JWTMiddleware, basketController.UnpublishBasket)
@@ -78,9 +97,13 @@ func SetupAdminBasketRoutes(app *fiber.App) {
 	// Time slot management (INTRA_HOUR)
 	adminGroup.Post("/time-slot", basketValidator.SetTimeSlot(), middleware.JWTMiddleware, basketController.SetTimeSlot)
 
+	// Go-live and sunset (DELIVERY, INTRADAY)
+	adminGroup.Post("/schedule", basketValidator.RescheduleBasket(), middleware.JWTMiddleware, basketController.RescheduleBasket)
+
 	// Calendar and audit
 	adminGroup.Get("/calendar", basketValidator.GetCalendarView(), middleware.JWTMiddleware, basketController.GetCalendarView)
 	adminGroup.Get("/audit/:id", middleware.JWTMiddleware, basketController.GetAuditLog)
+	adminGroup.Get("/:id/diff", middleware.JWTMiddleware, basketValidator.VersionDiff(), basketController.GetVersionDiff)
 
 	// Basket subscribers (admin)
 	adminGroup.Get("/:id/subscribers", middleware.JWTMiddleware, basketController.GetBasketSubscribersAdmin)
@@ -115,9 +138,10 @@ func SetupUserBasketRoutes(app *fiber.App) {
 	userGroup.Get("/messages/all", middleware.JWTMiddleware, basketController.GetAllMessages) // Global Inbox
 	userGroup.Get("/:id/messages", middleware.JWTMiddleware, basketController.GetBasketMessages)
 
-	// Stock price lookup
-	userGroup.Get("/stock-price", basketValidator.GetStockPrice(), middleware.JWTMiddleware, basketController.GetStockPrice)
-	userGroup.Get("/stock-price/details", basketValidator.GetStockPrice(), middleware.JWTMiddleware, basketController.GetStockPriceDetails)
+	// Stock price lookup (rate limited per user, each call hits the broker API)
+	stockPriceLimiter := middleware.RateLimit("stock-price", config.AppConfig.RateLimitStockPrice, middleware.RateLimitWindow(), true)
+	userGroup.Get("/stock-price", stockPriceLimiter, basketValidator.GetStockPrice(), middleware.JWTMiddleware, basketController.GetStockPrice)
+	userGroup.Get("/stock-price/details", stockPriceLimiter, basketValidator.GetStockPrice(), middleware.JWTMiddleware, basketController.GetStockPriceDetails)
 
 	// Stocks list for adding to basket
 	userGroup.Get("/stocks-list", middleware.JWTMiddleware, basketController.GetStocksList)
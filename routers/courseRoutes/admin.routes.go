@@ -20,6 +20,10 @@ func SetupAdminCourseRoutes(app *fiber.App) {
 	adminGroup.Get("/:id", middleware.JWTMiddleware, validators.DeleteCourse(), controllers.AdminGetCourseDetails)
 	adminGroup.Post("/:id/publish", middleware.JWTMiddleware, validators.PublishCourse(), controllers.AdminPublishCourse)
 
+	// Course packages (export and import a whole course)
+	adminGroup.Get("/:id/export", middleware.JWTMiddleware, validators.ExportCourse(), controllers.AdminExportCourse)
+	adminGroup.Post("/import", middleware.JWTMiddleware, validators.ImportCourse(), controllers.AdminImportCourse)
+
 	// Module Management
 	adminGroup.Post("/:id/module", middleware.JWTMiddleware, validators.CreateModule(), controllers.AdminCreateModule)
 	adminGroup.Put("/:course_id/module/:module_id", middleware.JWTMiddleware, validators.UpdateModule(), controllers.AdminUpdateModule)
@@ -43,9 +47,22 @@ func SetupAdminCourseRoutes(app *fiber.App) {
 	mcqGroup.Put("/:option_id", middleware.JWTMiddleware, validators.UpdateMCQOption(), controllers.AdminUpdateMCQOption)
 	mcqGroup.Delete("/:option_id", middleware.JWTMiddleware, validators.DeleteMCQOption(), controllers.AdminDeleteMCQOption)
 
+	// Quiz management
+	contentGroup.Get("/:content_id/quiz", middleware.JWTMiddleware, validators.DeleteContentAdmin(), controllers.AdminGetQuiz)
+	contentGroup.Put("/:content_id/quiz", middleware.JWTMiddleware, validators.UpdateQuizSettings(), controllers.AdminUpdateQuizSettings)
+	contentGroup.Post("/:content_id/quiz/question", middleware.JWTMiddleware, validators.AddQuizQuestion(), controllers.AdminAddQuizQuestion)
+
+	// Video analytics
+	contentGroup.Get("/:content_id/video/analytics", middleware.JWTMiddleware, validators.DeleteContentAdmin(), controllers.AdminGetVideoAnalytics)
+
+	quizGroup := app.Group("/admin/quiz")
+	quizGroup.Put("/question/:question_id", middleware.JWTMiddleware, validators.UpdateQuizQuestion(), controllers.AdminUpdateQuizQuestion)
+	quizGroup.Delete("/question/:question_id", middleware.JWTMiddleware, validators.DeleteQuizQuestion(), controllers.AdminDeleteQuizQuestion)
+
 	// Enrollment & Progress Tracking
 	adminGroup.Get("/:id/enrollments", middleware.JWTMiddleware, validators.GetCourseEnrollments(), controllers.AdminGetCourseEnrollments)
 	adminGroup.Get("/:id/completed", middleware.JWTMiddleware, validators.GetCourseEnrollments(), controllers.AdminGetCompletedStudents)
+	adminGroup.Get("/:id/analytics", middleware.JWTMiddleware, validators.CourseAnalytics(), controllers.AdminCourseAnalytics)
 
 	studentGroup := app.Group("/admin/student")
 	studentGroup.Get("/:user_id/progress", middleware.JWTMiddleware, validators.GetStudentProgress(), controllers.AdminGetStudentProgress)
@@ -59,6 +76,11 @@ func SetupAdminCourseRoutes(app *fiber.App) {
 	certRequestGroup.Post("/:request_id/approve", middleware.JWTMiddleware, validators.ApproveCertificate(), controllers.AdminApproveCertificate)
 	certRequestGroup.Post("/:request_id/reject", middleware.JWTMiddleware, validators.RejectCertificate(), controllers.AdminRejectCertificate)
 
+	// Review moderation and discussions
+	adminGroup.Get("/reviews/all", middleware.JWTMiddleware, validators.AdminCourseReviewList(), controllers.AdminGetCourseReviews)
+	adminGroup.Post("/review/:review_id/moderate", middleware.JWTMiddleware, validators.ModerateCourseReview(), controllers.AdminModerateCourseReview)
+	adminGroup.Get("/discussions/all", middleware.JWTMiddleware, validators.AdminDiscussionList(), controllers.AdminGetDiscussions)
+
 	// Dashboard
 	dashGroup := app.Group("/admin/dashboard")
 	dashGroup.Get("/stats", middleware.JWTMiddleware, controllers.AdminDashboardStats)
@@ -18,6 +18,7 @@ func SetupCourseRoutes(app *fiber.App) {
 
 	// Enrollment
 	userGroup.Post("/:id/enroll", middleware.JWTMiddleware, validators.EnrollCourse(), controllers.EnrollInCourse)
+	userGroup.Post("/:course_id/refund", middleware.JWTMiddleware, validators.RefundCourse(), controllers.RefundCourse)
 
 	// Content viewing (for enrolled users)
 	userGroup.Get("/:id/content", middleware.JWTMiddleware, validators.CourseContentList(), controllers.GetCourseContent)
@@ -29,6 +30,28 @@ func SetupCourseRoutes(app *fiber.App) {
 	// MCQ submission
 	userGroup.Post("/:course_id/content/:content_id/mcq/submit", middleware.JWTMiddleware, validators.SubmitMCQ(), controllers.SubmitMCQAnswer)
 
+	// Quizzes
+	userGroup.Post("/:course_id/content/:content_id/quiz/start", middleware.JWTMiddleware, validators.QuizContent(), controllers.StartQuiz)
+	userGroup.Post("/:course_id/content/:content_id/quiz/submit", middleware.JWTMiddleware, validators.SubmitQuiz(), controllers.SubmitQuiz)
+	userGroup.Get("/:course_id/content/:content_id/quiz/attempts", middleware.JWTMiddleware, validators.QuizContent(), controllers.GetQuizAttempts)
+
+	// Video watch progress
+	userGroup.Post("/:course_id/content/:content_id/video/progress", middleware.JWTMiddleware, validators.VideoProgress(), controllers.RecordVideoProgress)
+
+	// Reviews and ratings
+	userGroup.Get("/:course_id/reviews", middleware.JWTMiddleware, validators.CourseReviewList(), controllers.GetCourseReviews)
+	userGroup.Post("/:course_id/review", middleware.JWTMiddleware, validators.SubmitCourseReview(), controllers.SubmitCourseReview)
+	userGroup.Delete("/:course_id/review", middleware.JWTMiddleware, validators.GetCourseProgress(), controllers.DeleteCourseReview)
+
+	// Discussions (learners ask, admins and the course instructor answer)
+	userGroup.Get("/:course_id/content/:content_id/discussions", middleware.JWTMiddleware, validators.DiscussionList(), controllers.GetContentDiscussions)
+	userGroup.Post("/:course_id/content/:content_id/discussions", middleware.JWTMiddleware, validators.CreateDiscussionThread(), controllers.CreateDiscussionThread)
+	userGroup.Get("/:course_id/discussion/:thread_id", middleware.JWTMiddleware, validators.DiscussionThread(), controllers.GetDiscussionThread)
+	userGroup.Delete("/:course_id/discussion/:thread_id", middleware.JWTMiddleware, validators.DiscussionThread(), controllers.DeleteDiscussionThread)
+	userGroup.Post("/:course_id/discussion/:thread_id/reply", middleware.JWTMiddleware, validators.ReplyDiscussion(), controllers.ReplyDiscussion)
+	userGroup.Post("/:course_id/discussion/:thread_id/resolve", middleware.JWTMiddleware, validators.ResolveDiscussion(), controllers.ResolveDiscussion)
+	userGroup.Delete("/:course_id/discussion/reply/:reply_id", middleware.JWTMiddleware, validators.DeleteDiscussionReply(), controllers.DeleteDiscussionReply)
+
 	// Progress tracking
 	userGroup.Get("/:course_id/progress", middleware.JWTMiddleware, validators.GetCourseProgress(), controllers.GetUserProgress)
 
@@ -39,4 +62,9 @@ func SetupCourseRoutes(app *fiber.App) {
 
 	// Certificate request
 	userGroup.Post("/:course_id/certificate/request", middleware.JWTMiddleware, validators.RequestCertificateValidator(), controllers.RequestCertificate)
+
+	// Public certificate verification (QR code target) and PDF download
+	certificateGroup := app.Group("/certificate")
+	certificateGroup.Get("/verify/:number", controllers.VerifyCertificate)
+	certificateGroup.Get("/download/:number", controllers.DownloadCertificate)
 }
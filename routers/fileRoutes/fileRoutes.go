@@ -0,0 +1,13 @@
+package fileRoutes
+
+import (
+	"fib/config"
+	filesController "fib/controllers/files"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupFileRoutes(app *fiber.App) {
+	// Signed downloads from the local blob store (authenticated by the URL signature)
+	app.Get(config.AppConfig.BlobURLPrefix+"/*", filesController.ServeFile)
+}
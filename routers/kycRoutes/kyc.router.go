@@ -0,0 +1,27 @@
+package kycRoutes
+
+import (
+	kycController "fib/controllers/kyc"
+	"fib/middleware"
+	kycValidator "fib/validators/kyc"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupKYCRoutes(app *fiber.App) {
+	// Aadhaar OTP and PAN-Aadhaar link checks stay under /user
+	kycGroup := app.Group("/kyc")
+
+	kycGroup.Get("/status", middleware.JWTMiddleware, kycController.GetMyKYC)
+	kycGroup.Post("/document", middleware.JWTMiddleware, kycValidator.UploadDocument(), kycController.UploadDocument)
+	kycGroup.Get("/document/:id", middleware.JWTMiddleware, kycController.GetMyDocument)
+	kycGroup.Post("/submit", middleware.JWTMiddleware, kycController.SubmitKYC)
+
+	adminGroup := app.Group("/admin/kyc")
+
+	adminGroup.Get("/list", kycValidator.ListKYC(), middleware.JWTMiddleware, kycController.ListKYC)
+	adminGroup.Get("/document/:id", middleware.JWTMiddleware, kycController.GetDocument)
+	adminGroup.Post("/approve", kycValidator.ReviewKYC(), middleware.JWTMiddleware, kycController.ApproveKYC)
+	adminGroup.Post("/reject", kycValidator.ReviewKYC(), middleware.JWTMiddleware, kycController.RejectKYC)
+	adminGroup.Get("/:userId", middleware.JWTMiddleware, kycController.GetKYCDetails)
+}
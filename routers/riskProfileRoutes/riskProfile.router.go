@@ -0,0 +1,24 @@
+package riskProfileRoutes
+
+import (
+	riskProfileController "fib/controllers/riskProfile"
+	"fib/middleware"
+	riskProfileValidator "fib/validators/riskProfile"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupRiskProfileRoutes(app *fiber.App) {
+	riskGroup := app.Group("/risk-profile")
+
+	riskGroup.Get("/questionnaire", middleware.JWTMiddleware, riskProfileController.GetQuestionnaire)
+	riskGroup.Post("/submit", riskProfileValidator.SubmitRiskProfile(), middleware.JWTMiddleware, riskProfileController.SubmitRiskProfile)
+	riskGroup.Get("/", middleware.JWTMiddleware, riskProfileController.GetMyRiskProfile)
+
+	adminGroup := app.Group("/admin/risk-profile")
+
+	adminGroup.Get("/questions", middleware.JWTMiddleware, riskProfileController.ListRiskQuestions)
+	adminGroup.Post("/question", riskProfileValidator.SaveRiskQuestion(), middleware.JWTMiddleware, riskProfileController.SaveRiskQuestion)
+	adminGroup.Delete("/question/:id", middleware.JWTMiddleware, riskProfileController.DeleteRiskQuestion)
+	adminGroup.Get("/user/:userId", middleware.JWTMiddleware, riskProfileController.GetUserRiskProfiles)
+}
@@ -0,0 +1,20 @@
+package smsRoutes
+
+import (
+	smsController "fib/controllers/sms"
+	"fib/middleware"
+	smsValidator "fib/validators/sms"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupSMSRoutes(app *fiber.App) {
+	// Provider delivery report callbacks (authenticated via shared secret)
+	app.Post("/sms/delivery-report/:provider", smsController.DeliveryReport)
+
+	adminGroup := app.Group("/admin/sms")
+
+	adminGroup.Get("/templates", middleware.JWTMiddleware, smsController.ListTemplates)
+	adminGroup.Post("/template", smsValidator.UpsertTemplate(), middleware.JWTMiddleware, smsController.UpsertTemplate)
+	adminGroup.Get("/logs", smsValidator.ListLogs(), middleware.JWTMiddleware, smsController.ListLogs)
+}
@@ -20,4 +20,11 @@ func SetupSuperAdminRoutes(app *fiber.App) {
 	adminGroup.Get("/user/stats", middleware.JWTMiddleware, superAdminController.UserStats)
 	adminGroup.Get("/permission", superAdminValidator.PermissionByUserID(), middleware.JWTMiddleware, superAdminController.PermissionsByUserID)
 	adminGroup.Post("/create-maintenance", superAdminValidator.ValidateMaintenance(), middleware.JWTMiddleware, superAdminController.CreateMaintenance)
+
+	// Login security
+	adminGroup.Get("/security/locked-accounts", superAdminValidator.SecurityAccountList(), middleware.JWTMiddleware, superAdminController.LockedAccounts)
+	adminGroup.Get("/security/flagged-accounts", superAdminValidator.SecurityAccountList(), middleware.JWTMiddleware, superAdminController.FlaggedAccounts)
+	adminGroup.Get("/security/suspicious-logins", superAdminValidator.SecurityAccountList(), middleware.JWTMiddleware, superAdminController.SuspiciousLogins)
+	adminGroup.Post("/security/unlock", superAdminValidator.SecurityAccountAction(), middleware.JWTMiddleware, superAdminController.UnlockAccount)
+	adminGroup.Post("/security/clear-flag", superAdminValidator.SecurityAccountAction(), middleware.JWTMiddleware, superAdminController.ClearAccountFlag)
 }
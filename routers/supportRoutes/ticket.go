@@ -16,7 +16,16 @@ func SetupSupportRoutes(app *fiber.App) {
 	support.Get("/admin-list", validator.AdminTicketList(), middleware.JWTMiddleware, controller.AdminTicketList)
 	support.Get("/admin-stats", middleware.JWTMiddleware, controller.AdminSupportStats)
 	support.Post("/admin-replay", validator.AdminReplyTicket(), middleware.JWTMiddleware, controller.AdminReplyTicket)
-	support.Post("/user-replay", validator.AdminReplyTicket(), middleware.JWTMiddleware, controller.UserReplyTicket)
+	support.Post("/user-replay", validator.UserReplyTicket(), middleware.JWTMiddleware, controller.UserReplyTicket)
+	support.Get("/ticket/:id/messages", validator.TicketMessages(), middleware.JWTMiddleware, controller.GetTicketMessages)
+	support.Get("/admin-ticket/:id/messages", validator.TicketMessages(), middleware.JWTMiddleware, controller.AdminGetTicketMessages)
 	support.Post("/user-close-ticket", validator.CloseTicket(), middleware.JWTMiddleware, controller.UserCloseTicket)
 	support.Post("/admin-close-ticket", validator.CloseTicket(), middleware.JWTMiddleware, controller.AdminCloseTicket)
+
+	// Assignment and SLA
+	support.Post("/admin-assign", validator.AssignTicket(), middleware.JWTMiddleware, controller.AdminAssignTicket)
+	support.Get("/admin-agents", middleware.JWTMiddleware, controller.AdminGetSupportAgents)
+	support.Post("/admin-agents", validator.SaveSupportAgent(), middleware.JWTMiddleware, controller.AdminSaveSupportAgent)
+	support.Get("/admin-sla", middleware.JWTMiddleware, controller.AdminGetSLAPolicies)
+	support.Post("/admin-sla", validator.SaveSLAPolicy(), middleware.JWTMiddleware, controller.AdminSaveSLAPolicy)
 }
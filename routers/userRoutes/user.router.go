@@ -12,6 +12,11 @@ func SetupUserRoutes(app *fiber.App) {
 	userGroup := app.Group("/user")
 
 	userGroup.Post("/add/bank/account", userPorfileValidator.AddBankAccount(), middleware.JWTMiddleware, userProfileController.AddBankAccount)
+	userGroup.Get("/bank/accounts", middleware.JWTMiddleware, userProfileController.ListBankAccounts)
+	userGroup.Put("/bank/account", userPorfileValidator.UpdateBankAccount(), middleware.JWTMiddleware, userProfileController.UpdateBankAccount)
+	userGroup.Post("/bank/account/primary", userPorfileValidator.BankAccountAction(), middleware.JWTMiddleware, userProfileController.SetPrimaryBankAccount)
+	userGroup.Post("/bank/account/reverify", userPorfileValidator.BankAccountAction(), middleware.JWTMiddleware, userProfileController.ReverifyBankAccount)
+	userGroup.Delete("/bank/account/:id", middleware.JWTMiddleware, userProfileController.DeleteBankAccount)
 	userGroup.Post("/send/adhar/otp", userPorfileValidator.SendAdharOtp(), middleware.JWTMiddleware, userProfileController.SendAdharOtp)
 	userGroup.Post("/verify/adhar/otp", userPorfileValidator.VerifyAdharOtp(), middleware.JWTMiddleware, userProfileController.VerifyAdharOtp)
 	userGroup.Post("/pan/adhar/link/status", middleware.JWTMiddleware, userProfileController.PanLinkStatus)
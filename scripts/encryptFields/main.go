@@ -0,0 +1,133 @@
+// encryptFields encrypts sensitive columns stored in plaintext, re-encrypts values written with an
+// older key version and backfills their blind indexes. It is safe to run repeatedly.
+//
+// Run from the project root after deploying the encryption change, and again after rotating keys:
+//
+//	go run ./scripts/encryptFields           # encrypt / rotate
+//	go run ./scripts/encryptFields -dry-run  # only report what would change
+package main
+
+import (
+	"fib/config"
+	"fib/database"
+	"fib/encryption"
+	"flag"
+	"log"
+)
+
+// encryptedColumn is a table column encrypted by the "encrypted" serializer
+type encryptedColumn struct {
+	Table       string
+	Column      string
+	IndexColumn string // Blind index column, empty when the column has none
+}
+
+var encryptedColumns = []encryptedColumn{
+	{Table: "aadhar_details", Column: "aadhar_number", IndexColumn: "aadhar_number_index"},
+	{Table: "pan_details", Column: "pan_number", IndexColumn: "pan_number_index"},
+	{Table: "bank_details", Column: "account_no", IndexColumn: "account_no_index"},
+	{Table: "users", Column: "pan_number"},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report rows that need encrypting without changing them")
+	batchSize := flag.Int("batch", 500, "rows read per batch")
+	flag.Parse()
+
+	// Load config and connect to database (runs migrations, so index columns exist)
+	config.LoadConfig()
+	database.ConnectDb()
+
+	log.Printf("Active key version: %s", encryption.ActiveKeyVersion())
+
+	failed := false
+	totalFailed := 0
+	for _, col := range encryptedColumns {
+		updated, skipped, rowsFailed, err := migrateColumn(col, *batchSize, *dryRun)
+		if err != nil {
+			log.Printf("%s.%s: %v", col.Table, col.Column, err)
+			failed = true
+		}
+		totalFailed += rowsFailed
+		log.Printf("%s.%s: %d updated, %d already current, %d failed", col.Table, col.Column, updated, skipped, rowsFailed)
+	}
+
+	if *dryRun {
+		log.Println("=== Dry run, nothing was written ===")
+	} else {
+		log.Println("=== Encryption migration complete ===")
+	}
+	if failed || totalFailed > 0 {
+		log.Fatalf("%d rows could not be migrated, see errors above", totalFailed)
+	}
+}
+
+// migrateColumn walks a column in id order and rewrites values that are plaintext,
+// use an old key version or have a stale blind index. Rows that fail to decrypt or update are counted in failed.
+func migrateColumn(col encryptedColumn, batchSize int, dryRun bool) (updated int, skipped int, failed int, err error) {
+	db := database.Database.Db
+
+	selectCols := "id, " + col.Column + " AS value"
+	if col.IndexColumn != "" {
+		selectCols += ", " + col.IndexColumn + " AS blind_index"
+	}
+
+	var lastID uint
+	for {
+		var rows []struct {
+			ID         uint
+			Value      string
+			BlindIndex *string
+		}
+		if err := db.Table(col.Table).Select(selectCols).
+			Where("id > ? AND "+col.Column+" IS NOT NULL AND "+col.Column+" <> ''", lastID).
+			Order("id ASC").Limit(batchSize).
+			Scan(&rows).Error; err != nil {
+			return updated, skipped, failed, err
+		}
+		if len(rows) == 0 {
+			return updated, skipped, failed, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+
+			plaintext, err := encryption.Decrypt(row.Value)
+			if err != nil {
+				log.Printf("%s id=%d: failed to decrypt: %v", col.Table, row.ID, err)
+				failed++
+				continue
+			}
+
+			updates := map[string]interface{}{}
+			if encryption.KeyVersion(row.Value) != encryption.ActiveKeyVersion() {
+				ciphertext, err := encryption.Encrypt(plaintext)
+				if err != nil {
+					return updated, skipped, failed, err
+				}
+				updates[col.Column] = ciphertext
+			}
+			if col.IndexColumn != "" {
+				index := encryption.BlindIndex(plaintext)
+				if row.BlindIndex == nil || index == nil || *row.BlindIndex != *index {
+					updates[col.IndexColumn] = index
+				}
+			}
+
+			if len(updates) == 0 {
+				skipped++
+				continue
+			}
+
+			if !dryRun {
+				// UpdateColumns on the raw table skips the serializer so values aren't encrypted twice
+				if err := db.Table(col.Table).Where("id = ?", row.ID).UpdateColumns(updates).Error; err != nil {
+					log.Printf("%s id=%d: failed to update: %v", col.Table, row.ID, err)
+					failed++
+					continue
+				}
+			}
+			updated++
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package storage stores uploaded files behind a BlobStore so they can live on local disk or in an
+// S3-compatible bucket (AWS S3, MinIO). Files are content-addressed and served through short-lived signed URLs.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fib/config"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("blob not found")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrFileTypeInvalid = errors.New("file type is not allowed")
+	ErrInvalidKey      = errors.New("invalid blob key")
+)
+
+// BlobStore is implemented by every storage backend. Keys are slash separated paths such as "amc/logos/ab/abcd….png".
+type BlobStore interface {
+	// Put stores size bytes from r under key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the blob's content, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the blob until it expires
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultStore BlobStore
+)
+
+// Default returns the store selected by BLOB_BACKEND (local or s3)
+func Default() BlobStore {
+	defaultOnce.Do(func() {
+		cfg := config.AppConfig
+		switch strings.ToLower(cfg.BlobBackend) {
+		case "s3":
+			defaultStore = NewS3Store(S3Config{
+				Endpoint:     cfg.S3Endpoint,
+				Region:       cfg.S3Region,
+				Bucket:       cfg.S3Bucket,
+				AccessKey:    cfg.S3AccessKey,
+				SecretKey:    cfg.S3SecretKey,
+				UsePathStyle: cfg.S3UsePathStyle,
+			})
+			log.Printf("Blob storage uses S3 bucket %s", cfg.S3Bucket)
+		default:
+			defaultStore = NewLocalStore(cfg.BlobLocalDir, cfg.BlobURLPrefix, signingKey())
+			log.Printf("Blob storage uses local directory %s", cfg.BlobLocalDir)
+		}
+	})
+	return defaultStore
+}
+
+// SignedURLExpiry is how long download URLs handed to clients stay valid
+func SignedURLExpiry() time.Duration {
+	return time.Duration(config.AppConfig.BlobSignedURLSeconds) * time.Second
+}
+
+// URL returns a signed download URL for a stored key, or "" for an empty key or on error
+func URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	url, err := Default().SignedURL(key, SignedURLExpiry())
+	if err != nil {
+		log.Printf("Failed to sign URL for %s: %v", key, err)
+		return ""
+	}
+	return url
+}
+
+// UploadRules limits what an upload may contain
+type UploadRules struct {
+	MaxBytes     int64
+	AllowedTypes map[string]string // Sniffed content type => stored file extension
+}
+
+// Upload rules shared by handlers
+var (
+	ImageRules = UploadRules{
+		MaxBytes: 5 << 20,
+		AllowedTypes: map[string]string{
+			"image/jpeg": ".jpg",
+			"image/png":  ".png",
+			"image/webp": ".webp",
+		},
+	}
+	DocumentRules = UploadRules{
+		MaxBytes: 10 << 20,
+		AllowedTypes: map[string]string{
+			"image/jpeg":      ".jpg",
+			"image/png":       ".png",
+			"application/pdf": ".pdf",
+		},
+	}
+)
+
+// StoredFile describes a saved upload
+type StoredFile struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// SaveUpload validates a multipart upload by size and sniffed content type and stores it under
+// prefix with a content-addressed name, so identical files share a key and names never collide.
+func SaveUpload(ctx context.Context, file *multipart.FileHeader, prefix string, rules UploadRules) (*StoredFile, error) {
+	if rules.MaxBytes > 0 && file.Size > rules.MaxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// Read at most MaxBytes+1 so a lying Content-Length can't exhaust memory
+	limit := rules.MaxBytes
+	if limit <= 0 {
+		limit = file.Size
+	}
+	data, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrFileTooLarge
+	}
+
+	// Trust the bytes, not the client's filename or Content-Type
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	ext, ok := rules.AllowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFileTypeInvalid, contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	key := path.Join(strings.Trim(prefix, "/"), digest[:2], digest+ext)
+
+	if err := Default().Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, err
+	}
+
+	return &StoredFile{Key: key, ContentType: contentType, Size: int64(len(data)), SHA256: digest}, nil
+}
+
+// cleanKey rejects keys that could escape the store's root
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
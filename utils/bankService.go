@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// nameTitles are honorifics ignored when comparing names
+var nameTitles = map[string]bool{
+	"MR": true, "MRS": true, "MS": true, "MISS": true, "DR": true,
+	"SHRI": true, "SMT": true, "KUMARI": true, "KU": true, "MASTER": true,
+}
+
+// nameTokens uppercases a name, drops punctuation and titles and splits it into words
+func nameTokens(name string) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return ' '
+	}, name)
+
+	var tokens []string
+	for _, token := range strings.Fields(cleaned) {
+		if !nameTitles[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// similarity returns 0-1, where 1 means the strings are identical
+func similarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// NameMatchScore scores how likely two names belong to the same person, 0-100.
+// Word order, titles and punctuation are ignored, initials match the word they abbreviate
+// ("R. K. SHARMA" vs "Rajesh Kumar Sharma") and small spelling differences are tolerated.
+func NameMatchScore(a, b string) int {
+	tokensA, tokensB := nameTokens(a), nameTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+	if len(tokensA) > len(tokensB) {
+		tokensA, tokensB = tokensB, tokensA
+	}
+
+	// Match each word of the shorter name to its best unused word in the longer one
+	used := make([]bool, len(tokensB))
+	matched := 0.0
+	for _, ta := range tokensA {
+		best, bestIdx := 0.0, -1
+		for i, tb := range tokensB {
+			if used[i] {
+				continue
+			}
+			var score float64
+			switch {
+			case ta == tb:
+				score = 1
+			case (len(ta) == 1 || len(tb) == 1) && ta[0] == tb[0]:
+				score = 0.8
+			default:
+				if s := similarity(ta, tb); s >= 0.75 {
+					score = s
+				}
+			}
+			if score > best {
+				best, bestIdx = score, i
+			}
+		}
+		if bestIdx >= 0 {
+			used[bestIdx] = true
+			matched += best
+		}
+	}
+
+	// A missing middle name costs less than a wrong surname, so weight by the shorter name
+	// and take a small penalty for each extra word in the longer one
+	tokenScore := matched/float64(len(tokensA)) - 0.1*float64(len(tokensB)-len(tokensA))
+
+	// Also compare the names as a whole, which catches words that were split or joined
+	sortedA := append([]string(nil), tokensA...)
+	sortedB := append([]string(nil), tokensB...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	wholeScore := similarity(strings.Join(sortedA, ""), strings.Join(sortedB, ""))
+
+	score := max(tokenScore, wholeScore)
+	if score < 0 {
+		score = 0
+	}
+	return int(score*100 + 0.5)
+}
+
+// KYCName returns the user's verified identity name, preferring Aadhaar, then PAN, then the profile name
+func KYCName(userID uint) string {
+	db := database.Database.Db
+
+	var kyc models.UserKYC
+	if err := db.Preload("Aadhar").Preload("Pan").Where("user_id = ? AND is_deleted = false", userID).First(&kyc).Error; err == nil {
+		if kyc.Aadhar.Name != "" {
+			return kyc.Aadhar.Name
+		}
+		if kyc.Pan.Name != "" {
+			return kyc.Pan.Name
+		}
+	}
+
+	var user models.User
+	db.Select("name").Where("id = ?", userID).First(&user)
+	return user.Name
+}
+
+// ResetBankVerification marks an account for a fresh penny-drop, e.g. after its details changed
+func ResetBankVerification(bank *models.BankDetails) {
+	bank.IsVerified = false
+	bank.VerifiedAt = nil
+	bank.VerificationStatus = models.BankVerificationPending
+	bank.VerificationRef = ""
+	bank.VerificationMessage = ""
+	bank.VerificationAttempts = 0
+	bank.NameAtBank = ""
+	bank.NameMatchScore = 0
+}
+
+// QueueBankVerification runs the penny-drop for an account in the background
+func QueueBankVerification(bankID uint) {
+	go VerifyBankAccount(bankID)
+}
+
+// VerifyBankAccount runs the penny-drop for a PENDING account and records the outcome.
+// Provider errors leave the account PENDING for the scheduler to retry until BankVerificationMaxRetries.
+func VerifyBankAccount(bankID uint) {
+	db := database.Database.Db
+
+	// Claim the account so concurrent runs don't send two penny-drops
+	now := time.Now()
+	claim := db.Model(&models.BankDetails{}).
+		Where("id = ? AND verification_status = ? AND is_deleted = false", bankID, models.BankVerificationPending).
+		Updates(map[string]interface{}{"verification_status": models.BankVerificationVerifying, "last_verification_at": now})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	var bank models.BankDetails
+	if err := db.First(&bank, bankID).Error; err != nil {
+		log.Printf("Bank verification: account %d not found: %v", bankID, err)
+		return
+	}
+
+	var user models.User
+	db.Select("id", "mobile").Where("id = ?", bank.UserID).First(&user)
+
+	verifier := GetBankVerifier()
+	bank.VerificationProvider = verifier.Name()
+	bank.VerificationAttempts++
+
+	result, err := verifier.PennyDrop(bank.AccountNo, bank.IFSCCode, bank.HolderName, user.Mobile)
+	if err != nil {
+		log.Printf("Bank verification: penny-drop for account %d failed: %v", bankID, err)
+		if bank.VerificationAttempts >= config.AppConfig.BankVerificationMaxRetries {
+			bank.VerificationStatus = models.BankVerificationFailed
+			bank.VerificationMessage = "Bank verification is unavailable, please try again later"
+		} else {
+			bank.VerificationStatus = models.BankVerificationPending
+		}
+		saveBankVerification(&bank)
+		return
+	}
+
+	bank.VerificationRef = result.Reference
+	bank.VerificationMessage = result.Message
+	bank.NameAtBank = result.NameAtBank
+
+	switch {
+	case !result.AccountExists:
+		bank.VerificationStatus = models.BankVerificationFailed
+	default:
+		bank.NameMatchScore = NameMatchScore(result.NameAtBank, KYCName(bank.UserID))
+		if bank.NameMatchScore >= config.AppConfig.BankNameMatchMinScore {
+			bank.VerificationStatus = models.BankVerificationVerified
+			bank.IsVerified = true
+			verifiedAt := time.Now()
+			bank.VerifiedAt = &verifiedAt
+		} else {
+			bank.VerificationStatus = models.BankVerificationMismatch
+		}
+	}
+
+	saveBankVerification(&bank)
+}
+
+// saveBankVerification records the outcome of a penny-drop. Only the verification columns are written, and only
+// while the account is still claimed, so an account deleted or edited during the call isn't brought back.
+func saveBankVerification(bank *models.BankDetails) {
+	result := database.Database.Db.Model(&models.BankDetails{}).
+		Where("id = ? AND verification_status = ? AND is_deleted = false", bank.ID, models.BankVerificationVerifying).
+		Updates(map[string]interface{}{
+			"verification_status":   bank.VerificationStatus,
+			"verification_provider": bank.VerificationProvider,
+			"verification_ref":      bank.VerificationRef,
+			"verification_message":  bank.VerificationMessage,
+			"verification_attempts": bank.VerificationAttempts,
+			"name_at_bank":          bank.NameAtBank,
+			"name_match_score":      bank.NameMatchScore,
+			"is_verified":           bank.IsVerified,
+			"verified_at":           bank.VerifiedAt,
+		})
+	if result.Error != nil {
+		log.Printf("Bank verification: failed to save account %d: %v", bank.ID, result.Error)
+	} else if result.RowsAffected == 0 {
+		log.Printf("Bank verification: account %d changed during verification, result dropped", bank.ID)
+	}
+}
+
+// SetPrimaryBankAccount makes an account the user's payout account and unsets any other primary
+func SetPrimaryBankAccount(tx *gorm.DB, userID, bankID uint) error {
+	if err := tx.Model(&models.BankDetails{}).
+		Where("user_id = ? AND id <> ? AND is_primary = true", userID, bankID).
+		Update("is_primary", false).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.BankDetails{}).Where("id = ? AND user_id = ?", bankID, userID).Update("is_primary", true).Error; err != nil {
+		return err
+	}
+	// users.bank_details keeps pointing at the payout account for older readers
+	return tx.Model(&models.User{}).Where("id = ?", userID).Update("bank_details", bankID).Error
+}
+
+// GetPayoutBankAccount returns the user's primary account if it is verified
+func GetPayoutBankAccount(userID uint) (*models.BankDetails, error) {
+	var bank models.BankDetails
+	err := database.Database.Db.
+		Where("user_id = ? AND is_primary = true AND is_verified = true AND is_deleted = false", userID).
+		First(&bank).Error
+	if err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// InitializeBankVerificationScheduler retries penny-drops that hit provider errors or were interrupted
+func InitializeBankVerificationScheduler() {
+	c := cron.New()
+
+	_, err := c.AddFunc("*/10 * * * *", func() {
+		db := database.Database.Db
+
+		// Runs interrupted by a restart stay VERIFYING, hand them back to the queue
+		db.Model(&models.BankDetails{}).
+			Where("verification_status = ? AND last_verification_at < ?", models.BankVerificationVerifying, time.Now().Add(-30*time.Minute)).
+			Update("verification_status", models.BankVerificationPending)
+
+		var pending []models.BankDetails
+		db.Select("id").
+			Where("verification_status = ? AND is_deleted = false AND verification_attempts < ?", models.BankVerificationPending, config.AppConfig.BankVerificationMaxRetries).
+			Where("last_verification_at IS NULL OR last_verification_at < ?", time.Now().Add(-5*time.Minute)).
+			Find(&pending)
+
+		for _, bank := range pending {
+			VerifyBankAccount(bank.ID)
+		}
+	})
+	if err != nil {
+		log.Printf("Error scheduling bank verification retries: %v", err)
+		return
+	}
+
+	c.Start()
+	log.Println("Bank verification scheduler started - running every 10 minutes")
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"encoding/json"
+	"fib/config"
+	"fib/encryption"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PennyDropResult is what a provider learnt about an account from a ₹1 credit
+type PennyDropResult struct {
+	AccountExists bool
+	NameAtBank    string
+	Reference     string // Provider transaction ID or UTR
+	Message       string
+}
+
+// BankVerifier is implemented by every penny-drop backend
+type BankVerifier interface {
+	// Name returns the provider key stored on BankDetails (sandbox, fake)
+	Name() string
+	// PennyDrop credits a small amount to the account and reports the beneficiary name.
+	// An error means the check could not be made and should be retried.
+	PennyDrop(accountNo, ifscCode, holderName, mobile string) (*PennyDropResult, error)
+}
+
+// SandboxBankVerifier runs penny-drops through the Sandbox (credpay) bank account API
+type SandboxBankVerifier struct{}
+
+func (v *SandboxBankVerifier) Name() string { return "sandbox" }
+
+func (v *SandboxBankVerifier) PennyDrop(accountNo, ifscCode, holderName, mobile string) (*PennyDropResult, error) {
+	authToken, err := SandboxAuthToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token: %v", err)
+	}
+
+	endpoint := fmt.Sprintf(
+		"%sbank/%s/accounts/%s/verify?name=%s&mobile=%s",
+		config.AppConfig.SandboxApiURL,
+		url.PathEscape(ifscCode),
+		url.PathEscape(accountNo),
+		url.QueryEscape(holderName),
+		url.QueryEscape(mobile),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Authorization", authToken)
+	req.Header.Set("x-api-key", config.AppConfig.SandboxApiKey)
+	req.Header.Set("x-api-version", "2.0")
+	req.Header.Set("x-accept-cache", "true")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("bank verification provider returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var verifyResp struct {
+		Code          int    `json:"code"`
+		TransactionID string `json:"transaction_id"`
+		Data          struct {
+			Message       string `json:"message"`
+			AccountExists bool   `json:"account_exists"`
+			NameAtBank    string `json:"name_at_bank"`
+			Utr           string `json:"utr"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &verifyResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	reference := verifyResp.Data.Utr
+	if reference == "" {
+		reference = verifyResp.TransactionID
+	}
+
+	return &PennyDropResult{
+		AccountExists: verifyResp.Code == http.StatusOK && verifyResp.Data.AccountExists,
+		NameAtBank:    verifyResp.Data.NameAtBank,
+		Reference:     reference,
+		Message:       verifyResp.Data.Message,
+	}, nil
+}
+
+// FakeBankVerifier is a stub for development; it never leaves the process.
+// Account numbers ending in 0000 don't exist, ending in 9999 belong to someone else.
+type FakeBankVerifier struct{}
+
+func (v *FakeBankVerifier) Name() string { return "fake" }
+
+func (v *FakeBankVerifier) PennyDrop(accountNo, ifscCode, holderName, mobile string) (*PennyDropResult, error) {
+	reference := fmt.Sprintf("FAKE%d", time.Now().UnixNano()%1000000000)
+	log.Printf("[FAKE-BANK] Penny-drop to %s (%s), reference %s", encryption.MaskAccountNo(accountNo), ifscCode, reference)
+
+	switch {
+	case strings.HasSuffix(accountNo, "0000"):
+		return &PennyDropResult{AccountExists: false, Reference: reference, Message: "Invalid account number"}, nil
+	case strings.HasSuffix(accountNo, "9999"):
+		return &PennyDropResult{AccountExists: true, NameAtBank: "SOMEONE ELSE", Reference: reference, Message: "Transaction Successful"}, nil
+	}
+	return &PennyDropResult{AccountExists: true, NameAtBank: strings.ToUpper(holderName), Reference: reference, Message: "Transaction Successful"}, nil
+}
+
+// GetBankVerifier returns the verifier for the configured BANK_VERIFIER
+func GetBankVerifier() BankVerifier {
+	switch strings.ToLower(config.AppConfig.BankVerifier) {
+	case "fake":
+		return &FakeBankVerifier{}
+	default:
+		return &SandboxBankVerifier{}
+	}
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"fib/models"
+	"fib/models/basket"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ComplianceResult is the outcome of one compliance rule
+type ComplianceResult struct {
+	RuleType   string   `json:"ruleType"`
+	BasketType string   `json:"basketType"` // Empty when the rule applies to every basket type
+	Severity   string   `json:"severity"`
+	Passed     bool     `json:"passed"`
+	Message    string   `json:"message"`
+	Offenders  []string `json:"offenders,omitempty"` // Stocks or sectors that broke the rule
+}
+
+// ComplianceReport is the per-rule pass/fail report stored on a basket version
+type ComplianceReport struct {
+	Passed    bool               `json:"passed"` // False when a BLOCK rule failed
+	Blocking  int                `json:"blocking"`
+	Warnings  int                `json:"warnings"`
+	CheckedAt time.Time          `json:"checkedAt"`
+	Results   []ComplianceResult `json:"results"`
+}
+
+// complianceRulesFor returns the active rules for a basket type, a type-specific rule replacing the catch-all one
+func complianceRulesFor(db *gorm.DB, basketType string) ([]basket.ComplianceRule, error) {
+	var rules []basket.ComplianceRule
+	if err := db.Where("is_active = ? AND basket_type IN ?", true, []string{"", basketType}).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	byType := make(map[string]basket.ComplianceRule)
+	for _, rule := range rules {
+		if existing, ok := byType[rule.RuleType]; ok && existing.BasketType != "" {
+			continue
+		}
+		byType[rule.RuleType] = rule
+	}
+
+	var ordered []basket.ComplianceRule
+	for _, ruleType := range basket.ComplianceRuleTypes {
+		if rule, ok := byType[ruleType]; ok {
+			ordered = append(ordered, rule)
+		}
+	}
+	return ordered, nil
+}
+
+// allowedSet parses a rule's comma separated AllowedValues
+func allowedSet(values string) map[string]bool {
+	set := make(map[string]bool)
+	for _, value := range strings.Split(values, ",") {
+		if value = strings.ToUpper(strings.TrimSpace(value)); value != "" {
+			set[value] = true
+		}
+	}
+	return set
+}
+
+// EvaluateBasketCompliance checks a version's stocks against the compliance rules of its basket type
+func EvaluateBasketCompliance(db *gorm.DB, basketType string, stocks []basket.BasketStock) (*ComplianceReport, error) {
+	rules, err := complianceRulesFor(db, basketType)
+	if err != nil {
+		return nil, err
+	}
+
+	stockIDs := make([]uint, len(stocks))
+	for i, stock := range stocks {
+		stockIDs[i] = stock.StockID
+	}
+	var masters []models.Stocks
+	if err := db.Where("id IN ?", stockIDs).Find(&masters).Error; err != nil {
+		return nil, err
+	}
+	masterByID := make(map[uint]models.Stocks)
+	for _, master := range masters {
+		masterByID[master.ID] = master
+	}
+
+	label := func(stock basket.BasketStock) string {
+		if stock.Symbol != "" {
+			return stock.Symbol
+		}
+		if master, ok := masterByID[stock.StockID]; ok {
+			return master.Symbol
+		}
+		return fmt.Sprintf("stock %d", stock.StockID)
+	}
+
+	report := &ComplianceReport{Passed: true, CheckedAt: time.Now(), Results: []ComplianceResult{}}
+	for _, rule := range rules {
+		result := ComplianceResult{RuleType: rule.RuleType, BasketType: rule.BasketType, Severity: rule.Severity}
+
+		switch rule.RuleType {
+		case basket.RuleWeightSum:
+			total := 0.0
+			for _, stock := range stocks {
+				total += stock.Weightage
+			}
+			result.Passed = math.Abs(total-100) <= rule.Threshold
+			result.Message = fmt.Sprintf("Weights add up to %.2f%%, expected 100%% ± %.2f", total, rule.Threshold)
+
+		case basket.RuleMaxStockCount:
+			result.Passed = float64(len(stocks)) <= rule.Threshold
+			result.Message = fmt.Sprintf("%d stocks, at most %.0f allowed", len(stocks), rule.Threshold)
+
+		case basket.RuleMaxStockWeight:
+			for _, stock := range stocks {
+				if stock.Weightage > rule.Threshold {
+					result.Offenders = append(result.Offenders, fmt.Sprintf("%s (%.2f%%)", label(stock), stock.Weightage))
+				}
+			}
+			result.Passed = len(result.Offenders) == 0
+			result.Message = fmt.Sprintf("%d stocks above the %.2f%% cap", len(result.Offenders), rule.Threshold)
+
+		case basket.RuleMaxSectorWeight:
+			sectors := make(map[string]float64)
+			for _, stock := range stocks {
+				sector := strings.TrimSpace(masterByID[stock.StockID].Sector)
+				if sector == "" {
+					sector = "Unclassified"
+				}
+				sectors[sector] += stock.Weightage
+			}
+			for sector, weight := range sectors {
+				if weight > rule.Threshold {
+					result.Offenders = append(result.Offenders, fmt.Sprintf("%s (%.2f%%)", sector, weight))
+				}
+			}
+			sort.Strings(result.Offenders)
+			result.Passed = len(result.Offenders) == 0
+			result.Message = fmt.Sprintf("%d sectors above the %.2f%% cap", len(result.Offenders), rule.Threshold)
+
+		case basket.RuleAllowedExchanges, basket.RuleAllowedSeries, basket.RuleAllowedInstruments:
+			allowed := allowedSet(rule.AllowedValues)
+			for _, stock := range stocks {
+				master := masterByID[stock.StockID]
+				var value string
+				switch rule.RuleType {
+				case basket.RuleAllowedExchanges:
+					value = master.ExchID
+					if value == "" {
+						value = master.Exchange // Stocks uploaded before the scrip master import
+					}
+				case basket.RuleAllowedSeries:
+					value = master.Series
+				default:
+					value = master.InstrumentType
+				}
+				value = strings.ToUpper(strings.TrimSpace(value))
+				if !allowed[value] {
+					if value == "" {
+						value = "unknown"
+					}
+					result.Offenders = append(result.Offenders, fmt.Sprintf("%s (%s)", label(stock), value))
+				}
+			}
+			result.Passed = len(result.Offenders) == 0
+			result.Message = fmt.Sprintf("%d stocks outside %s", len(result.Offenders), rule.AllowedValues)
+
+		case basket.RuleMinMarketCap:
+			for _, stock := range stocks {
+				// An unknown market cap fails, the rule can't vouch for the stock
+				if marketCap := masterByID[stock.StockID].MarketCap; marketCap < rule.Threshold || marketCap == 0 {
+					result.Offenders = append(result.Offenders, fmt.Sprintf("%s (%.2f)", label(stock), marketCap))
+				}
+			}
+			result.Passed = len(result.Offenders) == 0
+			result.Message = fmt.Sprintf("%d stocks below the %.2f market cap floor", len(result.Offenders), rule.Threshold)
+		}
+
+		if !result.Passed {
+			if rule.Severity == basket.SeverityWarn {
+				report.Warnings++
+			} else {
+				report.Blocking++
+				report.Passed = false
+			}
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
@@ -4,10 +4,12 @@ import (
 	"fib/database"
 	"fib/models"
 	"fib/models/basket"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
 )
 
 // logScheduler logs scheduler events with timestamp
@@ -23,6 +25,7 @@ func recordSystemHistory(versionID uint, action string, notes string) {
 		ActorID:         0, // System
 		ActorType:       basket.ActorSystem,
 		Comments:        notes,
+		Metadata:        "{}",
 	}
 	database.Database.Db.Create(&history)
 }
@@ -44,6 +47,7 @@ func processIntraHourBaskets() {
 	for _, slot := range scheduledSlots {
 		if slot.BasketVersion.Status == basket.StatusScheduled {
 			slot.BasketVersion.Status = basket.StatusPublished
+			slot.BasketVersion.PublishedAt = &now
 			slot.ActualPublishTime = &now
 
 			db.Save(&slot.BasketVersion)
@@ -79,6 +83,7 @@ func processIntraHourBaskets() {
 	for _, slot := range expiredSlots {
 		if slot.BasketVersion.ID > 0 && slot.BasketVersion.Status == basket.StatusPublished {
 			slot.BasketVersion.Status = basket.StatusExpired
+			slot.BasketVersion.ExpiredAt = &now
 			slot.ActualExpireTime = &now
 
 			db.Save(&slot.BasketVersion)
@@ -115,6 +120,7 @@ func processIntradayBaskets() {
 
 	for _, version := range versions {
 		version.Status = basket.StatusExpired
+		version.ExpiredAt = &now
 		db.Save(&version)
 
 		// Expire subscriptions
@@ -127,7 +133,191 @@ func processIntradayBaskets() {
 	}
 
 	logScheduler("Market close: processed INTRADAY basket expiry")
-	_ = now // Avoid unused variable warning
+}
+
+// BasketValuation prices a version's stocks at their live quote, falling back to the approval or creation price
+func BasketValuation(accessToken string, stocks []basket.BasketStock) float64 {
+	var total float64
+	for _, stock := range stocks {
+		if accessToken != "" && stock.Token > 0 {
+			if livePrice, err := GetBajajQuote(accessToken, stock.Token); err == nil && livePrice > 0 {
+				total += livePrice * float64(stock.Quantity)
+				continue
+			}
+		}
+		if stock.PriceAtApproval > 0 {
+			total += stock.PriceAtApproval * float64(stock.Quantity)
+		} else {
+			total += stock.PriceAtCreation * float64(stock.Quantity)
+		}
+	}
+	return total
+}
+
+// ExpireBasketVersion retires a published or scheduled version at its current valuation. It reports false when
+// the version was no longer live. Subscriptions are left to the caller.
+func ExpireBasketVersion(db *gorm.DB, version *basket.BasketVersion, accessToken string) (bool, error) {
+	now := time.Now()
+	price := BasketValuation(accessToken, version.Stocks)
+	result := db.Model(&basket.BasketVersion{}).
+		Where("id = ? AND status IN ?", version.ID, []string{basket.StatusPublished, basket.StatusScheduled}).
+		Updates(map[string]interface{}{
+			"status":          basket.StatusExpired,
+			"price_at_expiry": price,
+			"expired_at":      now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	version.Status = basket.StatusExpired
+	version.PriceAtExpiry = price
+	version.ExpiredAt = &now
+	return result.RowsAffected > 0, nil
+}
+
+// activeSubscriptions returns a basket's active subscriptions, or only those of one version when versionID is set
+func activeSubscriptions(db *gorm.DB, basketID, versionID uint) []basket.BasketSubscription {
+	query := db.Where("basket_id = ? AND status = ? AND is_deleted = false", basketID, basket.SubscriptionActive)
+	if versionID != 0 {
+		query = query.Where("basket_version_id = ?", versionID)
+	}
+	var subs []basket.BasketSubscription
+	query.Find(&subs)
+	return subs
+}
+
+// notifySubscribers emails the users of the given subscriptions
+func notifySubscribers(subs []basket.BasketSubscription, send func(email, name string)) {
+	for _, sub := range subs {
+		var u models.User
+		if err := database.Database.Db.Select("name, email").First(&u, sub.UserID).Error; err == nil && u.Email != "" {
+			send(u.Email, u.Name)
+		}
+	}
+}
+
+// PublishBasketVersion takes a scheduled version live, retiring the version it replaces, and tells subscribers
+func PublishBasketVersion(db *gorm.DB, version *basket.BasketVersion, accessToken string) (bool, error) {
+	now := time.Now()
+	var published bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&basket.BasketVersion{}).
+			Where("id = ? AND status = ? AND is_deleted = false", version.ID, basket.StatusScheduled).
+			Updates(map[string]interface{}{"status": basket.StatusPublished, "published_at": now})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		published = true
+
+		var previous []basket.BasketVersion
+		if err := tx.Preload("Stocks", "is_deleted = false").
+			Where("basket_id = ? AND id != ? AND status = ?", version.BasketID, version.ID, basket.StatusPublished).
+			Find(&previous).Error; err != nil {
+			return err
+		}
+		for i := range previous {
+			if _, err := ExpireBasketVersion(tx, &previous[i], accessToken); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&basket.Basket{}).Where("id = ?", version.BasketID).Update("current_version_id", version.ID).Error
+	})
+	if err != nil || !published {
+		return false, err
+	}
+	version.Status = basket.StatusPublished
+	version.PublishedAt = &now
+
+	recordSystemHistory(version.ID, basket.ActionWentLive, "Auto-published at scheduled time")
+	go notifySubscribers(activeSubscriptions(database.Database.Db, version.BasketID, 0), func(email, name string) {
+		SendNewVersionEmail(email, name, version.Basket.Name, version.VersionNumber)
+	})
+	return true, nil
+}
+
+// processScheduledBaskets handles SCHEDULED → PUBLISHED at publish_at and PUBLISHED → EXPIRED at expire_at
+// for DELIVERY and INTRADAY baskets
+func processScheduledBaskets() {
+	db := database.Database.Db
+	now := time.Now()
+
+	var bajajToken models.BajajAccessToken
+	db.Order("created_at DESC").First(&bajajToken)
+
+	// Auto-PUBLISH
+	var due []basket.BasketVersion
+	if err := db.Preload("Basket").
+		Joins("JOIN baskets ON baskets.id = basket_versions.basket_id").
+		Where("basket_versions.status = ? AND basket_versions.is_deleted = false AND basket_versions.publish_at <= ?", basket.StatusScheduled, now).
+		Where("baskets.basket_type <> ? AND baskets.is_deleted = false", basket.BasketTypeIntraHour).
+		Find(&due).Error; err != nil {
+		logScheduler("Error fetching scheduled versions: " + err.Error())
+		return
+	}
+	for i := range due {
+		if published, err := PublishBasketVersion(db, &due[i], bajajToken.Token); err != nil {
+			logScheduler(fmt.Sprintf("Error publishing basket version %d: %v", due[i].ID, err))
+		} else if published {
+			logScheduler(fmt.Sprintf("%s basket version %d auto-PUBLISHED", due[i].Basket.BasketType, due[i].ID))
+		}
+	}
+
+	// Auto-EXPIRE at sunset
+	var ending []basket.BasketVersion
+	if err := db.Preload("Basket").Preload("Stocks", "is_deleted = false").
+		Where("status = ? AND is_deleted = false AND expire_at <= ?", basket.StatusPublished, now).
+		Find(&ending).Error; err != nil {
+		logScheduler("Error fetching versions past their sunset: " + err.Error())
+		return
+	}
+	for i := range ending {
+		version := &ending[i]
+
+		// The version and its subscriptions expire together
+		var expired bool
+		var subs []basket.BasketSubscription
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			expired, err = ExpireBasketVersion(tx, version, bajajToken.Token)
+			if err != nil || !expired {
+				return err
+			}
+
+			// Subscriptions end with the version, or with the basket when nothing else is live or scheduled
+			var remaining int64
+			if err := tx.Model(&basket.BasketVersion{}).
+				Where("basket_id = ? AND status IN ? AND is_deleted = false", version.BasketID, []string{basket.StatusPublished, basket.StatusScheduled}).
+				Count(&remaining).Error; err != nil {
+				return err
+			}
+			subsVersionID := version.ID
+			if remaining == 0 {
+				subsVersionID = 0
+			}
+			subs = activeSubscriptions(tx, version.BasketID, subsVersionID)
+			subIDs := make([]uint, len(subs))
+			for j, sub := range subs {
+				subIDs[j] = sub.ID
+			}
+			return tx.Model(&basket.BasketSubscription{}).
+				Where("id IN ? AND status = ?", subIDs, basket.SubscriptionActive).
+				Update("status", basket.SubscriptionExpired).Error
+		})
+		if err != nil {
+			logScheduler(fmt.Sprintf("Error expiring basket version %d: %v", version.ID, err))
+			continue
+		}
+		if !expired {
+			continue
+		}
+
+		recordSystemHistory(version.ID, basket.ActionExpired, "Auto-expired at sunset")
+		logScheduler(fmt.Sprintf("%s basket version %d expired at sunset", version.Basket.BasketType, version.ID))
+
+		go notifySubscribers(subs, func(email, name string) {
+			SendBasketExpiredEmail(email, name, version.Basket.Name)
+		})
+	}
 }
 
 // StartIntraHourScheduler runs every minute for INTRA_HOUR baskets
@@ -148,6 +338,14 @@ func StartIntradayScheduler(c *cron.Cron) {
 	logScheduler("INTRADAY scheduler started - runs at 3:30 PM IST on weekdays")
 }
 
+// StartScheduledPublishScheduler runs every minute for scheduled go-live and sunset of the other basket types
+func StartScheduledPublishScheduler(c *cron.Cron) {
+	c.AddFunc("* * * * *", func() {
+		processScheduledBaskets()
+	})
+	logScheduler("Scheduled publish/expiry scheduler started - runs every minute")
+}
+
 // InitializeBasketSchedulers initializes all basket schedulers
 func InitializeBasketSchedulers() *cron.Cron {
 	logScheduler("Initializing basket schedulers...")
@@ -162,6 +360,7 @@ func InitializeBasketSchedulers() *cron.Cron {
 
 	StartIntraHourScheduler(c)
 	StartIntradayScheduler(c)
+	StartScheduledPublishScheduler(c)
 
 	c.Start()
 
@@ -0,0 +1,336 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	courseModels "fib/models/course"
+	"fib/storage"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/robfig/cron/v3"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCertificateExists is returned when the user already holds a certificate for the course
+var ErrCertificateExists = errors.New("certificate already issued")
+
+// CertificateEligibility explains whether a completed enrollment earns a certificate under its course's policy
+type CertificateEligibility struct {
+	Policy   string  `json:"policy"`
+	Eligible bool    `json:"eligible"`
+	Score    float64 `json:"score"`     // MCQ and quiz score in percent, see CourseAssessmentScore
+	MinScore float64 `json:"min_score"` // Required score, MIN_SCORE policy only
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// CertificateVerifyURL is the public page a certificate's QR code points to
+func CertificateVerifyURL(number string) string {
+	return strings.TrimRight(config.AppConfig.PublicBaseURL, "/") + "/certificate/verify/" + url.PathEscape(number)
+}
+
+// CertificateDownloadURL is the stable public link stored in Certificate.CertificateURL
+func CertificateDownloadURL(number string) string {
+	return strings.TrimRight(config.AppConfig.PublicBaseURL, "/") + "/certificate/download/" + url.PathEscape(number)
+}
+
+// CreateCertificate adds the certificate row for a user and course inside the caller's transaction.
+// Call DeliverCertificate after the transaction commits to render the PDF and email the user.
+func CreateCertificate(tx *gorm.DB, userID, courseID uint) (*courseModels.Certificate, error) {
+	var user models.User
+	if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	var course courseModels.Course
+	if err := tx.Where("id = ?", courseID).First(&course).Error; err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	tx.Model(&courseModels.Certificate{}).Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).Count(&existing)
+	if existing > 0 {
+		return nil, ErrCertificateExists
+	}
+
+	// The random part keeps numbers from being guessed on the public verify and download pages
+	now := time.Now()
+	number := fmt.Sprintf("CERT-%d-%d-%s", courseID, userID, strings.ToUpper(GenerateSecureToken(8)))
+	certificate := courseModels.Certificate{
+		UserID:            userID,
+		CourseID:          courseID,
+		CertificateNumber: number,
+		CertificateURL:    CertificateDownloadURL(number),
+		RecipientName:     user.Name,
+		CourseTitle:       course.Title,
+		IssuedAt:          now,
+	}
+	if err := tx.Create(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// CourseAssessmentScore returns the learner's assessment score across a course in percent. MCQs count only the
+// first attempt at each question so retrying until correct doesn't inflate it; quizzes count the best submitted
+// attempt. Courses without MCQs or quizzes score 100.
+func CourseAssessmentScore(db *gorm.DB, userID, courseID uint) (float64, error) {
+	var mcq struct {
+		Score    int64
+		MaxScore int64
+	}
+	err := db.Model(&courseModels.MCQAttempt{}).
+		Select("COALESCE(SUM(mcq_attempts.score), 0) AS score, COALESCE(SUM(mcq_attempts.max_score), 0) AS max_score").
+		Joins("JOIN course_contents ON course_contents.id = mcq_attempts.content_id").
+		Where("mcq_attempts.user_id = ? AND mcq_attempts.attempt_number = 1 AND mcq_attempts.is_deleted = ?", userID, false).
+		Where("course_contents.course_id = ? AND course_contents.content_type = ? AND course_contents.is_published = ? AND course_contents.is_deleted = ?", courseID, "MCQ", true, false).
+		Scan(&mcq).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var quiz struct {
+		Score    float64
+		MaxScore float64
+	}
+	err = db.Raw(`SELECT COALESCE(SUM(best.score), 0) AS score, COALESCE(SUM(best.max_score), 0) AS max_score FROM (
+			SELECT DISTINCT ON (quiz_attempts.quiz_id) quiz_attempts.score, quiz_attempts.max_score
+			FROM quiz_attempts
+			JOIN course_contents ON course_contents.id = quiz_attempts.content_id
+			WHERE quiz_attempts.user_id = ? AND quiz_attempts.course_id = ? AND quiz_attempts.status = ? AND quiz_attempts.is_deleted = ?
+				AND course_contents.is_published = ? AND course_contents.is_deleted = ?
+			ORDER BY quiz_attempts.quiz_id, quiz_attempts.score DESC
+		) AS best`, userID, courseID, courseModels.QuizAttemptSubmitted, false, true, false).
+		Scan(&quiz).Error
+	if err != nil {
+		return 0, err
+	}
+
+	maxScore := float64(mcq.MaxScore) + quiz.MaxScore
+	if maxScore == 0 {
+		return 100, nil
+	}
+	return (float64(mcq.Score) + quiz.Score) / maxScore * 100, nil
+}
+
+// CheckCertificateEligibility evaluates a completed enrollment against the course's issuance policy.
+// MANUAL courses are never eligible for automatic issuance.
+func CheckCertificateEligibility(db *gorm.DB, course *courseModels.Course, userID uint) (*CertificateEligibility, error) {
+	policy := course.CertificatePolicy
+	if policy == "" {
+		policy = courseModels.CertificatePolicyManual
+	}
+
+	score, err := CourseAssessmentScore(db, userID, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	eligibility := &CertificateEligibility{Policy: policy, Score: score}
+	switch policy {
+	case courseModels.CertificatePolicyAuto:
+		eligibility.Eligible = true
+	case courseModels.CertificatePolicyMinScore:
+		eligibility.MinScore = course.CertificateMinScore
+		eligibility.Eligible = score >= course.CertificateMinScore
+		if !eligibility.Eligible {
+			eligibility.Reason = fmt.Sprintf("Assessment score %.0f%% is below the required %.0f%%", score, course.CertificateMinScore)
+		}
+	default:
+		eligibility.Reason = "Certificates for this course are issued after admin review"
+	}
+	return eligibility, nil
+}
+
+// IssueCertificateOnCompletion issues a certificate for a completed enrollment when the course's policy allows it
+// without review. It returns the new certificate, or nil with the reason when none was issued.
+func IssueCertificateOnCompletion(userID, courseID uint) (*courseModels.Certificate, *CertificateEligibility, error) {
+	var certificate *courseModels.Certificate
+	var eligibility *CertificateEligibility
+
+	err := database.Database.Db.Transaction(func(tx *gorm.DB) error {
+		// Lock the enrollment so concurrent completions can't issue twice
+		var enrollment courseModels.Enrollment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND course_id = ? AND is_deleted = ?", userID, courseID, false).
+			First(&enrollment).Error; err != nil {
+			return err
+		}
+		if enrollment.Status != "COMPLETED" {
+			eligibility = &CertificateEligibility{Reason: "Course is not completed"}
+			return nil
+		}
+
+		var course courseModels.Course
+		if err := tx.Where("id = ? AND is_deleted = ?", courseID, false).First(&course).Error; err != nil {
+			return err
+		}
+
+		var err error
+		eligibility, err = CheckCertificateEligibility(tx, &course, userID)
+		if err != nil || !eligibility.Eligible {
+			return err
+		}
+
+		certificate, err = CreateCertificate(tx, userID, courseID)
+		return err
+	})
+	if err != nil {
+		return nil, eligibility, err
+	}
+
+	if certificate != nil {
+		go DeliverCertificate(certificate)
+	}
+	return certificate, eligibility, nil
+}
+
+// DeliverCertificate renders and stores the certificate PDF, then emails the user. Meant to run in the background;
+// if rendering fails RenderMissingCertificates tries again.
+func DeliverCertificate(certificate *courseModels.Certificate) {
+	if err := GenerateCertificatePDF(database.Database.Db, certificate); err != nil {
+		log.Printf("Failed to generate certificate %s: %v", certificate.CertificateNumber, err)
+	}
+
+	var user models.User
+	if err := database.Database.Db.Where("id = ?", certificate.UserID).First(&user).Error; err != nil {
+		return
+	}
+	SendCertificateEmail(user.Email, certificate.RecipientName, certificate.CourseTitle, certificate.CertificateNumber, certificate.CertificateURL)
+}
+
+// RenderMissingCertificates renders the PDFs of certificates whose rendering failed at issuance, and of certificates
+// issued before PDFs existed
+func RenderMissingCertificates() {
+	var certificates []courseModels.Certificate
+	if err := database.Database.Db.Where("(file_key = '' OR file_key IS NULL) AND is_deleted = ?", false).
+		Order("id ASC").Limit(100).Find(&certificates).Error; err != nil {
+		log.Printf("[CERTIFICATES] Error fetching certificates without a PDF: %v", err)
+		return
+	}
+	for i := range certificates {
+		if err := GenerateCertificatePDF(database.Database.Db, &certificates[i]); err != nil {
+			log.Printf("[CERTIFICATES] Failed to generate certificate %s: %v", certificates[i].CertificateNumber, err)
+		}
+	}
+}
+
+// InitializeCertificateScheduler renders missing certificate PDFs every 10 minutes
+func InitializeCertificateScheduler() {
+	c := cron.New()
+	c.AddFunc("*/10 * * * *", RenderMissingCertificates)
+	c.Start()
+	log.Println("[CERTIFICATES] Certificate scheduler started - runs every 10 minutes")
+}
+
+// GenerateCertificatePDF renders a certificate, stores it and records its storage key and download link.
+// Certificates issued before PDFs existed get their recipient name and course title filled in here.
+func GenerateCertificatePDF(db *gorm.DB, certificate *courseModels.Certificate) error {
+	if certificate.RecipientName == "" {
+		var user models.User
+		if err := db.Where("id = ?", certificate.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		certificate.RecipientName = user.Name
+	}
+	if certificate.CourseTitle == "" {
+		var course courseModels.Course
+		if err := db.Where("id = ?", certificate.CourseID).First(&course).Error; err != nil {
+			return err
+		}
+		certificate.CourseTitle = course.Title
+	}
+
+	pdf, err := RenderCertificatePDF(certificate)
+	if err != nil {
+		return err
+	}
+
+	key := "certificates/" + certificate.CertificateNumber + ".pdf"
+	if err := storage.Default().Put(context.Background(), key, bytes.NewReader(pdf), int64(len(pdf)), "application/pdf"); err != nil {
+		return err
+	}
+
+	certificate.FileKey = key
+	certificate.CertificateURL = CertificateDownloadURL(certificate.CertificateNumber)
+	return db.Model(certificate).Updates(map[string]interface{}{
+		"file_key":        certificate.FileKey,
+		"certificate_url": certificate.CertificateURL,
+		"recipient_name":  certificate.RecipientName,
+		"course_title":    certificate.CourseTitle,
+	}).Error
+}
+
+// RenderCertificatePDF draws the branded A4 landscape certificate with a QR code linking to the verify page
+func RenderCertificatePDF(certificate *courseModels.Certificate) ([]byte, error) {
+	qr, err := qrcode.Encode(CertificateVerifyURL(certificate.CertificateNumber), qrcode.Medium, 512)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle("Certificate "+certificate.CertificateNumber, true)
+	pdf.SetAuthor("Classia Capital", true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // cp1252, covers names with accents
+
+	width, height := pdf.GetPageSize()
+
+	// Border
+	pdf.SetDrawColor(33, 150, 243)
+	pdf.SetLineWidth(2)
+	pdf.Rect(10, 10, width-20, height-20, "D")
+	pdf.SetDrawColor(76, 175, 80)
+	pdf.SetLineWidth(0.6)
+	pdf.Rect(15, 15, width-30, height-30, "D")
+
+	centered := func(y float64, family, style string, size float64, r, g, b int, text string) {
+		pdf.SetFont(family, style, size)
+		pdf.SetTextColor(r, g, b)
+		pdf.SetXY(20, y)
+		pdf.CellFormat(width-40, size*0.5, tr(text), "", 0, "C", false, 0, "")
+	}
+
+	centered(28, "Helvetica", "B", 18, 33, 150, 243, "Classia Capital")
+	centered(45, "Times", "B", 34, 51, 51, 51, "Certificate of Completion")
+	centered(70, "Helvetica", "", 14, 102, 102, 102, "This is to certify that")
+	centered(84, "Times", "BI", 30, 33, 33, 33, certificate.RecipientName)
+
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.SetLineWidth(0.3)
+	pdf.Line(width/2-70, 100, width/2+70, 100)
+
+	centered(108, "Helvetica", "", 14, 102, 102, 102, "has successfully completed the course")
+	centered(121, "Helvetica", "B", 22, 76, 175, 80, certificate.CourseTitle)
+	centered(140, "Helvetica", "", 12, 102, 102, 102, "Issued on "+certificate.IssuedAt.Format("2 January 2006"))
+
+	// Certificate number and QR code
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(102, 102, 102)
+	pdf.SetXY(25, height-40)
+	pdf.CellFormat(120, 5, "Certificate No. "+certificate.CertificateNumber, "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(120, 5, tr("Verify at "+CertificateVerifyURL(certificate.CertificateNumber)), "", 0, "L", false, 0, "")
+
+	pdf.RegisterImageOptionsReader("qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+	pdf.ImageOptions("qr", width-60, height-62, 35, 35, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetXY(width-60, height-26)
+	pdf.CellFormat(35, 4, "Scan to verify", "", 0, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
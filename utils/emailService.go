@@ -271,3 +271,39 @@ func SendStockAddedEmail(email, name, basketName, symbol, action string) {
 
 	go SendEmail([]string{email}, subject, getEmailTemplate("Stock Added", body))
 }
+
+// 15. Basket Changes Requested (To AMC)
+func SendBasketChangesRequestedEmail(amcEmail, amcName, basketName, stepName, comments string, noteCount int) {
+	subject := "Changes Requested: " + basketName
+	body := fmt.Sprintf(`
+		<p>Dear %s,</p>
+		<p>The <strong>%s</strong> reviewer has sent your basket <strong>%s</strong> back for changes.</p>
+		<div class="info-box">%s</div>
+		<p>%d stocks have review notes. Please update the basket and submit it again.</p>
+	`, amcName, stepName, basketName, comments, noteCount)
+
+	go SendEmail([]string{amcEmail}, subject, getEmailTemplate("Changes Requested", body))
+}
+
+// 16. Basket Awaiting Review (To approvers)
+func SendBasketReviewPendingEmail(email, name, basketName, stepName string) {
+	subject := "Basket Awaiting Your Review: " + basketName
+	body := fmt.Sprintf(`
+		<p>Dear %s,</p>
+		<p>The basket <strong>%s</strong> has reached the <strong>%s</strong> step of its approval and is waiting for your decision.</p>
+	`, name, basketName, stepName)
+
+	go SendEmail([]string{email}, subject, getEmailTemplate("Basket Awaiting Review", body))
+}
+
+// 17. Basket Expired (To subscribers)
+func SendBasketExpiredEmail(email, name, basketName string) {
+	subject := "Basket Closed: " + basketName
+	body := fmt.Sprintf(`
+		<p>Dear %s,</p>
+		<p>The basket <strong>%s</strong> has reached its end date and is no longer active.</p>
+		<p>Your subscription to it has ended. Browse our other baskets to continue investing.</p>
+	`, name, basketName)
+
+	go SendEmail([]string{email}, subject, getEmailTemplate("Basket Closed", body))
+}
@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"encoding/json"
+	"fib/config"
+	"fib/encryption"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AadhaarKYCData is the identity returned once an Aadhaar OTP is verified
+type AadhaarKYCData struct {
+	ReferenceID string
+	Name        string
+	DOB         string
+	Gender      string
+	Address     string
+	Photo       string
+}
+
+// KYCProviderError carries the provider's status code and message so handlers can pass them on
+type KYCProviderError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *KYCProviderError) Error() string {
+	return fmt.Sprintf("kyc provider responded with code %d: %s", e.StatusCode, e.Message)
+}
+
+// KYCProvider is implemented by every identity verification backend
+type KYCProvider interface {
+	// Name returns the provider key stored on UserKYC (sandbox, local)
+	Name() string
+	// SendAadhaarOTP asks UIDAI to send an OTP to the Aadhaar linked mobile and returns the reference ID
+	SendAadhaarOTP(aadhaarNumber string) (string, error)
+	// VerifyAadhaarOTP verifies the OTP for a reference ID and returns the Aadhaar holder's details
+	VerifyAadhaarOTP(referenceID, otp string) (*AadhaarKYCData, error)
+	// CheckPanAadhaarLink reports whether the PAN is seeded with the Aadhaar number
+	CheckPanAadhaarLink(pan, aadhaarNumber string) (bool, string, error)
+}
+
+// SandboxAuthToken fetches a short lived access token for the Sandbox API
+func SandboxAuthToken() (string, error) {
+	cfg := config.AppConfig
+	url := cfg.SandboxApiURL + "authenticate"
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Add("x-api-key", cfg.SandboxApiKey)
+	req.Header.Add("x-api-secret", cfg.SandboxSecretKey)
+	req.Header.Add("x-api-version", cfg.SandboxApiVersion)
+	req.Header.Add("accept", "application/json")
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make HTTP request: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("authentication failed with status %d: %s", res.StatusCode, string(body))
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("access token is missing in the response")
+	}
+
+	return response.AccessToken, nil
+}
+
+// SandboxKYCProvider verifies Aadhaar and PAN through the Sandbox (credpay) API
+type SandboxKYCProvider struct{}
+
+func (p *SandboxKYCProvider) Name() string { return "sandbox" }
+
+// post sends a JSON payload to a Sandbox KYC endpoint and returns the raw body of a 200 response
+func (p *SandboxKYCProvider) post(path string, payload map[string]interface{}) ([]byte, error) {
+	data, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest(http.MethodPost, config.AppConfig.SandboxApiURL+path, strings.NewReader(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	authToken, err := SandboxAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("authorization", authToken)
+	req.Header.Set("x-api-key", config.AppConfig.SandboxApiKey)
+	req.Header.Set("x-api-version", "2.0")
+	req.Header.Set("content-type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var errorResp struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Message != "" {
+			return nil, &KYCProviderError{StatusCode: res.StatusCode, Message: errorResp.Message}
+		}
+		return nil, &KYCProviderError{StatusCode: res.StatusCode, Message: string(body)}
+	}
+
+	return body, nil
+}
+
+func (p *SandboxKYCProvider) SendAadhaarOTP(aadhaarNumber string) (string, error) {
+	body, err := p.post("kyc/aadhaar/okyc/otp", map[string]interface{}{
+		"@entity":        "in.co.sandbox.kyc.aadhaar.okyc.otp.request",
+		"consent":        "y",
+		"aadhaar_number": aadhaarNumber,
+		"reason":         "Verification",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Data struct {
+			ReferenceID interface{} `json:"reference_id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+
+	refID := stringifyID(response.Data.ReferenceID)
+	if refID == "" {
+		return "", fmt.Errorf("reference_id is missing in the response")
+	}
+	return refID, nil
+}
+
+func (p *SandboxKYCProvider) VerifyAadhaarOTP(referenceID, otp string) (*AadhaarKYCData, error) {
+	body, err := p.post("kyc/aadhaar/okyc/otp/verify", map[string]interface{}{
+		"@entity":      "in.co.sandbox.kyc.aadhaar.okyc.request",
+		"reference_id": referenceID,
+		"otp":          otp,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data struct {
+			ReferenceID interface{} `json:"reference_id"`
+			Status      string      `json:"status"`
+			Message     string      `json:"message"`
+			DateOfBirth string      `json:"date_of_birth"`
+			Gender      string      `json:"gender"`
+			Name        string      `json:"name"`
+			Photo       string      `json:"photo"`
+			Address     struct {
+				Country  string      `json:"country"`
+				District string      `json:"district"`
+				House    string      `json:"house"`
+				Landmark string      `json:"landmark"`
+				Pincode  interface{} `json:"pincode"`
+				State    string      `json:"state"`
+				Street   string      `json:"street"`
+				Vtc      string      `json:"vtc"`
+			} `json:"address"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		log.Printf("Failed to parse Aadhaar verify response: %v, Body: %s", err, string(body))
+		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+
+	// Validate critical fields
+	var validationErrors []string
+	if response.Data.Name == "" {
+		validationErrors = append(validationErrors, "Name is empty")
+	}
+	if response.Data.DateOfBirth == "" {
+		validationErrors = append(validationErrors, "DateOfBirth is empty")
+	}
+	if !strings.EqualFold(response.Data.Status, "VALID") {
+		validationErrors = append(validationErrors, fmt.Sprintf("Status is invalid: %s", response.Data.Status))
+	}
+	if len(validationErrors) > 0 {
+		log.Printf("Aadhaar validation failed: %v, Message: %s", validationErrors, response.Data.Message)
+		return nil, &KYCProviderError{StatusCode: http.StatusBadRequest, Message: "Aadhaar verification failed"}
+	}
+
+	addr := response.Data.Address
+	var addressParts []string
+	for _, part := range []string{addr.House, addr.Street, addr.Landmark, addr.Vtc, addr.District, addr.State, addr.Country, stringifyID(addr.Pincode)} {
+		if part != "" {
+			addressParts = append(addressParts, part)
+		}
+	}
+
+	return &AadhaarKYCData{
+		ReferenceID: stringifyID(response.Data.ReferenceID),
+		Name:        response.Data.Name,
+		DOB:         response.Data.DateOfBirth,
+		Gender:      response.Data.Gender,
+		Address:     strings.Join(addressParts, ", "),
+		Photo:       response.Data.Photo,
+	}, nil
+}
+
+func (p *SandboxKYCProvider) CheckPanAadhaarLink(pan, aadhaarNumber string) (bool, string, error) {
+	body, err := p.post("kyc/pan-aadhaar/status", map[string]interface{}{
+		"@entity":        "in.co.sandbox.kyc.pan_aadhaar.status",
+		"pan":            pan,
+		"aadhaar_number": aadhaarNumber,
+		"consent":        "Y",
+		"reason":         "Verification",
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	var response struct {
+		Data struct {
+			AadhaarSeedingStatus string `json:"aadhaar_seeding_status"`
+			Message              string `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		log.Printf("Failed to parse PAN-Aadhaar response: %v, Body: %s", err, string(body))
+		return false, "", fmt.Errorf("failed to parse response JSON: %v", err)
+	}
+
+	isLinked := response.Data.AadhaarSeedingStatus == "y" &&
+		strings.HasPrefix(response.Data.Message, "Your PAN is linked to Aadhaar Number")
+	return isLinked, response.Data.Message, nil
+}
+
+// LocalKYCOTP is the only OTP the local provider accepts
+const LocalKYCOTP = "123456"
+
+var localPanPattern = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]$`)
+
+// LocalKYCProvider is a stub for development; it never leaves the process
+type LocalKYCProvider struct{}
+
+func (p *LocalKYCProvider) Name() string { return "local" }
+
+func (p *LocalKYCProvider) SendAadhaarOTP(aadhaarNumber string) (string, error) {
+	refID := fmt.Sprintf("%d", time.Now().UnixNano()%100000000)
+	log.Printf("[LOCAL-KYC] Aadhaar OTP for %s: %s (reference %s)", encryption.MaskAadhaar(aadhaarNumber), LocalKYCOTP, refID)
+	return refID, nil
+}
+
+func (p *LocalKYCProvider) VerifyAadhaarOTP(referenceID, otp string) (*AadhaarKYCData, error) {
+	if otp != LocalKYCOTP {
+		return nil, &KYCProviderError{StatusCode: http.StatusBadRequest, Message: "Invalid OTP"}
+	}
+	return &AadhaarKYCData{
+		ReferenceID: referenceID,
+		Name:        "Local Test User",
+		DOB:         "01-01-1990",
+		Gender:      "M",
+		Address:     "Local Test Address, India",
+	}, nil
+}
+
+func (p *LocalKYCProvider) CheckPanAadhaarLink(pan, aadhaarNumber string) (bool, string, error) {
+	if !localPanPattern.MatchString(pan) {
+		return false, "Invalid PAN", nil
+	}
+	return true, "Your PAN is linked to Aadhaar Number " + encryption.MaskAadhaar(aadhaarNumber), nil
+}
+
+// GetKYCProvider returns the provider for the configured KYC_PROVIDER
+func GetKYCProvider() KYCProvider {
+	switch strings.ToLower(config.AppConfig.KYCProvider) {
+	case "local":
+		return &LocalKYCProvider{}
+	default:
+		return &SandboxKYCProvider{}
+	}
+}
+
+// stringifyID converts IDs that providers return as either numbers or strings
+func stringifyID(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return fmt.Sprintf("%d", int64(id))
+	}
+	return ""
+}
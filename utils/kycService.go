@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var ErrKYCInvalidTransition = errors.New("invalid KYC status transition")
+
+// kycTransitions lists the states each KYC status may move to
+var kycTransitions = map[string][]string{
+	models.KYCStatusNotStarted:    {models.KYCStatusInProgress},
+	models.KYCStatusInProgress:    {models.KYCStatusPendingReview},
+	models.KYCStatusPendingReview: {models.KYCStatusVerified, models.KYCStatusRejected},
+	models.KYCStatusRejected:      {models.KYCStatusInProgress, models.KYCStatusPendingReview},
+	models.KYCStatusVerified:      {models.KYCStatusExpired},
+	models.KYCStatusExpired:       {models.KYCStatusInProgress, models.KYCStatusPendingReview},
+}
+
+// RequiredKYCDocuments must be uploaded before KYC can be submitted for review
+var RequiredKYCDocuments = []string{models.KYCDocPanCard, models.KYCDocPhoto}
+
+// KYCDocumentTypes lists every document type a user may upload
+var KYCDocumentTypes = []string{
+	models.KYCDocPanCard,
+	models.KYCDocAadhaarFront,
+	models.KYCDocAadhaarBack,
+	models.KYCDocPhoto,
+	models.KYCDocSignature,
+	models.KYCDocAddressProof,
+}
+
+// CanTransitionKYC reports whether a KYC record may move from one status to another
+func CanTransitionKYC(from, to string) bool {
+	for _, allowed := range kycTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKYCEditable reports whether the user may still change their KYC (verify IDs, upload documents)
+func IsKYCEditable(status string) bool {
+	return status != models.KYCStatusPendingReview && status != models.KYCStatusVerified
+}
+
+// GetOrCreateUserKYC returns the user's KYC record, creating a NOT_STARTED one if needed
+func GetOrCreateUserKYC(tx *gorm.DB, userID uint) (*models.UserKYC, error) {
+	var kyc models.UserKYC
+	err := tx.Where("user_id = ? AND is_deleted = false", userID).First(&kyc).Error
+	if err == nil {
+		if kyc.Status == "" {
+			kyc.Status = models.KYCStatusNotStarted
+		}
+		return &kyc, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	kyc = models.UserKYC{UserID: userID, Status: models.KYCStatusNotStarted}
+	// Aadhaar and PAN are linked later, leave them NULL
+	if err := tx.Omit("AdharID", "PanID").Create(&kyc).Error; err != nil {
+		return nil, err
+	}
+	return &kyc, nil
+}
+
+// TransitionKYC moves a KYC record to a new status, keeps IsVerified in sync and writes an audit log entry.
+// Pass a transaction when the change must be atomic with other writes.
+func TransitionKYC(tx *gorm.DB, kyc *models.UserKYC, to string, actorID uint, reason string) error {
+	from := kyc.Status
+	if from == "" {
+		from = models.KYCStatusNotStarted
+	}
+	if from == to {
+		return nil
+	}
+	if !CanTransitionKYC(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrKYCInvalidTransition, from, to)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      to,
+		"is_verified": to == models.KYCStatusVerified,
+	}
+
+	switch to {
+	case models.KYCStatusPendingReview:
+		updates["submitted_at"] = now
+		updates["rejection_reason"] = ""
+	case models.KYCStatusVerified:
+		updates["verified_at"] = now
+		updates["reviewed_at"] = now
+		updates["reviewed_by"] = actorID
+		updates["rejection_reason"] = ""
+		if config.AppConfig.KYCValidityDays > 0 {
+			updates["expires_at"] = now.AddDate(0, 0, config.AppConfig.KYCValidityDays)
+		} else {
+			updates["expires_at"] = nil
+		}
+	case models.KYCStatusRejected:
+		updates["reviewed_at"] = now
+		updates["reviewed_by"] = actorID
+		updates["rejection_reason"] = reason
+	}
+
+	if err := tx.Model(kyc).Updates(updates).Error; err != nil {
+		return err
+	}
+	kyc.Status = to
+	kyc.IsVerified = to == models.KYCStatusVerified
+
+	return tx.Create(&models.KYCAuditLog{
+		UserKYCID:  kyc.ID,
+		UserID:     kyc.UserID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}).Error
+}
+
+// StartKYC moves a new, rejected or expired KYC to IN_PROGRESS when the user begins working on it
+func StartKYC(tx *gorm.DB, kyc *models.UserKYC, userID uint) error {
+	if kyc.Status == models.KYCStatusInProgress || !IsKYCEditable(kyc.Status) {
+		return nil
+	}
+	return TransitionKYC(tx, kyc, models.KYCStatusInProgress, userID, "")
+}
+
+// MissingKYCRequirements lists what is still needed before the KYC can be submitted for review
+func MissingKYCRequirements(kyc *models.UserKYC) []string {
+	var missing []string
+	if kyc.AdharID == 0 {
+		missing = append(missing, "AADHAAR_VERIFICATION")
+	}
+	if kyc.PanID == 0 {
+		missing = append(missing, "PAN_VERIFICATION")
+	}
+
+	var uploaded []string
+	database.Database.Db.Model(&models.KYCDocument{}).
+		Where("user_kyc_id = ? AND is_deleted = false", kyc.ID).
+		Distinct().Pluck("document_type", &uploaded)
+
+	have := make(map[string]bool, len(uploaded))
+	for _, docType := range uploaded {
+		have[docType] = true
+	}
+	for _, docType := range RequiredKYCDocuments {
+		if !have[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	return missing
+}
+
+// IsKYCVerified reports whether the user has a verified, unexpired KYC
+func IsKYCVerified(userID uint) bool {
+	var kyc models.UserKYC
+	if err := database.Database.Db.Where("user_id = ? AND status = ? AND is_deleted = false", userID, models.KYCStatusVerified).
+		First(&kyc).Error; err != nil {
+		return false
+	}
+	return kyc.ExpiresAt == nil || kyc.ExpiresAt.After(time.Now())
+}
+
+// GetKYCStatus returns the user's KYC status, NOT_STARTED if they have no record
+func GetKYCStatus(userID uint) string {
+	var kyc models.UserKYC
+	if err := database.Database.Db.Where("user_id = ? AND is_deleted = false", userID).First(&kyc).Error; err != nil || kyc.Status == "" {
+		return models.KYCStatusNotStarted
+	}
+	return kyc.Status
+}
+
+// ExpireKYCs moves verified KYCs past their expiry date to EXPIRED
+func ExpireKYCs() {
+	db := database.Database.Db
+
+	var expired []models.UserKYC
+	if err := db.Where("status = ? AND expires_at IS NOT NULL AND expires_at < ? AND is_deleted = false", models.KYCStatusVerified, time.Now()).
+		Find(&expired).Error; err != nil {
+		log.Printf("[KYC-EXPIRY] Error fetching expired KYCs: %v", err)
+		return
+	}
+
+	for i := range expired {
+		if err := TransitionKYC(db, &expired[i], models.KYCStatusExpired, 0, "KYC validity period ended"); err != nil {
+			log.Printf("[KYC-EXPIRY] Error expiring KYC %d: %v", expired[i].ID, err)
+		}
+	}
+
+	if len(expired) > 0 {
+		log.Printf("[KYC-EXPIRY] Expired %d KYC records", len(expired))
+	}
+}
+
+// InitializeKYCExpiryScheduler runs the KYC expiry job daily
+func InitializeKYCExpiryScheduler() {
+	c := cron.New()
+	c.AddFunc("15 0 * * *", ExpireKYCs)
+	c.Start()
+	log.Println("[KYC-EXPIRY] KYC expiry scheduler started - runs daily at 00:15")
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fib/database"
+	"fib/models"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// Login reasons recorded on LoginTracking and LoginChallenge
+const (
+	LoginReasonNewDevice  = "NEW_DEVICE"
+	LoginReasonNewNetwork = "NEW_NETWORK"
+)
+
+// lockoutDurations escalate with each consecutive lockout; the last value repeats
+var lockoutDurations = []time.Duration{
+	1 * time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	1 * time.Hour,
+	24 * time.Hour,
+}
+
+// FlagAfterLockouts is the number of consecutive lockouts after which an account is flagged for review
+const FlagAfterLockouts = 3
+
+// LockoutDuration returns how long to block an account for its nth consecutive lockout (1-based)
+func LockoutDuration(lockoutCount int) time.Duration {
+	if lockoutCount < 1 {
+		lockoutCount = 1
+	}
+	if lockoutCount > len(lockoutDurations) {
+		return lockoutDurations[len(lockoutDurations)-1]
+	}
+	return lockoutDurations[lockoutCount-1]
+}
+
+// networkPrefix returns the /24 (IPv4) or /48 (IPv6) prefix of an IP, used to group addresses by network
+func networkPrefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		parts := strings.Split(v4.String(), ".")
+		return strings.Join(parts[:3], ".") + "."
+	}
+	parts := strings.Split(parsed.String(), ":")
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, ":") + ":"
+}
+
+// userAgentBrowsers and userAgentSystems map User-Agent markers to a browser family and OS, checked in order since
+// most User-Agents mention several (Edge and Opera also claim to be Chrome and Safari, Android claims to be Linux)
+var userAgentBrowsers = [][2]string{
+	{"Edg", "Edge"}, {"OPR/", "Opera"}, {"SamsungBrowser", "Samsung Internet"}, {"CriOS", "Chrome"},
+	{"Chrome/", "Chrome"}, {"FxiOS", "Firefox"}, {"Firefox/", "Firefox"}, {"Safari/", "Safari"},
+	{"okhttp", "Android app"}, {"Dart/", "Mobile app"}, {"CFNetwork", "iOS app"},
+}
+
+var userAgentSystems = [][2]string{
+	{"Windows", "Windows"}, {"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iOS"}, {"iPod", "iOS"},
+	{"Darwin", "iOS"}, {"CrOS", "ChromeOS"}, {"Macintosh", "macOS"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+}
+
+// DeviceFingerprint reduces a User-Agent to its browser family and OS, so browser updates don't look like a new device
+func DeviceFingerprint(userAgent string) string {
+	match := func(markers [][2]string) string {
+		for _, marker := range markers {
+			if strings.Contains(userAgent, marker[0]) {
+				return marker[1]
+			}
+		}
+		return "Other"
+	}
+	return match(userAgentBrowsers) + " on " + match(userAgentSystems)
+}
+
+// DetectUnusualLogin compares a login against the user's successful login history and
+// returns the reasons it looks unusual. The first ever login sets the baseline and is never unusual.
+// Devices are compared by DeviceFingerprint and networks by the prefix of the trusted client IP (c.IP()).
+func DetectUnusualLogin(userID uint, ip, userAgent string) []string {
+	db := database.Database.Db
+	base := "user_id = ? AND is_deleted = false AND status = ?"
+
+	var history int64
+	db.Model(&models.LoginTracking{}).Where(base, userID, models.LoginStatusSuccess).Count(&history)
+	if history == 0 {
+		return nil
+	}
+
+	var reasons []string
+
+	var devices []string
+	db.Model(&models.LoginTracking{}).Where(base, userID, models.LoginStatusSuccess).
+		Distinct("device").Pluck("device", &devices)
+	fingerprint := DeviceFingerprint(userAgent)
+	deviceSeen := false
+	for _, device := range devices {
+		if DeviceFingerprint(device) == fingerprint {
+			deviceSeen = true
+			break
+		}
+	}
+	if !deviceSeen {
+		reasons = append(reasons, LoginReasonNewDevice)
+	}
+
+	var networkSeen int64
+	db.Model(&models.LoginTracking{}).Where(base, userID, models.LoginStatusSuccess).
+		Where("ip_address LIKE ?", networkPrefix(ip)+"%").Count(&networkSeen)
+	if networkSeen == 0 {
+		reasons = append(reasons, LoginReasonNewNetwork)
+	}
+
+	return reasons
+}
+
+// FlagUser marks an account for admin review of suspicious login activity
+func FlagUser(userID uint, reason string) {
+	now := time.Now()
+	if err := database.Database.Db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"is_flagged":     true,
+		"flagged_reason": reason,
+		"flagged_at":     now,
+	}).Error; err != nil {
+		log.Printf("Error flagging user %d: %v", userID, err)
+	}
+}
+
+// GenerateSecureToken returns a random hex token of n bytes
+func GenerateSecureToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("failed to generate secure token: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
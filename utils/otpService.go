@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOTPInvalid          = errors.New("invalid OTP")
+	ErrOTPExpired          = errors.New("OTP has expired")
+	ErrOTPAttemptsExceeded = errors.New("too many invalid attempts, request a new OTP")
+)
+
+// OTPRateLimitError is returned when an OTP send is throttled
+type OTPRateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *OTPRateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %d seconds", e.Reason, int(e.RetryAfter.Seconds()))
+}
+
+// hashOTP hashes a code bound to its identifier and purpose so hashes can't be replayed across flows
+func hashOTP(identifier, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.OTPSecret))
+	mac.Write([]byte(purpose + "|" + identifier + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// otpIdentifierScope filters OTP rows by email or mobile
+func otpIdentifierScope(email, mobile string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if email != "" {
+			return db.Where("email = ?", email)
+		}
+		return db.Where("mobile = ?", mobile)
+	}
+}
+
+// CheckOTPSendLimits enforces resend cooldown, per-identifier and per-IP hourly limits
+func CheckOTPSendLimits(email, mobile, purpose, ip string) error {
+	db := database.Database.Db
+	now := time.Now()
+
+	// Resend cooldown for the same identifier and purpose
+	var last models.OTP
+	if err := db.Scopes(otpIdentifierScope(email, mobile)).
+		Where("purpose = ?", purpose).
+		Order("created_at DESC").
+		First(&last).Error; err == nil {
+		cooldown := time.Duration(config.AppConfig.OTPResendCooldownSecs) * time.Second
+		if wait := last.CreatedAt.Add(cooldown).Sub(now); wait > 0 {
+			return &OTPRateLimitError{Reason: "Please wait before requesting another OTP", RetryAfter: wait}
+		}
+	}
+
+	windowStart := now.Add(-time.Hour)
+
+	// Per identifier limit across all purposes
+	var identifierCount int64
+	var oldestForIdentifier models.OTP
+	db.Model(&models.OTP{}).Scopes(otpIdentifierScope(email, mobile)).
+		Where("created_at > ?", windowStart).
+		Count(&identifierCount)
+	if int(identifierCount) >= config.AppConfig.OTPMaxPerIdentifier {
+		db.Scopes(otpIdentifierScope(email, mobile)).Where("created_at > ?", windowStart).
+			Order("created_at ASC").First(&oldestForIdentifier)
+		return &OTPRateLimitError{
+			Reason:     "Too many OTP requests for this account",
+			RetryAfter: oldestForIdentifier.CreatedAt.Add(time.Hour).Sub(now),
+		}
+	}
+
+	// Per IP limit
+	if ip != "" {
+		var ipCount int64
+		var oldestForIP models.OTP
+		db.Model(&models.OTP{}).Where("ip_address = ? AND created_at > ?", ip, windowStart).Count(&ipCount)
+		if int(ipCount) >= config.AppConfig.OTPMaxPerIP {
+			db.Where("ip_address = ? AND created_at > ?", ip, windowStart).
+				Order("created_at ASC").First(&oldestForIP)
+			return &OTPRateLimitError{
+				Reason:     "Too many OTP requests from this network",
+				RetryAfter: oldestForIP.CreatedAt.Add(time.Hour).Sub(now),
+			}
+		}
+	}
+
+	return nil
+}
+
+// storeOTP invalidates previous OTPs for the identifier/purpose and saves the new hash
+func storeOTP(userID uint, email, mobile, purpose, ip, secret, description string, ttl time.Duration) error {
+	db := database.Database.Db
+	identifier := email + mobile
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OTP{}).Scopes(otpIdentifierScope(email, mobile)).
+			Where("purpose = ? AND is_used = false AND is_deleted = false", purpose).
+			Update("is_used", true).Error; err != nil {
+			return err
+		}
+
+		otpRecord := models.OTP{
+			UserID:      userID,
+			Email:       email,
+			Mobile:      mobile,
+			Purpose:     purpose,
+			CodeHash:    hashOTP(identifier, purpose, secret),
+			MaxAttempts: config.AppConfig.OTPMaxAttempts,
+			IPAddress:   ip,
+			ExpiresAt:   time.Now().Add(ttl),
+			Description: description,
+		}
+		return tx.Create(&otpRecord).Error
+	})
+}
+
+// IssueOTP checks send limits, generates a new code and stores only its hash.
+// Exactly one of email or mobile should be set; the plain code is returned for delivery.
+func IssueOTP(userID uint, email, mobile, purpose, ip, description string) (string, error) {
+	if err := CheckOTPSendLimits(email, mobile, purpose, ip); err != nil {
+		return "", err
+	}
+
+	code := GenerateOTP()
+	if err := storeOTP(userID, email, mobile, purpose, ip, code, description, time.Duration(config.AppConfig.OTPExpiryMinutes)*time.Minute); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// TrackExternalOTP records an OTP sent by a third party (e.g. UIDAI for Aadhaar) under its
+// hashed reference ID so the same send limits and attempt counting apply to it.
+// Call CheckOTPSendLimits before asking the third party to send.
+func TrackExternalOTP(userID uint, email, mobile, purpose, ip, reference string) error {
+	// UIDAI OTPs stay valid for 10 minutes
+	return storeOTP(userID, email, mobile, purpose, ip, reference, "External "+purpose+" OTP", 10*time.Minute)
+}
+
+// RecordExternalOTPAttempt counts one verification attempt against a tracked external OTP.
+// The record is returned so the caller can mark it used once the third party confirms the code.
+func RecordExternalOTPAttempt(email, mobile, purpose, reference string) (*models.OTP, error) {
+	db := database.Database.Db
+
+	var otpRecord models.OTP
+	if err := db.Scopes(otpIdentifierScope(email, mobile)).
+		Where("purpose = ? AND code_hash = ? AND is_used = false AND is_deleted = false", purpose, hashOTP(email+mobile, purpose, reference)).
+		First(&otpRecord).Error; err != nil {
+		return nil, ErrOTPInvalid
+	}
+
+	if otpRecord.ExpiresAt.Before(time.Now()) {
+		return nil, ErrOTPExpired
+	}
+
+	claim := db.Model(&models.OTP{}).
+		Where("id = ? AND is_used = false AND attempts < max_attempts", otpRecord.ID).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil, ErrOTPAttemptsExceeded
+	}
+	otpRecord.Attempts++
+
+	return &otpRecord, nil
+}
+
+// MarkOTPUsed consumes an OTP so it can't be verified again
+func MarkOTPUsed(otpRecord *models.OTP) error {
+	otpRecord.IsUsed = true
+	return database.Database.Db.Model(otpRecord).Update("is_used", true).Error
+}
+
+// VerifyOTP validates a code against the latest active OTP for the identifier and purpose.
+// Every wrong code counts as an attempt; the OTP is burned once MaxAttempts is reached.
+func VerifyOTP(email, mobile, purpose, code string) (*models.OTP, error) {
+	db := database.Database.Db
+
+	var otpRecord models.OTP
+	if err := db.Scopes(otpIdentifierScope(email, mobile)).
+		Where("purpose = ? AND is_used = false AND is_deleted = false", purpose).
+		Order("created_at DESC").
+		First(&otpRecord).Error; err != nil {
+		return nil, ErrOTPInvalid
+	}
+
+	if otpRecord.ExpiresAt.Before(time.Now()) {
+		return nil, ErrOTPExpired
+	}
+
+	// Claim an attempt before comparing, so parallel guesses can't get past MaxAttempts
+	claim := db.Model(&models.OTP{}).
+		Where("id = ? AND is_used = false AND attempts < max_attempts", otpRecord.ID).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil, ErrOTPAttemptsExceeded
+	}
+	otpRecord.Attempts++
+
+	expected := hashOTP(email+mobile, purpose, code)
+	if !hmac.Equal([]byte(expected), []byte(otpRecord.CodeHash)) {
+		// The last allowed guess retires the OTP
+		db.Model(&models.OTP{}).
+			Where("id = ? AND attempts >= max_attempts", otpRecord.ID).
+			Update("is_used", true)
+
+		if otpRecord.Attempts >= otpRecord.MaxAttempts {
+			return nil, ErrOTPAttemptsExceeded
+		}
+		return nil, ErrOTPInvalid
+	}
+
+	// A parallel request may have used the OTP meanwhile
+	used := db.Model(&models.OTP{}).Where("id = ? AND is_used = false", otpRecord.ID).Update("is_used", true)
+	if used.Error != nil {
+		return nil, used.Error
+	}
+	if used.RowsAffected == 0 {
+		return nil, ErrOTPInvalid
+	}
+	otpRecord.IsUsed = true
+
+	return &otpRecord, nil
+}
+
+// CleanupExpiredOTPs removes OTPs that expired more than a day ago.
+// Recent rows are kept because the hourly send limits are counted from them.
+func CleanupExpiredOTPs() {
+	result := database.Database.Db.Unscoped().
+		Where("expires_at < ?", time.Now().Add(-24*time.Hour)).
+		Delete(&models.OTP{})
+	if result.Error != nil {
+		log.Printf("[OTP-CLEANUP] Error deleting expired OTPs: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("[OTP-CLEANUP] Deleted %d expired OTPs", result.RowsAffected)
+	}
+}
+
+// InitializeOTPCleanupScheduler runs the OTP cleanup job every hour
+func InitializeOTPCleanupScheduler() {
+	c := cron.New()
+	c.AddFunc("0 * * * *", CleanupExpiredOTPs)
+	c.Start()
+	log.Println("[OTP-CLEANUP] OTP cleanup scheduler started - runs hourly")
+}
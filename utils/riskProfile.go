@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"fib/models/basket"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRiskQuestionnaireEmpty = errors.New("risk questionnaire has no active questions")
+	ErrRiskAnswersIncomplete  = errors.New("every active question must be answered")
+	ErrRiskInvalidOption      = errors.New("option does not belong to the question")
+)
+
+// Suitability outcomes returned by CheckSuitability
+const (
+	SuitabilitySuitable  = "SUITABLE"
+	SuitabilityWarn      = "WARN"  // Basket is riskier than the profile, the user must acknowledge
+	SuitabilityBlock     = "BLOCK" // Mismatch too large to subscribe
+	SuitabilityNoProfile = "NO_PROFILE"
+)
+
+// riskCategoryLevels ranks investor categories so they can be compared with basket ratings
+var riskCategoryLevels = map[string]int{
+	models.RiskCategoryConservative: 1,
+	models.RiskCategoryModerate:     2,
+	models.RiskCategoryAggressive:   3,
+}
+
+// basketRiskLevels ranks basket ratings on the same scale as riskCategoryLevels
+var basketRiskLevels = map[string]int{
+	basket.RiskRatingLow:    1,
+	basket.RiskRatingMedium: 2,
+	basket.RiskRatingHigh:   3,
+}
+
+// BasketRiskRatings lists the valid basket risk ratings
+var BasketRiskRatings = []string{basket.RiskRatingLow, basket.RiskRatingMedium, basket.RiskRatingHigh}
+
+// RiskAnswer is one answered question of a questionnaire submission
+type RiskAnswer struct {
+	QuestionID uint `json:"questionId"`
+	OptionID   uint `json:"optionId"`
+}
+
+// riskAnswerSnapshot is stored on UserRiskProfile so later questionnaire edits don't change past results
+type riskAnswerSnapshot struct {
+	QuestionID uint   `json:"questionId"`
+	Question   string `json:"question"`
+	OptionID   uint   `json:"optionId"`
+	Option     string `json:"option"`
+	Score      int    `json:"score"`
+}
+
+// SuitabilityResult describes how a basket's risk rating fits the user's risk profile
+type SuitabilityResult struct {
+	Result           string                  `json:"result"`
+	UserRiskCategory string                  `json:"userRiskCategory"`
+	BasketRiskRating string                  `json:"basketRiskRating"`
+	Message          string                  `json:"message"`
+	Profile          *models.UserRiskProfile `json:"-"`
+}
+
+// DefaultBasketRiskRating is used for baskets the AMC hasn't rated; shorter holding periods are riskier
+func DefaultBasketRiskRating(basketType string) string {
+	switch basketType {
+	case basket.BasketTypeIntraHour, basket.BasketTypeIntraday:
+		return basket.RiskRatingHigh
+	default:
+		return basket.RiskRatingMedium
+	}
+}
+
+// RiskCategoryForScore maps a questionnaire score onto an investor risk category
+func RiskCategoryForScore(score, maxScore int) string {
+	if maxScore <= 0 {
+		return models.RiskCategoryConservative
+	}
+	percent := score * 100 / maxScore
+	switch {
+	case percent >= config.AppConfig.RiskAggressiveMinPercent:
+		return models.RiskCategoryAggressive
+	case percent >= config.AppConfig.RiskModerateMinPercent:
+		return models.RiskCategoryModerate
+	default:
+		return models.RiskCategoryConservative
+	}
+}
+
+// GetRiskQuestionnaire returns the active questions with their options in display order
+func GetRiskQuestionnaire() ([]models.RiskQuestion, error) {
+	var questions []models.RiskQuestion
+	err := database.Database.Db.
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_deleted = false").Order("sort_order ASC, id ASC")
+		}).
+		Where("is_active = true AND is_deleted = false").
+		Order("sort_order ASC, id ASC").
+		Find(&questions).Error
+	return questions, err
+}
+
+// ScoreRiskProfile scores a submission against the active questionnaire and saves it as the user's current profile
+func ScoreRiskProfile(userID uint, answers []RiskAnswer) (*models.UserRiskProfile, error) {
+	questions, err := GetRiskQuestionnaire()
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrRiskQuestionnaireEmpty
+	}
+
+	chosen := make(map[uint]uint, len(answers))
+	for _, answer := range answers {
+		chosen[answer.QuestionID] = answer.OptionID
+	}
+
+	score, maxScore := 0, 0
+	snapshot := make([]riskAnswerSnapshot, 0, len(questions))
+	for _, question := range questions {
+		optionID, ok := chosen[question.ID]
+		if !ok {
+			return nil, ErrRiskAnswersIncomplete
+		}
+
+		var picked *models.RiskOption
+		best := 0
+		for i := range question.Options {
+			if question.Options[i].Score > best {
+				best = question.Options[i].Score
+			}
+			if question.Options[i].ID == optionID {
+				picked = &question.Options[i]
+			}
+		}
+		if picked == nil {
+			return nil, fmt.Errorf("%w: question %d", ErrRiskInvalidOption, question.ID)
+		}
+
+		score += picked.Score
+		maxScore += best
+		snapshot = append(snapshot, riskAnswerSnapshot{
+			QuestionID: question.ID,
+			Question:   question.Question,
+			OptionID:   picked.ID,
+			Option:     picked.Text,
+			Score:      picked.Score,
+		})
+	}
+
+	answersJSON, _ := json.Marshal(snapshot)
+	now := time.Now()
+	profile := models.UserRiskProfile{
+		UserID:     userID,
+		Score:      score,
+		MaxScore:   maxScore,
+		Category:   RiskCategoryForScore(score, maxScore),
+		Answers:    string(answersJSON),
+		AssessedAt: now,
+	}
+	if days := config.AppConfig.RiskProfileValidityDays; days > 0 {
+		expiresAt := now.AddDate(0, 0, days)
+		profile.ExpiresAt = &expiresAt
+	}
+
+	if err := database.Database.Db.Create(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetCurrentRiskProfile returns the user's latest unexpired risk profile
+func GetCurrentRiskProfile(userID uint) (*models.UserRiskProfile, error) {
+	var profile models.UserRiskProfile
+	err := database.Database.Db.
+		Where("user_id = ? AND is_deleted = false AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("assessed_at DESC").
+		First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// CheckSuitability compares a basket's risk rating with the user's current risk profile.
+// Baskets one level riskier than the profile need an acknowledgement; SuitabilityBlockGap levels or more are blocked.
+func CheckSuitability(userID uint, basketRiskRating string) SuitabilityResult {
+	result := SuitabilityResult{BasketRiskRating: basketRiskRating}
+
+	profile, err := GetCurrentRiskProfile(userID)
+	if err != nil {
+		result.Result = SuitabilityNoProfile
+		result.Message = "Complete your risk profile before subscribing to a basket."
+		return result
+	}
+	result.Profile = profile
+	result.UserRiskCategory = profile.Category
+
+	gap := basketRiskLevels[basketRiskRating] - riskCategoryLevels[profile.Category]
+	blockGap := config.AppConfig.SuitabilityBlockGap
+	switch {
+	case gap <= 0:
+		result.Result = SuitabilitySuitable
+	case blockGap > 0 && gap >= blockGap:
+		result.Result = SuitabilityBlock
+		result.Message = fmt.Sprintf("This basket is rated %s risk and is not suitable for a %s investor.", basketRiskRating, profile.Category)
+	default:
+		result.Result = SuitabilityWarn
+		result.Message = fmt.Sprintf("This basket is rated %s risk, which is higher than your %s risk profile. You may lose more than you are comfortable with.", basketRiskRating, profile.Category)
+	}
+	return result
+}
@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMSSender is implemented by every SMS gateway we can send through
+type SMSSender interface {
+	// Name returns the provider key stored on SMSLog (fast2sms, msg91, fake)
+	Name() string
+	// Send delivers a DLT template message and returns the provider's message/request ID
+	Send(mobile, senderID, templateID string, variables []string) (string, error)
+}
+
+// Fast2SMSSender sends DLT route messages through Fast2SMS
+type Fast2SMSSender struct {
+	ApiKey   string
+	SenderID string
+}
+
+func (s *Fast2SMSSender) Name() string { return "fast2sms" }
+
+func (s *Fast2SMSSender) Send(mobile, senderID, templateID string, variables []string) (string, error) {
+	if senderID == "" {
+		senderID = s.SenderID
+	}
+
+	params := url.Values{}
+	params.Set("authorization", s.ApiKey)
+	params.Set("route", "dlt")
+	params.Set("sender_id", senderID)
+	params.Set("message", templateID)
+	params.Set("variables_values", strings.Join(variables, "|"))
+	params.Set("flash", "0")
+	params.Set("numbers", mobile)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("https://www.fast2sms.com/dev/bulkV2?" + params.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fast2sms responded with code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Return    bool   `json:"return"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid fast2sms response: %v", err)
+	}
+	if !result.Return {
+		return "", fmt.Errorf("fast2sms rejected message: %s", string(body))
+	}
+
+	return result.RequestID, nil
+}
+
+// MSG91Sender sends DLT flow messages through MSG91
+type MSG91Sender struct {
+	AuthKey  string
+	SenderID string
+}
+
+func (s *MSG91Sender) Name() string { return "msg91" }
+
+func (s *MSG91Sender) Send(mobile, senderID, templateID string, variables []string) (string, error) {
+	if senderID == "" {
+		senderID = s.SenderID
+	}
+
+	// MSG91 expects the country code and named variables (var1, var2, ...)
+	recipient := map[string]string{"mobiles": "91" + mobile}
+	for i, v := range variables {
+		recipient[fmt.Sprintf("var%d", i+1)] = v
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"template_id": templateID,
+		"sender":      senderID,
+		"short_url":   "0",
+		"recipients":  []map[string]string{recipient},
+	})
+
+	req, err := http.NewRequest("POST", "https://control.msg91.com/api/v5/flow/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authkey", s.AuthKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("msg91 responded with code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Type    string `json:"type"`
+		Message string `json:"message"` // Request ID on success
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid msg91 response: %v", err)
+	}
+	if result.Type != "success" {
+		return "", fmt.Errorf("msg91 rejected message: %s", result.Message)
+	}
+
+	return result.Message, nil
+}
+
+// FakeSMSSender only logs messages, for local development
+type FakeSMSSender struct{}
+
+func (s *FakeSMSSender) Name() string { return "fake" }
+
+func (s *FakeSMSSender) Send(mobile, senderID, templateID string, variables []string) (string, error) {
+	log.Printf("[FAKE-SMS] To: %s Sender: %s Template: %s Variables: %v", mobile, senderID, templateID, variables)
+	return fmt.Sprintf("fake-%d", time.Now().UnixNano()), nil
+}
+
+// GetSMSSender returns the sender for the configured SMS_PROVIDER, or the fake sender when its key is not set
+func GetSMSSender() SMSSender {
+	switch strings.ToLower(config.AppConfig.SMSProvider) {
+	case "msg91":
+		if config.AppConfig.MSG91AuthKey != "" {
+			return &MSG91Sender{AuthKey: config.AppConfig.MSG91AuthKey, SenderID: config.AppConfig.MSG91SenderID}
+		}
+	case "fake":
+	default:
+		if config.AppConfig.Fast2SMSApiKey != "" {
+			return &Fast2SMSSender{ApiKey: config.AppConfig.Fast2SMSApiKey, SenderID: config.AppConfig.Fast2SMSSenderID}
+		}
+	}
+	return &FakeSMSSender{}
+}
+
+// resolveSMSTemplate finds the DLT template for a message type, falling back to config defaults
+func resolveSMSTemplate(provider, messageType string) (templateID string, senderID string) {
+	var template models.SMSTemplate
+	err := database.Database.Db.
+		Where("message_type = ? AND (provider = ? OR provider = '') AND is_active = true AND is_deleted = false", messageType, provider).
+		Order("provider DESC").
+		First(&template).Error
+	if err == nil {
+		return template.TemplateID, template.SenderID
+	}
+
+	switch messageType {
+	case models.SMSTypeOTP:
+		return config.AppConfig.SMSTemplateOTP, ""
+	case models.SMSTypeLoginAlert:
+		return config.AppConfig.SMSTemplateLogin, ""
+	case models.SMSTypeSubscriptionExpiry:
+		return config.AppConfig.SMSTemplateExpiry, ""
+	}
+	return "", ""
+}
+
+// SendSMS sends a templated SMS through the configured provider and records it in SMSLog
+func SendSMS(userID uint, mobile, messageType string, variables ...string) error {
+	sender := GetSMSSender()
+	templateID, senderID := resolveSMSTemplate(sender.Name(), messageType)
+	if templateID == "" {
+		return fmt.Errorf("no SMS template configured for %s", messageType)
+	}
+
+	smsLog := models.SMSLog{
+		UserID:      userID,
+		Mobile:      mobile,
+		MessageType: messageType,
+		TemplateID:  templateID,
+		Provider:    sender.Name(),
+		Status:      models.SMSStatusQueued,
+	}
+	database.Database.Db.Create(&smsLog)
+
+	messageID, err := sender.Send(mobile, senderID, templateID, variables)
+	if err != nil {
+		log.Printf("Error while sending %s SMS to %s: %v", messageType, mobile, err)
+		database.Database.Db.Model(&smsLog).Updates(map[string]interface{}{
+			"status":        models.SMSStatusFailed,
+			"error_message": err.Error(),
+		})
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":              models.SMSStatusSent,
+		"provider_message_id": messageID,
+		"sent_at":             now,
+	}
+	// The fake provider has no delivery reports, so treat it as delivered right away
+	if sender.Name() == "fake" {
+		updates["status"] = models.SMSStatusDelivered
+		updates["delivered_at"] = now
+	}
+	database.Database.Db.Model(&smsLog).Updates(updates)
+
+	log.Printf("%s SMS sent successfully to %s", messageType, mobile)
+	return nil
+}
+
+// UpdateSMSDeliveryStatus applies a provider delivery report to the matching SMSLog
+func UpdateSMSDeliveryStatus(provider, providerMessageID, status, reason string) error {
+	updates := map[string]interface{}{"status": status}
+	if status == models.SMSStatusDelivered {
+		updates["delivered_at"] = time.Now()
+	}
+	if reason != "" {
+		updates["error_message"] = reason
+	}
+
+	result := database.Database.Db.Model(&models.SMSLog{}).
+		Where("provider = ? AND provider_message_id = ? AND is_deleted = false", provider, providerMessageID).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no SMS found for %s message %s", provider, providerMessageID)
+	}
+	return nil
+}
+
+// SendOTPToMobile sends an OTP (valid for 10 minutes) using the OTP template
+func SendOTPToMobile(mobile, otp string) error {
+	return SendSMS(0, mobile, models.SMSTypeOTP, otp, "10")
+}
+
+// SendLoginAlertSMS notifies the user of a new login on their account
+func SendLoginAlertSMS(userID uint, mobile, ip, loginTime string) {
+	go func() {
+		if err := SendSMS(userID, mobile, models.SMSTypeLoginAlert, loginTime, ip); err != nil {
+			log.Printf("Error sending login alert SMS: %v", err)
+		}
+	}()
+}
+
+// SendSubscriptionExpirySMS reminds the user that their basket subscription is expiring
+func SendSubscriptionExpirySMS(userID uint, mobile, basketName, expiryDate string) {
+	go func() {
+		if err := SendSMS(userID, mobile, models.SMSTypeSubscriptionExpiry, basketName, expiryDate); err != nil {
+			log.Printf("Error sending subscription expiry SMS: %v", err)
+		}
+	}()
+}
@@ -57,6 +57,11 @@ func ProcessExpiringSubscriptions() {
 		// Send reminder email
 		SendSubscriptionExpiryReminder(user.Email, user.Name, sub.Basket.Name, sub.ExpiresAt)
 
+		// Send reminder SMS
+		if user.Mobile != "" && sub.ExpiresAt != nil {
+			SendSubscriptionExpirySMS(user.ID, user.Mobile, sub.Basket.Name, sub.ExpiresAt.Format("02 Jan 2006"))
+		}
+
 		// Mark reminder as sent
 		db.Model(&sub).Update("reminder_sent", true)
 		log.Printf("[SUBSCRIPTION-SCHEDULER] Sent expiry reminder for subscription %d to %s", sub.ID, user.Email)
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"errors"
+	"fib/config"
+	"fib/database"
+	"fib/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApplySupportSLA sets a ticket's first response and resolution deadlines from the SLA policy of its priority.
+// Tickets whose priority has no policy get no deadlines.
+func ApplySupportSLA(tx *gorm.DB, ticket *models.SupportTicket) error {
+	var policy models.SupportSLAPolicy
+	if err := tx.Where("priority = ?", strings.ToUpper(ticket.Priority)).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	opened := ticket.CreatedAt
+	firstResponseDue := opened.Add(time.Duration(policy.FirstResponseMinutes) * time.Minute)
+	resolutionDue := opened.Add(time.Duration(policy.ResolutionMinutes) * time.Minute)
+	ticket.FirstResponseDueAt = &firstResponseDue
+	ticket.ResolutionDueAt = &resolutionDue
+
+	return tx.Model(&models.SupportTicket{}).Where("id = ?", ticket.ID).Updates(map[string]interface{}{
+		"first_response_due_at": firstResponseDue,
+		"resolution_due_at":     resolutionDue,
+	}).Error
+}
+
+// AssignSupportTicket gives a ticket to an admin
+func AssignSupportTicket(tx *gorm.DB, ticket *models.SupportTicket, adminID uint) error {
+	now := time.Now()
+	if err := tx.Model(&models.SupportTicket{}).Where("id = ?", ticket.ID).Updates(map[string]interface{}{
+		"assigned_to": adminID,
+		"assigned_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	ticket.AssignedTo = &adminID
+	ticket.AssignedAt = &now
+	return nil
+}
+
+// AutoAssignSupportTicket assigns a ticket round-robin to the active agents of its category: the agent who has
+// gone longest without a new ticket gets it. It returns nil when the category has no agents.
+func AutoAssignSupportTicket(tx *gorm.DB, ticket *models.SupportTicket) (*models.User, error) {
+	// Locking the rotation keeps two new tickets from going to the same agent
+	var agent models.SupportAgent
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("category = ? AND is_active = ?", strings.ToUpper(ticket.Category), true).
+		Where("user_id IN (?)", tx.Model(&models.User{}).Select("id").Where("role = ? AND is_deleted = ?", "ADMIN", false)).
+		Order("last_assigned_at ASC NULLS FIRST, id ASC").
+		First(&agent).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := tx.Model(&agent).UpdateColumn("last_assigned_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	if err := AssignSupportTicket(tx, ticket, agent.UserID); err != nil {
+		return nil, err
+	}
+
+	var admin models.User
+	if err := tx.Where("id = ?", agent.UserID).First(&admin).Error; err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+// RecordSupportFirstResponse stamps the first admin reply on a ticket, flagging the SLA as breached if it came late
+func RecordSupportFirstResponse(tx *gorm.DB, ticketID uint, at time.Time) error {
+	return tx.Model(&models.SupportTicket{}).
+		Where("id = ? AND first_response_at IS NULL", ticketID).
+		Updates(map[string]interface{}{
+			"first_response_at":       at,
+			"first_response_breached": gorm.Expr("first_response_breached OR (first_response_due_at IS NOT NULL AND first_response_due_at < ?)", at),
+		}).Error
+}
+
+// RecordSupportResolution stamps a ticket's resolution time, flagging the SLA as breached if it came late
+func RecordSupportResolution(tx *gorm.DB, ticketID uint, at time.Time) error {
+	return tx.Model(&models.SupportTicket{}).
+		Where("id = ?", ticketID).
+		Updates(map[string]interface{}{
+			"resolved_at":         at,
+			"resolution_breached": gorm.Expr("resolution_breached OR (resolution_due_at IS NOT NULL AND resolution_due_at < ?)", at),
+		}).Error
+}
+
+// EscalateSupportTickets flags open tickets that missed their first response or resolution deadline, raises their
+// escalation level and notifies the assignee and the escalation contacts. Each breach escalates once.
+func EscalateSupportTickets() {
+	db := database.Database.Db
+	now := time.Now()
+
+	breaches := []struct {
+		name   string
+		column string
+		where  string
+	}{
+		{"first response", "first_response_breached", "first_response_at IS NULL AND first_response_due_at < ?"},
+		{"resolution", "resolution_breached", "resolved_at IS NULL AND resolution_due_at < ?"},
+	}
+
+	for _, breach := range breaches {
+		var tickets []models.SupportTicket
+		if err := db.Where("is_deleted = false AND UPPER(status) <> ? AND "+breach.column+" = false", "CLOSED").
+			Where(breach.where, now).
+			Find(&tickets).Error; err != nil {
+			log.Printf("[SUPPORT-SLA] Error fetching tickets past their %s deadline: %v", breach.name, err)
+			continue
+		}
+
+		for i := range tickets {
+			ticket := &tickets[i]
+
+			var assignee *models.User
+			err := db.Transaction(func(tx *gorm.DB) error {
+				// Another instance may have escalated the ticket already
+				result := tx.Model(&models.SupportTicket{}).
+					Where("id = ? AND "+breach.column+" = false", ticket.ID).
+					Updates(map[string]interface{}{
+						breach.column:      true,
+						"escalation_level": gorm.Expr("escalation_level + 1"),
+						"escalated_at":     now,
+					})
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					return gorm.ErrRecordNotFound
+				}
+				ticket.EscalationLevel++
+
+				if ticket.AssignedTo == nil {
+					var err error
+					assignee, err = AutoAssignSupportTicket(tx, ticket)
+					return err
+				}
+				var admin models.User
+				if err := tx.Where("id = ?", *ticket.AssignedTo).First(&admin).Error; err == nil {
+					assignee = &admin
+				}
+				return nil
+			})
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					log.Printf("[SUPPORT-SLA] Error escalating ticket %d: %v", ticket.ID, err)
+				}
+				continue
+			}
+
+			log.Printf("[SUPPORT-SLA] Ticket %d missed its %s deadline, escalated to level %d", ticket.ID, breach.name, ticket.EscalationLevel)
+			SendSupportEscalationEmail(ticket, assignee, breach.name)
+		}
+	}
+}
+
+// supportEscalationRecipients returns SUPPORT_ESCALATION_EMAILS, or every admin's email when it isn't set
+func supportEscalationRecipients() []string {
+	var recipients []string
+	for _, email := range strings.Split(config.AppConfig.SupportEscalationEmails, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	if len(recipients) > 0 {
+		return recipients
+	}
+
+	var admins []models.User
+	database.Database.Db.Select("email").Where("role = ? AND is_deleted = false AND email <> ''", "ADMIN").Find(&admins)
+	for _, admin := range admins {
+		recipients = append(recipients, admin.Email)
+	}
+	return recipients
+}
+
+// SendSupportAssignmentEmail tells an admin a ticket was assigned to them
+func SendSupportAssignmentEmail(ticket *models.SupportTicket, admin *models.User) {
+	if admin == nil || admin.Email == "" {
+		return
+	}
+
+	due := "no deadline"
+	if ticket.FirstResponseDueAt != nil {
+		due = ticket.FirstResponseDueAt.Format("02 Jan 2006 15:04")
+	}
+
+	subject := fmt.Sprintf("Support ticket #%d assigned to you", ticket.ID)
+	body := fmt.Sprintf(`
+		<p>Dear %s,</p>
+		<p>Support ticket <strong>#%d: %s</strong> has been assigned to you.</p>
+		<div class="info-box">Priority: <strong>%s</strong><br>Category: <strong>%s</strong><br>First response due: <strong>%s</strong></div>
+	`, admin.Name, ticket.ID, ticket.Title, ticket.Priority, ticket.Category, due)
+
+	go SendEmail([]string{admin.Email}, subject, getEmailTemplate("Ticket Assigned", body))
+}
+
+// SendSupportEscalationEmail alerts the assignee and the escalation contacts that a ticket missed an SLA deadline
+func SendSupportEscalationEmail(ticket *models.SupportTicket, assignee *models.User, breach string) {
+	recipients := supportEscalationRecipients()
+	owner := "nobody"
+	if assignee != nil {
+		owner = assignee.Name
+		if assignee.Email != "" {
+			recipients = append(recipients, assignee.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("Escalation: support ticket #%d missed its %s deadline", ticket.ID, breach)
+	body := fmt.Sprintf(`
+		<p>Support ticket <strong>#%d: %s</strong> has missed its %s deadline.</p>
+		<div class="info-box">Priority: <strong>%s</strong><br>Category: <strong>%s</strong><br>Assigned to: <strong>%s</strong><br>Escalation level: <strong>%d</strong></div>
+		<p>Please follow up on this ticket as soon as possible.</p>
+	`, ticket.ID, ticket.Title, breach, ticket.Priority, ticket.Category, owner, ticket.EscalationLevel)
+
+	go SendEmail(recipients, subject, getEmailTemplate("Support SLA Breached", body))
+}
+
+// InitializeSupportSLAScheduler checks support tickets against their SLA deadlines every 5 minutes
+func InitializeSupportSLAScheduler() {
+	c := cron.New()
+	c.AddFunc("*/5 * * * *", EscalateSupportTickets)
+	c.Start()
+	log.Println("[SUPPORT-SLA] Support SLA scheduler started - runs every 5 minutes")
+}
@@ -1,57 +1,30 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fib/config"
+	"fib/database"
+	"fib/models"
 	"fmt"
-	"log"
-	"math/rand"
-	"net/http"
+	"math/big"
 	"net/smtp"
-	"time"
 )
 
-// GenerateOTP generates a 6-digit OTP
+// GenerateOTP generates a 6-digit OTP using crypto/rand
 func GenerateOTP() string {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano())) // Create a new random number generator
-	otp := ""
-	for i := 0; i < 6; i++ {
-		otp += fmt.Sprintf("%d", rng.Intn(10)) // Generate a random digit (0-9) and append to OTP string
-	}
-	return otp
-}
-
-func SendOTPToMobile(mobile, otp string) error {
-	// Replace with your own values
-	apiKey := "0Opfk6PHdUBWhVy18bqGFSecsturJMjCInm5TiD24wZKAv9QlRKl1iZgMwt8peuNC47P5nFsABj3D29E"
-	senderID := "CLASIA"
-	messageID := "197302" // DLT Template ID
-	flash := "0"
-
-	// Variables (OTP and validity time in minutes)
-	variables := fmt.Sprintf("%s|10", otp)
-
-	// Build request URL
-	url := fmt.Sprintf(
-		"https://www.fast2sms.com/dev/bulkV2?authorization=%s&route=dlt&sender_id=%s&message=%s&variables_values=%s&flash=%s&numbers=%s",
-		apiKey, senderID, messageID, variables, flash, mobile,
-	)
-
-	// Make GET request
-	resp, err := http.Get(url)
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
 	if err != nil {
-		log.Printf("Error while sending OTP: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Check if response is OK
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Failed to send OTP, response code: %d", resp.StatusCode)
-		return fmt.Errorf("failed to send OTP, code: %d", resp.StatusCode)
+		// crypto/rand should never fail; refuse to fall back to a predictable code
+		panic(fmt.Sprintf("failed to generate OTP: %v", err))
 	}
+	return fmt.Sprintf("%06d", n.Int64())
+}
 
-	log.Println("OTP sent successfully to", mobile)
-	return nil
+// IsAdmin checks that a user has the ADMIN or SUPER-ADMIN role
+func IsAdmin(userId uint) bool {
+	var user models.User
+	err := database.Database.Db.Where("id = ? AND is_deleted = false AND role IN ?", userId, []string{"ADMIN", "SUPER-ADMIN"}).First(&user).Error
+	return err == nil
 }
 
 type EmailContent struct {
@@ -144,8 +117,8 @@ func SendEnrollmentEmail(email, userName, courseName string) error {
 	return nil
 }
 
-// SendCertificateEmail sends certificate notification email
-func SendCertificateEmail(email, userName, courseName, certificateNumber string) error {
+// SendCertificateEmail sends certificate notification email with a link to the PDF
+func SendCertificateEmail(email, userName, courseName, certificateNumber, downloadURL string) error {
 	smtpHost := "smtp.gmail.com"
 	smtpPort := "587"
 
@@ -168,13 +141,16 @@ func SendCertificateEmail(email, userName, courseName, certificateNumber string)
 						<p style="font-size: 14px; color: #666666; margin-bottom: 10px;">Your Certificate Number:</p>
 						<h2 style="color: #2196F3; margin: 0;">%s</h2>
 					</div>
+					<div style="text-align: center; margin: 20px 0;">
+						<a href="%s" style="background-color: #4CAF50; color: #ffffff; padding: 12px 24px; border-radius: 4px; text-decoration: none; font-size: 16px;">Download Certificate</a>
+					</div>
 					<p style="font-size: 14px; color: #666666;">Your certificate has been approved and is now available. You can use this certificate number for verification purposes.</p>
 					<p style="font-size: 14px; color: #999999; text-align: center; margin-top: 30px;">Congratulations on this achievement!</p>
 					<p style="text-align: center; font-size: 12px; color: #bbbbbb; margin-top: 20px;">Classia Capital Team</p>
 				</div>
 			</body>
 		</html>
-	`, userName, courseName, certificateNumber)
+	`, userName, courseName, certificateNumber, downloadURL)
 
 	message := []byte(subject + "\n" + body)
 	auth := smtp.PlainAuth("", from, password, smtpHost)
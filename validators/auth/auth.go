@@ -390,3 +390,35 @@ func LoginVerifyOtpValidator() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// verify login challenge (step-up OTP for unusual logins)
+func LoginChallengeVerify() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			ChallengeID string `json:"challengeId"`
+			Code        string `json:"code"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.ChallengeID == "" {
+			errors["challengeId"] = "Challenge ID is required!"
+		}
+
+		// OTP code required
+		if reqData.Code == "" {
+			errors["code"] = "OTP code is required!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedLoginChallenge", reqData)
+		return c.Next()
+	}
+}
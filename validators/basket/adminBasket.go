@@ -2,6 +2,9 @@ package basketValidator
 
 import (
 	"fib/middleware"
+	"fib/models/basket"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,6 +18,10 @@ func ApproveBasket() fiber.Handler {
 			StartTime       *time.Time `json:"startTime"`
 			EndTime         *time.Time `json:"endTime"`
 			ScheduledDate   *time.Time `json:"scheduledDate"`
+			RiskRating      *string    `json:"riskRating"` // Overrides the AMC's rating after review
+			Comments        string     `json:"comments"`
+			PublishAt       *time.Time `json:"publishAt"` // DELIVERY and INTRADAY, overrides the AMC's request
+			ExpireAt        *time.Time `json:"expireAt"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -26,6 +33,19 @@ func ApproveBasket() fiber.Handler {
 		if reqData.BasketVersionID == 0 {
 			errors["basketVersionId"] = "Basket version ID is required!"
 		}
+		validateSchedule(reqData.PublishAt, reqData.ExpireAt, errors)
+
+		reqData.Comments = strings.TrimSpace(reqData.Comments)
+		if len(reqData.Comments) > 2000 {
+			errors["comments"] = "Comments must not exceed 2000 characters!"
+		}
+
+		if reqData.RiskRating != nil {
+			*reqData.RiskRating = strings.ToUpper(strings.TrimSpace(*reqData.RiskRating))
+			if !validRiskRatings[*reqData.RiskRating] {
+				errors["riskRating"] = "Risk rating must be LOW, MEDIUM, or HIGH!"
+			}
+		}
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -156,3 +176,217 @@ func GetCalendarView() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// SaveComplianceRule validates creating or updating a basket compliance rule
+func SaveComplianceRule() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			RuleType      string  `json:"ruleType"`
+			BasketType    string  `json:"basketType"` // Empty for every basket type
+			Threshold     float64 `json:"threshold"`
+			AllowedValues string  `json:"allowedValues"`
+			Severity      string  `json:"severity"`
+			IsActive      *bool   `json:"isActive"`
+			Description   string  `json:"description"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.RuleType = strings.ToUpper(strings.TrimSpace(reqData.RuleType))
+		validRule := false
+		for _, ruleType := range basket.ComplianceRuleTypes {
+			if reqData.RuleType == ruleType {
+				validRule = true
+			}
+		}
+		if !validRule {
+			errors["ruleType"] = "Rule type must be one of " + strings.Join(basket.ComplianceRuleTypes, ", ") + "!"
+		}
+
+		reqData.BasketType = strings.ToUpper(strings.TrimSpace(reqData.BasketType))
+		switch reqData.BasketType {
+		case "", basket.BasketTypeIntraHour, basket.BasketTypeIntraday, basket.BasketTypeDelivery:
+		default:
+			errors["basketType"] = "Basket type must be INTRA_HOUR, INTRADAY, DELIVERY, or empty for all!"
+		}
+
+		reqData.Severity = strings.ToUpper(strings.TrimSpace(reqData.Severity))
+		if reqData.Severity == "" {
+			reqData.Severity = basket.SeverityBlock
+		}
+		if reqData.Severity != basket.SeverityBlock && reqData.Severity != basket.SeverityWarn {
+			errors["severity"] = "Severity must be BLOCK or WARN!"
+		}
+
+		if reqData.Threshold < 0 {
+			errors["threshold"] = "Threshold cannot be negative!"
+		}
+		switch reqData.RuleType {
+		case basket.RuleMaxStockWeight, basket.RuleMaxSectorWeight:
+			if reqData.Threshold <= 0 || reqData.Threshold > 100 {
+				errors["threshold"] = "Threshold must be a percentage between 0 and 100!"
+			}
+		case basket.RuleMaxStockCount:
+			if reqData.Threshold < 1 {
+				errors["threshold"] = "Threshold must be at least 1 stock!"
+			}
+		case basket.RuleAllowedExchanges, basket.RuleAllowedSeries, basket.RuleAllowedInstruments:
+			reqData.AllowedValues = strings.ToUpper(strings.ReplaceAll(reqData.AllowedValues, " ", ""))
+			if strings.Trim(reqData.AllowedValues, ",") == "" {
+				errors["allowedValues"] = "Allowed values are required for this rule!"
+			}
+		}
+
+		if len(reqData.Description) > 500 {
+			errors["description"] = "Description must not exceed 500 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedComplianceRule", reqData)
+		return c.Next()
+	}
+}
+
+// RequestChanges validates sending a pending basket version back to the AMC
+func RequestChanges() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BasketVersionID uint   `json:"basketVersionId"`
+			Comments        string `json:"comments"`
+			StockNotes      []struct {
+				BasketStockID uint   `json:"basketStockId"`
+				Note          string `json:"note"`
+			} `json:"stockNotes"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.BasketVersionID == 0 {
+			errors["basketVersionId"] = "Basket version ID is required!"
+		}
+		reqData.Comments = strings.TrimSpace(reqData.Comments)
+		if reqData.Comments == "" {
+			errors["comments"] = "Comments are required!"
+		} else if len(reqData.Comments) > 2000 {
+			errors["comments"] = "Comments must not exceed 2000 characters!"
+		}
+
+		seen := make(map[uint]bool)
+		for i := range reqData.StockNotes {
+			note := &reqData.StockNotes[i]
+			note.Note = strings.TrimSpace(note.Note)
+			key := fmt.Sprintf("stockNotes[%d]", i)
+			switch {
+			case note.BasketStockID == 0:
+				errors[key] = "Basket stock ID is required!"
+			case seen[note.BasketStockID]:
+				errors[key] = "Only one note per stock!"
+			case note.Note == "":
+				errors[key] = "Note is required!"
+			case len(note.Note) > 1000:
+				errors[key] = "Note must not exceed 1000 characters!"
+			}
+			seen[note.BasketStockID] = true
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedRequestChanges", reqData)
+		return c.Next()
+	}
+}
+
+// SaveApprovalChain validates replacing a basket type's approval chain
+func SaveApprovalChain() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BasketType string `json:"basketType"` // Empty for every basket type
+			Steps      []struct {
+				Name        string `json:"name"`
+				ApproverIDs []uint `json:"approverIds"` // Empty lets every admin decide the step
+			} `json:"steps"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.BasketType = strings.ToUpper(strings.TrimSpace(reqData.BasketType))
+		switch reqData.BasketType {
+		case "", basket.BasketTypeIntraHour, basket.BasketTypeIntraday, basket.BasketTypeDelivery:
+		default:
+			errors["basketType"] = "Basket type must be INTRA_HOUR, INTRADAY, DELIVERY, or empty for all!"
+		}
+
+		if len(reqData.Steps) > 10 {
+			errors["steps"] = "An approval chain can have at most 10 steps!"
+		}
+		for i := range reqData.Steps {
+			step := &reqData.Steps[i]
+			step.Name = strings.TrimSpace(step.Name)
+			if step.Name == "" {
+				errors[fmt.Sprintf("steps[%d].name", i)] = "Step name is required!"
+			} else if len(step.Name) > 100 {
+				errors[fmt.Sprintf("steps[%d].name", i)] = "Step name must not exceed 100 characters!"
+			}
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedApprovalChain", reqData)
+		return c.Next()
+	}
+}
+
+// RescheduleBasket validates changing a basket version's go-live or sunset time
+func RescheduleBasket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BasketVersionID uint       `json:"basketVersionId"`
+			PublishAt       *time.Time `json:"publishAt"`
+			ExpireAt        *time.Time `json:"expireAt"`
+			ClearExpiry     bool       `json:"clearExpiry"` // Removes the sunset
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.BasketVersionID == 0 {
+			errors["basketVersionId"] = "Basket version ID is required!"
+		}
+		if reqData.PublishAt == nil && reqData.ExpireAt == nil && !reqData.ClearExpiry {
+			errors["publishAt"] = "Provide publishAt, expireAt or clearExpiry!"
+		}
+		if reqData.ClearExpiry && reqData.ExpireAt != nil {
+			errors["expireAt"] = "Cannot set and clear the expiry at once!"
+		}
+		validateSchedule(reqData.PublishAt, reqData.ExpireAt, errors)
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedRescheduleBasket", reqData)
+		return c.Next()
+	}
+}
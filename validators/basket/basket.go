@@ -3,10 +3,35 @@ package basketValidator
 import (
 	"fib/middleware"
 	"fib/models/basket"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// validateSchedule checks that a requested go-live and sunset lie in the future, in that order
+func validateSchedule(publishAt, expireAt *time.Time, errors map[string]string) {
+	now := time.Now()
+	if publishAt != nil && !publishAt.After(now) {
+		errors["publishAt"] = "Go-live time must be in the future!"
+	}
+	if expireAt != nil {
+		if !expireAt.After(now) {
+			errors["expireAt"] = "Expiry must be in the future!"
+		} else if publishAt != nil && !expireAt.After(*publishAt) {
+			errors["expireAt"] = "Expiry must be after the go-live time!"
+		}
+	}
+}
+
+// validRiskRatings are the risk ratings an AMC or admin may assign to a basket
+var validRiskRatings = map[string]bool{
+	basket.RiskRatingLow:    true,
+	basket.RiskRatingMedium: true,
+	basket.RiskRatingHigh:   true,
+}
+
 // CreateBasket validates basket creation request
 func CreateBasket() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -16,6 +41,7 @@ func CreateBasket() fiber.Handler {
 			BasketType      string  `json:"basketType"`
 			SubscriptionFee float64 `json:"subscriptionFee"`
 			IsFeeBased      bool    `json:"isFeeBased"`
+			RiskRating      string  `json:"riskRating"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -41,6 +67,11 @@ func CreateBasket() fiber.Handler {
 			errors["subscriptionFee"] = "Fee-based baskets must have a subscription fee greater than 0!"
 		}
 
+		reqData.RiskRating = strings.ToUpper(strings.TrimSpace(reqData.RiskRating))
+		if reqData.RiskRating != "" && !validRiskRatings[reqData.RiskRating] {
+			errors["riskRating"] = "Risk rating must be LOW, MEDIUM, or HIGH!"
+		}
+
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
 		}
@@ -59,6 +90,7 @@ func UpdateBasket() fiber.Handler {
 			Description     *string  `json:"description"`
 			SubscriptionFee *float64 `json:"subscriptionFee"`
 			IsFeeBased      *bool    `json:"isFeeBased"`
+			RiskRating      *string  `json:"riskRating"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -71,6 +103,13 @@ func UpdateBasket() fiber.Handler {
 			errors["basketId"] = "Basket ID is required!"
 		}
 
+		if reqData.RiskRating != nil {
+			*reqData.RiskRating = strings.ToUpper(strings.TrimSpace(*reqData.RiskRating))
+			if !validRiskRatings[*reqData.RiskRating] {
+				errors["riskRating"] = "Risk rating must be LOW, MEDIUM, or HIGH!"
+			}
+		}
+
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
 		}
@@ -162,7 +201,9 @@ func RemoveStock() fiber.Handler {
 func SubmitForApproval() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
-			BasketID uint `json:"basketId"`
+			BasketID  uint       `json:"basketId"`
+			PublishAt *time.Time `json:"publishAt"` // Requested go-live, DELIVERY and INTRADAY only
+			ExpireAt  *time.Time `json:"expireAt"`  // Requested sunset
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -173,6 +214,12 @@ func SubmitForApproval() fiber.Handler {
 			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Basket ID is required!", nil)
 		}
 
+		errors := make(map[string]string)
+		validateSchedule(reqData.PublishAt, reqData.ExpireAt, errors)
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
 		c.Locals("validatedSubmitForApproval", reqData)
 		return c.Next()
 	}
@@ -269,3 +316,125 @@ func EditStock() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// NewVersion validates creating a draft version from an existing one
+func NewVersion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BasketID      uint `json:"basketId"`
+			FromVersionID uint `json:"fromVersionId"` // Defaults to the basket's current version
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		if reqData.BasketID == 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Basket ID is required!", nil)
+		}
+
+		c.Locals("validatedNewVersion", reqData)
+		return c.Next()
+	}
+}
+
+// DiscardDraft validates discarding a basket's draft version
+func DiscardDraft() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BasketID uint   `json:"basketId"`
+			Reason   string `json:"reason"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.BasketID == 0 {
+			errors["basketId"] = "Basket ID is required!"
+		}
+		reqData.Reason = strings.TrimSpace(reqData.Reason)
+		if len(reqData.Reason) > 500 {
+			errors["reason"] = "Reason must not exceed 500 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedDiscardDraft", reqData)
+		return c.Next()
+	}
+}
+
+// VersionDiff validates comparing two versions of a basket by version number
+func VersionDiff() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		basketID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || basketID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid basket ID!", nil)
+		}
+
+		reqData := new(struct {
+			From int `query:"from"` // Defaults to the basket's current version
+			To   int `query:"to"`   // Defaults to the latest version
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request query!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.From < 0 {
+			errors["from"] = "From must be a version number!"
+		}
+		if reqData.To < 0 {
+			errors["to"] = "To must be a version number!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("basketID", uint(basketID))
+		c.Locals("validatedVersionDiff", reqData)
+		return c.Next()
+	}
+}
+
+// BasketCompliance validates previewing the compliance report of a basket's draft
+func BasketCompliance() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		basketID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || basketID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid basket ID!", nil)
+		}
+
+		c.Locals("basketID", uint(basketID))
+		return c.Next()
+	}
+}
+
+// ApprovalTrail validates fetching a basket version's approval decisions
+func ApprovalTrail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		basketID, err := strconv.Atoi(c.Params("id"))
+		if err != nil || basketID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid basket ID!", nil)
+		}
+
+		versionID := 0
+		if raw := c.Query("versionId"); raw != "" {
+			if versionID, err = strconv.Atoi(raw); err != nil || versionID <= 0 {
+				return middleware.ValidationErrorResponse(c, map[string]string{"versionId": "Version ID must be a positive number!"})
+			}
+		}
+
+		c.Locals("basketID", uint(basketID))
+		c.Locals("versionID", uint(versionID))
+		return c.Next()
+	}
+}
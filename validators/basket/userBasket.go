@@ -10,8 +10,9 @@ import (
 func Subscribe() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
-			BasketID uint   `json:"basketId"`
-			Period   string `json:"period"` // MONTHLY or YEARLY (optional, default: MONTHLY)
+			BasketID        uint   `json:"basketId"`
+			Period          string `json:"period"`          // MONTHLY or YEARLY (optional, default: MONTHLY)
+			AcknowledgeRisk bool   `json:"acknowledgeRisk"` // User accepts a risk profile mismatch warning
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -2,9 +2,9 @@ package courseValidator
 
 import (
 	"fib/middleware"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,11 +15,18 @@ import (
 func CreateCourseAdmin() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
-			Title        string `json:"title"`
-			Description  string `json:"description"`
-			Author       string `json:"author"`
-			Duration     int64  `json:"duration"`
-			ThumbnailURL string `json:"thumbnail_url"`
+			Title               string   `json:"title"`
+			Description         string   `json:"description"`
+			Author              string   `json:"author"`
+			Duration            int64    `json:"duration"`
+			ThumbnailURL        string   `json:"thumbnail_url"`
+			CertificatePolicy   string   `json:"certificate_policy"`    // Optional, defaults to MANUAL
+			CertificateMinScore *float64 `json:"certificate_min_score"` // Required for MIN_SCORE
+			DripEnabled         bool     `json:"drip_enabled"`
+			Price               float64  `json:"price"`
+			RefundWindowDays    *int     `json:"refund_window_days"`  // Defaults to 7
+			IncludedBasketIDs   []uint   `json:"included_basket_ids"` // Subscribers of these baskets enrol for free
+			InstructorID        *uint    `json:"instructor_id"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -32,29 +39,14 @@ func CreateCourseAdmin() fiber.Handler {
 		reqData.Description = strings.TrimSpace(reqData.Description)
 		reqData.Author = strings.TrimSpace(reqData.Author)
 
-		if reqData.Title == "" {
-			errors["title"] = "Title is required!"
-		} else if len(reqData.Title) < 3 {
-			errors["title"] = "Title must be at least 3 characters long!"
+		reqData.CertificatePolicy = strings.ToUpper(strings.TrimSpace(reqData.CertificatePolicy))
+		if reqData.CertificatePolicy == "" {
+			reqData.CertificatePolicy = "MANUAL"
 		}
 
-		if reqData.Description == "" {
-			errors["description"] = "Description is required!"
-		} else if len(reqData.Description) < 5 {
-			errors["description"] = "Description must be at least 5 characters long!"
-		}
-
-		if reqData.Author == "" {
-			errors["author"] = "Author is required!"
-		} else if len(reqData.Author) < 3 {
-			errors["author"] = "Author must be at least 3 characters long!"
-		} else if matched, _ := regexp.MatchString(`[<>{}]`, reqData.Author); matched {
-			errors["author"] = "Author name contains invalid characters!"
-		}
-
-		if reqData.Duration <= 0 {
-			errors["duration"] = "Duration must be a positive number!"
-		}
+		ValidateCourseFields(reqData.Title, reqData.Description, reqData.Author, reqData.Duration, true, "", errors)
+		ValidateCertificatePolicy(reqData.CertificatePolicy, reqData.CertificateMinScore, true, "", errors)
+		ValidateCoursePricing(&reqData.Price, reqData.RefundWindowDays, "", errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -79,12 +71,19 @@ func UpdateCourseAdmin() fiber.Handler {
 		}
 
 		reqData := new(struct {
-			Title        string `json:"title"`
-			Description  string `json:"description"`
-			Author       string `json:"author"`
-			Duration     int64  `json:"duration"`
-			ThumbnailURL string `json:"thumbnail_url"`
-			Status       string `json:"status"`
+			Title               string   `json:"title"`
+			Description         string   `json:"description"`
+			Author              string   `json:"author"`
+			Duration            int64    `json:"duration"`
+			ThumbnailURL        string   `json:"thumbnail_url"`
+			Status              string   `json:"status"`
+			CertificatePolicy   string   `json:"certificate_policy"`
+			CertificateMinScore *float64 `json:"certificate_min_score"`
+			DripEnabled         *bool    `json:"drip_enabled"`
+			Price               *float64 `json:"price"`
+			RefundWindowDays    *int     `json:"refund_window_days"`
+			IncludedBasketIDs   []uint   `json:"included_basket_ids"` // Replaces the list when given, [] clears it
+			InstructorID        *uint    `json:"instructor_id"`       // 0 removes the instructor
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -98,21 +97,7 @@ func UpdateCourseAdmin() fiber.Handler {
 		reqData.Author = strings.TrimSpace(reqData.Author)
 		reqData.Status = strings.TrimSpace(reqData.Status)
 
-		if reqData.Title != "" && len(reqData.Title) < 3 {
-			errors["title"] = "Title must be at least 3 characters long!"
-		}
-
-		if reqData.Description != "" && len(reqData.Description) < 5 {
-			errors["description"] = "Description must be at least 5 characters long!"
-		}
-
-		if reqData.Author != "" {
-			if len(reqData.Author) < 3 {
-				errors["author"] = "Author must be at least 3 characters long!"
-			} else if matched, _ := regexp.MatchString(`[<>{}]`, reqData.Author); matched {
-				errors["author"] = "Author name contains invalid characters!"
-			}
-		}
+		ValidateCourseFields(reqData.Title, reqData.Description, reqData.Author, reqData.Duration, false, "", errors)
 
 		if reqData.Status != "" {
 			validStatuses := map[string]bool{"DRAFT": true, "ACTIVE": true, "INACTIVE": true}
@@ -121,6 +106,10 @@ func UpdateCourseAdmin() fiber.Handler {
 			}
 		}
 
+		reqData.CertificatePolicy = strings.ToUpper(strings.TrimSpace(reqData.CertificatePolicy))
+		ValidateCertificatePolicy(reqData.CertificatePolicy, reqData.CertificateMinScore, false, "", errors)
+		ValidateCoursePricing(reqData.Price, reqData.RefundWindowDays, "", errors)
+
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
 		}
@@ -192,9 +181,11 @@ func CreateModule() fiber.Handler {
 		}
 
 		reqData := new(struct {
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			OrderIndex  int    `json:"order_index"`
+			Title                string `json:"title"`
+			Description          string `json:"description"`
+			OrderIndex           int    `json:"order_index"`
+			PrerequisiteModuleID *uint  `json:"prerequisite_module_id"` // 0 removes the prerequisite
+			DripOffsetDays       *int   `json:"drip_offset_days"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -206,11 +197,7 @@ func CreateModule() fiber.Handler {
 		reqData.Title = strings.TrimSpace(reqData.Title)
 		reqData.Description = strings.TrimSpace(reqData.Description)
 
-		if reqData.Title == "" {
-			errors["title"] = "Module title is required!"
-		} else if len(reqData.Title) < 3 {
-			errors["title"] = "Module title must be at least 3 characters long!"
-		}
+		ValidateModuleFields(reqData.Title, reqData.DripOffsetDays, true, "", errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -243,9 +230,11 @@ func UpdateModule() fiber.Handler {
 		}
 
 		reqData := new(struct {
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			OrderIndex  int    `json:"order_index"`
+			Title                string `json:"title"`
+			Description          string `json:"description"`
+			OrderIndex           int    `json:"order_index"`
+			PrerequisiteModuleID *uint  `json:"prerequisite_module_id"` // 0 removes the prerequisite
+			DripOffsetDays       *int   `json:"drip_offset_days"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -255,9 +244,7 @@ func UpdateModule() fiber.Handler {
 		errors := make(map[string]string)
 		reqData.Title = strings.TrimSpace(reqData.Title)
 
-		if reqData.Title != "" && len(reqData.Title) < 3 {
-			errors["title"] = "Module title must be at least 3 characters long!"
-		}
+		ValidateModuleFields(reqData.Title, reqData.DripOffsetDays, false, "", errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -337,14 +324,16 @@ func CreateContentAdmin() fiber.Handler {
 		}
 
 		reqData := new(struct {
-			Day         int    `json:"day"`
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			ContentType string `json:"content_type"`
-			TextContent string `json:"text_content"`
-			VideoURL    string `json:"video_url"`
-			ImageURL    string `json:"image_url"`
-			OrderIndex  int    `json:"order_index"`
+			Day                    int     `json:"day"`
+			Title                  string  `json:"title"`
+			Description            string  `json:"description"`
+			ContentType            string  `json:"content_type"`
+			TextContent            string  `json:"text_content"`
+			VideoURL               string  `json:"video_url"`
+			ImageURL               string  `json:"image_url"`
+			OrderIndex             int     `json:"order_index"`
+			VideoDurationSeconds   int     `json:"video_duration_seconds"`
+			VideoCompletionPercent float64 `json:"video_completion_percent"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -357,38 +346,16 @@ func CreateContentAdmin() fiber.Handler {
 		reqData.Description = strings.TrimSpace(reqData.Description)
 		reqData.ContentType = strings.ToUpper(strings.TrimSpace(reqData.ContentType))
 
-		if reqData.Title == "" {
-			errors["title"] = "Content title is required!"
-		} else if len(reqData.Title) < 3 {
-			errors["title"] = "Content title must be at least 3 characters long!"
-		}
-
-		if reqData.Day < 1 {
-			errors["day"] = "Day must be at least 1!"
-		}
-
-		validContentTypes := map[string]bool{"TEXT": true, "MCQ": true, "VIDEO": true, "IMAGE": true}
-		if reqData.ContentType == "" {
-			errors["content_type"] = "Content type is required!"
-		} else if !validContentTypes[reqData.ContentType] {
-			errors["content_type"] = "Content type must be TEXT, MCQ, VIDEO, or IMAGE!"
-		}
-
-		// Validate based on content type
-		switch reqData.ContentType {
-		case "TEXT":
-			if strings.TrimSpace(reqData.TextContent) == "" {
-				errors["text_content"] = "Text content is required for TEXT type!"
-			}
-		case "VIDEO":
-			if strings.TrimSpace(reqData.VideoURL) == "" {
-				errors["video_url"] = "Video URL is required for VIDEO type!"
-			}
-		case "IMAGE":
-			if strings.TrimSpace(reqData.ImageURL) == "" {
-				errors["image_url"] = "Image URL is required for IMAGE type!"
-			}
-		}
+		ValidateContentFields(ContentFields{
+			Day:                    reqData.Day,
+			Title:                  reqData.Title,
+			ContentType:            reqData.ContentType,
+			TextContent:            reqData.TextContent,
+			VideoURL:               reqData.VideoURL,
+			ImageURL:               reqData.ImageURL,
+			VideoDurationSeconds:   reqData.VideoDurationSeconds,
+			VideoCompletionPercent: reqData.VideoCompletionPercent,
+		}, true, "", errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -415,14 +382,16 @@ func UpdateContentAdmin() fiber.Handler {
 		}
 
 		reqData := new(struct {
-			Day         int    `json:"day"`
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			ContentType string `json:"content_type"`
-			TextContent string `json:"text_content"`
-			VideoURL    string `json:"video_url"`
-			ImageURL    string `json:"image_url"`
-			OrderIndex  int    `json:"order_index"`
+			Day                    int     `json:"day"`
+			Title                  string  `json:"title"`
+			Description            string  `json:"description"`
+			ContentType            string  `json:"content_type"`
+			TextContent            string  `json:"text_content"`
+			VideoURL               string  `json:"video_url"`
+			ImageURL               string  `json:"image_url"`
+			OrderIndex             int     `json:"order_index"`
+			VideoDurationSeconds   int     `json:"video_duration_seconds"`
+			VideoCompletionPercent float64 `json:"video_completion_percent"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -433,16 +402,16 @@ func UpdateContentAdmin() fiber.Handler {
 		reqData.Title = strings.TrimSpace(reqData.Title)
 		reqData.ContentType = strings.ToUpper(strings.TrimSpace(reqData.ContentType))
 
-		if reqData.Title != "" && len(reqData.Title) < 3 {
-			errors["title"] = "Content title must be at least 3 characters long!"
-		}
-
-		if reqData.ContentType != "" {
-			validContentTypes := map[string]bool{"TEXT": true, "MCQ": true, "VIDEO": true, "IMAGE": true}
-			if !validContentTypes[reqData.ContentType] {
-				errors["content_type"] = "Content type must be TEXT, MCQ, VIDEO, or IMAGE!"
-			}
-		}
+		ValidateContentFields(ContentFields{
+			Day:                    reqData.Day,
+			Title:                  reqData.Title,
+			ContentType:            reqData.ContentType,
+			TextContent:            reqData.TextContent,
+			VideoURL:               reqData.VideoURL,
+			ImageURL:               reqData.ImageURL,
+			VideoDurationSeconds:   reqData.VideoDurationSeconds,
+			VideoCompletionPercent: reqData.VideoCompletionPercent,
+		}, false, "", errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -588,6 +557,193 @@ func DeleteMCQOption() fiber.Handler {
 	}
 }
 
+// ============ Quiz Validators ============
+
+// UpdateQuizSettings validates quiz settings update; omitted fields are left unchanged
+func UpdateQuizSettings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+		if contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content ID is required!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			PassPercent      *float64 `json:"pass_percent"`
+			NegativeMarking  *bool    `json:"negative_marking"`
+			NegativeFraction *float64 `json:"negative_fraction"`
+			MaxAttempts      *int     `json:"max_attempts"`
+			TimeLimitSeconds *int     `json:"time_limit_seconds"`
+			ShuffleQuestions *bool    `json:"shuffle_questions"`
+			ShuffleOptions   *bool    `json:"shuffle_options"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		ValidateQuizSettings(reqData.PassPercent, reqData.NegativeFraction, reqData.MaxAttempts, reqData.TimeLimitSeconds, "", errors)
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("contentID", contentID)
+		c.Locals("validatedQuizSettings", reqData)
+		return c.Next()
+	}
+}
+
+// validateQuizOptions checks that a quiz question has at least 2 options and at least one correct answer
+func validateQuizOptions(options []struct {
+	OptionText string `json:"option_text"`
+	IsCorrect  bool   `json:"is_correct"`
+}, errors map[string]string) {
+	if len(options) < 2 {
+		errors["options"] = "At least 2 options are required!"
+		return
+	}
+	if len(options) > 10 {
+		errors["options"] = "A question can have at most 10 options!"
+		return
+	}
+
+	hasCorrect := false
+	for i := range options {
+		options[i].OptionText = strings.TrimSpace(options[i].OptionText)
+		if options[i].OptionText == "" {
+			errors["options"] = "Option text is required!"
+			return
+		}
+		hasCorrect = hasCorrect || options[i].IsCorrect
+	}
+	if !hasCorrect {
+		errors["options"] = "At least one option must be correct!"
+	}
+}
+
+// AddQuizQuestion validates quiz question creation with its options
+func AddQuizQuestion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+		if contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Content ID is required!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			Question   string  `json:"question"`
+			Marks      float64 `json:"marks"`
+			OrderIndex int     `json:"order_index"`
+			Options    []struct {
+				OptionText string `json:"option_text"`
+				IsCorrect  bool   `json:"is_correct"`
+			} `json:"options"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+		reqData.Question = strings.TrimSpace(reqData.Question)
+
+		if reqData.Question == "" {
+			errors["question"] = "Question is required!"
+		}
+		if reqData.Marks == 0 {
+			reqData.Marks = 1
+		}
+		if reqData.Marks < 0 || reqData.Marks > 100 {
+			errors["marks"] = "Marks must be greater than 0 and at most 100!"
+		}
+		validateQuizOptions(reqData.Options, errors)
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("contentID", contentID)
+		c.Locals("validatedQuizQuestion", reqData)
+		return c.Next()
+	}
+}
+
+// UpdateQuizQuestion validates quiz question update; options replace the existing ones when given
+func UpdateQuizQuestion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		questionIDStr := strings.TrimSpace(c.Params("question_id"))
+		if questionIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Question ID is required!", nil)
+		}
+
+		questionID, err := strconv.Atoi(questionIDStr)
+		if err != nil || questionID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Question ID!", nil)
+		}
+
+		reqData := new(struct {
+			Question   string  `json:"question"`
+			Marks      float64 `json:"marks"`
+			OrderIndex int     `json:"order_index"`
+			Options    []struct {
+				OptionText string `json:"option_text"`
+				IsCorrect  bool   `json:"is_correct"`
+			} `json:"options"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+		reqData.Question = strings.TrimSpace(reqData.Question)
+
+		if reqData.Marks < 0 || reqData.Marks > 100 {
+			errors["marks"] = "Marks must be greater than 0 and at most 100!"
+		}
+		if reqData.Options != nil {
+			validateQuizOptions(reqData.Options, errors)
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("questionID", questionID)
+		c.Locals("validatedQuizQuestionUpdate", reqData)
+		return c.Next()
+	}
+}
+
+// DeleteQuizQuestion validates quiz question deletion
+func DeleteQuizQuestion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		questionIDStr := strings.TrimSpace(c.Params("question_id"))
+		if questionIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Question ID is required!", nil)
+		}
+
+		questionID, err := strconv.Atoi(questionIDStr)
+		if err != nil || questionID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Question ID!", nil)
+		}
+
+		c.Locals("questionID", questionID)
+		return c.Next()
+	}
+}
+
 // ============ Enrollment & Progress Validators ============
 
 // GetCourseEnrollments validates course enrollments list request
@@ -717,3 +873,119 @@ func AdminList() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// ExportCourse validates a course package export request
+func ExportCourse() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("id"))
+		if courseIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID is required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		format := strings.ToLower(strings.TrimSpace(c.Query("format", "json")))
+		if format != "json" && format != "zip" {
+			return middleware.ValidationErrorResponse(c, map[string]string{"format": "Format must be json or zip!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("exportFormat", format)
+		return c.Next()
+	}
+}
+
+// ImportCourse validates a course package import. The package is the JSON body or a multipart "file" (JSON or ZIP).
+func ImportCourse() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			CourseID int  `json:"course_id"` // Course to update, 0 creates a new course
+			DryRun   bool `json:"dry_run"`   // Only report the changes
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		if reqData.CourseID < 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		if len(c.Body()) == 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course package is required!", nil)
+		}
+
+		c.Locals("courseID", reqData.CourseID)
+		c.Locals("dryRun", reqData.DryRun)
+		return c.Next()
+	}
+}
+
+// CourseAnalytics validates a course analytics request. from and to filter by enrolment date, both inclusive;
+// CSV exports need the report to export.
+func CourseAnalytics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("id"))
+		if courseIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID is required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		reqData := new(struct {
+			From   string `json:"from"`   // YYYY-MM-DD
+			To     string `json:"to"`     // YYYY-MM-DD
+			Format string `json:"format"` // json (default) or csv
+			Report string `json:"report"` // funnel, contents or cohorts, for CSV
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		var from, to time.Time
+		if reqData.From != "" {
+			if from, err = time.ParseInLocation("2006-01-02", reqData.From, time.Local); err != nil {
+				errors["from"] = "From must be a date in YYYY-MM-DD format!"
+			}
+		}
+		if reqData.To != "" {
+			if to, err = time.ParseInLocation("2006-01-02", reqData.To, time.Local); err != nil {
+				errors["to"] = "To must be a date in YYYY-MM-DD format!"
+			}
+		}
+		if !from.IsZero() && !to.IsZero() && to.Before(from) {
+			errors["to"] = "To must not be before from!"
+		}
+
+		reqData.Format = strings.ToLower(strings.TrimSpace(reqData.Format))
+		if reqData.Format == "" {
+			reqData.Format = "json"
+		}
+		if reqData.Format != "json" && reqData.Format != "csv" {
+			errors["format"] = "Format must be json or csv!"
+		}
+
+		reqData.Report = strings.ToLower(strings.TrimSpace(reqData.Report))
+		validReports := map[string]bool{"funnel": true, "contents": true, "cohorts": true}
+		if reqData.Format == "csv" && !validReports[reqData.Report] {
+			errors["report"] = "Report must be funnel, contents, or cohorts for CSV exports!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("validatedAnalyticsQuery", reqData)
+		return c.Next()
+	}
+}
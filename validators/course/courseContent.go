@@ -261,6 +261,147 @@ func SubmitMCQ() fiber.Handler {
 	}
 }
 
+// QuizContent validates the course and content IDs of quiz start and attempt history requests
+func QuizContent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+
+		if courseIDStr == "" || contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Content ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("contentID", contentID)
+		return c.Next()
+	}
+}
+
+// SubmitQuiz validates quiz submission request
+func SubmitQuiz() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+
+		if courseIDStr == "" || contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Content ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			AttemptID uint `json:"attempt_id"`
+			Answers   []struct {
+				QuestionID uint   `json:"question_id"`
+				OptionIDs  []uint `json:"option_ids"`
+			} `json:"answers"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.AttemptID == 0 {
+			errors["attempt_id"] = "Attempt ID is required!"
+		}
+
+		seen := make(map[uint]bool)
+		for _, answer := range reqData.Answers {
+			if answer.QuestionID == 0 {
+				errors["answers"] = "Each answer needs a question ID!"
+				break
+			}
+			if seen[answer.QuestionID] {
+				errors["answers"] = "Each question can be answered only once!"
+				break
+			}
+			seen[answer.QuestionID] = true
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("contentID", contentID)
+		c.Locals("validatedQuizSubmission", reqData)
+		return c.Next()
+	}
+}
+
+// VideoProgress validates a video player progress event
+func VideoProgress() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+
+		if courseIDStr == "" || contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Content ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			PositionSeconds float64 `json:"position_seconds"`
+			DurationSeconds float64 `json:"duration_seconds"`
+			WatchedSeconds  float64 `json:"watched_seconds"` // Seconds played since the previous event
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.PositionSeconds < 0 {
+			errors["position_seconds"] = "Position can't be negative!"
+		}
+		if reqData.DurationSeconds < 0 || reqData.DurationSeconds > 86400 {
+			errors["duration_seconds"] = "Duration must be between 0 and 86400 seconds!"
+		}
+		if reqData.WatchedSeconds < 0 || reqData.WatchedSeconds > 3600 {
+			errors["watched_seconds"] = "Watched seconds must be between 0 and 3600!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("contentID", contentID)
+		c.Locals("validatedVideoProgress", reqData)
+		return c.Next()
+	}
+}
+
 // GetCourseProgress validates progress request
 func GetCourseProgress() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -57,3 +57,37 @@ func GetUserEnrollments() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// RefundCourse validates a course refund request; the reason is optional
+func RefundCourse() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		if courseIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID is required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		reqData := new(struct {
+			Reason string `json:"reason"`
+		})
+
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(reqData); err != nil {
+				return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+			}
+		}
+
+		reqData.Reason = strings.TrimSpace(reqData.Reason)
+		if len(reqData.Reason) > 500 {
+			return middleware.ValidationErrorResponse(c, map[string]string{"reason": "Reason must not exceed 500 characters!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("refundReason", reqData.Reason)
+		return c.Next()
+	}
+}
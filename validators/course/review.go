@@ -0,0 +1,418 @@
+package courseValidator
+
+import (
+	"fib/middleware"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SubmitCourseReview validates a learner's rating and review of a course
+func SubmitCourseReview() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		if courseIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID is required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		reqData := new(struct {
+			Rating int    `json:"rating"`
+			Review string `json:"review"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.Rating < 1 || reqData.Rating > 5 {
+			errors["rating"] = "Rating must be between 1 and 5!"
+		}
+
+		reqData.Review = strings.TrimSpace(reqData.Review)
+		if len(reqData.Review) > 2000 {
+			errors["review"] = "Review must not exceed 2000 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("validatedCourseReview", reqData)
+		return c.Next()
+	}
+}
+
+// CourseReviewList validates the public review list of a course
+func CourseReviewList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		if courseIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID is required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		reqData := new(struct {
+			Page  *int `json:"page"`
+			Limit *int `json:"limit"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		if reqData.Limit != nil && (*reqData.Limit < 1 || *reqData.Limit > 100) {
+			return middleware.ValidationErrorResponse(c, map[string]string{"limit": "Limit must be between 1 and 100!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("validatedReviewQuery", reqData)
+		return c.Next()
+	}
+}
+
+// AdminCourseReviewList validates the review moderation queue filters
+func AdminCourseReviewList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Page     *int   `json:"page"`
+			Limit    *int   `json:"limit"`
+			Status   string `json:"status"`
+			CourseID uint   `json:"course_id"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		if reqData.Limit != nil && (*reqData.Limit < 1 || *reqData.Limit > 100) {
+			return middleware.ValidationErrorResponse(c, map[string]string{"limit": "Limit must be between 1 and 100!"})
+		}
+
+		reqData.Status = strings.ToUpper(strings.TrimSpace(reqData.Status))
+		if reqData.Status != "" {
+			validStatuses := map[string]bool{"PENDING": true, "APPROVED": true, "REJECTED": true}
+			if !validStatuses[reqData.Status] {
+				return middleware.ValidationErrorResponse(c, map[string]string{"status": "Status must be PENDING, APPROVED, or REJECTED!"})
+			}
+		}
+
+		c.Locals("validatedAdminReviewQuery", reqData)
+		return c.Next()
+	}
+}
+
+// ModerateCourseReview validates approving or rejecting a course review
+func ModerateCourseReview() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reviewIDStr := strings.TrimSpace(c.Params("review_id"))
+		if reviewIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Review ID is required!", nil)
+		}
+
+		reviewID, err := strconv.Atoi(reviewIDStr)
+		if err != nil || reviewID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Review ID!", nil)
+		}
+
+		reqData := new(struct {
+			Action string `json:"action"` // APPROVE, REJECT
+			Reason string `json:"reason"` // Shown to the reviewer on rejection
+			Reply  string `json:"reply"`  // Optional public reply
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.Action = strings.ToUpper(strings.TrimSpace(reqData.Action))
+		if reqData.Action != "APPROVE" && reqData.Action != "REJECT" {
+			errors["action"] = "Action must be APPROVE or REJECT!"
+		}
+
+		reqData.Reason = strings.TrimSpace(reqData.Reason)
+		if reqData.Action == "REJECT" && reqData.Reason == "" {
+			errors["reason"] = "Reason is required when rejecting a review!"
+		} else if len(reqData.Reason) > 500 {
+			errors["reason"] = "Reason must not exceed 500 characters!"
+		}
+
+		reqData.Reply = strings.TrimSpace(reqData.Reply)
+		if len(reqData.Reply) > 2000 {
+			errors["reply"] = "Reply must not exceed 2000 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("reviewID", reviewID)
+		c.Locals("validatedReviewModeration", reqData)
+		return c.Next()
+	}
+}
+
+// DiscussionList validates the discussion thread list of a content item
+func DiscussionList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+
+		if courseIDStr == "" || contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Content ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			Page       *int `json:"page"`
+			Limit      *int `json:"limit"`
+			Unanswered bool `json:"unanswered"` // Only threads without a reply from the course team
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		if reqData.Limit != nil && (*reqData.Limit < 1 || *reqData.Limit > 100) {
+			return middleware.ValidationErrorResponse(c, map[string]string{"limit": "Limit must be between 1 and 100!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("contentID", contentID)
+		c.Locals("validatedDiscussionQuery", reqData)
+		return c.Next()
+	}
+}
+
+// CreateDiscussionThread validates a new question on a content item
+func CreateDiscussionThread() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		contentIDStr := strings.TrimSpace(c.Params("content_id"))
+
+		if courseIDStr == "" || contentIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Content ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		contentID, err := strconv.Atoi(contentIDStr)
+		if err != nil || contentID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Content ID!", nil)
+		}
+
+		reqData := new(struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.Title = strings.TrimSpace(reqData.Title)
+		if reqData.Title == "" {
+			errors["title"] = "Title is required!"
+		} else if len(reqData.Title) < 5 {
+			errors["title"] = "Title must be at least 5 characters long!"
+		} else if len(reqData.Title) > 200 {
+			errors["title"] = "Title must not exceed 200 characters!"
+		}
+
+		reqData.Body = strings.TrimSpace(reqData.Body)
+		if len(reqData.Body) > 5000 {
+			errors["body"] = "Body must not exceed 5000 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("contentID", contentID)
+		c.Locals("validatedDiscussionThread", reqData)
+		return c.Next()
+	}
+}
+
+// DiscussionThread validates the course and thread IDs of a discussion route
+func DiscussionThread() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		threadIDStr := strings.TrimSpace(c.Params("thread_id"))
+
+		if courseIDStr == "" || threadIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Thread ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		threadID, err := strconv.Atoi(threadIDStr)
+		if err != nil || threadID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Thread ID!", nil)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("threadID", threadID)
+		return c.Next()
+	}
+}
+
+// ReplyDiscussion validates a reply to a discussion thread
+func ReplyDiscussion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		threadIDStr := strings.TrimSpace(c.Params("thread_id"))
+
+		if courseIDStr == "" || threadIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Thread ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		threadID, err := strconv.Atoi(threadIDStr)
+		if err != nil || threadID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Thread ID!", nil)
+		}
+
+		reqData := new(struct {
+			Body string `json:"body"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		reqData.Body = strings.TrimSpace(reqData.Body)
+		if reqData.Body == "" {
+			return middleware.ValidationErrorResponse(c, map[string]string{"body": "Reply is required!"})
+		}
+		if len(reqData.Body) > 5000 {
+			return middleware.ValidationErrorResponse(c, map[string]string{"body": "Reply must not exceed 5000 characters!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("threadID", threadID)
+		c.Locals("validatedDiscussionReply", reqData)
+		return c.Next()
+	}
+}
+
+// ResolveDiscussion validates closing or reopening a discussion thread
+func ResolveDiscussion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		threadIDStr := strings.TrimSpace(c.Params("thread_id"))
+
+		if courseIDStr == "" || threadIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Thread ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		threadID, err := strconv.Atoi(threadIDStr)
+		if err != nil || threadID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Thread ID!", nil)
+		}
+
+		reqData := new(struct {
+			IsResolved      bool  `json:"is_resolved"`
+			AcceptedReplyID *uint `json:"accepted_reply_id"` // Optional, marks the reply that answered the question
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		if !reqData.IsResolved && reqData.AcceptedReplyID != nil {
+			return middleware.ValidationErrorResponse(c, map[string]string{"accepted_reply_id": "Only a resolved thread can have an accepted reply!"})
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("threadID", threadID)
+		c.Locals("validatedDiscussionResolve", reqData)
+		return c.Next()
+	}
+}
+
+// DeleteDiscussionReply validates removing a reply from a discussion thread
+func DeleteDiscussionReply() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		courseIDStr := strings.TrimSpace(c.Params("course_id"))
+		replyIDStr := strings.TrimSpace(c.Params("reply_id"))
+
+		if courseIDStr == "" || replyIDStr == "" {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Course ID and Reply ID are required!", nil)
+		}
+
+		courseID, err := strconv.Atoi(courseIDStr)
+		if err != nil || courseID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Course ID!", nil)
+		}
+
+		replyID, err := strconv.Atoi(replyIDStr)
+		if err != nil || replyID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid Reply ID!", nil)
+		}
+
+		c.Locals("courseID", courseID)
+		c.Locals("replyID", replyID)
+		return c.Next()
+	}
+}
+
+// AdminDiscussionList validates the discussion queue filters for admins
+func AdminDiscussionList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Page       *int `json:"page"`
+			Limit      *int `json:"limit"`
+			CourseID   uint `json:"course_id"`
+			Unanswered bool `json:"unanswered"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid query parameters!", nil)
+		}
+
+		c.Locals("validatedAdminDiscussionQuery", reqData)
+		return c.Next()
+	}
+}
@@ -0,0 +1,160 @@
+package courseValidator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Field rules shared by the admin endpoints and course package imports. Problems are added to errors under the field
+// name, prefixed by at when the value is nested in a larger document (e.g. "modules[0]" gives "modules[0].title").
+
+// fieldKey joins the path of a nested value and a field name
+func fieldKey(at, field string) string {
+	if at == "" {
+		return field
+	}
+	return at + "." + field
+}
+
+// ValidateCourseFields checks the title, description, author and duration of a course.
+// On update empty values keep the current ones, so they are only required when creating.
+func ValidateCourseFields(title, description, author string, duration int64, creating bool, at string, errors map[string]string) {
+	if title == "" && creating {
+		errors[fieldKey(at, "title")] = "Title is required!"
+	} else if title != "" && len(title) < 3 {
+		errors[fieldKey(at, "title")] = "Title must be at least 3 characters long!"
+	}
+
+	if description == "" && creating {
+		errors[fieldKey(at, "description")] = "Description is required!"
+	} else if description != "" && len(description) < 5 {
+		errors[fieldKey(at, "description")] = "Description must be at least 5 characters long!"
+	}
+
+	if author == "" && creating {
+		errors[fieldKey(at, "author")] = "Author is required!"
+	} else if author != "" && len(author) < 3 {
+		errors[fieldKey(at, "author")] = "Author must be at least 3 characters long!"
+	} else if matched, _ := regexp.MatchString(`[<>{}]`, author); matched {
+		errors[fieldKey(at, "author")] = "Author name contains invalid characters!"
+	}
+
+	if (creating || duration != 0) && duration <= 0 {
+		errors[fieldKey(at, "duration")] = "Duration must be a positive number!"
+	}
+}
+
+// ValidateCertificatePolicy checks the certificate issuance policy and minimum assessment score of a course.
+// On update an empty policy keeps the current one, so the score is only required when creating.
+func ValidateCertificatePolicy(policy string, minScore *float64, creating bool, at string, errors map[string]string) {
+	if policy != "" {
+		validPolicies := map[string]bool{"MANUAL": true, "AUTO": true, "MIN_SCORE": true}
+		if !validPolicies[policy] {
+			errors[fieldKey(at, "certificate_policy")] = "Certificate policy must be MANUAL, AUTO, or MIN_SCORE!"
+		}
+	}
+
+	if minScore != nil && (*minScore < 0 || *minScore > 100) {
+		errors[fieldKey(at, "certificate_min_score")] = "Minimum score must be between 0 and 100!"
+	} else if policy == "MIN_SCORE" && minScore == nil && creating {
+		errors[fieldKey(at, "certificate_min_score")] = "Minimum score is required for the MIN_SCORE policy!"
+	}
+}
+
+// ValidateCoursePricing checks a course's price and refund window. Wallet balances are whole rupees, so is the price.
+func ValidateCoursePricing(price *float64, refundWindowDays *int, at string, errors map[string]string) {
+	if price != nil && (*price < 0 || *price != float64(int64(*price))) {
+		errors[fieldKey(at, "price")] = "Price must be a whole amount of 0 or more!"
+	}
+	if refundWindowDays != nil && (*refundWindowDays < 0 || *refundWindowDays > 90) {
+		errors[fieldKey(at, "refund_window_days")] = "Refund window must be between 0 and 90 days!"
+	}
+}
+
+// ValidateModuleFields checks the title and drip offset of a module; the prerequisite is checked against the course
+// by the caller
+func ValidateModuleFields(title string, dripOffsetDays *int, creating bool, at string, errors map[string]string) {
+	if title == "" && creating {
+		errors[fieldKey(at, "title")] = "Module title is required!"
+	} else if title != "" && len(title) < 3 {
+		errors[fieldKey(at, "title")] = "Module title must be at least 3 characters long!"
+	}
+
+	if dripOffsetDays != nil && (*dripOffsetDays < 0 || *dripOffsetDays > 3650) {
+		errors[fieldKey(at, "drip_offset_days")] = "Drip offset must be between 0 and 3650 days!"
+	}
+}
+
+// ContentFields are the fields of a content item that ValidateContentFields checks
+type ContentFields struct {
+	Day                    int
+	Title                  string
+	ContentType            string
+	TextContent            string
+	VideoURL               string
+	ImageURL               string
+	VideoDurationSeconds   int
+	VideoCompletionPercent float64
+}
+
+// ValidateContentFields checks a content item. On update empty values keep the current ones, so the day, the type and
+// the body its type needs are only required when creating.
+func ValidateContentFields(content ContentFields, creating bool, at string, errors map[string]string) {
+	if content.Title == "" && creating {
+		errors[fieldKey(at, "title")] = "Content title is required!"
+	} else if content.Title != "" && len(content.Title) < 3 {
+		errors[fieldKey(at, "title")] = "Content title must be at least 3 characters long!"
+	}
+
+	if creating && content.Day < 1 {
+		errors[fieldKey(at, "day")] = "Day must be at least 1!"
+	}
+
+	validContentTypes := map[string]bool{"TEXT": true, "MCQ": true, "VIDEO": true, "IMAGE": true, "QUIZ": true}
+	if content.ContentType == "" && creating {
+		errors[fieldKey(at, "content_type")] = "Content type is required!"
+	} else if content.ContentType != "" && !validContentTypes[content.ContentType] {
+		errors[fieldKey(at, "content_type")] = "Content type must be TEXT, MCQ, VIDEO, IMAGE, or QUIZ!"
+	}
+
+	if creating {
+		switch content.ContentType {
+		case "TEXT":
+			if strings.TrimSpace(content.TextContent) == "" {
+				errors[fieldKey(at, "text_content")] = "Text content is required for TEXT type!"
+			}
+		case "VIDEO":
+			if strings.TrimSpace(content.VideoURL) == "" {
+				errors[fieldKey(at, "video_url")] = "Video URL is required for VIDEO type!"
+			}
+		case "IMAGE":
+			if strings.TrimSpace(content.ImageURL) == "" {
+				errors[fieldKey(at, "image_url")] = "Image URL is required for IMAGE type!"
+			}
+		}
+	}
+
+	// The video length and completion threshold are optional
+	if content.VideoDurationSeconds < 0 {
+		errors[fieldKey(at, "video_duration_seconds")] = "Video duration can't be negative!"
+	}
+	if content.VideoCompletionPercent < 0 || content.VideoCompletionPercent > 100 {
+		errors[fieldKey(at, "video_completion_percent")] = "Video completion percent must be between 0 and 100!"
+	}
+}
+
+// ValidateQuizSettings checks the scoring and limits of a quiz; nil values are left unchanged
+func ValidateQuizSettings(passPercent, negativeFraction *float64, maxAttempts, timeLimitSeconds *int, at string, errors map[string]string) {
+	if passPercent != nil && (*passPercent <= 0 || *passPercent > 100) {
+		errors[fieldKey(at, "pass_percent")] = "Pass percent must be greater than 0 and at most 100!"
+	}
+	if negativeFraction != nil && (*negativeFraction < 0 || *negativeFraction > 1) {
+		errors[fieldKey(at, "negative_fraction")] = "Negative fraction must be between 0 and 1!"
+	}
+	if maxAttempts != nil && *maxAttempts < 0 {
+		errors[fieldKey(at, "max_attempts")] = "Max attempts must be 0 (unlimited) or more!"
+	}
+	if timeLimitSeconds != nil && *timeLimitSeconds != 0 && (*timeLimitSeconds < 60 || *timeLimitSeconds > 86400) {
+		errors[fieldKey(at, "time_limit_seconds")] = "Time limit must be 0 (untimed) or between 60 and 86400 seconds!"
+	}
+}
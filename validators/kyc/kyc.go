@@ -0,0 +1,108 @@
+package kycValidator
+
+import (
+	"fib/middleware"
+	"fib/models"
+	"fib/utils"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var validKYCStatuses = []string{
+	models.KYCStatusNotStarted,
+	models.KYCStatusInProgress,
+	models.KYCStatusPendingReview,
+	models.KYCStatusVerified,
+	models.KYCStatusRejected,
+	models.KYCStatusExpired,
+}
+
+// UploadDocument validates the documentType form field of a multipart upload
+func UploadDocument() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		documentType := strings.ToUpper(strings.TrimSpace(c.FormValue("documentType")))
+
+		errors := make(map[string]string)
+
+		if documentType == "" {
+			errors["documentType"] = "Document type is required!"
+		} else if !slices.Contains(utils.KYCDocumentTypes, documentType) {
+			errors["documentType"] = "Invalid document type! Must be one of " + strings.Join(utils.KYCDocumentTypes, ", ")
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedKYCDocumentType", documentType)
+		return c.Next()
+	}
+}
+
+func ListKYC() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Page   *int   `json:"page"`
+			Limit  *int   `json:"limit"`
+			Status string `json:"status"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request query!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.Page == nil || *reqData.Page < 1 {
+			errors["page"] = "Page must be greater than 0!"
+		}
+
+		if reqData.Limit == nil || *reqData.Limit < 1 {
+			errors["limit"] = "Limit must be greater than 0!"
+		}
+
+		reqData.Status = strings.ToUpper(strings.TrimSpace(reqData.Status))
+		if reqData.Status != "" && !slices.Contains(validKYCStatuses, reqData.Status) {
+			errors["status"] = "Invalid status!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedKYCList", reqData)
+		return c.Next()
+	}
+}
+
+func ReviewKYC() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			UserID uint   `json:"userId"`
+			Reason string `json:"reason"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.UserID == 0 {
+			errors["userId"] = "userId is required!"
+		}
+
+		if len(reqData.Reason) > 500 {
+			errors["reason"] = "Reason must be at most 500 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedKYCReview", reqData)
+		return c.Next()
+	}
+}
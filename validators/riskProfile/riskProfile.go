@@ -0,0 +1,95 @@
+package riskProfileValidator
+
+import (
+	"fib/middleware"
+	"fib/utils"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SubmitRiskProfile validates a questionnaire submission
+func SubmitRiskProfile() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Answers []utils.RiskAnswer `json:"answers"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if len(reqData.Answers) == 0 {
+			errors["answers"] = "Answers are required!"
+		}
+
+		seen := make(map[uint]bool, len(reqData.Answers))
+		for i, answer := range reqData.Answers {
+			if answer.QuestionID == 0 || answer.OptionID == 0 {
+				errors[fmt.Sprintf("answers[%d]", i)] = "questionId and optionId are required!"
+				continue
+			}
+			if seen[answer.QuestionID] {
+				errors[fmt.Sprintf("answers[%d]", i)] = "Question answered more than once!"
+			}
+			seen[answer.QuestionID] = true
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedRiskProfileSubmit", reqData)
+		return c.Next()
+	}
+}
+
+// SaveRiskQuestion validates creating (questionId 0) or replacing a questionnaire question
+func SaveRiskQuestion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			QuestionID uint   `json:"questionId"`
+			Question   string `json:"question"`
+			SortOrder  int    `json:"sortOrder"`
+			IsActive   *bool  `json:"isActive"`
+			Options    []struct {
+				Text      string `json:"text"`
+				Score     int    `json:"score"`
+				SortOrder int    `json:"sortOrder"`
+			} `json:"options"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.Question = strings.TrimSpace(reqData.Question)
+		if reqData.Question == "" {
+			errors["question"] = "Question is required!"
+		}
+
+		if len(reqData.Options) < 2 {
+			errors["options"] = "At least two options are required!"
+		}
+		for i, option := range reqData.Options {
+			if strings.TrimSpace(option.Text) == "" {
+				errors[fmt.Sprintf("options[%d].text", i)] = "Option text is required!"
+			}
+			if option.Score < 0 {
+				errors[fmt.Sprintf("options[%d].score", i)] = "Score cannot be negative!"
+			}
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedRiskQuestion", reqData)
+		return c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package smsValidator
+
+import (
+	"fib/middleware"
+	"fib/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var validMessageTypes = map[string]bool{
+	models.SMSTypeOTP:                true,
+	models.SMSTypeLoginAlert:         true,
+	models.SMSTypeSubscriptionExpiry: true,
+}
+
+var validProviders = map[string]bool{"": true, "fast2sms": true, "msg91": true, "fake": true}
+
+// UpsertTemplate validates create/update of a DLT template
+func UpsertTemplate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			MessageType string `json:"messageType"`
+			Provider    string `json:"provider"`
+			TemplateID  string `json:"templateId"`
+			SenderID    string `json:"senderId"`
+			Body        string `json:"body"`
+			IsActive    *bool  `json:"isActive"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		reqData.MessageType = strings.ToUpper(strings.TrimSpace(reqData.MessageType))
+		if !validMessageTypes[reqData.MessageType] {
+			errors["messageType"] = "Invalid message type! Allowed: OTP, LOGIN_ALERT, SUBSCRIPTION_EXPIRY"
+		}
+
+		reqData.Provider = strings.ToLower(strings.TrimSpace(reqData.Provider))
+		if !validProviders[reqData.Provider] {
+			errors["provider"] = "Invalid provider! Allowed: fast2sms, msg91, fake"
+		}
+
+		reqData.TemplateID = strings.TrimSpace(reqData.TemplateID)
+		if reqData.TemplateID == "" {
+			errors["templateId"] = "DLT template ID is required!"
+		}
+
+		if len(reqData.SenderID) > 20 {
+			errors["senderId"] = "Sender ID must not exceed 20 characters!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSMSTemplate", reqData)
+		return c.Next()
+	}
+}
+
+// ListLogs validates the SMS log list query
+func ListLogs() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Page        *int    `json:"page"`
+			Limit       *int    `json:"limit"`
+			Mobile      *string `json:"mobile"`
+			MessageType *string `json:"messageType"`
+			Status      *string `json:"status"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request query!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.Page == nil || *reqData.Page < 1 {
+			errors["page"] = "Page must be greater than 0!"
+		}
+		if reqData.Limit == nil || *reqData.Limit < 1 {
+			errors["limit"] = "Limit must be greater than 0!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSMSLogs", reqData)
+		return c.Next()
+	}
+}
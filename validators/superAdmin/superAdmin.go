@@ -2,9 +2,11 @@ package superAdminValidator
 
 import (
 	"fib/middleware"
+	"fib/models"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -305,10 +307,15 @@ func PermissionByUserID() fiber.Handler {
 func ValidateMaintenance() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
-			AppMaintenance       bool   `json:"app_maintenance"`
-			ForceUpdate          bool   `json:"force_update"`
-			IosLatestVersion     string `json:"ios_latest_version"`
-			AndroidLatestVersion string `json:"android_latest_version"`
+			AppMaintenance       bool       `json:"app_maintenance"`
+			ForceUpdate          bool       `json:"force_update"`
+			IosLatestVersion     string     `json:"ios_latest_version"`
+			AndroidLatestVersion string     `json:"android_latest_version"`
+			IosMinVersion        string     `json:"ios_min_version"`
+			AndroidMinVersion    string     `json:"android_min_version"`
+			StartsAt             *time.Time `json:"starts_at"`
+			EndsAt               *time.Time `json:"ends_at"`
+			Message              string     `json:"message"`
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -331,6 +338,30 @@ func ValidateMaintenance() fiber.Handler {
 			errors["android_latest_version"] = "Invalid Android version format! (expected x.y.z)"
 		}
 
+		// Minimum versions are optional but can't be newer than the latest release
+		if reqData.IosMinVersion != "" {
+			if !isValidSemVer(reqData.IosMinVersion) {
+				errors["ios_min_version"] = "Invalid iOS minimum version format! (expected x.y.z)"
+			} else if isValidSemVer(reqData.IosLatestVersion) && models.CompareVersions(reqData.IosMinVersion, reqData.IosLatestVersion) > 0 {
+				errors["ios_min_version"] = "iOS minimum version can't be newer than the latest version!"
+			}
+		}
+		if reqData.AndroidMinVersion != "" {
+			if !isValidSemVer(reqData.AndroidMinVersion) {
+				errors["android_min_version"] = "Invalid Android minimum version format! (expected x.y.z)"
+			} else if isValidSemVer(reqData.AndroidLatestVersion) && models.CompareVersions(reqData.AndroidMinVersion, reqData.AndroidLatestVersion) > 0 {
+				errors["android_min_version"] = "Android minimum version can't be newer than the latest version!"
+			}
+		}
+
+		// Scheduled window
+		if reqData.StartsAt != nil && reqData.EndsAt != nil && !reqData.EndsAt.After(*reqData.StartsAt) {
+			errors["ends_at"] = "Maintenance end must be after its start!"
+		}
+		if reqData.EndsAt != nil && reqData.EndsAt.Before(time.Now()) {
+			errors["ends_at"] = "Maintenance end must be in the future!"
+		}
+
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
 		}
@@ -340,3 +371,58 @@ func ValidateMaintenance() fiber.Handler {
 		return c.Next()
 	}
 }
+
+func SecurityAccountList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Page  *int `json:"page"`
+			Limit *int `json:"limit"`
+		})
+
+		if err := c.QueryParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request query!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.Page == nil || *reqData.Page < 1 {
+			errors["page"] = "Page must be greater than 0!"
+		}
+
+		if reqData.Limit == nil || *reqData.Limit < 1 {
+			errors["limit"] = "Limit must be greater than 0!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSecurityList", reqData)
+		return c.Next()
+	}
+}
+
+func SecurityAccountAction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			UserID uint `json:"userId"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.UserID == 0 {
+			errors["userId"] = "userId is required!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSecurityAction", reqData)
+		return c.Next()
+	}
+}
@@ -2,11 +2,37 @@ package supportValidators
 
 import (
 	"fib/middleware"
+	"fib/models"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
+// attachmentCount returns how many files were uploaded as attachments in a multipart request
+func attachmentCount(c *fiber.Ctx) int {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return 0
+	}
+	return len(form.File["attachments"])
+}
+
+// validateReplyBody checks a message text and its attachments; a message needs one or the other
+func validateReplyBody(c *fiber.Ctx, message string, errors map[string]string) {
+	files := attachmentCount(c)
+	if message == "" && files == 0 {
+		errors["message"] = "Reply message is required!"
+	}
+	if len(message) > 5000 {
+		errors["message"] = "Message must not exceed 5000 characters!"
+	}
+	if files > models.SupportMaxAttachments {
+		errors["attachments"] = fmt.Sprintf("At most %d attachments are allowed!", models.SupportMaxAttachments)
+	}
+}
+
 func CreateSupportTicket() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
@@ -47,10 +73,15 @@ func CreateSupportTicket() fiber.Handler {
 			}
 		}
 
-		// Message (must be valid JSON)
+		// Message, optionally with attachments
 		reqData.Message = strings.TrimSpace(reqData.Message)
 		if reqData.Message == "" {
 			errors["message"] = "message is required!"
+		} else if len(reqData.Message) > 5000 {
+			errors["message"] = "Message must not exceed 5000 characters!"
+		}
+		if attachmentCount(c) > models.SupportMaxAttachments {
+			errors["attachments"] = fmt.Sprintf("At most %d attachments are allowed!", models.SupportMaxAttachments)
 		}
 
 		validPriority := map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true}
@@ -128,11 +159,13 @@ func TicketList() fiber.Handler {
 func AdminTicketList() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
-			Page     *int    `query:"page"`
-			Limit    *int    `query:"limit"`
-			Status   *string `query:"status"`
-			Priority *string `query:"priority"`
-			Category *string `query:"category"`
+			Page       *int    `query:"page"`
+			Limit      *int    `query:"limit"`
+			Status     *string `query:"status"`
+			Priority   *string `query:"priority"`
+			Category   *string `query:"category"`
+			AssignedTo *uint   `query:"assignedTo"` // 0 lists unassigned tickets
+			Breached   *bool   `query:"breached"`   // Tickets that missed an SLA deadline
 		})
 
 		if err := c.QueryParser(reqData); err != nil {
@@ -185,6 +218,7 @@ func AdminReplyTicket() fiber.Handler {
 		reqData := new(struct {
 			TicketID uint   `json:"ticketId"`
 			Message  string `json:"message"`
+			Internal bool   `json:"internal"` // Admin-only note, hidden from the user
 		})
 
 		if err := c.BodyParser(reqData); err != nil {
@@ -198,9 +232,7 @@ func AdminReplyTicket() fiber.Handler {
 		}
 
 		reqData.Message = strings.TrimSpace(reqData.Message)
-		if reqData.Message == "" {
-			errors["message"] = "Reply message is required!"
-		}
+		validateReplyBody(c, reqData.Message, errors)
 
 		if len(errors) > 0 {
 			return middleware.ValidationErrorResponse(c, errors)
@@ -211,6 +243,48 @@ func AdminReplyTicket() fiber.Handler {
 	}
 }
 
+func UserReplyTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			TicketID uint   `json:"ticketId"`
+			Message  string `json:"message"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.TicketID == 0 {
+			errors["ticketId"] = "Ticket ID is required!"
+		}
+
+		reqData.Message = strings.TrimSpace(reqData.Message)
+		validateReplyBody(c, reqData.Message, errors)
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedUserReply", reqData)
+		return c.Next()
+	}
+}
+
+// TicketMessages validates the ticket ID of a conversation request
+func TicketMessages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ticketID, err := strconv.Atoi(strings.TrimSpace(c.Params("id")))
+		if err != nil || ticketID <= 0 {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid ticket ID!", nil)
+		}
+
+		c.Locals("ticketID", uint(ticketID))
+		return c.Next()
+	}
+}
+
 func CloseTicket() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		reqData := new(struct {
@@ -235,3 +309,97 @@ func CloseTicket() fiber.Handler {
 		return c.Next()
 	}
 }
+
+func AssignTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			TicketID uint `json:"ticketId"`
+			AdminID  uint `json:"adminId"` // 0 assigns round-robin by category
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.TicketID == 0 {
+			errors["ticketId"] = "Ticket ID is required!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedAssignTicket", reqData)
+		return c.Next()
+	}
+}
+
+func SaveSupportAgent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			AdminID  uint   `json:"adminId"`
+			Category string `json:"category"`
+			IsActive *bool  `json:"isActive"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.AdminID == 0 {
+			errors["adminId"] = "Admin ID is required!"
+		}
+
+		validCategory := map[string]bool{"GENERAL": true, "TECHNICAL": true, "BILLING": true}
+		reqData.Category = strings.ToUpper(strings.TrimSpace(reqData.Category))
+		if !validCategory[reqData.Category] {
+			errors["category"] = "Invalid category! Must be one of: GENERAL, TECHNICAL, BILLING."
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSupportAgent", reqData)
+		return c.Next()
+	}
+}
+
+func SaveSLAPolicy() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			Priority             string `json:"priority"`
+			FirstResponseMinutes int    `json:"firstResponseMinutes"`
+			ResolutionMinutes    int    `json:"resolutionMinutes"`
+		})
+
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		validPriority := map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true}
+		reqData.Priority = strings.ToUpper(strings.TrimSpace(reqData.Priority))
+		if !validPriority[reqData.Priority] {
+			errors["priority"] = "Invalid priority! Must be one of: LOW, MEDIUM, HIGH."
+		}
+		if reqData.FirstResponseMinutes < 1 {
+			errors["firstResponseMinutes"] = "First response target must be at least 1 minute!"
+		}
+		if reqData.ResolutionMinutes < reqData.FirstResponseMinutes {
+			errors["resolutionMinutes"] = "Resolution target must not be shorter than the first response target!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedSLAPolicy", reqData)
+		return c.Next()
+	}
+}
@@ -80,6 +80,84 @@ func AddBankAccount() fiber.Handler {
 	}
 }
 
+func UpdateBankAccount() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BankID      uint    `json:"bankId"`
+			BankName    *string `json:"bankName"`
+			AccountNo   *string `json:"accountNo"`
+			HolderName  *string `json:"holderName"`
+			IFSCCode    *string `json:"ifscCode"`
+			BranchName  *string `json:"branchName"`
+			AccountType *string `json:"accountType"`
+		})
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		errors := make(map[string]string)
+
+		if reqData.BankID == 0 {
+			errors["bankId"] = "Bank account ID is required!"
+		}
+
+		if reqData.BankName != nil && len(strings.TrimSpace(*reqData.BankName)) < 3 {
+			errors["bankName"] = "Bank name must be at least 3 characters long!"
+		}
+
+		if reqData.AccountNo != nil {
+			if len(strings.TrimSpace(*reqData.AccountNo)) < 10 || len(*reqData.AccountNo) > 18 {
+				errors["accountNo"] = "Account number must be between 10 and 18 digits!"
+			} else if !isValidNumeric(*reqData.AccountNo) {
+				errors["accountNo"] = "Account number must contain only numeric characters!"
+			}
+		}
+
+		if reqData.HolderName != nil && len(strings.TrimSpace(*reqData.HolderName)) < 3 {
+			errors["holderName"] = "Holder name must be at least 3 characters long!"
+		}
+
+		if reqData.IFSCCode != nil && (len(strings.TrimSpace(*reqData.IFSCCode)) != 11 || !isValidIFSC(*reqData.IFSCCode)) {
+			errors["ifscCode"] = "Invalid IFSC code! It must be 11 characters long and alphanumeric."
+		}
+
+		if reqData.BranchName != nil && *reqData.BranchName != "" && len(strings.TrimSpace(*reqData.BranchName)) < 3 {
+			errors["branchName"] = "Branch name must be at least 3 characters long if provided!"
+		}
+
+		validAccountTypes := map[string]bool{"savings": true, "current": true}
+		if reqData.AccountType != nil && !validAccountTypes[strings.ToLower(*reqData.AccountType)] {
+			errors["accountType"] = "Account type must be 'savings' or 'current'!"
+		}
+
+		if len(errors) > 0 {
+			return middleware.ValidationErrorResponse(c, errors)
+		}
+
+		c.Locals("validatedUpdateBankAccount", reqData)
+		return c.Next()
+	}
+}
+
+// BankAccountAction validates requests that act on one of the user's bank accounts
+func BankAccountAction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		reqData := new(struct {
+			BankID uint `json:"bankId"`
+		})
+		if err := c.BodyParser(reqData); err != nil {
+			return middleware.JsonResponse(c, fiber.StatusBadRequest, false, "Invalid request body!", nil)
+		}
+
+		if reqData.BankID == 0 {
+			return middleware.ValidationErrorResponse(c, map[string]string{"bankId": "Bank account ID is required!"})
+		}
+
+		c.Locals("validatedBankAccountAction", reqData)
+		return c.Next()
+	}
+}
+
 func SendAdharOtp() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Parse request body